@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const apiPrefix = "/api/v1"
+
+// Client calls the loan service API of a profile.
+type Client struct {
+	Server string
+	Token  string
+	HTTP   *http.Client
+}
+
+func NewClient(profile *Profile) *Client {
+	return &Client{
+		Server: strings.TrimSuffix(profile.Server, "/"),
+		Token:  profile.Token,
+		HTTP:   &http.Client{Timeout: time.Minute},
+	}
+}
+
+// APIError is a response the service refused a request with.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.Status, http.StatusText(e.Status))
+}
+
+// call sends a JSON request to an API path and returns what the response
+// holds: its data, or the body without error_message for responses that
+// are not wrapped in data.
+func (c *Client) call(method, path string, query url.Values, body any) (any, error) {
+	resp, err := c.send(method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoded, err := decodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	object, _ := decoded.(map[string]any)
+	if message, ok := object["error_message"].(string); ok {
+		return nil, &APIError{Status: resp.StatusCode, Message: message}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &APIError{Status: resp.StatusCode, Message: "request failed"}
+	}
+	if data, ok := object["data"]; ok {
+		return data, nil
+	}
+	delete(object, "error_message")
+	return object, nil
+}
+
+// graphQL runs a GraphQL query and returns its data. Errors of any field
+// fail the whole call, as the commands print complete results only.
+func (c *Client) graphQL(query string, variables map[string]any) (map[string]any, error) {
+	resp, err := c.send(http.MethodPost, "/graphql", nil, map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoded, err := decodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	object, _ := decoded.(map[string]any)
+	if errs, _ := object["errors"].([]any); len(errs) > 0 {
+		var messages []string
+		for _, err := range errs {
+			if err, ok := err.(map[string]any); ok {
+				messages = append(messages, fmt.Sprint(err["message"]))
+			}
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: strings.Join(messages, "; ")}
+	}
+	if message, ok := object["error_message"].(string); ok {
+		return nil, &APIError{Status: resp.StatusCode, Message: message}
+	}
+	data, _ := object["data"].(map[string]any)
+	return data, nil
+}
+
+// download copies the body of a GET to w, returning the response headers.
+func (c *Client) download(path string, query url.Values, w io.Writer) (http.Header, error) {
+	resp, err := c.send(http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		decoded, err := decodeResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+		object, _ := decoded.(map[string]any)
+		message, ok := object["error_message"].(string)
+		if !ok {
+			message = "request failed"
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: message}
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
+
+func (c *Client) send(method, path string, query url.Values, body any) (*http.Response, error) {
+	target := c.Server + apiPrefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return c.HTTP.Do(req)
+}
+
+// decodeResponse reads a JSON body, keeping integers exact. A body that is
+// not JSON is the message of a failed request.
+func decodeResponse(resp *http.Response) (any, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType != "application/json" {
+		message := strings.TrimSpace(string(body))
+		if message == "" {
+			message = "request failed"
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: message}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %w", resp.Request.URL, err)
+	}
+	return normalizeNumbers(value), nil
+}
+
+// normalizeNumbers turns JSON numbers into int64 where they are integers
+// and float64 otherwise, so that YAML prints amounts in full.
+func normalizeNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]any:
+		for key, item := range value {
+			value[key] = normalizeNumbers(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = normalizeNumbers(item)
+		}
+	}
+	return value
+}
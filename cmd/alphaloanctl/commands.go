@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxPageSize is the largest page the GraphQL list queries return.
+const maxPageSize = 500
+
+var customerColumns = []string{"customer_id", "full_name", "phone_number", "address_city", "locale"}
+
+var submissionColumns = []string{"submission_id", "customer_id", "loan_status", "vehicle_brand", "vehicle_model",
+	"vehicle_license_number", "proposed_loan_amount", "proposed_loan_tenure_month", "created_at"}
+
+const customersQuery = `query ($filter: CustomerFilter, $first: Int, $after: String) {
+  customers(filter: $filter, first: $first, after: $after) {
+    nodes { customer_id full_name phone_number email address_city locale }
+    page_info { end_cursor has_next_page }
+  }
+}`
+
+const customerQuery = `query ($id: ID!) {
+  customer(customer_id: $id) {
+    customer_id id_card_number full_name birth_date phone_number email
+    monthly_income address_street address_city locale
+    submissions {
+      submission_id party_role loan_status vehicle_brand vehicle_model vehicle_license_number
+      proposed_loan_amount proposed_loan_tenure_month created_at
+    }
+  }
+}`
+
+const submissionsQuery = `query ($filter: SubmissionFilter, $first: Int, $after: String) {
+  submissions(filter: $filter, first: $first, after: $after) {
+    nodes {
+      submission_id customer_id loan_status vehicle_brand vehicle_model vehicle_license_number
+      proposed_loan_amount proposed_loan_tenure_month dealer_id product_id created_at
+    }
+    page_info { end_cursor has_next_page }
+  }
+}`
+
+func (e *env) runCustomers(args []string) error {
+	if len(args) == 0 {
+		return errors.New("customers needs list, search or show")
+	}
+	switch args[0] {
+	case "list", "search":
+		synopsis := "customers list [-city <city>] [-locale <locale>] [-search <text>] [-limit <n>]"
+		nargs := 0
+		if args[0] == "search" {
+			synopsis = "customers search [-city <city>] [-locale <locale>] [-limit <n>] <text>"
+			nargs = 1
+		}
+		fs := newFlagSet(synopsis)
+		city := fs.String("city", "", "only customers living in this city")
+		locale := fs.String("locale", "", "only customers with this locale")
+		search := fs.String("search", "", "part of the full name, phone number or ID card number")
+		limit := fs.Int("limit", 50, "most customers to list, 0 lists all")
+		if operands := parseArgs(fs, args[1:], nargs); nargs == 1 {
+			*search = operands[0]
+		}
+
+		filter := map[string]any{}
+		setIfGiven(filter, "address_city", *city)
+		setIfGiven(filter, "locale", *locale)
+		setIfGiven(filter, "search", *search)
+		customers, err := e.listAll(customersQuery, "customers", filter, *limit)
+		if err != nil {
+			return err
+		}
+		return e.out.print(customers, customerColumns)
+
+	case "show":
+		fs := newFlagSet("customers show <customer-id>")
+		operands := parseArgs(fs, args[1:], 1)
+		data, err := e.client.graphQL(customerQuery, map[string]any{"id": operands[0]})
+		if err != nil {
+			return err
+		}
+		customer, ok := data["customer"].(map[string]any)
+		if !ok {
+			return fmt.Errorf("customer %s not found", operands[0])
+		}
+		if e.out.format != "table" {
+			return e.out.print(customer, nil)
+		}
+		submissions := customer["submissions"]
+		delete(customer, "submissions")
+		if err := e.out.print(customer, nil); err != nil {
+			return err
+		}
+		fmt.Fprintln(e.out.w, "\nSubmissions:")
+		return e.out.print(submissions, append([]string{"submission_id", "party_role"}, submissionColumns[2:]...))
+	}
+	return fmt.Errorf("unknown customers command %q", args[0])
+}
+
+func (e *env) runSubmissions(args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New("submissions needs list")
+	}
+	fs := newFlagSet("submissions list [-status <status>] [-customer <id>] [-dealer <id>] [-product <id>] [-limit <n>]")
+	status := fs.String("status", "", "only submissions in this loan status")
+	customer := fs.String("customer", "", "only submissions of this primary applicant")
+	dealer := fs.String("dealer", "", "only submissions made through this dealer")
+	product := fs.String("product", "", "only submissions for this loan product")
+	limit := fs.Int("limit", 50, "most submissions to list, newest first, 0 lists all")
+	parseArgs(fs, args[1:], 0)
+
+	filter := map[string]any{}
+	setIfGiven(filter, "loan_status", strings.ToUpper(*status))
+	setIfGiven(filter, "customer_id", *customer)
+	setIfGiven(filter, "dealer_id", *dealer)
+	setIfGiven(filter, "product_id", *product)
+	submissions, err := e.listAll(submissionsQuery, "submissions", filter, *limit)
+	if err != nil {
+		return err
+	}
+	return e.out.print(submissions, submissionColumns)
+}
+
+// listAll follows the pages of a GraphQL list query until limit nodes, or
+// all of them when limit is 0, have been read.
+func (e *env) listAll(query, field string, filter map[string]any, limit int) ([]any, error) {
+	nodes := []any{}
+	var after any
+	for limit == 0 || len(nodes) < limit {
+		first := maxPageSize
+		if limit > 0 {
+			first = min(limit-len(nodes), maxPageSize)
+		}
+		data, err := e.client.graphQL(query, map[string]any{"filter": filter, "first": first, "after": after})
+		if err != nil {
+			return nil, err
+		}
+		page, _ := data[field].(map[string]any)
+		pageNodes, _ := page["nodes"].([]any)
+		nodes = append(nodes, pageNodes...)
+		pageInfo, _ := page["page_info"].(map[string]any)
+		if hasNext, _ := pageInfo["has_next_page"].(bool); !hasNext {
+			break
+		}
+		after = pageInfo["end_cursor"]
+	}
+	return nodes, nil
+}
+
+func (e *env) runSubmit(args []string) error {
+	fs := newFlagSet("submit [-dealer] <loan.json|loan.yaml>")
+	dealer := fs.Bool("dealer", false, "submit through the dealer portal, with the token as the dealer API key")
+	operands := parseArgs(fs, args, 1)
+
+	path := operands[0]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var request any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &request)
+	default:
+		err = json.Unmarshal(data, &request)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	route := "/submissions"
+	if *dealer {
+		route = "/dealer/submissions"
+	}
+	submitted, err := e.client.call(http.MethodPost, route, nil, request)
+	if err != nil {
+		return err
+	}
+	return e.out.print(submitted, nil)
+}
+
+func (e *env) runTransition(args []string) error {
+	fs := newFlagSet("transition <submission-id> <APPROVED|REJECTED|CANCELLED>")
+	operands := parseArgs(fs, args, 2)
+
+	decision, err := e.client.call(http.MethodPost, "/admin/submissions/"+url.PathEscape(operands[0])+"/decision", nil,
+		map[string]any{"decision": strings.ToUpper(operands[1])})
+	if err != nil {
+		return err
+	}
+	return e.out.print(decision, []string{"submission_id", "loan_status", "commissions"})
+}
+
+func (e *env) runExport(args []string) error {
+	if len(args) == 0 || (args[0] != "submissions" && args[0] != "customers") {
+		return errors.New("export needs submissions or customers")
+	}
+	kind := args[0]
+	fs := newFlagSet("export " + kind + " [-format <format>] [-columns <columns>] [-out <file>] [filters]")
+	format := fs.String("format", "csv", "csv, xlsx or ndjson")
+	columns := fs.String("columns", "", "comma separated columns in the order wanted, all by default")
+	out := fs.String("out", "", "file to write, standard output by default")
+	query := url.Values{}
+	filter := func(name, usage string) {
+		fs.Func(name, usage, func(value string) error {
+			query.Set(strings.ReplaceAll(name, "-", "_"), value)
+			return nil
+		})
+	}
+	if kind == "submissions" {
+		filter("loan-status", "only submissions in this loan status")
+		filter("dealer-id", "only submissions made through this dealer")
+		filter("product-id", "only submissions for this loan product")
+		filter("from", "only submissions created on or after this date (YYYY-MM-DD)")
+		filter("to", "only submissions created before this date (YYYY-MM-DD)")
+	} else {
+		filter("address-city", "only customers living in this city")
+		filter("locale", "only customers with this locale")
+	}
+	parseArgs(fs, args[1:], 0)
+	query.Set("format", *format)
+	setIfGiven(query, "columns", *columns)
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	header, err := e.client.download("/admin/exports/"+kind, query, w)
+	if err != nil {
+		if *out != "" {
+			os.Remove(*out)
+		}
+		return err
+	}
+	if header.Get("X-PII-Masked") == "true" {
+		fmt.Fprintln(os.Stderr, "Personal data is masked; set the PII export token as the profile token to unmask it.")
+	}
+	return nil
+}
+
+func (e *env) runConfig(args []string) error {
+	if len(args) == 0 {
+		return errors.New("config needs set, use or show")
+	}
+	switch args[0] {
+	case "set":
+		fs := newFlagSet("config set [-server <url>] [-token <token>] <profile>")
+		server := fs.String("server", "", "server URL, "+defaultServer+" for a new profile")
+		token := fs.String("token", "", "bearer token sent with every request")
+		operands := parseArgs(fs, args[1:], 1)
+
+		name := operands[0]
+		profile, ok := e.config.Profiles[name]
+		if !ok {
+			profile = &Profile{Server: defaultServer}
+			e.config.Profiles[name] = profile
+		}
+		if *server != "" {
+			profile.Server = *server
+		}
+		if *token != "" {
+			profile.Token = *token
+		}
+		if e.config.CurrentProfile == "" {
+			e.config.CurrentProfile = name
+		}
+		return saveConfig(e.configPath, e.config)
+
+	case "use":
+		fs := newFlagSet("config use <profile>")
+		operands := parseArgs(fs, args[1:], 1)
+		if _, ok := e.config.Profiles[operands[0]]; !ok {
+			return fmt.Errorf("no profile %q in the config", operands[0])
+		}
+		e.config.CurrentProfile = operands[0]
+		return saveConfig(e.configPath, e.config)
+
+	case "show":
+		fs := newFlagSet("config show")
+		parseArgs(fs, args[1:], 0)
+		profiles := []any{}
+		for _, name := range slices.Sorted(maps.Keys(e.config.Profiles)) {
+			profile := e.config.Profiles[name]
+			token := ""
+			if profile.Token != "" {
+				token = "set"
+			}
+			profiles = append(profiles, map[string]any{
+				"profile": name,
+				"current": name == e.config.CurrentProfile,
+				"server":  profile.Server,
+				"token":   token,
+			})
+		}
+		return e.out.print(profiles, []string{"profile", "current", "server", "token"})
+	}
+	return fmt.Errorf("unknown config command %q", args[0])
+}
+
+// setIfGiven sets the filter key only for non-empty values, as the API
+// treats a given empty filter as a value to match.
+func setIfGiven[M map[string]any | url.Values](filter M, key, value string) {
+	if value == "" {
+		return
+	}
+	switch filter := any(filter).(type) {
+	case map[string]any:
+		filter[key] = value
+	case url.Values:
+		filter.Set(key, value)
+	}
+}
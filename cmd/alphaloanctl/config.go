@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// Config is the profile file. Each profile names a server and the token sent
+// as its bearer credentials: the PII export token, or a dealer API key for
+// the dealer routes.
+type Config struct {
+	CurrentProfile string              `yaml:"current_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+type Profile struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token,omitempty"`
+}
+
+// configPath is $ALPHALOANCTL_CONFIG, or config.yaml in the user's config
+// directory.
+func configPath() (string, error) {
+	if path := os.Getenv("ALPHALOANCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "alphaloanctl", "config.yaml"), nil
+}
+
+// loadConfig reads the profile file; a missing file is an empty config.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{Profiles: make(map[string]*Profile)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]*Profile)
+	}
+	return config, nil
+}
+
+// saveConfig writes the file readable by the user only, as it holds tokens.
+func saveConfig(path string, config *Config) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// profile returns the named profile, or the current one when name is empty.
+// Without any profile the local server is used.
+func (c *Config) profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		return &Profile{Server: defaultServer}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no profile %q in the config", name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,140 @@
+// Command alphaloanctl is the command-line client of the loan service for
+// operations staff. It talks to the HTTP API of the server named by the
+// active profile.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+const usage = `Usage: alphaloanctl [flags] <command> [arguments]
+
+Commands:
+  customers list [-city <city>] [-locale <locale>] [-search <text>] [-limit <n>]
+  customers search <text>
+  customers show <customer-id>
+  submissions list [-status <status>] [-customer <id>] [-dealer <id>] [-product <id>] [-limit <n>]
+  submit [-dealer] <loan.json|loan.yaml>
+  transition <submission-id> <APPROVED|REJECTED|CANCELLED>
+  export submissions|customers [-format <format>] [-out <file>] [filters]
+  config set <profile> [-server <url>] [-token <token>]
+  config use <profile>
+  config show
+
+Profiles are kept in $ALPHALOANCTL_CONFIG, by default config.yaml in the
+alphaloanctl folder of the user's config directory.
+
+Flags:
+`
+
+// env is what every command runs with.
+type env struct {
+	client     *Client
+	out        *printer
+	configPath string
+	config     *Config
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("alphaloanctl: ")
+
+	profileName := flag.String("profile", "", "profile to use instead of the current one")
+	server := flag.String("server", "", "server URL, overriding the profile")
+	token := flag.String("token", "", "bearer token, overriding the profile")
+	output := flag.String("o", "table", "output format: "+strings.Join(outputFormats, ", "))
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !slices.Contains(outputFormats, *output) {
+		log.Fatalf("unknown output format %q, want one of %s", *output, strings.Join(outputFormats, ", "))
+	}
+
+	path, err := configPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	e := &env{out: &printer{format: *output, w: os.Stdout}, configPath: path, config: config}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	if command != "config" {
+		profile, err := config.profile(*profileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		resolved := *profile
+		if *server != "" {
+			resolved.Server = *server
+		}
+		if *token != "" {
+			resolved.Token = *token
+		}
+		e.client = NewClient(&resolved)
+	}
+
+	switch command {
+	case "customers":
+		err = e.runCustomers(args)
+	case "submissions":
+		err = e.runSubmissions(args)
+	case "submit":
+		err = e.runSubmit(args)
+	case "transition":
+		err = e.runTransition(args)
+	case "export":
+		err = e.runExport(args)
+	case "config":
+		err = e.runConfig(args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newFlagSet returns the flag set of a command, whose usage is synopsis.
+func newFlagSet(synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(strings.Fields(synopsis)[0], flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: alphaloanctl %s\n", synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags of a command, which may come before or after its
+// arguments, and returns the arguments. It exits with the usage unless nargs
+// arguments are given.
+func parseArgs(fs *flag.FlagSet, args []string, nargs int) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != nargs {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return positional
+}
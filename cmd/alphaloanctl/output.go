@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+// printer writes results in the output format. Tables show the given
+// columns, or every field of an object when there are none.
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func (p *printer) print(value any, columns []string) error {
+	switch p.format {
+	case "json":
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case "yaml":
+		encoder := yaml.NewEncoder(p.w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	switch value := value.(type) {
+	case []any:
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = strings.ToUpper(column)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, item := range value {
+			object, _ := item.(map[string]any)
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = cell(object[column])
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+	case map[string]any:
+		if columns == nil {
+			for key := range value {
+				columns = append(columns, key)
+			}
+			slices.Sort(columns)
+		}
+		for _, column := range columns {
+			fmt.Fprintf(tw, "%s:\t%s\n", column, cell(value[column]))
+		}
+	default:
+		fmt.Fprintln(tw, cell(value))
+	}
+	return tw.Flush()
+}
+
+// cell renders a value on one line; nested objects and lists as JSON.
+func cell(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		return value
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/handler"
+)
+
+const importLoansUsage = `Usage: vehicle import-loans [flags] <file.csv>
+
+Imports historical customers and their loans from CSV, one loan per row.
+Rows are checked like submissions made through the API; rejected rows are
+written to the error report with their reason and skipped.
+
+The mapping file is a JSON object of import field to CSV column, e.g.
+{"id_card_number": "KTP", "reference": "LoanNo"}. Fields that are not mapped
+are read from the column of the same name. Mapping a reference makes the
+import safe to run again.
+
+Flags:
+`
+
+func runImportLoans(args []string) {
+	fs := flag.NewFlagSet("import-loans", flag.ExitOnError)
+	dbPath := fs.String("db", "alphaloan.db", "path to the sqlite database file")
+	migrationFolder := fs.String("migrations", "db/migration", "folder containing the sql migrations")
+	mappingPath := fs.String("mapping", "", "JSON file mapping import fields to CSV columns")
+	dryRun := fs.Bool("dry-run", false, "check every row without writing anything")
+	batchSize := fs.Int("batch-size", handler.DefaultLoanImportBatchSize, "rows saved per transaction")
+	errorsPath := fs.String("errors", "", "where the CSV report of rejected rows is written, defaults to <file>.errors.csv")
+	maxLoanToValue := fs.Float64("max-ltv", 0, "reject loans whose loan-to-value exceeds this ratio, 0 disables the cap")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), importLoansUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	path := fs.Arg(0)
+	if *errorsPath == "" {
+		*errorsPath = strings.TrimSuffix(path, ".csv") + ".errors.csv"
+	}
+
+	var options handler.LoanImportOptions
+	options.DryRun = *dryRun
+	if *mappingPath != "" {
+		data, err := os.ReadFile(*mappingPath)
+		if err != nil {
+			log.Fatalf("Failed to read mapping: %v", err)
+		}
+		if err := json.Unmarshal(data, &options.Mapping); err != nil {
+			log.Fatalf("Mapping must be a JSON object of field to column: %v", err)
+		}
+	}
+
+	if err := prepareSchema(*migrationFolder, *dbPath, false); err != nil {
+		log.Fatalf("Refusing to import: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", *dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
+		log.Fatal("Failed to enable foreign_keys ", err)
+	}
+
+	submitHandler := handler.NewLoanSubmitHandler(*datastore.NewLoanSubmissionStore(db),
+		*datastore.NewVehicleCatalogueStore(db), *datastore.NewLoanProductStore(db), *datastore.NewDealerStore(db))
+	submitHandler.MaxLoanToValue = *maxLoanToValue
+	importHandler := handler.NewLoanImportHandler(submitHandler)
+	importHandler.BatchSize = *batchSize
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	report, err := importHandler.ImportLoans(context.Background(), file, options)
+	if report == nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	if report.Rejected > 0 {
+		if err := writeImportErrorReport(*errorsPath, report); err != nil {
+			log.Fatalf("Failed to write error report: %v", err)
+		}
+		log.Printf("Wrote %d rejected rows to %s", report.Rejected, *errorsPath)
+	}
+	verb := "imported"
+	if report.DryRun {
+		verb = "valid (dry run)"
+	}
+	fmt.Printf("%d rows: %d %s, %d rejected\n", report.Rows, report.Imported, verb, report.Rejected)
+	if err != nil {
+		log.Fatalf("Import stopped early: %v", err)
+	}
+}
+
+func writeImportErrorReport(path string, report *handler.LoanImportReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := handler.WriteLoanImportErrorReport(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -1,18 +1,110 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/alphaloan/vehicle/collections"
+	"github.com/alphaloan/vehicle/commission"
 	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/events"
+	"github.com/alphaloan/vehicle/graphqlapi"
+	"github.com/alphaloan/vehicle/grpcapi"
 	"github.com/alphaloan/vehicle/handler"
+	"github.com/alphaloan/vehicle/notification"
+	"github.com/alphaloan/vehicle/outbox"
+	"github.com/alphaloan/vehicle/repayment"
+	"github.com/alphaloan/vehicle/storage"
+	"github.com/alphaloan/vehicle/webhook"
 )
 
 func main() {
-	//datastore.InitializeDatabase("db/migration", "sqlite3://alphaloan.db")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "webhook-listen" {
+		runWebhookListen(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "notify-sink" {
+		runNotifySink(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import-loans" {
+		runImportLoans(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		runOpenAPI(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "operator-key" {
+		runOperatorKey(os.Args[2:])
+		return
+	}
+
+	dbPath := flag.String("db", "alphaloan.db", "path to the sqlite database file")
+	migrationFolder := flag.String("migrations", "db/migration", "folder containing the sql migrations")
+	autoMigrate := flag.Bool("auto-migrate", false, "apply pending migrations before starting the server")
+	documentDir := flag.String("document-dir", "data/documents", "directory where uploaded documents are stored")
+	disbursementOutbox := flag.String("disbursement-outbox", "data/outbox", "directory where bank payment files of approved disbursements are written")
+	maxDocumentBytes := flag.Int64("max-document-bytes", handler.DefaultMaxDocumentBytes, "maximum size of an uploaded document")
+	paymentWaterfall := flag.String("payment-waterfall", repayment.DefaultWaterfall.String(), "order in which payments settle installment components")
+	collectionsInterval := flag.Duration("collections-interval", time.Hour, "how often overdue installments and penalties are assessed, 0 disables the job")
+	payoffQuoteDays := flag.Int("payoff-quote-days", handler.DefaultPayoffQuoteValidDays, "number of days a payoff quote can be settled after its as_of date")
+	eventSink := flag.String("event-sink", "", "where outbox events are relayed: file:<path>, http(s)://<url> or nats|tls://<host:port>/<subject prefix>, empty keeps them in the outbox")
+	eventRelayInterval := flag.Duration("event-relay-interval", 5*time.Second, "how often pending outbox events are relayed to -event-sink")
+	webhookInterval := flag.Duration("webhook-interval", 10*time.Second, "how often due dealer webhook deliveries are sent, 0 disables delivery")
+	webhookMaxAttempts := flag.Int("webhook-max-attempts", webhook.DefaultMaxAttempts, "attempts after which a webhook delivery is dead-lettered")
+	webhookRetryBase := flag.Duration("webhook-retry-base", webhook.DefaultBaseBackoff, "wait before the first webhook retry, doubled after every further failure")
+	webhookAllowLocal := flag.Bool("webhook-allow-local", false, "accept plain http and loopback, private or link-local webhook endpoints, only for trying webhooks against a local stub such as webhook-listen")
+	notifyEmail := flag.String("notify-email", "", "SMTP relay for customer email as smtp://[user:password@]host:port?from=<address>, empty keeps email notifications pending")
+	notifySMS := flag.String("notify-sms", "", "SMS gateway URL that customer text messages are POSTed to, empty keeps SMS notifications pending")
+	notifyInterval := flag.Duration("notify-interval", 10*time.Second, "how often pending customer notifications are sent")
+	reminderDays := flag.Int("reminder-days", notification.DefaultReminderDays, "days before its due date that customers are reminded of an installment")
+	operatorKeysFile := flag.String("operator-keys", "", "file of back-office operator API keys as printed by operator-key, empty refuses every disbursement request")
+	piiExportToken := flag.String("pii-export-token", "", "bearer token that unmasks personal data in exports and GraphQL responses, empty always masks it")
+	reportCacheTTL := flag.Duration("report-cache-ttl", handler.DefaultReportCacheTTL, "how long a computed portfolio report is served before it is recomputed, 0 disables the cache")
+	validateRequests := flag.Bool("validate-requests", true, "refuse requests that do not match the OpenAPI document with 400")
+	validateResponses := flag.Bool("validate-responses", false, "log and flag responses that do not match the OpenAPI document, for development")
+	legacySunsetDate := flag.String("legacy-sunset", handler.DefaultLegacySunset.Format(time.DateOnly), "date (YYYY-MM-DD) from which the deprecated routes predating /api/v1 answer 410 Gone")
+	graphQLMaxDepth := flag.Int("graphql-max-depth", graphqlapi.DefaultMaxDepth, "refuse GraphQL queries nested deeper than this, 0 disables the limit")
+	graphQLMaxComplexity := flag.Int("graphql-max-complexity", graphqlapi.DefaultMaxComplexity, "refuse GraphQL queries estimated to resolve more fields than this, 0 disables the limit")
+	grpcAddr := flag.String("grpc-addr", "", "address the gRPC API listens on, off when empty; it has no TLS or authentication, so listen on a private interface only")
+	maxLoanToValue := flag.Float64("max-ltv", 0, "reject submissions whose loan-to-value exceeds this ratio, 0 disables the cap")
+	flag.Parse()
+
+	waterfall, err := repayment.ParseWaterfall(*paymentWaterfall)
+	if err != nil {
+		log.Fatal("Invalid -payment-waterfall ", err)
+	}
+
+	legacySunset, err := time.Parse(time.DateOnly, *legacySunsetDate)
+	if err != nil {
+		log.Fatal("Invalid -legacy-sunset ", err)
+	}
+
+	operatorKeys := handler.OperatorKeys{}
+	if *operatorKeysFile != "" {
+		operatorKeys, err = handler.LoadOperatorKeys(*operatorKeysFile)
+		if err != nil {
+			log.Fatal("Invalid -operator-keys ", err)
+		}
+	}
 
-	db, err := sql.Open("sqlite3", "alphaloan.db")
+	if err := prepareSchema(*migrationFolder, *dbPath, *autoMigrate); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", *dbPath)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -22,23 +114,286 @@ func main() {
 		log.Fatal("Failed to enable foreign_keys ", err)
 	}
 
+	// Exports read from an open cursor for as long as the client downloads;
+	// with a write-ahead log writers can still commit meanwhile.
+	_, err = db.Exec("PRAGMA journal_mode = WAL;")
+	if err != nil {
+		log.Fatal("Failed to enable write-ahead logging ", err)
+	}
+
 	defer db.Close()
 
 	loanCustomerStore := datastore.NewLoanCustomerStore(db)
 	loanSubmissionStore := datastore.NewLoanSubmissionStore(db)
+	vehicleCatalogueStore := datastore.NewVehicleCatalogueStore(db)
+	loanValuationStore := datastore.NewLoanValuationStore(db)
+	loanProductStore := datastore.NewLoanProductStore(db)
+	loanQuoteStore := datastore.NewLoanQuoteStore(db)
+	documentStore := datastore.NewDocumentStore(db)
+	submissionPartyStore := datastore.NewSubmissionPartyStore(db)
+	loanRepaymentStore := datastore.NewLoanRepaymentStore(db)
+	loanDelinquencyStore := datastore.NewLoanDelinquencyStore(db)
+	loanPayoffStore := datastore.NewLoanPayoffStore(db)
+	loanDisbursementStore := datastore.NewLoanDisbursementStore(db)
+	dealerStore := datastore.NewDealerStore(db)
+	commissionStore := datastore.NewCommissionStore(db)
+	eventOutboxStore := datastore.NewEventOutboxStore(db)
+	webhookStore := datastore.NewWebhookStore(db)
+	notificationStore := datastore.NewNotificationStore(db)
+	reportStore := datastore.NewReportStore(db)
 
-	loanSubmitHandler := handler.NewLoanSubmitHandler(*loanCustomerStore, *loanSubmissionStore)
-	loanSubmissionHandler := handler.NewLoanSubmissionHandler(*loanSubmissionStore)
+	blobStore, err := storage.NewLocalBlobStore(*documentDir)
+	if err != nil {
+		log.Fatal("Failed to initialize document storage ", err)
+	}
+	paymentOutbox, err := storage.NewLocalBlobStore(*disbursementOutbox)
+	if err != nil {
+		log.Fatal("Failed to initialize disbursement outbox ", err)
+	}
+
+	loanSubmitHandler := handler.NewLoanSubmitHandler(*loanSubmissionStore, *vehicleCatalogueStore,
+		*loanProductStore, *dealerStore)
+	loanSubmitHandler.MaxLoanToValue = *maxLoanToValue
+	loanImportHandler := handler.NewLoanImportHandler(loanSubmitHandler)
+	loanSubmissionHandler := handler.NewLoanSubmissionHandler(*loanSubmissionStore, *loanValuationStore, *loanQuoteStore,
+		*submissionPartyStore)
 	loanCustomerHandler := handler.NewLoanCustomerHandler(*loanCustomerStore, *loanSubmissionStore)
+	vehicleCatalogueHandler := handler.NewVehicleCatalogueHandler(*vehicleCatalogueStore)
+	loanProductHandler := handler.NewLoanProductHandler(*loanProductStore)
+	documentHandler := handler.NewDocumentHandler(*documentStore, *loanCustomerStore, *loanSubmissionStore,
+		*loanProductStore, blobStore)
+	documentHandler.MaxUploadBytes = *maxDocumentBytes
+	repaymentHandler := handler.NewRepaymentHandler(*loanSubmissionStore, *loanQuoteStore, *loanRepaymentStore)
+	repaymentHandler.Waterfall = waterfall
+	payoffHandler := handler.NewPayoffHandler(*loanSubmissionStore, *loanProductStore, *loanRepaymentStore,
+		*loanPayoffStore)
+	payoffHandler.QuoteValidDays = *payoffQuoteDays
+	disbursementHandler := handler.NewDisbursementHandler(*loanDisbursementStore, *loanSubmissionStore, *loanQuoteStore,
+		paymentOutbox)
+	dealerHandler := handler.NewDealerHandler(*dealerStore, *loanSubmissionStore)
+	commissionLedger := commission.NewLedger(dealerStore, commissionStore)
+	commissionHandler := handler.NewCommissionHandler(*loanSubmissionStore, *dealerStore, *commissionStore,
+		commissionLedger)
+	collectionsJob := collections.NewJob(loanRepaymentStore, loanProductStore, loanQuoteStore, loanDelinquencyStore)
+	collectionsJob.Commissions = commissionLedger
+	collectionsJob.Reminders = notification.NewReminders(notificationStore)
+	collectionsJob.Reminders.DaysBefore = *reminderDays
+	collectionsHandler := handler.NewCollectionsHandler(*loanDelinquencyStore, collectionsJob)
+	eventHandler := handler.NewEventHandler(*eventOutboxStore)
+	webhookHandler := handler.NewWebhookHandler(*webhookStore)
+	webhookHandler.AllowLocalEndpoints = *webhookAllowLocal
+	notificationHandler := handler.NewNotificationHandler(*notificationStore)
+	exportHandler := handler.NewExportHandler(*loanSubmissionStore, *loanCustomerStore)
+	exportHandler.PIIToken = *piiExportToken
+	reportHandler := handler.NewReportHandler(*reportStore)
+	reportHandler.CacheTTL = *reportCacheTTL
+	graphQLServer := graphqlapi.NewServer(*loanCustomerStore, *loanSubmissionStore, *submissionPartyStore, *documentStore,
+		*eventOutboxStore)
+	graphQLServer.MaxDepth = *graphQLMaxDepth
+	graphQLServer.MaxComplexity = *graphQLMaxComplexity
+	graphQLHandler := handler.NewGraphQLHandler(graphQLServer)
+	graphQLHandler.PIIToken = *piiExportToken
+	apiDocument, err := handler.OpenAPIDocument()
+	if err != nil {
+		log.Fatal("Invalid OpenAPI document ", err)
+	}
+	openAPIHandler := handler.NewOpenAPIHandler(apiDocument)
 
-	http.HandleFunc("/api/loan/submit", loanSubmitHandler.HandleSubmitLoan)
-	http.HandleFunc("/api/loan/submissions", loanSubmissionHandler.HandleGetAllLoanSubmission)
-	http.HandleFunc("/api/loan/submission/tracks", loanSubmissionHandler.HandleSubmissionLoanById)
-	http.HandleFunc("/api/loan/customers", loanCustomerHandler.HandleGetAllLoanSubmission)
-	http.HandleFunc("/api/loan/customers/{customerID}/info", loanCustomerHandler.HandleGetCustomerAndSubmissionById)
-	http.HandleFunc("/api/loan/customer/{customerID}/update", loanCustomerHandler.HandlerUpdateCustomerById)
-	http.HandleFunc("/api/loan/customer/{customerID}/delete", loanCustomerHandler.HandlerDeleteCustomerById)
+	if *collectionsInterval > 0 {
+		go collectionsJob.Start(context.Background(), *collectionsInterval)
+	}
+
+	if *eventSink != "" {
+		sink, err := events.OpenSink(*eventSink)
+		if err != nil {
+			log.Fatal("Invalid -event-sink ", err)
+		}
+		go outbox.NewRelay(eventOutboxStore, sink).Start(context.Background(), *eventRelayInterval)
+	}
+
+	if *webhookInterval > 0 {
+		dispatcher := webhook.NewDispatcher(webhookStore)
+		dispatcher.MaxAttempts = *webhookMaxAttempts
+		dispatcher.BaseBackoff = *webhookRetryBase
+		dispatcher.Client = webhook.NewClient(*webhookAllowLocal)
+		go dispatcher.Start(context.Background(), *webhookInterval)
+	}
+
+	notifier := notification.NewDispatcher(notificationStore)
+	if *notifyEmail != "" {
+		sender, err := notification.NewSMTPSender(*notifyEmail)
+		if err != nil {
+			log.Fatal("Invalid -notify-email ", err)
+		}
+		notifier.Senders[datastore.NotificationChannelEmail] = sender
+	}
+	if *notifySMS != "" {
+		sender, err := notification.NewSMSGatewaySender(*notifySMS)
+		if err != nil {
+			log.Fatal("Invalid -notify-sms ", err)
+		}
+		notifier.Senders[datastore.NotificationChannelSMS] = sender
+	}
+	if len(notifier.Senders) > 0 {
+		go notifier.Start(context.Background(), *notifyInterval)
+	}
+
+	router := handler.NewRouter(http.DefaultServeMux)
+	router.Sunset = legacySunset
+	v1 := router.Version("v1")
+
+	v1.HandleFunc("POST /submissions", handler.AcceptDealerKey(*dealerStore, loanSubmitHandler.HandleSubmitLoan))
+	v1.HandleFunc("GET /submissions", loanSubmissionHandler.HandleGetAllLoanSubmission)
+	v1.HandleFunc("GET /submissions/{submissionID}", loanSubmissionHandler.HandleSubmissionLoanById)
+	v1.HandleFunc("GET /submissions/{submissionID}/valuation", loanSubmissionHandler.HandleGetSubmissionValuation)
+	v1.HandleFunc("GET /submissions/{submissionID}/duplicates", loanSubmissionHandler.HandleGetDuplicateCollateral)
+	v1.HandleFunc("GET /submissions/{submissionID}/quote", loanSubmissionHandler.HandleGetSubmissionQuote)
+	v1.HandleFunc("GET /submissions/{submissionID}/parties", loanSubmissionHandler.HandleGetSubmissionParties)
+	v1.HandleFunc("GET /submissions/{submissionID}/documents", documentHandler.HandleGetSubmissionDocuments)
+	v1.HandleFunc("POST /submissions/{submissionID}/documents", documentHandler.HandleUploadSubmissionDocument)
+	v1.HandleFunc("GET /submissions/{submissionID}/documents/checklist", documentHandler.HandleGetDocumentChecklist)
+	v1.HandleFunc("GET /customers/{customerID}/documents", documentHandler.HandleGetCustomerDocuments)
+	v1.HandleFunc("POST /customers/{customerID}/documents", documentHandler.HandleUploadCustomerDocument)
+	v1.HandleFunc("GET /documents/{documentID}", documentHandler.HandleDownloadDocument)
+	v1.HandleFunc("DELETE /documents/{documentID}", documentHandler.HandleDeleteDocument)
+	v1.HandleFunc("GET /products", loanProductHandler.HandleGetActiveLoanProducts)
+	v1.HandleFunc("GET /customers", loanCustomerHandler.HandleGetAllLoanSubmission)
+	v1.HandleFunc("GET /customers/{customerID}", loanCustomerHandler.HandleGetCustomerAndSubmissionById)
+	v1.HandleFunc("PATCH /customers/{customerID}", loanCustomerHandler.HandlerUpdateCustomerById)
+	v1.HandleFunc("DELETE /customers/{customerID}", loanCustomerHandler.HandlerDeleteCustomerById)
+	v1.HandleFunc("GET /customers/{customerID}/notifications", notificationHandler.HandleGetCustomerNotifications)
+
+	v1.HandleFunc("GET /loans/{submissionID}/schedule", repaymentHandler.HandleGetRepaymentSchedule)
+	v1.HandleFunc("POST /loans/{submissionID}/schedule", repaymentHandler.HandleGenerateRepaymentSchedule)
+	v1.HandleFunc("GET /loans/{submissionID}/payments", repaymentHandler.HandleGetLoanPayments)
+	v1.HandleFunc("POST /loans/{submissionID}/payments", handler.RequireOperatorKey(operatorKeys, repaymentHandler.HandlePostLoanPayment))
+	v1.HandleFunc("GET /loans/{submissionID}/balance", repaymentHandler.HandleGetLoanBalance)
+	v1.HandleFunc("GET /loans/{submissionID}/payoff", payoffHandler.HandleGetPayoffQuote)
+	v1.HandleFunc("POST /loans/{submissionID}/settlement", handler.RequireOperatorKey(operatorKeys, payoffHandler.HandleSettleLoan))
+	v1.HandleFunc("GET /collections/aging", collectionsHandler.HandleGetAgingReport)
+	v1.HandleFunc("GET /events/schemas", eventHandler.HandleGetEventSchemas)
+	v1.HandleFunc("GET /events/schemas/{eventType}/{version}", eventHandler.HandleGetEventSchema)
+
+	v1.HandleFunc("POST /dealer/submissions", handler.RequireDealerKey(*dealerStore, loanSubmitHandler.HandleSubmitLoan))
+	v1.HandleFunc("GET /dealer/submissions", handler.RequireDealerKey(*dealerStore, dealerHandler.HandleGetOwnSubmissions))
+	v1.HandleFunc("GET /dealer/submissions/{submissionID}", handler.RequireDealerKey(*dealerStore, dealerHandler.HandleGetOwnSubmissionById))
+	v1.HandleFunc("GET /dealer/agents", handler.RequireDealerKey(*dealerStore, dealerHandler.HandleGetOwnAgents))
+	v1.HandleFunc("GET /dealer/summary", handler.RequireDealerKey(*dealerStore, dealerHandler.HandleGetOwnSummary))
+	v1.HandleFunc("GET /dealer/commissions", handler.RequireDealerKey(*dealerStore, commissionHandler.HandleGetOwnCommissionStatement))
+	v1.HandleFunc("GET /dealer/webhooks", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleGetWebhooks))
+	v1.HandleFunc("POST /dealer/webhooks", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleCreateWebhook))
+	v1.HandleFunc("GET /dealer/webhooks/{subscriptionID}", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleGetWebhookById))
+	v1.HandleFunc("PUT /dealer/webhooks/{subscriptionID}", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleUpdateWebhook))
+	v1.HandleFunc("DELETE /dealer/webhooks/{subscriptionID}", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleDeleteWebhook))
+	v1.HandleFunc("GET /dealer/webhooks/{subscriptionID}/deliveries", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleGetWebhookDeliveries))
+	v1.HandleFunc("POST /dealer/webhooks/{subscriptionID}/deliveries/{deliveryID}/redeliver", handler.RequireDealerKey(*dealerStore, webhookHandler.HandleRedeliverWebhook))
+
+	v1.HandleFunc("GET /admin/vehicle/types", vehicleCatalogueHandler.HandleGetVehicleTypes)
+	v1.HandleFunc("POST /admin/vehicle/types", vehicleCatalogueHandler.HandleCreateVehicleType)
+	v1.HandleFunc("PUT /admin/vehicle/types/{typeID}", vehicleCatalogueHandler.HandleUpdateVehicleType)
+	v1.HandleFunc("DELETE /admin/vehicle/types/{typeID}", vehicleCatalogueHandler.HandleDeleteVehicleType)
+	v1.HandleFunc("GET /admin/vehicle/brands", vehicleCatalogueHandler.HandleGetVehicleBrands)
+	v1.HandleFunc("POST /admin/vehicle/brands", vehicleCatalogueHandler.HandleCreateVehicleBrand)
+	v1.HandleFunc("PUT /admin/vehicle/brands/{brandID}", vehicleCatalogueHandler.HandleUpdateVehicleBrand)
+	v1.HandleFunc("DELETE /admin/vehicle/brands/{brandID}", vehicleCatalogueHandler.HandleDeleteVehicleBrand)
+	v1.HandleFunc("GET /admin/vehicle/models", vehicleCatalogueHandler.HandleGetVehicleModels)
+	v1.HandleFunc("POST /admin/vehicle/models", vehicleCatalogueHandler.HandleCreateVehicleModel)
+	v1.HandleFunc("PUT /admin/vehicle/models/{modelID}", vehicleCatalogueHandler.HandleUpdateVehicleModel)
+	v1.HandleFunc("DELETE /admin/vehicle/models/{modelID}", vehicleCatalogueHandler.HandleDeleteVehicleModel)
+	v1.HandleFunc("POST /admin/vehicle/catalogue/import", vehicleCatalogueHandler.HandleImportCatalogue)
+	v1.HandleFunc("GET /admin/products", loanProductHandler.HandleGetLoanProducts)
+	v1.HandleFunc("POST /admin/products", loanProductHandler.HandleCreateLoanProduct)
+	v1.HandleFunc("GET /admin/products/{productID}", loanProductHandler.HandleGetLoanProductById)
+	v1.HandleFunc("PUT /admin/products/{productID}", loanProductHandler.HandleUpdateLoanProduct)
+	v1.HandleFunc("DELETE /admin/products/{productID}", loanProductHandler.HandleDeactivateLoanProduct)
+	v1.HandleFunc("POST /admin/loans/import", handler.RequireOperatorKey(operatorKeys, loanImportHandler.HandleImportLoans))
+	v1.HandleFunc("POST /admin/submissions/{submissionID}/decision", handler.RequireOperatorKey(operatorKeys, commissionHandler.HandleSubmissionDecision))
+	v1.HandleFunc("POST /admin/collections/run", handler.RequireOperatorKey(operatorKeys, collectionsHandler.HandleRunCollections))
+	v1.HandleFunc("GET /admin/events/outbox", eventHandler.HandleGetOutboxStats)
+	v1.HandleFunc("GET /admin/notifications", notificationHandler.HandleGetNotifications)
+	v1.HandleFunc("GET /admin/exports/submissions", exportHandler.HandleExportSubmissions)
+	v1.HandleFunc("GET /admin/exports/customers", exportHandler.HandleExportCustomers)
+	v1.HandleFunc("GET /admin/reports/submissions", reportHandler.HandleGetSubmissionVolumes)
+	v1.HandleFunc("GET /admin/reports/funnel", reportHandler.HandleGetSubmissionFunnel)
+	v1.HandleFunc("POST /graphql", graphQLHandler.HandleGraphQL)
+	v1.HandleFunc("GET /admin/dealers", dealerHandler.HandleGetDealers)
+	v1.HandleFunc("POST /admin/dealers", dealerHandler.HandleCreateDealer)
+	v1.HandleFunc("GET /admin/dealers/summary", dealerHandler.HandleGetDealerSummaries)
+	v1.HandleFunc("GET /admin/dealers/{dealerID}", dealerHandler.HandleGetDealerById)
+	v1.HandleFunc("PUT /admin/dealers/{dealerID}", dealerHandler.HandleUpdateDealer)
+	v1.HandleFunc("DELETE /admin/dealers/{dealerID}", dealerHandler.HandleDeactivateDealer)
+	v1.HandleFunc("GET /admin/dealers/{dealerID}/branches", dealerHandler.HandleGetDealerBranches)
+	v1.HandleFunc("POST /admin/dealers/{dealerID}/branches", dealerHandler.HandleCreateDealerBranch)
+	v1.HandleFunc("GET /admin/dealers/{dealerID}/agents", dealerHandler.HandleGetDealerAgents)
+	v1.HandleFunc("POST /admin/dealers/{dealerID}/agents", dealerHandler.HandleCreateDealerAgent)
+	v1.HandleFunc("GET /admin/dealers/{dealerID}/credentials", handler.RequireOperatorKey(operatorKeys, dealerHandler.HandleGetDealerCredentials))
+	v1.HandleFunc("POST /admin/dealers/{dealerID}/credentials", handler.RequireOperatorKey(operatorKeys, dealerHandler.HandleIssueDealerCredential))
+	v1.HandleFunc("DELETE /admin/dealers/{dealerID}/credentials/{credentialID}", handler.RequireOperatorKey(operatorKeys, dealerHandler.HandleRevokeDealerCredential))
+	v1.HandleFunc("GET /admin/dealers/{dealerID}/commissions", commissionHandler.HandleGetCommissionStatement)
+	v1.HandleFunc("GET /admin/disbursements", handler.RequireOperatorKey(operatorKeys, disbursementHandler.HandleGetDisbursements))
+	v1.HandleFunc("POST /admin/disbursements", handler.RequireOperatorKey(operatorKeys, disbursementHandler.HandleCreateDisbursement))
+	v1.HandleFunc("GET /admin/disbursements/{disbursementID}", handler.RequireOperatorKey(operatorKeys, disbursementHandler.HandleGetDisbursementById))
+	v1.HandleFunc("POST /admin/disbursements/{disbursementID}/approve", handler.RequireOperatorKey(operatorKeys, disbursementHandler.HandleApproveDisbursement))
+	v1.HandleFunc("POST /admin/disbursements/{disbursementID}/reject", handler.RequireOperatorKey(operatorKeys, disbursementHandler.HandleRejectDisbursement))
+	v1.HandleFunc("POST /admin/disbursements/{disbursementID}/payment-file/retry", handler.RequireOperatorKey(operatorKeys, disbursementHandler.HandleRetryPaymentFile))
+
+	router.HandleFunc("GET /openapi.json", openAPIHandler.HandleGetOpenAPI)
+	router.HandleFunc("GET /docs/", openAPIHandler.HandleSwaggerUI)
+
+	for _, route := range handler.LegacyRoutes {
+		router.Alias(route)
+	}
+
+	contractValidator := handler.NewContractValidator(apiDocument, http.DefaultServeMux)
+	contractValidator.ValidateRequests = *validateRequests
+	contractValidator.ValidateResponses = *validateResponses
+
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %v", *grpcAddr, err)
+		}
+		grpcServer := grpcapi.NewServer(grpcapi.NewLoanServer(*loanCustomerStore, *loanSubmissionStore, loanSubmitHandler))
+		go func() {
+			log.Fatal(grpcServer.Serve(listener))
+		}()
+		log.Printf("gRPC API listening on %s", listener.Addr())
+	}
 
 	log.Println("Listening on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Fatal(http.ListenAndServe(":8080", contractValidator))
+}
+
+// prepareSchema refuses a schema it cannot run on, and migrates it when
+// autoMigrate is set. The caller exits on its error, so that the migrator is
+// closed first.
+func prepareSchema(migrationFolder, dbPath string, autoMigrate bool) error {
+	migrator, err := datastore.NewMigrator(migrationFolder, "sqlite3://"+dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+	defer migrator.Close()
+
+	if err := migrator.CheckSchema(); err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		log.Println("Applying database migrations...")
+		if err := migrator.Up(); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		log.Println("Database migrations applied successfully!")
+		return nil
+	}
+
+	version, _, err := migrator.Version()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version < migrator.LatestVersion() {
+		log.Printf("Database schema at version %d, latest is %d; run migrate up or start with -auto-migrate",
+			version, migrator.LatestVersion())
+	}
+	return nil
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+const migrateUsage = `Usage: vehicle migrate [flags] <command>
+
+Commands:
+  up            apply all pending migrations
+  down N        roll back the last N migrations
+  goto V        migrate up or down to version V
+  version       print the current schema version
+  force V       set the version without running migrations, clearing the dirty flag
+  create NAME   create a new empty up/down migration pair
+
+Flags:
+`
+
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbPath := fs.String("db", "alphaloan.db", "path to the sqlite database file")
+	migrationFolder := fs.String("migrations", "db/migration", "folder containing the sql migrations")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), migrateUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+
+	if command == "create" {
+		if len(rest) != 1 {
+			log.Fatal("usage: migrate create NAME")
+		}
+		files, err := datastore.CreateMigration(*migrationFolder, rest[0])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		for _, file := range files {
+			fmt.Println(file)
+		}
+		return
+	}
+
+	migrator, err := datastore.NewMigrator(*migrationFolder, "sqlite3://"+*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize migrations: %v", err)
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		var steps int
+		steps, err = intArg(rest, "down N")
+		if err == nil {
+			err = migrator.Down(steps)
+		}
+	case "goto":
+		var version int
+		version, err = intArg(rest, "goto V")
+		if err == nil && version < 0 {
+			err = fmt.Errorf("version must not be negative, got %d", version)
+		}
+		if err == nil {
+			err = migrator.Goto(uint(version))
+		}
+	case "force":
+		var version int
+		version, err = intArg(rest, "force V")
+		if err == nil {
+			err = migrator.Force(version)
+		}
+	case "version":
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("migrate %s failed: %v", command, err)
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		log.Fatalf("Failed to read schema version: %v", err)
+	}
+	fmt.Printf("version %d (latest %d), dirty=%t\n", version, migrator.LatestVersion(), dirty)
+}
+
+func intArg(args []string, usage string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: migrate %s", usage)
+	}
+	return strconv.Atoi(args[0])
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/alphaloan/vehicle/notification/notificationtest"
+)
+
+const notifySinkUsage = `Usage: vehicle notify-sink [flags]
+
+Runs the fake SMTP server and SMS gateway that tests use, from the
+notificationtest package, and logs every message they accept, for trying out
+customer notifications locally. Point the server at them with
+-notify-email smtp://localhost:2525?from=... and
+-notify-sms http://localhost:9091/sms.
+
+Flags:
+`
+
+func runNotifySink(args []string) {
+	fs := flag.NewFlagSet("notify-sink", flag.ExitOnError)
+	smtpAddr := fs.String("smtp", ":2525", "address of the fake SMTP server, empty disables it")
+	smsAddr := fs.String("sms", ":9091", "address of the fake SMS gateway, empty disables it")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), notifySinkUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 || (*smtpAddr == "" && *smsAddr == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	errs := make(chan error, 2)
+	if *smtpAddr != "" {
+		server, err := notificationtest.ListenSMTP(*smtpAddr, logFakeEmail)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Fake SMTP server listening on", server.Addr)
+		go func() { errs <- server.Serve() }()
+	}
+	if *smsAddr != "" {
+		log.Println("Fake SMS gateway listening on", *smsAddr)
+		go func() { errs <- http.ListenAndServe(*smsAddr, notificationtest.NewSMSGateway(logFakeSMS)) }()
+	}
+	log.Fatal(<-errs)
+}
+
+func logFakeSMS(message notificationtest.SMS) {
+	log.Printf("sms to %s (ref %s): %s\n", message.To, message.Reference, message.Message)
+}
+
+func logFakeEmail(email notificationtest.Email) {
+	log.Printf("email to %s: %s\n    %s\n", email.To, email.Subject, strings.ReplaceAll(email.Body, "\n", "\n    "))
+}
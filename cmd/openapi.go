@@ -0,0 +1,483 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alphaloan/vehicle/handler"
+	"github.com/alphaloan/vehicle/openapi"
+)
+
+const openAPIUsage = `Usage: vehicle openapi [flags]
+
+Prints the OpenAPI document the server publishes at /openapi.json.
+
+With -check the document is compared with the routes registered in
+cmd/main.go and with what their handlers do: every method and path must be
+documented with its handler and the JSON bodies it answers with. Any drift
+is listed and the command exits with status 1. go test ./cmd runs the same
+check.
+
+Flags:
+`
+
+func runOpenAPI(args []string) {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	output := fs.String("o", "", "write the document to this file instead of stdout")
+	check := fs.Bool("check", false, "compare the document with the routes and handlers instead of printing it")
+	source := fs.String("source", ".", "root of the module source read by -check")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), openAPIUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	doc, err := handler.OpenAPIDocument()
+	if err != nil {
+		log.Fatalf("Failed to build the OpenAPI document: %v", err)
+	}
+
+	if *check {
+		problems, err := checkOpenAPIDrift(doc, *source)
+		if err != nil {
+			log.Fatalf("Failed to read the source: %v", err)
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("%d differences between the OpenAPI document and the handlers\n", len(problems))
+			os.Exit(1)
+		}
+		fmt.Println("OpenAPI document matches the handlers")
+		return
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// apiRoute is a route registered in main with the handler method serving it
+// and the middleware authenticating it, if any.
+type apiRoute struct {
+	method  string
+	path    string
+	handler string
+	auth    string
+}
+
+// authSecurity is the security scheme documenting each authenticating
+// middleware of the handler package. Optional middleware serves requests
+// without credentials too and is documented as optional security.
+var authSecurity = map[string]struct {
+	scheme   string
+	optional bool
+}{
+	"RequireDealerKey":   {scheme: handler.DealerKeySecurity},
+	"AcceptDealerKey":    {scheme: handler.DealerKeySecurity, optional: true},
+	"RequireOperatorKey": {scheme: handler.OperatorKeySecurity},
+}
+
+// handlerBehaviour is what a handler was seen doing: the JSON bodies it writes
+// with a constant status and those written with a status decided elsewhere.
+type handlerBehaviour struct {
+	replies  map[string]bool
+	anyReply map[string]bool
+	texts    map[int]bool
+}
+
+// checkOpenAPIDrift reads the routes of cmd/main.go and the handler package
+// source and lists every way the document disagrees with them.
+func checkOpenAPIDrift(doc *openapi.Document, source string) ([]string, error) {
+	routes, err := parseRoutes(filepath.Join(source, "cmd", "main.go"))
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := parseHandlerFuncs(filepath.Join(source, "handler"))
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		registered[route.method+" "+route.path] = true
+		var operation *openapi.Operation
+		if item, ok := doc.Paths[route.path]; ok {
+			operation = item.Operations()[route.method]
+		}
+		if operation == nil {
+			problems = append(problems, fmt.Sprintf("%s %s: route is not documented", route.method, route.path))
+			continue
+		}
+		operations := map[string]*openapi.Operation{route.method: operation}
+
+		if operation.Handler != route.handler {
+			problems = append(problems, fmt.Sprintf("%s %s: documented as served by %s, registered with %s",
+				route.method, route.path, operation.Handler, route.handler))
+		}
+		auth, authenticated := authSecurity[route.auth]
+		checked := make(map[string]bool)
+		for _, middleware := range authSecurity {
+			if checked[middleware.scheme] {
+				continue
+			}
+			checked[middleware.scheme] = true
+			if (authenticated && auth.scheme == middleware.scheme) != slices.ContainsFunc(operation.Security, func(s map[string][]string) bool {
+				_, ok := s[middleware.scheme]
+				return ok
+			}) {
+				problems = append(problems, fmt.Sprintf("%s %s: %s requirement is not documented as registered",
+					route.method, route.path, middleware.scheme))
+			}
+		}
+		if authenticated && auth.optional != slices.ContainsFunc(operation.Security, func(s map[string][]string) bool {
+			return len(s) == 0
+		}) {
+			problems = append(problems, fmt.Sprintf("%s %s: %s is not documented as optional only when %s is",
+				route.method, route.path, auth.scheme, route.auth))
+		}
+
+		behaviour := &handlerBehaviour{
+			replies:  make(map[string]bool),
+			anyReply: make(map[string]bool),
+			texts:    make(map[int]bool),
+		}
+		roots := []string{route.handler}
+		if route.auth != "" {
+			roots = append(roots, route.auth)
+		}
+		visited := make(map[string]bool)
+		for _, root := range roots {
+			inspectHandler(funcs, root, behaviour, visited)
+		}
+
+		for reply := range behaviour.replies {
+			status, name, _ := strings.Cut(reply, " ")
+			if !documentsReply(operations, status, name) {
+				problems = append(problems, fmt.Sprintf("%s %s: %s reply with %s is not documented", route.method, route.path, status, name))
+			}
+		}
+		for name := range behaviour.anyReply {
+			if !documentsReply(operations, "", name) {
+				problems = append(problems, fmt.Sprintf("%s %s: reply with %s is not documented", route.method, route.path, name))
+			}
+		}
+		for status := range behaviour.texts {
+			if !documentsText(operations, strconv.Itoa(status)) {
+				problems = append(problems, fmt.Sprintf("%s %s: %d plain text error is not documented", route.method, route.path, status))
+			}
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			if !registered[method+" "+path] {
+				problems = append(problems, fmt.Sprintf("%s %s: documented but not registered in main", method, path))
+			}
+		}
+	}
+	slices.Sort(problems)
+	return problems, nil
+}
+
+// documentsReply reports whether an operation answers status, or any status
+// when it is empty, with the JSON schema of the named type.
+func documentsReply(operations map[string]*openapi.Operation, status, name string) bool {
+	ref := "#/components/schemas/" + name
+	for _, operation := range operations {
+		for code, response := range operation.Responses {
+			if status != "" && code != status {
+				continue
+			}
+			if media := response.Content[openapi.ContentTypeJSON]; media != nil && media.Schema != nil &&
+				slices.Contains(media.Schema.Refs(), ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func documentsText(operations map[string]*openapi.Operation, status string) bool {
+	for _, operation := range operations {
+		if response := operation.Responses[status]; response != nil && response.Content["text/plain"] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRoutes collects the HandleFunc registrations of main on the router and
+// its versions, naming each handler after the type its variable was created
+// with by handler.NewX. When main registers the legacy routes with Alias they
+// are served by the handler of their successor.
+func parseRoutes(path string) ([]apiRoute, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string)
+	prefixes := make(map[string]string)
+	ast.Inspect(file, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		pkg, name := selectorName(call.Fun)
+		if pkg == "handler" && strings.HasPrefix(name, "New") {
+			types[ident.Name] = strings.TrimPrefix(name, "New")
+			if name == "NewRouter" {
+				prefixes[ident.Name] = ""
+			}
+		}
+		if types[pkg] == "Router" && name == "Version" && len(call.Args) == 1 {
+			if literal, ok := call.Args[0].(*ast.BasicLit); ok {
+				version, _ := strconv.Unquote(literal.Value)
+				prefixes[ident.Name] = handler.APIPrefix + "/" + version
+			}
+		}
+		return true
+	})
+
+	var routes []apiRoute
+	var aliased bool
+	var failure error
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		variable, name := selectorName(call.Fun)
+		prefix, ok := prefixes[variable]
+		if !ok {
+			return true
+		}
+		if name == "Alias" {
+			aliased = true
+			return true
+		}
+		if name != "HandleFunc" || len(call.Args) != 2 {
+			return true
+		}
+		literal, ok := call.Args[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		pattern, _ := strconv.Unquote(literal.Value)
+		method, routePath, ok := strings.Cut(pattern, " ")
+		if !ok {
+			failure = fmt.Errorf("%s: route has no method", pattern)
+			return false
+		}
+
+		route := apiRoute{method: method, path: prefix + routePath}
+		target := call.Args[1]
+		if wrapper, ok := target.(*ast.CallExpr); ok {
+			pkg, name := selectorName(wrapper.Fun)
+			if _, ok := authSecurity[name]; ok && pkg == "handler" && len(wrapper.Args) == 2 {
+				route.auth = name
+				target = wrapper.Args[1]
+			}
+		}
+		handlerVariable, handlerMethod := selectorName(target)
+		if types[handlerVariable] == "" {
+			failure = fmt.Errorf("%s: cannot tell which handler serves the route", pattern)
+			return false
+		}
+		route.handler = types[handlerVariable] + "." + handlerMethod
+		routes = append(routes, route)
+		return true
+	})
+	if failure != nil || !aliased {
+		return routes, failure
+	}
+
+	for _, legacy := range handler.LegacyRoutes {
+		i := slices.IndexFunc(routes, func(route apiRoute) bool { return route.method+" "+route.path == legacy.Successor })
+		if i < 0 {
+			return nil, fmt.Errorf("%s: successor %s is not registered", legacy.Pattern, legacy.Successor)
+		}
+		route := routes[i]
+		route.method, route.path, _ = strings.Cut(legacy.Pattern, " ")
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// parseHandlerFuncs indexes the functions of the handler package by name and
+// its methods by Type.Method.
+func parseHandlerFuncs(dir string) (map[string]*ast.FuncDecl, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	funcs := make(map[string]*ast.FuncDecl)
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			funcs[funcKey(fn)] = fn
+		}
+	}
+	return funcs, nil
+}
+
+func funcKey(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// inspectHandler records what the function does, following calls to the
+// methods of the same type and to functions of the package.
+func inspectHandler(funcs map[string]*ast.FuncDecl, key string, behaviour *handlerBehaviour, visited map[string]bool) {
+	fn, ok := funcs[key]
+	if !ok || visited[key] {
+		return
+	}
+	visited[key] = true
+	typeName, _, isMethod := strings.Cut(key, ".")
+
+	var receiver string
+	if isMethod && len(fn.Recv.List[0].Names) > 0 {
+		receiver = fn.Recv.List[0].Names[0].Name
+	}
+
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			switch fun := node.Fun.(type) {
+			case *ast.Ident:
+				switch fun.Name {
+				case "writeJSON":
+					recordReply(node, behaviour)
+				default:
+					inspectHandler(funcs, fun.Name, behaviour, visited)
+				}
+			case *ast.SelectorExpr:
+				if pkg, name := selectorName(fun); pkg == "http" && name == "Error" && len(node.Args) == 3 {
+					if status, ok := statusCode(node.Args[2]); ok && status != http.StatusMethodNotAllowed {
+						behaviour.texts[status] = true
+					}
+				} else if pkg == receiver && receiver != "" {
+					inspectHandler(funcs, typeName+"."+name, behaviour, visited)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func recordReply(call *ast.CallExpr, behaviour *handlerBehaviour) {
+	if len(call.Args) != 3 {
+		return
+	}
+	body := call.Args[2]
+	if unary, ok := body.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		body = unary.X
+	}
+	literal, ok := body.(*ast.CompositeLit)
+	if !ok {
+		return
+	}
+	ident, ok := literal.Type.(*ast.Ident)
+	if !ok {
+		return
+	}
+	if status, ok := statusCode(call.Args[1]); ok {
+		behaviour.replies[strconv.Itoa(status)+" "+ident.Name] = true
+		return
+	}
+	behaviour.anyReply[ident.Name] = true
+}
+
+// statusCode resolves http.StatusX constants by the names net/http gives
+// them.
+func statusCode(expr ast.Expr) (int, bool) {
+	pkg, name := selectorName(expr)
+	if pkg != "http" || !strings.HasPrefix(name, "Status") {
+		return 0, false
+	}
+	for status := 100; status < 600; status++ {
+		text := http.StatusText(status)
+		if text != "" && name == "Status"+statusIdentifier(text) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// statusIdentifier turns "Unprocessable Entity" into UnprocessableEntity. The
+// few constants not named after their text are listed.
+func statusIdentifier(text string) string {
+	switch text {
+	case "I'm a teapot":
+		return "Teapot"
+	}
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == '-' }) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+func selectorName(expr ast.Expr) (string, string) {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return "", selector.Sel.Name
+	}
+	return ident.Name, selector.Sel.Name
+}
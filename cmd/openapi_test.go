@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alphaloan/vehicle/handler"
+)
+
+func TestOpenAPIDocumentMatchesHandlers(t *testing.T) {
+	doc, err := handler.OpenAPIDocument()
+	if err != nil {
+		t.Fatalf("building the OpenAPI document: %v", err)
+	}
+	problems, err := checkOpenAPIDrift(doc, "..")
+	if err != nil {
+		t.Fatalf("reading the source: %v", err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/alphaloan/vehicle/handler"
+)
+
+const operatorKeyUsage = `Usage: vehicle operator-key <user>
+
+Issues an API key for a back-office operator. The key is printed once, on
+the first line, for the operator to send as a bearer token. The second line
+is to be appended to the file the server reads with -operator-keys; it holds
+only the hash of the key.
+
+`
+
+func runOperatorKey(args []string) {
+	fs := flag.NewFlagSet("operator-key", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), operatorKeyUsage)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || strings.ContainsFunc(fs.Arg(0), func(r rune) bool { return r <= ' ' || r == '#' }) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	key, hash, err := handler.NewOperatorKey()
+	if err != nil {
+		log.Fatalf("Failed to generate a key: %v", err)
+	}
+	fmt.Println(key)
+	fmt.Println(fs.Arg(0), hash)
+}
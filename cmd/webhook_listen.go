@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/alphaloan/vehicle/webhook"
+)
+
+const webhookListenUsage = `Usage: vehicle webhook-listen [flags]
+
+Runs a local webhook receiver that verifies signatures and logs deliveries,
+for trying out dealer webhooks without a partner endpoint. The server only
+delivers to it when started with -webhook-allow-local.
+
+Flags:
+`
+
+func runWebhookListen(args []string) {
+	fs := flag.NewFlagSet("webhook-listen", flag.ExitOnError)
+	addr := fs.String("addr", ":9090", "address to listen on")
+	secret := fs.String("secret", "", "signing secret of the subscription, empty skips verification")
+	failFirst := fs.Int("fail-first", 0, "answer 503 to this many deliveries before accepting")
+	tolerance := fs.Duration("tolerance", 5*time.Minute, "maximum age of a signature timestamp")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), webhookListenUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var received atomic.Int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			return
+		}
+		n := received.Add(1)
+		log.Printf("delivery %s (%s): %s\n", r.Header.Get(webhook.DeliveryHeader), r.Header.Get(webhook.EventHeader), body)
+
+		if *secret != "" {
+			err := webhook.Verify(*secret, r.Header.Get(webhook.SignatureHeader), body, *tolerance, time.Now())
+			if err != nil {
+				log.Println("rejected:", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+		if n <= int64(*failFirst) {
+			log.Println("failing on purpose")
+			http.Error(w, "failing on purpose", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Println("Listening for webhooks on", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,309 @@
+package collections
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alphaloan/vehicle/commission"
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/notification"
+	"github.com/alphaloan/vehicle/repayment"
+	"github.com/alphaloan/vehicle/worker"
+)
+
+// Job marks overdue installments, accrues late penalties and refreshes the
+// days-past-due bucket of every approved or disbursed loan. Loans without a
+// repayment schedule get one derived from their quote first. When
+// Commissions is set, loans that fall into the 90+ bucket have their dealer
+// commission clawed back. When Reminders is set, customers are reminded of
+// upcoming and overdue installments once the loans have been assessed.
+type Job struct {
+	RepaymentStore   *datastore.LoanRepaymentStore
+	ProductStore     *datastore.LoanProductStore
+	QuoteStore       *datastore.LoanQuoteStore
+	DelinquencyStore *datastore.LoanDelinquencyStore
+	Commissions      *commission.Ledger
+	Reminders        *notification.Reminders
+}
+
+type Summary struct {
+	AsOf                time.Time
+	SchedulesDerived    int
+	LoansAssessed       int
+	LoansFailed         int
+	OverdueLoans        int
+	PenaltyCharged      int
+	CommissionClawbacks int
+	RemindersQueued     int
+}
+
+func NewJob(
+	repaymentStore *datastore.LoanRepaymentStore,
+	productStore *datastore.LoanProductStore,
+	quoteStore *datastore.LoanQuoteStore,
+	delinquencyStore *datastore.LoanDelinquencyStore) *Job {
+	return &Job{
+		RepaymentStore:   repaymentStore,
+		ProductStore:     productStore,
+		QuoteStore:       quoteStore,
+		DelinquencyStore: delinquencyStore,
+	}
+}
+
+// Start runs the job once straight away and then on every tick until ctx is
+// cancelled. Failures are logged and retried on the next tick.
+func (j *Job) Start(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, interval, func(context.Context) {
+		summary, err := j.Run(Today())
+		if err != nil {
+			log.Println("collections run failed:", err)
+		} else {
+			log.Printf("collections run as of %s: %d schedules derived, %d loans assessed, %d failed, %d overdue, %d penalty charged, %d commission clawbacks, %d reminders queued\n",
+				summary.AsOf.Format(time.DateOnly), summary.SchedulesDerived, summary.LoansAssessed, summary.LoansFailed, summary.OverdueLoans, summary.PenaltyCharged,
+				summary.CommissionClawbacks, summary.RemindersQueued)
+		}
+	})
+}
+
+// Run assesses every collectible loan as of a day. A loan that cannot be
+// assessed, e.g. for a broken schedule or a missing product, is logged and
+// counted as failed without holding up the others.
+func (j *Job) Run(asOf time.Time) (*Summary, error) {
+	loans, err := j.DelinquencyStore.GetCollectibleLoans()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{AsOf: asOf}
+	rules := make(map[string]repayment.PenaltyRule)
+	for _, loan := range loans {
+		if err := j.collect(loan, rules, asOf, summary); err != nil {
+			log.Printf("collections: submission %s: %v\n", loan.SubmissionID, err)
+			summary.LoansFailed++
+		}
+	}
+
+	if j.Reminders != nil {
+		queued, err := j.Reminders.Run(asOf)
+		summary.RemindersQueued = queued
+		if err != nil {
+			return summary, fmt.Errorf("installment reminders: %w", err)
+		}
+	}
+	return summary, nil
+}
+
+func (j *Job) collect(loan *datastore.CollectibleLoanRow, rules map[string]repayment.PenaltyRule, asOf time.Time, summary *Summary) error {
+	if !loan.HasSchedule {
+		derived, err := j.deriveSchedule(loan)
+		if err != nil {
+			return fmt.Errorf("repayment schedule: %w", err)
+		}
+		if !derived {
+			log.Printf("collections: submission %s has no quote or approval date to derive a repayment schedule from\n", loan.SubmissionID)
+			return nil
+		}
+		summary.SchedulesDerived++
+	}
+
+	rule, err := j.penaltyRule(rules, loan.ProductID)
+	if err != nil {
+		return err
+	}
+
+	delinquency, charged, err := j.assess(loan.SubmissionID, rule, asOf)
+	if err != nil {
+		return err
+	}
+	summary.LoansAssessed++
+	summary.PenaltyCharged += charged
+	if delinquency.DaysPastDue > 0 {
+		summary.OverdueLoans++
+	}
+
+	if j.Commissions != nil && delinquency.Bucket == repayment.BucketOver90 {
+		clawbacks, err := j.Commissions.ClawbackDefault(loan.SubmissionID, asOf)
+		if err != nil {
+			return fmt.Errorf("commission clawback: %w", err)
+		}
+		summary.CommissionClawbacks += clawbacks
+	}
+	return nil
+}
+
+// deriveSchedule stores the repayment schedule of a loan that has none, from
+// the terms it was quoted and approved on. The first installment falls due a
+// month after the loan was disbursed or, before that, approved. It reports
+// false when the loan has no quote or no approval date. A schedule posted
+// meanwhile is left alone.
+func (j *Job) deriveSchedule(loan *datastore.CollectibleLoanRow) (bool, error) {
+	startedAt := loan.DisbursedAt
+	if !startedAt.Valid {
+		startedAt = loan.ApprovedAt
+	}
+	if !startedAt.Valid {
+		return false, nil
+	}
+
+	quote, err := j.QuoteStore.GetQuoteBySubmissionId(loan.SubmissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Unix(startedAt.Int64, 0).UTC()
+	firstDueDate := repayment.AddMonths(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC), 1)
+	err = j.RepaymentStore.InsertInstallments(ScheduleRows(loan.SubmissionID, quote, firstDueDate))
+	if err != nil && !errors.Is(err, datastore.ErrScheduleExists) {
+		return false, err
+	}
+	return true, nil
+}
+
+// ScheduleRows lays out the installments of a loan on its quoted terms,
+// monthly from firstDueDate.
+func ScheduleRows(submissionID string, quote *datastore.LoanQuoteRow, firstDueDate time.Time) []*datastore.LoanInstallmentRow {
+	schedule := repayment.Schedule(repayment.Terms{
+		Principal:          quote.Principal,
+		AnnualInterestRate: quote.AnnualInterestRate,
+		TenureMonth:        quote.TenureMonth,
+		MonthlyInstallment: quote.MonthlyInstallment,
+		TotalFees:          quote.TotalFees,
+		FirstDueDate:       firstDueDate,
+	})
+	rows := make([]*datastore.LoanInstallmentRow, 0, len(schedule))
+	for _, installment := range schedule {
+		rows = append(rows, &datastore.LoanInstallmentRow{
+			SubmissionID:      submissionID,
+			InstallmentNumber: installment.Number,
+			DueDate:           installment.DueDate.Format(time.DateOnly),
+			PrincipalDue:      installment.PrincipalDue,
+			InterestDue:       installment.InterestDue,
+			FeeDue:            installment.FeeDue,
+		})
+	}
+	return rows
+}
+
+func (j *Job) assess(submissionID string, rule repayment.PenaltyRule, asOf time.Time) (*datastore.LoanDelinquencyRow, int, error) {
+	rows, err := j.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	installments, err := InstallmentsFromRows(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	charged := rule.Assess(installments, asOf)
+	for i, installment := range installments {
+		rows[i].PenaltyDue = installment.PenaltyDue
+		rows[i].OverdueSince = nullDate(installment.OverdueSince)
+		rows[i].PenaltyAccruedThrough = nullDate(installment.PenaltyAccruedThrough)
+	}
+
+	balance := repayment.BalanceAsOf(installments, asOf)
+	daysPastDue := repayment.DaysPastDue(installments, asOf)
+	delinquency := &datastore.LoanDelinquencyRow{
+		SubmissionID:        submissionID,
+		AsOf:                asOf.Format(time.DateOnly),
+		DaysPastDue:         daysPastDue,
+		Bucket:              repayment.Bucket(daysPastDue),
+		OverdueAmount:       balance.OverdueAmount,
+		OverdueInstallments: balance.OverdueInstallments,
+		OutstandingAmount:   balance.TotalOutstanding,
+		PenaltyOutstanding:  balance.OutstandingPenalty,
+		EvaluatedAt:         time.Now().Unix(),
+	}
+	if err := j.DelinquencyStore.SaveAssessment(rows, delinquency); err != nil {
+		return nil, 0, err
+	}
+	return delinquency, charged, nil
+}
+
+// penaltyRule caches product rules for the duration of a run. Loans without
+// a product carry no penalty.
+func (j *Job) penaltyRule(cache map[string]repayment.PenaltyRule, productID sql.NullString) (repayment.PenaltyRule, error) {
+	if !productID.Valid {
+		return repayment.PenaltyRule{}, nil
+	}
+	if rule, ok := cache[productID.String]; ok {
+		return rule, nil
+	}
+
+	product, err := j.ProductStore.GetLoanProductById(productID.String)
+	if err != nil {
+		return repayment.PenaltyRule{}, fmt.Errorf("loan product %s: %w", productID.String, err)
+	}
+	rule := repayment.PenaltyRule{
+		LateFee:   product.LateFee,
+		DailyRate: product.LatePenaltyRate,
+		GraceDays: product.PenaltyGraceDays,
+	}
+	cache[productID.String] = rule
+	return rule, nil
+}
+
+func InstallmentsFromRows(rows []*datastore.LoanInstallmentRow) ([]repayment.Installment, error) {
+	installments := make([]repayment.Installment, 0, len(rows))
+	for _, row := range rows {
+		dueDate, err := time.Parse(time.DateOnly, row.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("installment %d has an invalid due date %q", row.InstallmentNumber, row.DueDate)
+		}
+		overdueSince, err := parseNullDate(row.OverdueSince)
+		if err != nil {
+			return nil, fmt.Errorf("installment %d: %w", row.InstallmentNumber, err)
+		}
+		accruedThrough, err := parseNullDate(row.PenaltyAccruedThrough)
+		if err != nil {
+			return nil, fmt.Errorf("installment %d: %w", row.InstallmentNumber, err)
+		}
+
+		installments = append(installments, repayment.Installment{
+			Number:                row.InstallmentNumber,
+			DueDate:               dueDate,
+			PrincipalDue:          row.PrincipalDue,
+			InterestDue:           row.InterestDue,
+			FeeDue:                row.FeeDue,
+			PenaltyDue:            row.PenaltyDue,
+			PrincipalPaid:         row.PrincipalPaid,
+			InterestPaid:          row.InterestPaid,
+			FeePaid:               row.FeePaid,
+			PenaltyPaid:           row.PenaltyPaid,
+			OverdueSince:          overdueSince,
+			PenaltyAccruedThrough: accruedThrough,
+		})
+	}
+	return installments, nil
+}
+
+// Today is the current UTC calendar day, the granularity due dates use.
+func Today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func parseNullDate(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	date, err := time.Parse(time.DateOnly, value.String)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", value.String)
+	}
+	return &date, nil
+}
+
+func nullDate(date *time.Time) sql.NullString {
+	if date == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: date.Format(time.DateOnly), Valid: true}
+}
@@ -0,0 +1,151 @@
+package collections
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/google/uuid"
+)
+
+const testProductID = "8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002"
+
+// insertLoan stores a customer and a quoted submission in the given status,
+// approved and last updated at approvedAt.
+func insertLoan(t *testing.T, db *sql.DB, status string, approvedAt time.Time) string {
+	t.Helper()
+	customerID, submissionID := uuid.New().String(), uuid.New().String()
+	_, err := db.Exec(`INSERT INTO loan_customers (customer_id, id_card_number, full_name, birth_date, phone_number, address_street, address_city)
+		VALUES ($1, $2, 'Budi', '1990-01-01', '0812', 'Jl. Sudirman', 'Jakarta')`, customerID, customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO loan_submissions (submission_id, vehicle_type, vehicle_brand, vehicle_model, vehicle_license_number,
+		manufacturing_year, proposed_loan_amount, proposed_loan_tenure_month, loan_status, created_at, updated_at, approved_at, customer_id, product_id)
+		VALUES ($1, 'Car', 'Toyota', 'Avanza', 'B 1234 XYZ', 2020, 12000, 12, $2, $3, $3, $3, $4, $5)`,
+		submissionID, status, approvedAt.Unix(), customerID, testProductID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = datastore.NewLoanQuoteStore(db).UpsertQuote(&datastore.LoanQuoteRow{
+		SubmissionID: submissionID, ProductID: testProductID, AnnualInterestRate: 0.12, TenureMonth: 12,
+		Principal: 12000, MonthlyInstallment: 1066, TotalInterest: 792, TotalFees: 0, TotalCost: 12792,
+		QuotedAt: approvedAt.Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return submissionID
+}
+
+func newTestJob(db *sql.DB) *Job {
+	return NewJob(datastore.NewLoanRepaymentStore(db), datastore.NewLoanProductStore(db),
+		datastore.NewLoanQuoteStore(db), datastore.NewLoanDelinquencyStore(db))
+}
+
+func TestRunDerivesSchedulesOfApprovedLoans(t *testing.T) {
+	db := datastoretest.Open(t)
+	approvedAt := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	approved := insertLoan(t, db, "APPROVED", approvedAt)
+	pending := insertLoan(t, db, "NEW", approvedAt)
+	editedAt := time.Date(2026, time.February, 3, 0, 0, 0, 0, time.UTC).Unix()
+	if _, err := db.Exec(`UPDATE loan_submissions SET updated_at = $1 WHERE submission_id = $2`, editedAt, approved); err != nil {
+		t.Fatal(err)
+	}
+	job := newTestJob(db)
+
+	asOf := time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC)
+	summary, err := job.Run(asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.SchedulesDerived != 1 || summary.LoansAssessed != 1 || summary.OverdueLoans != 1 {
+		t.Fatalf("summary = %+v, want the approved loan scheduled, assessed and overdue", summary)
+	}
+
+	rows, err := job.RepaymentStore.GetInstallmentsBySubmissionId(approved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 12 || rows[0].DueDate != "2026-02-15" || rows[11].DueDate != "2027-01-15" {
+		t.Fatalf("schedule = %d installments, want 12 monthly from 2026-02-15", len(rows))
+	}
+	if rows, err := job.RepaymentStore.GetInstallmentsBySubmissionId(pending); err != nil || len(rows) != 0 {
+		t.Fatalf("the NEW submission got %d installments (%v), want none", len(rows), err)
+	}
+
+	summary, err = job.Run(asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.SchedulesDerived != 0 || summary.LoansAssessed != 1 {
+		t.Errorf("second run = %+v, want the existing schedule reused", summary)
+	}
+}
+
+func TestRunSkipsLoansWithoutQuote(t *testing.T) {
+	db := datastoretest.Open(t)
+	submissionID := insertLoan(t, db, "DISBURSED", time.Now())
+	if _, err := db.Exec(`DELETE FROM loan_submission_quotes WHERE submission_id = $1`, submissionID); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := newTestJob(db).Run(Today())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.SchedulesDerived != 0 || summary.LoansAssessed != 0 {
+		t.Errorf("summary = %+v, want the loan without a quote skipped", summary)
+	}
+}
+
+func TestRunSchedulesDisbursedLoansFromTheDisbursement(t *testing.T) {
+	db := datastoretest.Open(t)
+	submissionID := insertLoan(t, db, "DISBURSED", time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC))
+	disbursedAt := time.Date(2026, time.January, 20, 8, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`INSERT INTO loan_disbursements (disbursement_id, submission_id, payee_type, payee_name, bank_code,
+		account_number, account_holder, loan_amount, net_amount, status, created_by, reviewed_by, created_at, reviewed_at)
+		VALUES ($1, $2, 'DEALER', 'Jaya Motor', '014', '1234567890', 'PT Jaya Motor', 12000, 12000, 'APPROVED',
+		'maker', 'checker', $3, $3)`, uuid.New().String(), submissionID, disbursedAt.Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := newTestJob(db)
+	if _, err := job.Run(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := job.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 12 || rows[0].DueDate != "2026-02-20" {
+		t.Fatalf("schedule = %d installments, want 12 monthly from 2026-02-20", len(rows))
+	}
+}
+
+func TestRunCarriesOnPastAFailingLoan(t *testing.T) {
+	db := datastoretest.Open(t)
+	approvedAt := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	broken := insertLoan(t, db, "APPROVED", approvedAt)
+	healthy := insertLoan(t, db, "APPROVED", approvedAt.Add(time.Hour))
+	_, err := db.Exec(`INSERT INTO loan_installments (submission_id, installment_number, due_date, principal_due, interest_due, fee_due)
+		VALUES ($1, 1, 'soon', 1000, 66, 0)`, broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := newTestJob(db)
+	summary, err := job.Run(time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.LoansFailed != 1 || summary.LoansAssessed != 1 {
+		t.Fatalf("summary = %+v, want the broken loan failed and the other assessed", summary)
+	}
+	if rows, err := job.RepaymentStore.GetInstallmentsBySubmissionId(healthy); err != nil || len(rows) != 12 {
+		t.Errorf("the loan after the broken one got %d installments (%v), want 12", len(rows), err)
+	}
+}
@@ -0,0 +1,67 @@
+package commission
+
+import (
+	"math"
+	"time"
+)
+
+// Tier replaces the base rate once a dealer reaches MinMonthlyApprovals
+// approved submissions in the calendar month.
+type Tier struct {
+	MinMonthlyApprovals int
+	Rate                float64
+}
+
+// Scheme is the commission plan of a dealer. Dealer commission is a rate of
+// the proposed loan amount plus a flat fee, capped when Cap is positive. Agent
+// commission is a separate rate with neither fee nor cap.
+type Scheme struct {
+	Rate           float64
+	FlatFee        int
+	Cap            int
+	AgentRate      float64
+	Tiers          []Tier
+	ClawbackMonths int
+}
+
+// DealerRate returns the rate of the highest tier reached with
+// monthlyApprovals, counting the submission being approved.
+func (s Scheme) DealerRate(monthlyApprovals int) float64 {
+	rate := s.Rate
+	best := 0
+	for _, tier := range s.Tiers {
+		if monthlyApprovals >= tier.MinMonthlyApprovals && tier.MinMonthlyApprovals > best {
+			rate = tier.Rate
+			best = tier.MinMonthlyApprovals
+		}
+	}
+	return rate
+}
+
+func (s Scheme) DealerCommission(loanAmount, monthlyApprovals int) (int, float64) {
+	rate := s.DealerRate(monthlyApprovals)
+	amount := int(math.Round(float64(loanAmount)*rate)) + s.FlatFee
+	if s.Cap > 0 && amount > s.Cap {
+		amount = s.Cap
+	}
+	return amount, rate
+}
+
+func (s Scheme) AgentCommission(loanAmount int) int {
+	return int(math.Round(float64(loanAmount) * s.AgentRate))
+}
+
+// ClawbackApplies reports whether a loan cancelled or defaulted at the given
+// time is still inside the clawback window of a commission accrued at
+// accruedAt.
+func (s Scheme) ClawbackApplies(accruedAt, at time.Time) bool {
+	if s.ClawbackMonths <= 0 {
+		return false
+	}
+	return at.Before(accruedAt.AddDate(0, s.ClawbackMonths, 0))
+}
+
+// StatementMonth is the YYYY-MM month an entry booked at t belongs to.
+func StatementMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
@@ -0,0 +1,169 @@
+package commission
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/google/uuid"
+)
+
+const (
+	ReasonCancelled = "CANCELLED"
+	ReasonDefaulted = "DEFAULTED"
+)
+
+// Ledger turns approvals, cancellations and defaults into commission ledger
+// entries following the scheme of the originating dealer.
+type Ledger struct {
+	DealerStore     *datastore.DealerStore
+	CommissionStore *datastore.CommissionStore
+}
+
+func NewLedger(dealerStore *datastore.DealerStore, commissionStore *datastore.CommissionStore) *Ledger {
+	return &Ledger{
+		DealerStore:     dealerStore,
+		CommissionStore: commissionStore,
+	}
+}
+
+func SchemeFromDealer(dealer *datastore.DealerRow) Scheme {
+	scheme := Scheme{
+		Rate:           dealer.CommissionRate,
+		FlatFee:        dealer.CommissionFlatFee,
+		AgentRate:      dealer.AgentCommissionRate,
+		ClawbackMonths: dealer.ClawbackMonths,
+	}
+	if dealer.CommissionCap.Valid {
+		scheme.Cap = int(dealer.CommissionCap.Int64)
+	}
+	for _, tier := range dealer.CommissionTiers {
+		scheme.Tiers = append(scheme.Tiers, Tier{MinMonthlyApprovals: tier.MinMonthlyApprovals, Rate: tier.CommissionRate})
+	}
+	return scheme
+}
+
+// Accruals computes the commission owed to the dealer and agent of a
+// submission approved at approvedAt. Submissions without a dealer earn none.
+// The entries are returned unsaved so they can be written together with the
+// approval.
+func (l *Ledger) Accruals(submission *datastore.LoanSubmissionRow, approvedAt time.Time) ([]*datastore.CommissionEntryRow, error) {
+	if !submission.DealerID.Valid {
+		return nil, nil
+	}
+	dealer, err := l.DealerStore.GetDealerById(submission.DealerID.String)
+	if err != nil {
+		return nil, fmt.Errorf("dealer %s: %w", submission.DealerID.String, err)
+	}
+	scheme := SchemeFromDealer(dealer)
+
+	month := StatementMonth(approvedAt)
+	approvals, err := l.CommissionStore.CountMonthlyApprovals(dealer.DealerID, month)
+	if err != nil {
+		return nil, err
+	}
+	approvals++
+
+	amount, rate := scheme.DealerCommission(submission.ProposedLoanAmount, approvals)
+	entries := []*datastore.CommissionEntryRow{{
+		EntryID:          uuid.New().String(),
+		SubmissionID:     submission.SubmissionID,
+		DealerID:         dealer.DealerID,
+		BeneficiaryType:  datastore.CommissionBeneficiaryDealer,
+		EntryType:        datastore.CommissionEntryAccrual,
+		StatementMonth:   month,
+		LoanAmount:       submission.ProposedLoanAmount,
+		CommissionRate:   rate,
+		MonthlyApprovals: sql.NullInt64{Int64: int64(approvals), Valid: true},
+		Amount:           amount,
+		CreatedAt:        approvedAt.Unix(),
+	}}
+
+	if submission.AgentID.Valid && scheme.AgentRate > 0 {
+		entries = append(entries, &datastore.CommissionEntryRow{
+			EntryID:         uuid.New().String(),
+			SubmissionID:    submission.SubmissionID,
+			DealerID:        dealer.DealerID,
+			AgentID:         submission.AgentID,
+			BeneficiaryType: datastore.CommissionBeneficiaryAgent,
+			EntryType:       datastore.CommissionEntryAccrual,
+			StatementMonth:  month,
+			LoanAmount:      submission.ProposedLoanAmount,
+			CommissionRate:  scheme.AgentRate,
+			Amount:          scheme.AgentCommission(submission.ProposedLoanAmount),
+			CreatedAt:       approvedAt.Unix(),
+		})
+	}
+	return entries, nil
+}
+
+// Clawbacks reverses the accruals of a submission that were booked less than
+// the dealer's clawback months before at. Accruals already clawed back and
+// those outside the window are left alone. The entries are booked to the
+// statement month of at and stamped with the time they are computed.
+func (l *Ledger) Clawbacks(submissionID, reason string, at time.Time) ([]*datastore.CommissionEntryRow, error) {
+	existing, err := l.CommissionStore.GetCommissionEntriesBySubmissionId(submissionID)
+	if err != nil || len(existing) == 0 {
+		return nil, err
+	}
+
+	clawedBack := make(map[string]bool)
+	for _, entry := range existing {
+		if entry.EntryType == datastore.CommissionEntryClawback {
+			clawedBack[entry.BeneficiaryType] = true
+		}
+	}
+
+	bookedAt := time.Now().Unix()
+	schemes := make(map[string]Scheme)
+	var entries []*datastore.CommissionEntryRow
+	for _, accrual := range existing {
+		if accrual.EntryType != datastore.CommissionEntryAccrual || clawedBack[accrual.BeneficiaryType] || accrual.Amount == 0 {
+			continue
+		}
+
+		scheme, ok := schemes[accrual.DealerID]
+		if !ok {
+			dealer, err := l.DealerStore.GetDealerById(accrual.DealerID)
+			if err != nil {
+				return nil, fmt.Errorf("dealer %s: %w", accrual.DealerID, err)
+			}
+			scheme = SchemeFromDealer(dealer)
+			schemes[accrual.DealerID] = scheme
+		}
+		if !scheme.ClawbackApplies(time.Unix(accrual.CreatedAt, 0), at) {
+			continue
+		}
+
+		entries = append(entries, &datastore.CommissionEntryRow{
+			EntryID:         uuid.New().String(),
+			SubmissionID:    accrual.SubmissionID,
+			DealerID:        accrual.DealerID,
+			AgentID:         accrual.AgentID,
+			BeneficiaryType: accrual.BeneficiaryType,
+			EntryType:       datastore.CommissionEntryClawback,
+			StatementMonth:  StatementMonth(at),
+			LoanAmount:      accrual.LoanAmount,
+			CommissionRate:  accrual.CommissionRate,
+			Amount:          -accrual.Amount,
+			Reason:          sql.NullString{String: reason, Valid: true},
+			CreatedAt:       bookedAt,
+		})
+	}
+	return entries, nil
+}
+
+// ClawbackDefault books the clawback of a loan that has defaulted and returns
+// the number of entries written.
+func (l *Ledger) ClawbackDefault(submissionID string, at time.Time) (int, error) {
+	entries, err := l.Clawbacks(submissionID, ReasonDefaulted, at)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	inserted, err := l.CommissionStore.InsertCommissionEntries(entries)
+	if err != nil {
+		return 0, err
+	}
+	return len(inserted), nil
+}
@@ -0,0 +1,59 @@
+package commission
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+var statementHeader = []string{
+	"booked_on",
+	"entry_id",
+	"submission_id",
+	"entry_type",
+	"reason",
+	"beneficiary_type",
+	"agent_id",
+	"agent_name",
+	"loan_amount",
+	"commission_rate",
+	"monthly_approvals",
+	"amount",
+}
+
+// WriteStatementCSV writes the ledger entries of a monthly statement as CSV
+// for finance, amounts in whole currency units and clawbacks negative.
+func WriteStatementCSV(w io.Writer, entries []*datastore.CommissionEntryRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(statementHeader); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		monthlyApprovals := ""
+		if entry.MonthlyApprovals.Valid {
+			monthlyApprovals = strconv.FormatInt(entry.MonthlyApprovals.Int64, 10)
+		}
+		err := writer.Write([]string{
+			time.Unix(entry.CreatedAt, 0).UTC().Format(time.DateOnly),
+			entry.EntryID,
+			entry.SubmissionID,
+			entry.EntryType,
+			entry.Reason.String,
+			entry.BeneficiaryType,
+			entry.AgentID.String,
+			entry.AgentName.String,
+			strconv.Itoa(entry.LoanAmount),
+			strconv.FormatFloat(entry.CommissionRate, 'f', -1, 64),
+			monthlyApprovals,
+			strconv.Itoa(entry.Amount),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,266 @@
+package datastore
+
+import (
+	"database/sql"
+	"errors"
+)
+
+const (
+	CommissionEntryAccrual  = "ACCRUAL"
+	CommissionEntryClawback = "CLAWBACK"
+)
+
+const (
+	CommissionBeneficiaryDealer = "DEALER"
+	CommissionBeneficiaryAgent  = "AGENT"
+)
+
+var ErrSubmissionNotCancellable = errors.New("only approved or disbursed submissions can be cancelled")
+
+const sqlApproveSubmission = `
+UPDATE loan_submissions
+SET loan_status = 'APPROVED',
+    approved_at = $1,
+    updated_at = $1
+WHERE submission_id = $2
+AND loan_status = 'NEW';`
+
+const sqlCancelSubmission = `
+UPDATE loan_submissions
+SET loan_status = 'CANCELLED',
+    updated_at = $1
+WHERE submission_id = $2
+AND loan_status IN ('APPROVED', 'DISBURSED');`
+
+// Entries are written once per submission, beneficiary and type, so clawing
+// back a loan that is assessed as defaulted again is a no-op. Only an entry
+// actually written returns its id.
+const sqlInsertCommissionEntry = `
+INSERT INTO commission_ledger (
+    entry_id,
+    submission_id,
+    dealer_id,
+    agent_id,
+    beneficiary_type,
+    entry_type,
+    statement_month,
+    loan_amount,
+    commission_rate,
+    monthly_approvals,
+    amount,
+    reason,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) ON CONFLICT (submission_id, beneficiary_type, entry_type) DO NOTHING
+RETURNING entry_id;`
+
+const sqlSelectCommissionEntries = `
+SELECT
+    entry.entry_id,
+    entry.submission_id,
+    entry.dealer_id,
+    entry.agent_id,
+    agent.full_name,
+    entry.beneficiary_type,
+    entry.entry_type,
+    entry.statement_month,
+    entry.loan_amount,
+    entry.commission_rate,
+    entry.monthly_approvals,
+    entry.amount,
+    entry.reason,
+    entry.created_at
+FROM commission_ledger entry
+LEFT JOIN dealer_agents agent
+ON agent.agent_id = entry.agent_id
+`
+
+const sqlGetCommissionEntriesBySubmissionId = sqlSelectCommissionEntries + `
+WHERE entry.submission_id = $1
+ORDER BY entry.created_at, entry.entry_type, entry.beneficiary_type;`
+
+const sqlGetCommissionStatement = sqlSelectCommissionEntries + `
+WHERE entry.dealer_id = $1
+AND entry.statement_month = $2
+ORDER BY entry.created_at, entry.submission_id, entry.entry_type, entry.beneficiary_type;`
+
+const sqlCountMonthlyApprovals = `
+SELECT COUNT(*)
+FROM commission_ledger
+WHERE dealer_id = $1
+AND statement_month = $2
+AND beneficiary_type = 'DEALER'
+AND entry_type = 'ACCRUAL';`
+
+// CommissionEntryRow is one line of the commission ledger. Clawbacks carry
+// the negated amount of the accrual they reverse.
+type CommissionEntryRow struct {
+	EntryID          string
+	SubmissionID     string
+	DealerID         string
+	AgentID          sql.NullString
+	AgentName        sql.NullString
+	BeneficiaryType  string
+	EntryType        string
+	StatementMonth   string
+	LoanAmount       int
+	CommissionRate   float64
+	MonthlyApprovals sql.NullInt64
+	Amount           int
+	Reason           sql.NullString
+	CreatedAt        int64
+}
+
+type CommissionStore struct {
+	db *sql.DB
+}
+
+func NewCommissionStore(db *sql.DB) *CommissionStore {
+	return &CommissionStore{
+		db: db,
+	}
+}
+
+// ApproveSubmission moves a NEW submission to APPROVED and accrues its
+// commission in one transaction. It returns the entries written.
+func (s *CommissionStore) ApproveSubmission(submissionID, decidedBy string, approvedAt int64, entries []*CommissionEntryRow) ([]*CommissionEntryRow, error) {
+	return s.transition(sqlApproveSubmission, "APPROVED", submissionID, decidedBy, approvedAt, entries, ErrSubmissionNotPending)
+}
+
+// CancelSubmission moves an approved or disbursed submission to CANCELLED and
+// records the clawback of its commission in one transaction. It returns the
+// entries written; those already in the ledger are left out.
+func (s *CommissionStore) CancelSubmission(submissionID, decidedBy string, cancelledAt int64, entries []*CommissionEntryRow) ([]*CommissionEntryRow, error) {
+	return s.transition(sqlCancelSubmission, "CANCELLED", submissionID, decidedBy, cancelledAt, entries, ErrSubmissionNotCancellable)
+}
+
+// transition moves a submission with query, records the decision and the
+// operator who made it, and writes the commission entries it entails.
+func (s *CommissionStore) transition(query, decision, submissionID, decidedBy string, at int64, entries []*CommissionEntryRow, conflict error) ([]*CommissionEntryRow, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	changed, err := changeSubmissionStatus(tx, query, submissionID, at)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, conflict
+	}
+	if _, err := tx.Exec(sqlInsertSubmissionDecision, submissionID, decision, decidedBy, at); err != nil {
+		return nil, err
+	}
+
+	inserted, err := insertCommissionEntries(tx, entries)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, tx.Commit()
+}
+
+// InsertCommissionEntries writes the entries not yet in the ledger and returns
+// them.
+func (s *CommissionStore) InsertCommissionEntries(entries []*CommissionEntryRow) ([]*CommissionEntryRow, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inserted, err := insertCommissionEntries(tx, entries)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, tx.Commit()
+}
+
+func (s *CommissionStore) GetCommissionEntriesBySubmissionId(submissionID string) ([]*CommissionEntryRow, error) {
+	return s.queryCommissionEntries(sqlGetCommissionEntriesBySubmissionId, submissionID)
+}
+
+// GetCommissionStatement returns the ledger entries booked to a dealer in a
+// statement month, formatted YYYY-MM.
+func (s *CommissionStore) GetCommissionStatement(dealerID, month string) ([]*CommissionEntryRow, error) {
+	return s.queryCommissionEntries(sqlGetCommissionStatement, dealerID, month)
+}
+
+// CountMonthlyApprovals counts the submissions of a dealer whose commission
+// accrued in the statement month.
+func (s *CommissionStore) CountMonthlyApprovals(dealerID, month string) (int, error) {
+	var count int
+	if err := s.db.QueryRow(sqlCountMonthlyApprovals, dealerID, month).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *CommissionStore) queryCommissionEntries(query string, args ...any) ([]*CommissionEntryRow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*CommissionEntryRow
+	for rows.Next() {
+		entry := &CommissionEntryRow{}
+		err := rows.Scan(
+			&entry.EntryID,
+			&entry.SubmissionID,
+			&entry.DealerID,
+			&entry.AgentID,
+			&entry.AgentName,
+			&entry.BeneficiaryType,
+			&entry.EntryType,
+			&entry.StatementMonth,
+			&entry.LoanAmount,
+			&entry.CommissionRate,
+			&entry.MonthlyApprovals,
+			&entry.Amount,
+			&entry.Reason,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func insertCommissionEntries(tx *sql.Tx, entries []*CommissionEntryRow) ([]*CommissionEntryRow, error) {
+	inserted := make([]*CommissionEntryRow, 0, len(entries))
+	for _, entry := range entries {
+		err := tx.QueryRow(sqlInsertCommissionEntry,
+			entry.EntryID,
+			entry.SubmissionID,
+			entry.DealerID,
+			entry.AgentID,
+			entry.BeneficiaryType,
+			entry.EntryType,
+			entry.StatementMonth,
+			entry.LoanAmount,
+			entry.CommissionRate,
+			entry.MonthlyApprovals,
+			entry.Amount,
+			entry.Reason,
+			entry.CreatedAt,
+		).Scan(&entry.EntryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, entry)
+	}
+	return inserted, nil
+}
@@ -0,0 +1,67 @@
+package datastore_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/google/uuid"
+)
+
+func testCommissionEntry(submissionID, dealerID, entryType string, amount int) *datastore.CommissionEntryRow {
+	return &datastore.CommissionEntryRow{
+		EntryID: uuid.New().String(), SubmissionID: submissionID, DealerID: dealerID,
+		BeneficiaryType: datastore.CommissionBeneficiaryDealer, EntryType: entryType, StatementMonth: "2026-10",
+		LoanAmount: 12000, CommissionRate: 0.01, Amount: amount, CreatedAt: time.Now().Unix(),
+	}
+}
+
+func TestCommissionEntriesAreReportedOnlyWhenWritten(t *testing.T) {
+	db := datastoretest.Open(t)
+	now := time.Now().Unix()
+	dealerID, err := datastore.NewDealerStore(db).UpsertDealer(&datastore.DealerRow{
+		DealerID: uuid.New().String(), Code: "JKT01", Name: "Jaya Motor", PhoneNumber: "021", AddressCity: "Jakarta",
+		CommissionRate: 0.01, IsActive: true, CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := testSubmitRecord("3171000000000001", "B1234XYZ")
+	record.Submission.DealerID = sql.NullString{String: dealerID, Valid: true}
+	submitted, err := datastore.NewLoanSubmissionStore(db).SubmitLoan(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submissionID := submitted.SubmissionID
+
+	store := datastore.NewCommissionStore(db)
+	accrued, err := store.ApproveSubmission(submissionID, "checker", now,
+		[]*datastore.CommissionEntryRow{testCommissionEntry(submissionID, dealerID, datastore.CommissionEntryAccrual, 120)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accrued) != 1 {
+		t.Fatalf("%d accruals written, want 1", len(accrued))
+	}
+
+	clawback := testCommissionEntry(submissionID, dealerID, datastore.CommissionEntryClawback, -120)
+	if written, err := store.InsertCommissionEntries([]*datastore.CommissionEntryRow{clawback}); err != nil || len(written) != 1 {
+		t.Fatalf("first clawback: %d written (%v), want 1", len(written), err)
+	}
+	again := testCommissionEntry(submissionID, dealerID, datastore.CommissionEntryClawback, -120)
+	cancelled, err := store.CancelSubmission(submissionID, "checker", now, []*datastore.CommissionEntryRow{again})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cancelled) != 0 {
+		t.Errorf("cancelling reported %d clawbacks already in the ledger", len(cancelled))
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM commission_ledger WHERE submission_id = $1`, submissionID); n != 2 {
+		t.Errorf("%d ledger entries, want the accrual and one clawback", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM submission_decisions WHERE submission_id = $1 AND decided_by = 'checker'`, submissionID); n != 2 {
+		t.Errorf("%d decisions recorded with their operator, want the approval and the cancellation", n)
+	}
+}
@@ -0,0 +1,46 @@
+// Package datastoretest opens throwaway databases for tests, migrated to the
+// latest schema.
+package datastoretest
+
+import (
+	"database/sql"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+// Open returns a migrated database in the test's temporary directory, closed
+// when the test ends.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	migrator, db := Migrator(t)
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("migrating the test database: %v", err)
+	}
+	return db
+}
+
+// Migrator returns an empty database in the test's temporary directory with
+// a migrator of the repository's migrations for it, both closed when the test
+// ends. Tests of the migrations themselves move it to the version they need.
+func Migrator(t testing.TB) (*datastore.Migrator, *sql.DB) {
+	t.Helper()
+	_, file, _, _ := runtime.Caller(0)
+	migrations := filepath.Join(filepath.Dir(file), "..", "..", "db", "migration")
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	migrator, err := datastore.NewMigrator(migrations, "sqlite3://"+path)
+	if err != nil {
+		t.Fatalf("opening migrations: %v", err)
+	}
+	t.Cleanup(func() { migrator.Close() })
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("opening the test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return migrator, db
+}
@@ -0,0 +1,622 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const sqlUpsertDealer = `
+INSERT INTO dealers (
+    dealer_id,
+    code,
+    name,
+    phone_number,
+    email,
+    address_city,
+    commission_rate,
+    commission_flat_fee,
+    commission_cap,
+    agent_commission_rate,
+    clawback_months,
+    is_active,
+    created_at,
+    updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+) ON CONFLICT (dealer_id) DO UPDATE SET
+    code = EXCLUDED.code,
+    name = EXCLUDED.name,
+    phone_number = EXCLUDED.phone_number,
+    email = EXCLUDED.email,
+    address_city = EXCLUDED.address_city,
+    commission_rate = EXCLUDED.commission_rate,
+    commission_flat_fee = EXCLUDED.commission_flat_fee,
+    commission_cap = EXCLUDED.commission_cap,
+    agent_commission_rate = EXCLUDED.agent_commission_rate,
+    clawback_months = EXCLUDED.clawback_months,
+    is_active = EXCLUDED.is_active,
+    updated_at = EXCLUDED.updated_at
+RETURNING dealer_id;`
+
+const sqlSelectDealers = `
+SELECT
+    dealer_id,
+    code,
+    name,
+    phone_number,
+    email,
+    address_city,
+    commission_rate,
+    commission_flat_fee,
+    commission_cap,
+    agent_commission_rate,
+    clawback_months,
+    is_active,
+    created_at,
+    updated_at
+FROM dealers
+`
+
+const sqlGetAllDealers = sqlSelectDealers + `ORDER BY name;`
+
+const sqlGetDealerById = sqlSelectDealers + `WHERE dealer_id = $1;`
+
+const sqlDeleteDealerCommissionTiers = `
+DELETE FROM dealer_commission_tiers
+WHERE dealer_id = $1;`
+
+const sqlInsertDealerCommissionTier = `
+INSERT INTO dealer_commission_tiers (
+    dealer_id,
+    min_monthly_approvals,
+    commission_rate
+) VALUES (
+    $1, $2, $3
+);`
+
+const sqlGetDealerCommissionTiers = `
+SELECT min_monthly_approvals, commission_rate
+FROM dealer_commission_tiers
+WHERE dealer_id = $1
+ORDER BY min_monthly_approvals;`
+
+const sqlDeactivateDealer = `
+UPDATE dealers
+SET is_active = FALSE,
+    updated_at = $1
+WHERE dealer_id = $2;`
+
+const sqlInsertDealerBranch = `
+INSERT INTO dealer_branches (
+    branch_id,
+    dealer_id,
+    name,
+    address_city,
+    is_active,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);`
+
+const sqlGetDealerBranches = `
+SELECT branch_id, dealer_id, name, address_city, is_active, created_at
+FROM dealer_branches
+WHERE dealer_id = $1
+ORDER BY name;`
+
+const sqlInsertDealerAgent = `
+INSERT INTO dealer_agents (
+    agent_id,
+    dealer_id,
+    branch_id,
+    full_name,
+    phone_number,
+    email,
+    is_active,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+);`
+
+const sqlSelectDealerAgents = `
+SELECT agent_id, dealer_id, branch_id, full_name, phone_number, email, is_active, created_at
+FROM dealer_agents
+`
+
+const sqlGetDealerAgents = sqlSelectDealerAgents + `
+WHERE dealer_id = $1
+ORDER BY full_name;`
+
+const sqlGetDealerAgentById = sqlSelectDealerAgents + `WHERE agent_id = $1;`
+
+const sqlInsertDealerCredential = `
+INSERT INTO dealer_api_credentials (
+    credential_id,
+    dealer_id,
+    key_prefix,
+    key_hash,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5
+);`
+
+const sqlGetDealerCredentials = `
+SELECT credential_id, dealer_id, key_prefix, created_at, last_used_at, revoked_at
+FROM dealer_api_credentials
+WHERE dealer_id = $1
+ORDER BY created_at;`
+
+const sqlRevokeDealerCredential = `
+UPDATE dealer_api_credentials
+SET revoked_at = $1
+WHERE credential_id = $2
+AND dealer_id = $3
+AND revoked_at IS NULL;`
+
+const sqlGetDealerIdByKeyHash = `
+SELECT credential.dealer_id
+FROM dealer_api_credentials credential
+INNER JOIN dealers dealer
+ON dealer.dealer_id = credential.dealer_id
+WHERE credential.key_hash = $1
+AND credential.revoked_at IS NULL
+AND dealer.is_active;`
+
+const sqlTouchDealerCredential = `
+UPDATE dealer_api_credentials
+SET last_used_at = $1
+WHERE key_hash = $2;`
+
+// Submissions count as approved once they reach APPROVED, whatever happened
+// to the loan afterwards.
+const sqlGetDealerSummaries = `
+SELECT
+    dealer.dealer_id,
+    dealer.code,
+    dealer.name,
+    COUNT(submission.submission_id),
+    COALESCE(SUM(submission.proposed_loan_amount), 0),
+    COUNT(CASE WHEN submission.loan_status IN ('APPROVED', 'DISBURSED', 'CLOSED') THEN 1 END),
+    COUNT(CASE WHEN submission.loan_status = 'REJECTED' THEN 1 END),
+    COUNT(CASE WHEN submission.loan_status IN ('DISBURSED', 'CLOSED') THEN 1 END),
+    COALESCE((
+        SELECT SUM(disbursement.loan_amount)
+        FROM loan_disbursements disbursement
+        INNER JOIN loan_submissions disbursed
+        ON disbursed.submission_id = disbursement.submission_id
+        WHERE disbursed.dealer_id = dealer.dealer_id
+        AND disbursement.status = 'APPROVED'
+        AND disbursed.created_at >= $1
+        AND disbursed.created_at < $2
+    ), 0)
+FROM dealers dealer
+LEFT JOIN loan_submissions submission
+ON submission.dealer_id = dealer.dealer_id
+AND submission.created_at >= $1
+AND submission.created_at < $2
+WHERE ($3 = '' OR dealer.dealer_id = $3)
+GROUP BY dealer.dealer_id, dealer.code, dealer.name
+ORDER BY COUNT(submission.submission_id) DESC, dealer.name;`
+
+type DealerRow struct {
+	DealerID            string
+	Code                string
+	Name                string
+	PhoneNumber         string
+	Email               sql.NullString
+	AddressCity         string
+	CommissionRate      float64
+	CommissionFlatFee   int
+	CommissionCap       sql.NullInt64
+	AgentCommissionRate float64
+	ClawbackMonths      int
+	CommissionTiers     []*DealerCommissionTierRow
+	IsActive            bool
+	CreatedAt           int64
+	UpdatedAt           int64
+}
+
+// DealerCommissionTierRow replaces the dealer's base commission rate once the
+// dealer has MinMonthlyApprovals approved submissions in a calendar month.
+type DealerCommissionTierRow struct {
+	MinMonthlyApprovals int
+	CommissionRate      float64
+}
+
+type DealerBranchRow struct {
+	BranchID    string
+	DealerID    string
+	Name        string
+	AddressCity string
+	IsActive    bool
+	CreatedAt   int64
+}
+
+type DealerAgentRow struct {
+	AgentID     string
+	DealerID    string
+	BranchID    sql.NullString
+	FullName    string
+	PhoneNumber string
+	Email       sql.NullString
+	IsActive    bool
+	CreatedAt   int64
+}
+
+// DealerCredentialRow never carries the key itself, only its SHA-256 hash is
+// stored.
+type DealerCredentialRow struct {
+	CredentialID string
+	DealerID     string
+	KeyPrefix    string
+	KeyHash      string
+	CreatedAt    int64
+	LastUsedAt   sql.NullInt64
+	RevokedAt    sql.NullInt64
+}
+
+type DealerSummaryRow struct {
+	DealerID            string
+	Code                string
+	Name                string
+	SubmissionCount     int
+	RequestedAmount     int
+	ApprovedCount       int
+	RejectedCount       int
+	DisbursedCount      int
+	DisbursedLoanAmount int
+}
+
+type DealerStore struct {
+	db *sql.DB
+}
+
+func NewDealerStore(db *sql.DB) *DealerStore {
+	return &DealerStore{
+		db: db,
+	}
+}
+
+// UpsertDealer saves the dealer and replaces its commission tiers.
+func (s *DealerStore) UpsertDealer(dealer *DealerRow) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var dealerID string
+	err = tx.QueryRow(sqlUpsertDealer,
+		dealer.DealerID,
+		dealer.Code,
+		dealer.Name,
+		dealer.PhoneNumber,
+		dealer.Email,
+		dealer.AddressCity,
+		dealer.CommissionRate,
+		dealer.CommissionFlatFee,
+		dealer.CommissionCap,
+		dealer.AgentCommissionRate,
+		dealer.ClawbackMonths,
+		dealer.IsActive,
+		dealer.CreatedAt,
+		dealer.UpdatedAt,
+	).Scan(&dealerID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(sqlDeleteDealerCommissionTiers, dealerID); err != nil {
+		return "", err
+	}
+	for _, tier := range dealer.CommissionTiers {
+		if _, err := tx.Exec(sqlInsertDealerCommissionTier, dealerID, tier.MinMonthlyApprovals, tier.CommissionRate); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return dealerID, nil
+}
+
+func (s *DealerStore) GetAllDealers() ([]*DealerRow, error) {
+	rows, err := s.db.Query(sqlGetAllDealers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dealers []*DealerRow
+	for rows.Next() {
+		dealer, err := scanDealer(rows)
+		if err != nil {
+			return nil, err
+		}
+		dealers = append(dealers, dealer)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, dealer := range dealers {
+		if err := s.loadCommissionTiers(dealer); err != nil {
+			return nil, err
+		}
+	}
+	return dealers, nil
+}
+
+func (s *DealerStore) GetDealerById(dealerID string) (*DealerRow, error) {
+	dealer, err := scanDealer(s.db.QueryRow(sqlGetDealerById, dealerID))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadCommissionTiers(dealer); err != nil {
+		return nil, err
+	}
+	return dealer, nil
+}
+
+func (s *DealerStore) loadCommissionTiers(dealer *DealerRow) error {
+	rows, err := s.db.Query(sqlGetDealerCommissionTiers, dealer.DealerID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	dealer.CommissionTiers = nil
+	for rows.Next() {
+		tier := &DealerCommissionTierRow{}
+		if err := rows.Scan(&tier.MinMonthlyApprovals, &tier.CommissionRate); err != nil {
+			return err
+		}
+		dealer.CommissionTiers = append(dealer.CommissionTiers, tier)
+	}
+	return rows.Err()
+}
+
+func (s *DealerStore) DeactivateDealer(dealerID string, updatedAt int64) error {
+	result, err := s.db.Exec(sqlDeactivateDealer, updatedAt, dealerID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no dealer found with id %s", dealerID)
+	}
+	return nil
+}
+
+func (s *DealerStore) InsertDealerBranch(branch *DealerBranchRow) error {
+	_, err := s.db.Exec(sqlInsertDealerBranch,
+		branch.BranchID,
+		branch.DealerID,
+		branch.Name,
+		branch.AddressCity,
+		branch.IsActive,
+		branch.CreatedAt,
+	)
+	return err
+}
+
+func (s *DealerStore) GetDealerBranches(dealerID string) ([]*DealerBranchRow, error) {
+	rows, err := s.db.Query(sqlGetDealerBranches, dealerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var branches []*DealerBranchRow
+	for rows.Next() {
+		branch := &DealerBranchRow{}
+		err := rows.Scan(
+			&branch.BranchID,
+			&branch.DealerID,
+			&branch.Name,
+			&branch.AddressCity,
+			&branch.IsActive,
+			&branch.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+func (s *DealerStore) InsertDealerAgent(agent *DealerAgentRow) error {
+	_, err := s.db.Exec(sqlInsertDealerAgent,
+		agent.AgentID,
+		agent.DealerID,
+		agent.BranchID,
+		agent.FullName,
+		agent.PhoneNumber,
+		agent.Email,
+		agent.IsActive,
+		agent.CreatedAt,
+	)
+	return err
+}
+
+func (s *DealerStore) GetDealerAgents(dealerID string) ([]*DealerAgentRow, error) {
+	rows, err := s.db.Query(sqlGetDealerAgents, dealerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agents []*DealerAgentRow
+	for rows.Next() {
+		agent, err := scanDealerAgent(rows)
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, agent)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+func (s *DealerStore) GetDealerAgentById(agentID string) (*DealerAgentRow, error) {
+	return scanDealerAgent(s.db.QueryRow(sqlGetDealerAgentById, agentID))
+}
+
+func (s *DealerStore) InsertDealerCredential(credential *DealerCredentialRow) error {
+	_, err := s.db.Exec(sqlInsertDealerCredential,
+		credential.CredentialID,
+		credential.DealerID,
+		credential.KeyPrefix,
+		credential.KeyHash,
+		credential.CreatedAt,
+	)
+	return err
+}
+
+func (s *DealerStore) GetDealerCredentials(dealerID string) ([]*DealerCredentialRow, error) {
+	rows, err := s.db.Query(sqlGetDealerCredentials, dealerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []*DealerCredentialRow
+	for rows.Next() {
+		credential := &DealerCredentialRow{}
+		err := rows.Scan(
+			&credential.CredentialID,
+			&credential.DealerID,
+			&credential.KeyPrefix,
+			&credential.CreatedAt,
+			&credential.LastUsedAt,
+			&credential.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (s *DealerStore) RevokeDealerCredential(dealerID, credentialID string, revokedAt int64) error {
+	result, err := s.db.Exec(sqlRevokeDealerCredential, revokedAt, credentialID, dealerID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no active credential found with id %s", credentialID)
+	}
+	return nil
+}
+
+// AuthenticateDealer resolves the hash of an API key to the active dealer it
+// was issued to and records when the key was last used.
+func (s *DealerStore) AuthenticateDealer(keyHash string, usedAt int64) (string, error) {
+	var dealerID string
+	if err := s.db.QueryRow(sqlGetDealerIdByKeyHash, keyHash).Scan(&dealerID); err != nil {
+		return "", err
+	}
+	if _, err := s.db.Exec(sqlTouchDealerCredential, usedAt, keyHash); err != nil {
+		return "", err
+	}
+	return dealerID, nil
+}
+
+// GetDealerSummaries aggregates the submissions created in [from, to) per
+// dealer. An empty dealerID summarises every dealer.
+func (s *DealerStore) GetDealerSummaries(dealerID string, from, to int64) ([]*DealerSummaryRow, error) {
+	rows, err := s.db.Query(sqlGetDealerSummaries, from, to, dealerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*DealerSummaryRow
+	for rows.Next() {
+		summary := &DealerSummaryRow{}
+		err := rows.Scan(
+			&summary.DealerID,
+			&summary.Code,
+			&summary.Name,
+			&summary.SubmissionCount,
+			&summary.RequestedAmount,
+			&summary.ApprovedCount,
+			&summary.RejectedCount,
+			&summary.DisbursedCount,
+			&summary.DisbursedLoanAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func scanDealer(row rowScanner) (*DealerRow, error) {
+	dealer := &DealerRow{}
+	err := row.Scan(
+		&dealer.DealerID,
+		&dealer.Code,
+		&dealer.Name,
+		&dealer.PhoneNumber,
+		&dealer.Email,
+		&dealer.AddressCity,
+		&dealer.CommissionRate,
+		&dealer.CommissionFlatFee,
+		&dealer.CommissionCap,
+		&dealer.AgentCommissionRate,
+		&dealer.ClawbackMonths,
+		&dealer.IsActive,
+		&dealer.CreatedAt,
+		&dealer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return dealer, nil
+}
+
+func scanDealerAgent(row rowScanner) (*DealerAgentRow, error) {
+	agent := &DealerAgentRow{}
+	err := row.Scan(
+		&agent.AgentID,
+		&agent.DealerID,
+		&agent.BranchID,
+		&agent.FullName,
+		&agent.PhoneNumber,
+		&agent.Email,
+		&agent.IsActive,
+		&agent.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
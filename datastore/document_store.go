@@ -0,0 +1,180 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const sqlInsertDocument = `
+INSERT INTO documents (
+    document_id,
+    customer_id,
+    submission_id,
+    document_type,
+    file_name,
+    content_type,
+    size_bytes,
+    sha256,
+    storage_key,
+    uploaded_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+);`
+
+const sqlSelectDocuments = `
+SELECT
+    document_id,
+    customer_id,
+    submission_id,
+    document_type,
+    file_name,
+    content_type,
+    size_bytes,
+    sha256,
+    storage_key,
+    uploaded_at
+FROM documents
+`
+
+const sqlGetDocumentById = sqlSelectDocuments + `
+WHERE document_id = $1;`
+
+const sqlGetDocumentsByCustomerId = sqlSelectDocuments + `
+WHERE customer_id = $1
+ORDER BY uploaded_at DESC;`
+
+const sqlGetDocumentsBySubmissionId = sqlSelectDocuments + `
+WHERE submission_id = $1
+ORDER BY uploaded_at DESC;`
+
+const sqlGetDocumentsByCustomerIds = sqlSelectDocuments + `
+WHERE customer_id IN (SELECT value FROM json_each($1))
+ORDER BY uploaded_at DESC;`
+
+const sqlGetDocumentsBySubmissionIds = sqlSelectDocuments + `
+WHERE submission_id IN (SELECT value FROM json_each($1))
+ORDER BY uploaded_at DESC;`
+
+const sqlDeleteDocument = `
+DELETE FROM documents
+WHERE document_id = $1;`
+
+type DocumentRow struct {
+	DocumentID   string
+	CustomerID   string
+	SubmissionID sql.NullString
+	DocumentType string
+	FileName     string
+	ContentType  string
+	SizeBytes    int64
+	SHA256       string
+	StorageKey   string
+	UploadedAt   int64
+}
+
+type DocumentStore struct {
+	db *sql.DB
+}
+
+func NewDocumentStore(db *sql.DB) *DocumentStore {
+	return &DocumentStore{
+		db: db,
+	}
+}
+
+func (s *DocumentStore) InsertDocument(document *DocumentRow) error {
+	_, err := s.db.Exec(sqlInsertDocument,
+		document.DocumentID,
+		document.CustomerID,
+		document.SubmissionID,
+		document.DocumentType,
+		document.FileName,
+		document.ContentType,
+		document.SizeBytes,
+		document.SHA256,
+		document.StorageKey,
+		document.UploadedAt,
+	)
+	return err
+}
+
+func (s *DocumentStore) GetDocumentById(documentID string) (*DocumentRow, error) {
+	return scanDocument(s.db.QueryRow(sqlGetDocumentById, documentID))
+}
+
+func (s *DocumentStore) GetDocumentsByCustomerId(customerID string) ([]*DocumentRow, error) {
+	return s.queryDocuments(sqlGetDocumentsByCustomerId, customerID)
+}
+
+func (s *DocumentStore) GetDocumentsBySubmissionId(submissionID string) ([]*DocumentRow, error) {
+	return s.queryDocuments(sqlGetDocumentsBySubmissionId, submissionID)
+}
+
+// GetDocumentsByCustomerIds returns the documents of all the customers,
+// newest first, in a single query.
+func (s *DocumentStore) GetDocumentsByCustomerIds(customerIDs []string) ([]*DocumentRow, error) {
+	return s.queryDocuments(sqlGetDocumentsByCustomerIds, idList(customerIDs))
+}
+
+// GetDocumentsBySubmissionIds returns the documents of all the submissions,
+// newest first, in a single query.
+func (s *DocumentStore) GetDocumentsBySubmissionIds(submissionIDs []string) ([]*DocumentRow, error) {
+	return s.queryDocuments(sqlGetDocumentsBySubmissionIds, idList(submissionIDs))
+}
+
+func (s *DocumentStore) DeleteDocument(documentID string) error {
+	result, err := s.db.Exec(sqlDeleteDocument, documentID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no rows affected")
+	}
+	return nil
+}
+
+func (s *DocumentStore) queryDocuments(query string, args ...any) ([]*DocumentRow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []*DocumentRow
+	for rows.Next() {
+		document, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func scanDocument(row rowScanner) (*DocumentRow, error) {
+	document := &DocumentRow{}
+	err := row.Scan(
+		&document.DocumentID,
+		&document.CustomerID,
+		&document.SubmissionID,
+		&document.DocumentType,
+		&document.FileName,
+		&document.ContentType,
+		&document.SizeBytes,
+		&document.SHA256,
+		&document.StorageKey,
+		&document.UploadedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
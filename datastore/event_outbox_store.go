@@ -0,0 +1,297 @@
+package datastore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/alphaloan/vehicle/events"
+)
+
+const sqlInsertOutboxEvent = `
+INSERT INTO event_outbox (
+    event_id,
+    event_type,
+    schema_version,
+    aggregate_id,
+    payload,
+    created_at,
+    next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $6
+);`
+
+// Events are relayed in the order they were written, rowid breaks ties
+// between events written in the same second.
+const sqlGetPendingOutboxEvents = `
+SELECT
+    event_id,
+    event_type,
+    schema_version,
+    aggregate_id,
+    payload,
+    created_at,
+    attempts,
+    next_attempt_at,
+    last_error,
+    published_at
+FROM event_outbox
+WHERE published_at IS NULL
+ORDER BY created_at, rowid
+LIMIT $1;`
+
+const sqlMarkOutboxEventPublished = `
+UPDATE event_outbox
+SET published_at = $1,
+    attempts = attempts + 1,
+    last_error = NULL
+WHERE event_id = $2;`
+
+const sqlMarkOutboxEventFailed = `
+UPDATE event_outbox
+SET attempts = attempts + 1,
+    last_error = $1,
+    next_attempt_at = $2
+WHERE event_id = $3;`
+
+const sqlGetOutboxStats = `
+SELECT
+    COUNT(CASE WHEN published_at IS NULL THEN 1 END),
+    COUNT(CASE WHEN published_at IS NULL AND attempts > 0 THEN 1 END),
+    COUNT(published_at),
+    MIN(CASE WHEN published_at IS NULL THEN created_at END),
+    MAX(published_at)
+FROM event_outbox;`
+
+const sqlGetSubmissionEventFields = `
+SELECT loan_status, customer_id, dealer_id
+FROM loan_submissions
+WHERE submission_id = $1;`
+
+// The outbox keeps published events, so the submission events in it are the
+// status history of every submission created since the outbox was added.
+const sqlGetSubmissionStatusHistory = `
+SELECT
+    aggregate_id,
+    json_extract(payload, '$.data.from_status'),
+    COALESCE(json_extract(payload, '$.data.to_status'), json_extract(payload, '$.data.loan_status')),
+    created_at
+FROM event_outbox
+WHERE event_type IN ('` + events.TypeSubmissionCreated + `', '` + events.TypeSubmissionStatusChanged + `')
+AND aggregate_id IN (SELECT value FROM json_each($1))
+ORDER BY created_at, rowid;`
+
+type OutboxEventRow struct {
+	EventID       string
+	EventType     string
+	SchemaVersion int
+	AggregateID   string
+	Payload       string
+	CreatedAt     int64
+	Attempts      int
+	NextAttemptAt int64
+	LastError     sql.NullString
+	PublishedAt   sql.NullInt64
+}
+
+type OutboxStatsRow struct {
+	Pending         int
+	Failing         int
+	Published       int
+	OldestPendingAt sql.NullInt64
+	LastPublishedAt sql.NullInt64
+}
+
+// SubmissionStatusChangeRow is one step of a submission's status history.
+// FromStatus is null for the status the submission was created with.
+type SubmissionStatusChangeRow struct {
+	SubmissionID string
+	FromStatus   sql.NullString
+	ToStatus     string
+	ChangedAt    int64
+}
+
+type EventOutboxStore struct {
+	db *sql.DB
+}
+
+func NewEventOutboxStore(db *sql.DB) *EventOutboxStore {
+	return &EventOutboxStore{
+		db: db,
+	}
+}
+
+// GetPendingEvents returns the oldest unpublished events, including those
+// still waiting for their next attempt.
+func (s *EventOutboxStore) GetPendingEvents(limit int) ([]*OutboxEventRow, error) {
+	rows, err := s.db.Query(sqlGetPendingOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outboxEvents []*OutboxEventRow
+	for rows.Next() {
+		event := &OutboxEventRow{}
+		err := rows.Scan(
+			&event.EventID,
+			&event.EventType,
+			&event.SchemaVersion,
+			&event.AggregateID,
+			&event.Payload,
+			&event.CreatedAt,
+			&event.Attempts,
+			&event.NextAttemptAt,
+			&event.LastError,
+			&event.PublishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		outboxEvents = append(outboxEvents, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return outboxEvents, nil
+}
+
+func (s *EventOutboxStore) MarkEventPublished(eventID string, publishedAt int64) error {
+	_, err := s.db.Exec(sqlMarkOutboxEventPublished, publishedAt, eventID)
+	return err
+}
+
+func (s *EventOutboxStore) MarkEventFailed(eventID, lastError string, nextAttemptAt int64) error {
+	_, err := s.db.Exec(sqlMarkOutboxEventFailed, lastError, nextAttemptAt, eventID)
+	return err
+}
+
+func (s *EventOutboxStore) GetOutboxStats() (*OutboxStatsRow, error) {
+	stats := &OutboxStatsRow{}
+	err := s.db.QueryRow(sqlGetOutboxStats).Scan(
+		&stats.Pending,
+		&stats.Failing,
+		&stats.Published,
+		&stats.OldestPendingAt,
+		&stats.LastPublishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetSubmissionStatusHistory returns the status changes of all the
+// submissions, oldest first, in a single query.
+func (s *EventOutboxStore) GetSubmissionStatusHistory(submissionIDs []string) ([]*SubmissionStatusChangeRow, error) {
+	rows, err := s.db.Query(sqlGetSubmissionStatusHistory, idList(submissionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*SubmissionStatusChangeRow
+	for rows.Next() {
+		change := &SubmissionStatusChangeRow{}
+		if err := rows.Scan(&change.SubmissionID, &change.FromStatus, &change.ToStatus, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// queueEvent writes an event to the outbox in the transaction of the mutation
+// it describes, so the event exists if and only if the mutation committed.
+func queueEvent(tx *sql.Tx, eventType, aggregateID string, occurredAt int64, data any) error {
+	envelope, err := events.New(eventType, aggregateID, time.Unix(occurredAt, 0), data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(sqlInsertOutboxEvent,
+		envelope.EventID,
+		envelope.EventType,
+		envelope.SchemaVersion,
+		envelope.AggregateID,
+		string(payload),
+		occurredAt,
+	)
+	return err
+}
+
+// changeSubmissionStatus runs a status update taking ($1 updated_at,
+// $2 submission_id) in tx and queues a submission.status_changed event when
+// the status moved. It reports whether the update matched the submission.
+func changeSubmissionStatus(tx *sql.Tx, query, submissionID string, at int64) (bool, error) {
+	var fromStatus, customerID string
+	var dealerID sql.NullString
+	err := tx.QueryRow(sqlGetSubmissionEventFields, submissionID).Scan(&fromStatus, &customerID, &dealerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(query, at, submissionID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	var toStatus string
+	if err := tx.QueryRow(sqlGetSubmissionEventFields, submissionID).Scan(&toStatus, &customerID, &dealerID); err != nil {
+		return false, err
+	}
+	if toStatus == fromStatus {
+		return true, nil
+	}
+	return true, queueSubmissionStatusChanged(tx, submissionID, customerID, dealerID, fromStatus, toStatus, at)
+}
+
+func queueSubmissionStatusChanged(tx *sql.Tx, submissionID, customerID string, dealerID sql.NullString,
+	fromStatus, toStatus string, at int64) error {
+	data := events.SubmissionStatusChangedV1{
+		SubmissionID: submissionID,
+		CustomerID:   customerID,
+		DealerID:     nullStringPtr(dealerID),
+		FromStatus:   fromStatus,
+		ToStatus:     toStatus,
+	}
+	if err := queueEvent(tx, events.TypeSubmissionStatusChanged, submissionID, at, data); err != nil {
+		return err
+	}
+	if err := queueWebhookDeliveries(tx, submissionID, dealerID, toStatus, at); err != nil {
+		return err
+	}
+	switch toStatus {
+	case "APPROVED":
+		return queueSubmissionNotification(tx, NotificationSubmissionApproved, submissionID, at)
+	case "REJECTED":
+		return queueSubmissionNotification(tx, NotificationSubmissionRejected, submissionID, at)
+	}
+	return nil
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
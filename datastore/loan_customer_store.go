@@ -2,7 +2,11 @@ package datastore
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/alphaloan/vehicle/events"
 )
 
 const sqlUpsertCustomer = `
@@ -15,9 +19,10 @@ const sqlUpsertCustomer = `
         email,
         monthly_income,
         address_street,
-        address_city
+        address_city,
+        locale
     ) VALUES (
-        $1, $2, $3, $4, $5, $6, $7, $8, $9
+        $1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(NULLIF($10, ''), 'en')
     ) ON CONFLICT (id_card_number) DO UPDATE SET
         full_name = EXCLUDED.full_name,
         birth_date = EXCLUDED.birth_date,
@@ -25,10 +30,36 @@ const sqlUpsertCustomer = `
         email = EXCLUDED.email,
         monthly_income = EXCLUDED.monthly_income,
         address_street = EXCLUDED.address_street,
-        address_city = EXCLUDED.address_city
+        address_city = EXCLUDED.address_city,
+        locale = COALESCE(NULLIF($10, ''), loan_customers.locale)
     RETURNING customer_id;
 `
 
+// A co-applicant or guarantor named on someone else's submission is only
+// created when new; an existing customer's details are left as they are.
+const sqlInsertCustomerIfNew = `
+    INSERT INTO loan_customers (
+        customer_id,
+        id_card_number,
+        full_name,
+        birth_date,
+        phone_number,
+        email,
+        monthly_income,
+        address_street,
+        address_city,
+        locale
+    ) VALUES (
+        $1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(NULLIF($10, ''), 'en')
+    ) ON CONFLICT (id_card_number) DO NOTHING
+    RETURNING customer_id;
+`
+
+const sqlGetCustomerIdByIdCardNumber = `
+SELECT customer_id
+FROM loan_customers
+WHERE id_card_number = $1;`
+
 const sqlGetAllLoanCustomers = `
 SELECT
     customer_id,
@@ -39,9 +70,57 @@ SELECT
 	email,
 	monthly_income,
 	address_street,
-	address_city
+	address_city,
+	locale
 FROM loan_customers;`
 
+const sqlExportLoanCustomers = `
+SELECT
+    customer_id,
+	id_card_number,
+	full_name,
+	birth_date,
+	phone_number,
+	email,
+	monthly_income,
+	address_street,
+	address_city,
+	locale
+FROM loan_customers
+WHERE ($1 = '' OR address_city = $1 COLLATE NOCASE)
+AND ($2 = '' OR locale = $2)
+ORDER BY full_name, customer_id;`
+
+const sqlGetLoanCustomerById = `
+SELECT
+    customer_id,
+	id_card_number,
+	full_name,
+	birth_date,
+	phone_number,
+	email,
+	monthly_income,
+	address_street,
+	address_city,
+	locale
+FROM loan_customers
+WHERE customer_id = $1;`
+
+const sqlGetLoanCustomersByIds = `
+SELECT
+    customer_id,
+	id_card_number,
+	full_name,
+	birth_date,
+	phone_number,
+	email,
+	monthly_income,
+	address_street,
+	address_city,
+	locale
+FROM loan_customers
+WHERE customer_id IN (SELECT value FROM json_each($1));`
+
 const sqlGetCustomerByCustomerId = `
 select
     customer.customer_id,
@@ -53,6 +132,7 @@ select
     customer.monthly_income,
     customer.address_street, 
     customer.address_city,
+    customer.locale,
     submission.submission_id,
     submission.vehicle_type,
     submission.vehicle_brand,
@@ -63,12 +143,21 @@ select
     submission.proposed_loan_amount,
     submission.proposed_loan_tenure_month,
     submission.is_commercial_vehicle,
+    submission.loan_status,
     submission.created_at,
-    submission.updated_at
+    submission.updated_at,
+    submission.is_duplicate_collateral,
+    submission.product_id,
+    submission.dealer_id,
+    submission.agent_id,
+    party.party_role
 from loan_customers customer
+inner join submission_parties party
+on customer.customer_id = party.customer_id
 inner join loan_submissions submission
-on customer.customer_id = submission.customer_id
-where customer.customer_id = $1;`
+on party.submission_id = submission.submission_id
+where customer.customer_id = $1
+order by submission.created_at;`
 
 const sqlUpdateCustomerByCustomerId = `
 Update loan_customers 
@@ -78,8 +167,9 @@ phone_number = COALESCE($3, phone_number),
 email = COALESCE($4, email),
 monthly_income = COALESCE($5, monthly_income),
 address_street = COALESCE($6, address_street),
-address_city = COALESCE($7, address_city)
-where customer_id = $8;`
+address_city = COALESCE($7, address_city),
+locale = COALESCE(NULLIF($8, ''), locale)
+where customer_id = $9;`
 
 const sqlDeleteCustomerByCostumerId = `
 delete
@@ -96,11 +186,19 @@ type LoanCustomerRow struct {
 	MonthlyIncome float64
 	AddressStreet string
 	AddressCity   string
+	Locale        string
 }
 
 type LoanCustomerWithAllSubmissionsRow struct {
 	LoanCustomerRow *LoanCustomerRow
 	LoanSubmissions []*LoanSubmissionRow
+	// PartyRoles maps each submission id to the role the customer holds on it.
+	PartyRoles map[string]string
+}
+
+type CustomerExportFilter struct {
+	AddressCity string
+	Locale      string
 }
 
 type LoanCustomerStore struct {
@@ -114,8 +212,27 @@ func NewLoanCustomerStore(db *sql.DB) *LoanCustomerStore {
 }
 
 func (s *LoanCustomerStore) UpsertCustomer(customer *LoanCustomerRow) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	customerID, err := upsertCustomer(tx, customer)
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return customerID, nil
+}
+
+// upsertCustomer matches an existing customer on the id card number and
+// returns its id.
+func upsertCustomer(tx *sql.Tx, customer *LoanCustomerRow) (string, error) {
 	var customerID string
-	err := s.db.QueryRow(sqlUpsertCustomer,
+	err := tx.QueryRow(sqlUpsertCustomer,
 		customer.CustomerID,
 		customer.IDCardNumber,
 		customer.FullName,
@@ -124,17 +241,62 @@ func (s *LoanCustomerStore) UpsertCustomer(customer *LoanCustomerRow) (string, e
 		customer.Email,
 		customer.MonthlyIncome,
 		customer.AddressStreet,
-		customer.AddressCity).Scan(&customerID)
+		customer.AddressCity,
+		customer.Locale).Scan(&customerID)
 
 	if err != nil {
 		return "", err
 	}
 
+	if err := queueCustomerUpserted(tx, customerID); err != nil {
+		return "", err
+	}
+	return customerID, nil
+}
+
+// linkCustomer returns the id of the customer with the id card number,
+// creating the customer when there is none. Unlike upsertCustomer it never
+// changes an existing customer, so naming someone as a party to a submission
+// cannot rewrite their details.
+func linkCustomer(tx *sql.Tx, customer *LoanCustomerRow) (string, error) {
+	var customerID string
+	err := tx.QueryRow(sqlInsertCustomerIfNew,
+		customer.CustomerID,
+		customer.IDCardNumber,
+		customer.FullName,
+		customer.BirthDate,
+		customer.PhoneNumber,
+		customer.Email,
+		customer.MonthlyIncome,
+		customer.AddressStreet,
+		customer.AddressCity,
+		customer.Locale).Scan(&customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRow(sqlGetCustomerIdByIdCardNumber, customer.IDCardNumber).Scan(&customerID)
+		return customerID, err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := queueCustomerUpserted(tx, customerID); err != nil {
+		return "", err
+	}
 	return customerID, nil
 }
 
 func (s *LoanCustomerStore) GetAllLoanCustomers() ([]*LoanCustomerRow, error) {
-	rows, err := s.db.Query(sqlGetAllLoanCustomers)
+	return s.queryCustomers(sqlGetAllLoanCustomers)
+}
+
+// GetLoanCustomersByIds returns the customers that exist among ids, in no
+// particular order.
+func (s *LoanCustomerStore) GetLoanCustomersByIds(ids []string) ([]*LoanCustomerRow, error) {
+	return s.queryCustomers(sqlGetLoanCustomersByIds, idList(ids))
+}
+
+func (s *LoanCustomerStore) queryCustomers(query string, args ...any) ([]*LoanCustomerRow, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +315,7 @@ func (s *LoanCustomerStore) GetAllLoanCustomers() ([]*LoanCustomerRow, error) {
 			&customer.MonthlyIncome,
 			&customer.AddressStreet,
 			&customer.AddressCity,
+			&customer.Locale,
 		)
 		if err != nil {
 			return nil, err
@@ -166,6 +329,59 @@ func (s *LoanCustomerStore) GetAllLoanCustomers() ([]*LoanCustomerRow, error) {
 	return customers, nil
 }
 
+// ExportLoanCustomers hands the matching customers to fn one at a time
+// straight from the cursor. An error from fn stops the export and is returned.
+func (s *LoanCustomerStore) ExportLoanCustomers(filter CustomerExportFilter, fn func(*LoanCustomerRow) error) error {
+	rows, err := s.db.Query(sqlExportLoanCustomers, filter.AddressCity, filter.Locale)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		customer := &LoanCustomerRow{}
+		err := rows.Scan(
+			&customer.CustomerID,
+			&customer.IDCardNumber,
+			&customer.FullName,
+			&customer.BirthDate,
+			&customer.PhoneNumber,
+			&customer.Email,
+			&customer.MonthlyIncome,
+			&customer.AddressStreet,
+			&customer.AddressCity,
+			&customer.Locale,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(customer); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *LoanCustomerStore) GetLoanCustomerById(id string) (*LoanCustomerRow, error) {
+	customer := &LoanCustomerRow{}
+	err := s.db.QueryRow(sqlGetLoanCustomerById, id).Scan(
+		&customer.CustomerID,
+		&customer.IDCardNumber,
+		&customer.FullName,
+		&customer.BirthDate,
+		&customer.PhoneNumber,
+		&customer.Email,
+		&customer.MonthlyIncome,
+		&customer.AddressStreet,
+		&customer.AddressCity,
+		&customer.Locale,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
 func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWithAllSubmissionsRow, error) {
 	rows, err := s.db.Query(sqlGetCustomerByCustomerId, id)
 	if err != nil {
@@ -175,9 +391,11 @@ func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWit
 
 	var customer *LoanCustomerRow
 	var submissions []*LoanSubmissionRow
+	partyRoles := make(map[string]string)
 
 	for rows.Next() {
 		submission := &LoanSubmissionRow{}
+		var partyRole string
 		if customer == nil {
 			customer = &LoanCustomerRow{}
 			err = rows.Scan(
@@ -190,6 +408,7 @@ func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWit
 				&customer.MonthlyIncome,
 				&customer.AddressStreet,
 				&customer.AddressCity,
+				&customer.Locale,
 				&submission.SubmissionID,
 				&submission.VehicleType,
 				&submission.VehicleBrand,
@@ -200,8 +419,14 @@ func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWit
 				&submission.ProposedLoanAmount,
 				&submission.ProposedLoanTenure,
 				&submission.IsCommercialVehicle,
+				&submission.LoanStatus,
 				&submission.CreatedAt,
 				&submission.UpdatedAt,
+				&submission.IsDuplicateCollateral,
+				&submission.ProductID,
+				&submission.DealerID,
+				&submission.AgentID,
+				&partyRole,
 			)
 			if err != nil {
 				return nil, err
@@ -217,6 +442,7 @@ func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWit
 				new(float64),
 				new(string),
 				new(string),
+				new(string),
 				&submission.SubmissionID,
 				&submission.VehicleType,
 				&submission.VehicleBrand,
@@ -227,14 +453,21 @@ func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWit
 				&submission.ProposedLoanAmount,
 				&submission.ProposedLoanTenure,
 				&submission.IsCommercialVehicle,
+				&submission.LoanStatus,
 				&submission.CreatedAt,
 				&submission.UpdatedAt,
+				&submission.IsDuplicateCollateral,
+				&submission.ProductID,
+				&submission.DealerID,
+				&submission.AgentID,
+				&partyRole,
 			)
 			if err != nil {
 				return nil, err
 			}
 		}
 		submissions = append(submissions, submission)
+		partyRoles[submission.SubmissionID] = partyRole
 	}
 
 	if err = rows.Err(); err != nil {
@@ -248,13 +481,20 @@ func (s *LoanCustomerStore) GetCustomerByCustomerId(id string) (*LoanCustomerWit
 	return &LoanCustomerWithAllSubmissionsRow{
 		LoanCustomerRow: customer,
 		LoanSubmissions: submissions,
+		PartyRoles:      partyRoles,
 	}, nil
 }
 
 func (s *LoanCustomerStore) UpdateCustomerByCustomerId(customer *LoanCustomerRow) error {
-	result, err := s.db.Exec(sqlUpdateCustomerByCustomerId, customer.FullName, customer.BirthDate, customer.PhoneNumber,
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(sqlUpdateCustomerByCustomerId, customer.FullName, customer.BirthDate, customer.PhoneNumber,
 		customer.Email, customer.MonthlyIncome, customer.AddressStreet, customer.AddressCity,
-		customer.CustomerID)
+		customer.Locale, customer.CustomerID)
 
 	if err != nil {
 		fmt.Println("UpdateCustomerByCustomerId err:", err)
@@ -262,17 +502,27 @@ func (s *LoanCustomerStore) UpdateCustomerByCustomerId(customer *LoanCustomerRow
 	}
 	rows, err := result.RowsAffected()
 	if err != nil {
-		fmt.Printf(err.Error())
+		fmt.Println("UpdateCustomerByCustomerId rows affected err:", err)
 		return err
 	}
 	if rows == 0 {
 		return fmt.Errorf("no rows affected")
 	}
-	return nil
+
+	if err := queueCustomerUpserted(tx, customer.CustomerID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *LoanCustomerStore) DeleteCustomerByCustomerId(customerId string) error {
-	result, err := s.db.Exec(sqlDeleteCustomerByCostumerId, customerId)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(sqlDeleteCustomerByCostumerId, customerId)
 
 	if err != nil {
 		fmt.Println("DeleteCustomerByCustomerId err:", err)
@@ -280,11 +530,46 @@ func (s *LoanCustomerStore) DeleteCustomerByCustomerId(customerId string) error
 	}
 	rows, err := result.RowsAffected()
 	if err != nil {
-		fmt.Printf(err.Error())
+		fmt.Println("DeleteCustomerByCustomerId rows affected err:", err)
 		return err
 	}
 	if rows == 0 {
 		return fmt.Errorf("no rows affected")
 	}
-	return nil
+
+	data := events.CustomerDeletedV1{CustomerID: customerId}
+	if err := queueEvent(tx, events.TypeCustomerDeleted, customerId, time.Now().Unix(), data); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queueCustomerUpserted reads the customer back so partial updates publish
+// the full record.
+func queueCustomerUpserted(tx *sql.Tx, customerID string) error {
+	customer := &LoanCustomerRow{}
+	err := tx.QueryRow(sqlGetLoanCustomerById, customerID).Scan(
+		&customer.CustomerID,
+		&customer.IDCardNumber,
+		&customer.FullName,
+		&customer.BirthDate,
+		&customer.PhoneNumber,
+		&customer.Email,
+		&customer.MonthlyIncome,
+		&customer.AddressStreet,
+		&customer.AddressCity,
+		&customer.Locale,
+	)
+	if err != nil {
+		return err
+	}
+
+	data := events.CustomerUpsertedV1{
+		CustomerID:  customer.CustomerID,
+		FullName:    customer.FullName,
+		PhoneNumber: customer.PhoneNumber,
+		Email:       nullStringPtr(customer.Email),
+		AddressCity: customer.AddressCity,
+	}
+	return queueEvent(tx, events.TypeCustomerUpserted, customer.CustomerID, time.Now().Unix(), data)
 }
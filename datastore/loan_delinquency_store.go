@@ -0,0 +1,229 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const sqlGetCollectibleLoans = `
+SELECT
+    submission.submission_id,
+    submission.product_id,
+    submission.approved_at,
+    (
+        SELECT disbursement.reviewed_at
+        FROM loan_disbursements disbursement
+        WHERE disbursement.submission_id = submission.submission_id
+        AND disbursement.status = 'APPROVED'
+    ),
+    EXISTS (
+        SELECT 1
+        FROM loan_installments installment
+        WHERE installment.submission_id = submission.submission_id
+    )
+FROM loan_submissions submission
+WHERE submission.loan_status IN (` + repayableLoanStatusList + `)
+ORDER BY submission.created_at;`
+
+const sqlUpdateInstallmentAssessment = `
+UPDATE loan_installments
+SET penalty_due = $1,
+    overdue_since = $2,
+    penalty_accrued_through = $3
+WHERE submission_id = $4
+AND installment_number = $5;`
+
+const sqlUpsertDelinquency = `
+INSERT INTO loan_delinquencies (
+    submission_id,
+    as_of,
+    days_past_due,
+    bucket,
+    overdue_amount,
+    overdue_installments,
+    outstanding_amount,
+    penalty_outstanding,
+    evaluated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) ON CONFLICT (submission_id) DO UPDATE SET
+    as_of = EXCLUDED.as_of,
+    days_past_due = EXCLUDED.days_past_due,
+    bucket = EXCLUDED.bucket,
+    overdue_amount = EXCLUDED.overdue_amount,
+    overdue_installments = EXCLUDED.overdue_installments,
+    outstanding_amount = EXCLUDED.outstanding_amount,
+    penalty_outstanding = EXCLUDED.penalty_outstanding,
+    evaluated_at = EXCLUDED.evaluated_at;`
+
+const sqlGetAgingReport = `
+SELECT
+    delinquency.submission_id,
+    delinquency.as_of,
+    delinquency.days_past_due,
+    delinquency.bucket,
+    delinquency.overdue_amount,
+    delinquency.overdue_installments,
+    delinquency.outstanding_amount,
+    delinquency.penalty_outstanding,
+    delinquency.evaluated_at,
+    customer.customer_id,
+    customer.full_name,
+    customer.phone_number,
+    customer.address_city,
+    submission.vehicle_type,
+    submission.vehicle_license_number
+FROM loan_delinquencies delinquency
+INNER JOIN loan_submissions submission
+ON submission.submission_id = delinquency.submission_id
+INNER JOIN loan_customers customer
+ON customer.customer_id = submission.customer_id
+WHERE submission.loan_status IN (` + repayableLoanStatusList + `)
+AND ($1 = '' OR customer.address_city = $1 COLLATE NOCASE)
+AND ($2 = '' OR submission.vehicle_type = $2 COLLATE NOCASE)
+ORDER BY delinquency.days_past_due DESC, delinquency.overdue_amount DESC;`
+
+type CollectibleLoanRow struct {
+	SubmissionID string
+	ProductID    sql.NullString
+	ApprovedAt   sql.NullInt64
+	DisbursedAt  sql.NullInt64
+	HasSchedule  bool
+}
+
+type LoanDelinquencyRow struct {
+	SubmissionID        string
+	AsOf                string
+	DaysPastDue         int
+	Bucket              string
+	OverdueAmount       int
+	OverdueInstallments int
+	OutstandingAmount   int
+	PenaltyOutstanding  int
+	EvaluatedAt         int64
+}
+
+type LoanAgingRow struct {
+	LoanDelinquencyRow
+	CustomerID           string
+	FullName             string
+	PhoneNumber          string
+	AddressCity          string
+	VehicleType          string
+	VehicleLicenseNumber string
+}
+
+type LoanDelinquencyStore struct {
+	db *sql.DB
+}
+
+func NewLoanDelinquencyStore(db *sql.DB) *LoanDelinquencyStore {
+	return &LoanDelinquencyStore{
+		db: db,
+	}
+}
+
+// GetCollectibleLoans lists the approved and disbursed submissions, with or
+// without a repayment schedule.
+func (s *LoanDelinquencyStore) GetCollectibleLoans() ([]*CollectibleLoanRow, error) {
+	rows, err := s.db.Query(sqlGetCollectibleLoans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []*CollectibleLoanRow
+	for rows.Next() {
+		loan := &CollectibleLoanRow{}
+		if err := rows.Scan(&loan.SubmissionID, &loan.ProductID, &loan.ApprovedAt, &loan.DisbursedAt, &loan.HasSchedule); err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// SaveAssessment stores the overdue markers and penalties of the installments
+// together with the delinquency snapshot of the loan. Paid amounts are left
+// alone so a payment posted meanwhile is not lost.
+func (s *LoanDelinquencyStore) SaveAssessment(installments []*LoanInstallmentRow, delinquency *LoanDelinquencyRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, installment := range installments {
+		_, err := tx.Exec(sqlUpdateInstallmentAssessment,
+			installment.PenaltyDue,
+			installment.OverdueSince,
+			installment.PenaltyAccruedThrough,
+			installment.SubmissionID,
+			installment.InstallmentNumber,
+		)
+		if err != nil {
+			return fmt.Errorf("installment %d: %w", installment.InstallmentNumber, err)
+		}
+	}
+
+	_, err = tx.Exec(sqlUpsertDelinquency,
+		delinquency.SubmissionID,
+		delinquency.AsOf,
+		delinquency.DaysPastDue,
+		delinquency.Bucket,
+		delinquency.OverdueAmount,
+		delinquency.OverdueInstallments,
+		delinquency.OutstandingAmount,
+		delinquency.PenaltyOutstanding,
+		delinquency.EvaluatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAgingReport returns the latest delinquency snapshot of every active
+// loan. Empty filters match everything.
+func (s *LoanDelinquencyStore) GetAgingReport(addressCity, vehicleType string) ([]*LoanAgingRow, error) {
+	rows, err := s.db.Query(sqlGetAgingReport, addressCity, vehicleType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []*LoanAgingRow
+	for rows.Next() {
+		loan := &LoanAgingRow{}
+		err := rows.Scan(
+			&loan.SubmissionID,
+			&loan.AsOf,
+			&loan.DaysPastDue,
+			&loan.Bucket,
+			&loan.OverdueAmount,
+			&loan.OverdueInstallments,
+			&loan.OutstandingAmount,
+			&loan.PenaltyOutstanding,
+			&loan.EvaluatedAt,
+			&loan.CustomerID,
+			&loan.FullName,
+			&loan.PhoneNumber,
+			&loan.AddressCity,
+			&loan.VehicleType,
+			&loan.VehicleLicenseNumber,
+		)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
@@ -0,0 +1,317 @@
+package datastore
+
+import (
+	"database/sql"
+	"errors"
+)
+
+const (
+	DisbursementStatusPending  = "PENDING_APPROVAL"
+	DisbursementStatusApproved = "APPROVED"
+	DisbursementStatusRejected = "REJECTED"
+)
+
+// Delivery states of the payment file of an approved disbursement. A file is
+// PENDING from the approval until it is put in the outbox, where the bank
+// integration picks it up; once DELIVERED it is never written again.
+const (
+	PaymentFilePending   = "PENDING"
+	PaymentFileDelivered = "DELIVERED"
+	PaymentFileFailed    = "FAILED"
+)
+
+const (
+	PayeeTypeDealer   = "DEALER"
+	PayeeTypeCustomer = "CUSTOMER"
+)
+
+var (
+	ErrDisbursementReviewed  = errors.New("disbursement already reviewed")
+	ErrSubmissionNotApproved = errors.New("submission is not approved")
+	ErrDisbursementOpen      = errors.New("submission already has an open disbursement")
+	ErrPaymentFileNotFailed  = errors.New("payment file has not failed")
+)
+
+const sqlInsertDisbursement = `
+INSERT INTO loan_disbursements (
+    disbursement_id,
+    submission_id,
+    payee_type,
+    payee_name,
+    bank_code,
+    account_number,
+    account_holder,
+    loan_amount,
+    deducted_fees,
+    net_amount,
+    status,
+    created_by,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+);`
+
+const sqlSelectDisbursements = `
+SELECT
+    disbursement_id,
+    submission_id,
+    payee_type,
+    payee_name,
+    bank_code,
+    account_number,
+    account_holder,
+    loan_amount,
+    deducted_fees,
+    net_amount,
+    status,
+    created_by,
+    reviewed_by,
+    review_note,
+    payment_file,
+    payment_file_status,
+    payment_file_delivered_at,
+    created_at,
+    reviewed_at
+FROM loan_disbursements
+`
+
+const sqlGetDisbursementById = sqlSelectDisbursements + `WHERE disbursement_id = $1;`
+
+const sqlGetDisbursements = sqlSelectDisbursements + `
+WHERE ($1 = '' OR status = $1)
+AND ($2 = '' OR submission_id = $2)
+ORDER BY created_at;`
+
+const sqlReviewDisbursement = `
+UPDATE loan_disbursements
+SET status = $1,
+    reviewed_by = $2,
+    review_note = $3,
+    payment_file = $4,
+    payment_file_status = $5,
+    reviewed_at = $6
+WHERE disbursement_id = $7
+AND status = 'PENDING_APPROVAL';`
+
+const sqlFinishPaymentFile = `
+UPDATE loan_disbursements
+SET payment_file_status = $1,
+    payment_file_delivered_at = $2
+WHERE disbursement_id = $3
+AND payment_file_status = 'PENDING';`
+
+const sqlRetryPaymentFile = `
+UPDATE loan_disbursements
+SET payment_file_status = 'PENDING'
+WHERE disbursement_id = $1
+AND payment_file_status = 'FAILED';`
+
+const sqlMarkSubmissionDisbursed = `
+UPDATE loan_submissions
+SET loan_status = 'DISBURSED',
+    updated_at = $1
+WHERE submission_id = $2
+AND loan_status = 'APPROVED';`
+
+type LoanDisbursementRow struct {
+	DisbursementID string
+	SubmissionID   string
+	PayeeType      string
+	PayeeName      string
+	BankCode       string
+	AccountNumber  string
+	AccountHolder  string
+	LoanAmount     int
+	DeductedFees   int
+	NetAmount      int
+	Status         string
+	CreatedBy      string
+	ReviewedBy     sql.NullString
+	ReviewNote     sql.NullString
+	PaymentFile    sql.NullString
+	// PaymentFileStatus is set once the disbursement is approved.
+	PaymentFileStatus      sql.NullString
+	PaymentFileDeliveredAt sql.NullInt64
+	CreatedAt              int64
+	ReviewedAt             sql.NullInt64
+}
+
+type LoanDisbursementStore struct {
+	db *sql.DB
+}
+
+func NewLoanDisbursementStore(db *sql.DB) *LoanDisbursementStore {
+	return &LoanDisbursementStore{
+		db: db,
+	}
+}
+
+func (s *LoanDisbursementStore) InsertDisbursement(disbursement *LoanDisbursementRow) error {
+	_, err := s.db.Exec(sqlInsertDisbursement,
+		disbursement.DisbursementID,
+		disbursement.SubmissionID,
+		disbursement.PayeeType,
+		disbursement.PayeeName,
+		disbursement.BankCode,
+		disbursement.AccountNumber,
+		disbursement.AccountHolder,
+		disbursement.LoanAmount,
+		disbursement.DeductedFees,
+		disbursement.NetAmount,
+		disbursement.Status,
+		disbursement.CreatedBy,
+		disbursement.CreatedAt,
+	)
+	if isConstraintError(err) {
+		return ErrDisbursementOpen
+	}
+	return err
+}
+
+func (s *LoanDisbursementStore) GetDisbursementById(disbursementID string) (*LoanDisbursementRow, error) {
+	return scanDisbursement(s.db.QueryRow(sqlGetDisbursementById, disbursementID))
+}
+
+// GetDisbursements lists disbursement instructions oldest first. Empty
+// filters match everything.
+func (s *LoanDisbursementStore) GetDisbursements(status, submissionID string) ([]*LoanDisbursementRow, error) {
+	rows, err := s.db.Query(sqlGetDisbursements, status, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disbursements []*LoanDisbursementRow
+	for rows.Next() {
+		disbursement, err := scanDisbursement(rows)
+		if err != nil {
+			return nil, err
+		}
+		disbursements = append(disbursements, disbursement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return disbursements, nil
+}
+
+// ApproveDisbursement records the checker's approval and moves the submission
+// to DISBURSED in one transaction.
+func (s *LoanDisbursementStore) ApproveDisbursement(disbursement *LoanDisbursementRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reviewDisbursement(tx, disbursement); err != nil {
+		return err
+	}
+
+	changed, err := changeSubmissionStatus(tx, sqlMarkSubmissionDisbursed, disbursement.SubmissionID, disbursement.ReviewedAt.Int64)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrSubmissionNotApproved
+	}
+	return tx.Commit()
+}
+
+func (s *LoanDisbursementStore) RejectDisbursement(disbursement *LoanDisbursementRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reviewDisbursement(tx, disbursement); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkPaymentFileDelivered records that the pending payment file of an
+// approved disbursement was put in the outbox.
+func (s *LoanDisbursementStore) MarkPaymentFileDelivered(disbursementID string, deliveredAt int64) error {
+	_, err := s.db.Exec(sqlFinishPaymentFile, PaymentFileDelivered, deliveredAt, disbursementID)
+	return err
+}
+
+// MarkPaymentFileFailed records that the pending payment file of an approved
+// disbursement could not be put in the outbox, which allows a retry.
+func (s *LoanDisbursementStore) MarkPaymentFileFailed(disbursementID string) error {
+	_, err := s.db.Exec(sqlFinishPaymentFile, PaymentFileFailed, nil, disbursementID)
+	return err
+}
+
+// RetryPaymentFile moves a FAILED payment file back to PENDING so that the
+// caller writes it again. Of concurrent retries only one claims the file, the
+// others get ErrPaymentFileNotFailed, as does a file that was delivered.
+func (s *LoanDisbursementStore) RetryPaymentFile(disbursementID string) error {
+	result, err := s.db.Exec(sqlRetryPaymentFile, disbursementID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPaymentFileNotFailed
+	}
+	return nil
+}
+
+func reviewDisbursement(tx *sql.Tx, disbursement *LoanDisbursementRow) error {
+	result, err := tx.Exec(sqlReviewDisbursement,
+		disbursement.Status,
+		disbursement.ReviewedBy,
+		disbursement.ReviewNote,
+		disbursement.PaymentFile,
+		disbursement.PaymentFileStatus,
+		disbursement.ReviewedAt,
+		disbursement.DisbursementID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrDisbursementReviewed
+	}
+	return nil
+}
+
+func scanDisbursement(row rowScanner) (*LoanDisbursementRow, error) {
+	disbursement := &LoanDisbursementRow{}
+	err := row.Scan(
+		&disbursement.DisbursementID,
+		&disbursement.SubmissionID,
+		&disbursement.PayeeType,
+		&disbursement.PayeeName,
+		&disbursement.BankCode,
+		&disbursement.AccountNumber,
+		&disbursement.AccountHolder,
+		&disbursement.LoanAmount,
+		&disbursement.DeductedFees,
+		&disbursement.NetAmount,
+		&disbursement.Status,
+		&disbursement.CreatedBy,
+		&disbursement.ReviewedBy,
+		&disbursement.ReviewNote,
+		&disbursement.PaymentFile,
+		&disbursement.PaymentFileStatus,
+		&disbursement.PaymentFileDeliveredAt,
+		&disbursement.CreatedAt,
+		&disbursement.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return disbursement, nil
+}
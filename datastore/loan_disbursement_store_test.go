@@ -0,0 +1,79 @@
+package datastore_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/google/uuid"
+)
+
+func testDisbursement(submissionID string) *datastore.LoanDisbursementRow {
+	return &datastore.LoanDisbursementRow{
+		DisbursementID: uuid.New().String(), SubmissionID: submissionID, PayeeType: datastore.PayeeTypeDealer,
+		PayeeName: "Jaya Motor", BankCode: "014", AccountNumber: "1234567890", AccountHolder: "PT Jaya Motor",
+		LoanAmount: 12000, NetAmount: 12000, Status: datastore.DisbursementStatusPending, CreatedBy: "maker",
+		CreatedAt: time.Now().Unix(),
+	}
+}
+
+func TestPaymentFileIsRetriedOnlyUntilDelivered(t *testing.T) {
+	db := datastoretest.Open(t)
+	record := testSubmitRecord("3171000000000001", "B1234XYZ")
+	record.Submission.LoanStatus = "APPROVED"
+	submitted, err := datastore.NewLoanSubmissionStore(db).SubmitLoan(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := datastore.NewLoanDisbursementStore(db)
+	row := testDisbursement(submitted.SubmissionID)
+	if err := store.InsertDisbursement(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InsertDisbursement(testDisbursement(submitted.SubmissionID)); !errors.Is(err, datastore.ErrDisbursementOpen) {
+		t.Errorf("second open disbursement: err = %v, want ErrDisbursementOpen", err)
+	}
+
+	row.Status = datastore.DisbursementStatusApproved
+	row.ReviewedBy = sql.NullString{String: "checker", Valid: true}
+	row.PaymentFile = sql.NullString{String: "payments/" + row.DisbursementID + ".csv", Valid: true}
+	row.PaymentFileStatus = sql.NullString{String: datastore.PaymentFilePending, Valid: true}
+	row.ReviewedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+	if err := store.ApproveDisbursement(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RetryPaymentFile(row.DisbursementID); !errors.Is(err, datastore.ErrPaymentFileNotFailed) {
+		t.Errorf("retrying a pending payment file: err = %v, want ErrPaymentFileNotFailed", err)
+	}
+
+	if err := store.MarkPaymentFileFailed(row.DisbursementID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RetryPaymentFile(row.DisbursementID); err != nil {
+		t.Fatalf("retrying a failed payment file: %v", err)
+	}
+	if err := store.RetryPaymentFile(row.DisbursementID); !errors.Is(err, datastore.ErrPaymentFileNotFailed) {
+		t.Errorf("retrying a payment file another retry claimed: err = %v, want ErrPaymentFileNotFailed", err)
+	}
+
+	if err := store.MarkPaymentFileDelivered(row.DisbursementID, time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkPaymentFileFailed(row.DisbursementID); err != nil {
+		t.Fatal(err)
+	}
+	delivered, err := store.GetDisbursementById(row.DisbursementID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered.PaymentFileStatus.String != datastore.PaymentFileDelivered || !delivered.PaymentFileDeliveredAt.Valid {
+		t.Errorf("payment file is %q, want it to stay DELIVERED", delivered.PaymentFileStatus.String)
+	}
+	if err := store.RetryPaymentFile(row.DisbursementID); !errors.Is(err, datastore.ErrPaymentFileNotFailed) {
+		t.Errorf("retrying a delivered payment file: err = %v, want ErrPaymentFileNotFailed", err)
+	}
+}
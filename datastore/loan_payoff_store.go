@@ -0,0 +1,210 @@
+package datastore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPayoffQuoteSettled   = errors.New("payoff quote already settled")
+	ErrPayoffBalanceChanged = errors.New("loan balance changed since the payoff was priced")
+	ErrLoanNotRepayable     = errors.New("loan is not approved or disbursed")
+)
+
+const sqlInsertPayoffQuote = `
+INSERT INTO loan_payoff_quotes (
+    quote_id,
+    submission_id,
+    as_of,
+    expires_on,
+    principal,
+    accrued_interest,
+    fee,
+    penalty,
+    termination_fee,
+    waived,
+    total,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+);`
+
+const sqlGetPayoffQuoteById = `
+SELECT
+    quote_id,
+    submission_id,
+    as_of,
+    expires_on,
+    principal,
+    accrued_interest,
+    fee,
+    penalty,
+    termination_fee,
+    waived,
+    total,
+    created_at,
+    settled_at
+FROM loan_payoff_quotes
+WHERE quote_id = $1;`
+
+// Installments are only waived while they are as the settlement found them,
+// so a payment or settlement posted meanwhile fails the settlement.
+const sqlWaiveInstallment = `
+UPDATE loan_installments
+SET interest_due = $1,
+    fee_due = $2
+WHERE submission_id = $3
+AND installment_number = $4
+AND penalty_due = $5
+AND principal_paid = $6
+AND interest_paid = $7
+AND fee_paid = $8
+AND penalty_paid = $9;`
+
+const sqlSettlePayoffQuote = `
+UPDATE loan_payoff_quotes
+SET settled_at = $1
+WHERE quote_id = $2
+AND settled_at IS NULL;`
+
+const sqlCloseLoanSubmission = `
+UPDATE loan_submissions
+SET loan_status = 'CLOSED',
+    updated_at = $1
+WHERE submission_id = $2
+AND loan_status IN (` + repayableLoanStatusList + `);`
+
+type LoanPayoffQuoteRow struct {
+	QuoteID         string
+	SubmissionID    string
+	AsOf            string
+	ExpiresOn       string
+	Principal       int
+	AccruedInterest int
+	Fee             int
+	Penalty         int
+	TerminationFee  int
+	Waived          int
+	Total           int
+	CreatedAt       int64
+	SettledAt       sql.NullInt64
+}
+
+type LoanPayoffStore struct {
+	db *sql.DB
+}
+
+func NewLoanPayoffStore(db *sql.DB) *LoanPayoffStore {
+	return &LoanPayoffStore{
+		db: db,
+	}
+}
+
+func (s *LoanPayoffStore) InsertPayoffQuote(quote *LoanPayoffQuoteRow) error {
+	_, err := s.db.Exec(sqlInsertPayoffQuote,
+		quote.QuoteID,
+		quote.SubmissionID,
+		quote.AsOf,
+		quote.ExpiresOn,
+		quote.Principal,
+		quote.AccruedInterest,
+		quote.Fee,
+		quote.Penalty,
+		quote.TerminationFee,
+		quote.Waived,
+		quote.Total,
+		quote.CreatedAt,
+	)
+	return err
+}
+
+func (s *LoanPayoffStore) GetPayoffQuoteById(quoteID string) (*LoanPayoffQuoteRow, error) {
+	quote := &LoanPayoffQuoteRow{}
+	err := s.db.QueryRow(sqlGetPayoffQuoteById, quoteID).Scan(
+		&quote.QuoteID,
+		&quote.SubmissionID,
+		&quote.AsOf,
+		&quote.ExpiresOn,
+		&quote.Principal,
+		&quote.AccruedInterest,
+		&quote.Fee,
+		&quote.Penalty,
+		&quote.TerminationFee,
+		&quote.Waived,
+		&quote.Total,
+		&quote.CreatedAt,
+		&quote.SettledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// SettleLoan posts the settlement payment, lowers the installments to what is
+// owed after waiving future interest and fees, marks the quote settled and
+// closes the loan, all or nothing. The installments carry the waived dues
+// together with the penalty and paid amounts the settlement was priced on; it
+// returns ErrPayoffBalanceChanged when any of them moved meanwhile and
+// ErrLoanNotRepayable when the loan is no longer approved or disbursed.
+func (s *LoanPayoffStore) SettleLoan(payment *LoanPaymentRow, installments []*LoanInstallmentRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(sqlSettlePayoffQuote, payment.CreatedAt, payment.PayoffQuoteID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPayoffQuoteSettled
+	}
+
+	if err := insertPayment(tx, payment); err != nil {
+		return err
+	}
+	for _, installment := range installments {
+		result, err := tx.Exec(sqlWaiveInstallment,
+			installment.InterestDue,
+			installment.FeeDue,
+			installment.SubmissionID,
+			installment.InstallmentNumber,
+			installment.PenaltyDue,
+			installment.PrincipalPaid,
+			installment.InterestPaid,
+			installment.FeePaid,
+			installment.PenaltyPaid,
+		)
+		if err != nil {
+			return fmt.Errorf("installment %d: %w", installment.InstallmentNumber, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fmt.Errorf("%w: installment %d", ErrPayoffBalanceChanged, installment.InstallmentNumber)
+		}
+	}
+	for _, allocation := range payment.Allocations {
+		if err := applyAllocation(tx, payment.SubmissionID, allocation); err != nil {
+			return err
+		}
+	}
+
+	closed, err := changeSubmissionStatus(tx, sqlCloseLoanSubmission, payment.SubmissionID, payment.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return ErrLoanNotRepayable
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,68 @@
+package datastore_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/google/uuid"
+)
+
+func TestSettleLoanRefusesASecondQuotePricedOnTheSameBalance(t *testing.T) {
+	db := datastoretest.Open(t)
+	record := testSubmitRecord("3171000000000001", "B1234XYZ")
+	record.Submission.LoanStatus = "DISBURSED"
+	submitted, err := datastore.NewLoanSubmissionStore(db).SubmitLoan(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submissionID := submitted.SubmissionID
+	err = datastore.NewLoanRepaymentStore(db).InsertInstallments([]*datastore.LoanInstallmentRow{
+		{SubmissionID: submissionID, InstallmentNumber: 1, DueDate: "2026-02-15", PrincipalDue: 6000, InterestDue: 120},
+		{SubmissionID: submissionID, InstallmentNumber: 2, DueDate: "2026-03-15", PrincipalDue: 6000, InterestDue: 60},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := datastore.NewLoanPayoffStore(db)
+	settle := func() error {
+		quote := &datastore.LoanPayoffQuoteRow{
+			QuoteID: uuid.New().String(), SubmissionID: submissionID, AsOf: "2026-02-01", ExpiresOn: "2026-02-04",
+			Principal: 12000, AccruedInterest: 120, Total: 12120, CreatedAt: time.Now().Unix(),
+		}
+		if err := store.InsertPayoffQuote(quote); err != nil {
+			t.Fatal(err)
+		}
+		paymentID := uuid.New().String()
+		payment := &datastore.LoanPaymentRow{
+			PaymentID: paymentID, SubmissionID: submissionID, Amount: 12120, AppliedAmount: 12120, PaidOn: "2026-02-01",
+			PayoffQuoteID: sql.NullString{String: quote.QuoteID, Valid: true}, CreatedAt: time.Now().Unix(),
+			Allocations: []*datastore.LoanPaymentAllocationRow{
+				{PaymentID: paymentID, InstallmentNumber: 1, InterestAmount: 120, PrincipalAmount: 6000},
+				{PaymentID: paymentID, InstallmentNumber: 2, PrincipalAmount: 6000},
+			},
+		}
+		// Both settlements are priced on the schedule before either was posted.
+		return store.SettleLoan(payment, []*datastore.LoanInstallmentRow{
+			{SubmissionID: submissionID, InstallmentNumber: 1, InterestDue: 120},
+			{SubmissionID: submissionID, InstallmentNumber: 2},
+		})
+	}
+
+	if err := settle(); err != nil {
+		t.Fatal(err)
+	}
+	if err := settle(); !errors.Is(err, datastore.ErrPayoffBalanceChanged) {
+		t.Errorf("second settlement: err = %v, want ErrPayoffBalanceChanged", err)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM loan_payments WHERE submission_id = $1`, submissionID); n != 1 {
+		t.Errorf("%d payments posted, want the first settlement only", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM loan_submissions WHERE submission_id = $1 AND loan_status = 'CLOSED'`, submissionID); n != 1 {
+		t.Error("the settled loan is not CLOSED")
+	}
+}
@@ -0,0 +1,369 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const sqlSelectLoanProducts = `
+SELECT
+    product_id,
+    code,
+    name,
+    vehicle_type,
+    is_commercial,
+    min_amount,
+    max_amount,
+    min_vehicle_age_years,
+    max_vehicle_age_years,
+    admin_fee,
+    provision_fee_rate,
+    insurance_fee_rate,
+    late_fee,
+    late_penalty_daily_rate,
+    penalty_grace_days,
+    early_termination_fee_rate,
+    early_termination_min_fee,
+    is_active
+FROM loan_products
+`
+
+const sqlGetAllLoanProducts = sqlSelectLoanProducts + `
+ORDER BY code;`
+
+const sqlGetLoanProductById = sqlSelectLoanProducts + `
+WHERE product_id = $1;`
+
+const sqlGetLoanProductTenures = `
+SELECT tenure_month
+FROM loan_product_tenures
+WHERE product_id = $1
+ORDER BY tenure_month;`
+
+const sqlGetLoanProductRates = `
+SELECT max_tenure_month, max_vehicle_age_years, annual_interest_rate
+FROM loan_product_rates
+WHERE product_id = $1
+ORDER BY max_tenure_month, max_vehicle_age_years;`
+
+const sqlGetLoanProductRequiredDocuments = `
+SELECT document_type
+FROM loan_product_required_documents
+WHERE product_id = $1
+ORDER BY document_type;`
+
+const sqlUpsertLoanProduct = `
+INSERT INTO loan_products (
+    product_id,
+    code,
+    name,
+    vehicle_type,
+    is_commercial,
+    min_amount,
+    max_amount,
+    min_vehicle_age_years,
+    max_vehicle_age_years,
+    admin_fee,
+    provision_fee_rate,
+    insurance_fee_rate,
+    late_fee,
+    late_penalty_daily_rate,
+    penalty_grace_days,
+    early_termination_fee_rate,
+    early_termination_min_fee,
+    is_active
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+) ON CONFLICT (product_id) DO UPDATE SET
+    code = EXCLUDED.code,
+    name = EXCLUDED.name,
+    vehicle_type = EXCLUDED.vehicle_type,
+    is_commercial = EXCLUDED.is_commercial,
+    min_amount = EXCLUDED.min_amount,
+    max_amount = EXCLUDED.max_amount,
+    min_vehicle_age_years = EXCLUDED.min_vehicle_age_years,
+    max_vehicle_age_years = EXCLUDED.max_vehicle_age_years,
+    admin_fee = EXCLUDED.admin_fee,
+    provision_fee_rate = EXCLUDED.provision_fee_rate,
+    insurance_fee_rate = EXCLUDED.insurance_fee_rate,
+    late_fee = EXCLUDED.late_fee,
+    late_penalty_daily_rate = EXCLUDED.late_penalty_daily_rate,
+    penalty_grace_days = EXCLUDED.penalty_grace_days,
+    early_termination_fee_rate = EXCLUDED.early_termination_fee_rate,
+    early_termination_min_fee = EXCLUDED.early_termination_min_fee,
+    is_active = EXCLUDED.is_active
+RETURNING product_id;`
+
+const sqlDeleteLoanProductTenures = `
+DELETE FROM loan_product_tenures
+WHERE product_id = $1;`
+
+const sqlInsertLoanProductTenure = `
+INSERT INTO loan_product_tenures (product_id, tenure_month)
+VALUES ($1, $2);`
+
+const sqlDeleteLoanProductRates = `
+DELETE FROM loan_product_rates
+WHERE product_id = $1;`
+
+const sqlInsertLoanProductRate = `
+INSERT INTO loan_product_rates (product_id, max_tenure_month, max_vehicle_age_years, annual_interest_rate)
+VALUES ($1, $2, $3, $4);`
+
+const sqlDeleteLoanProductRequiredDocuments = `
+DELETE FROM loan_product_required_documents
+WHERE product_id = $1;`
+
+const sqlInsertLoanProductRequiredDocument = `
+INSERT INTO loan_product_required_documents (product_id, document_type)
+VALUES ($1, $2);`
+
+const sqlDeactivateLoanProduct = `
+UPDATE loan_products
+SET is_active = FALSE
+WHERE product_id = $1;`
+
+type LoanProductRateRow struct {
+	MaxTenureMonth     int
+	MaxVehicleAgeYears int
+	AnnualInterestRate float64
+}
+
+type LoanProductRow struct {
+	ProductID          string
+	Code               string
+	Name               string
+	VehicleType        sql.NullString
+	IsCommercial       bool
+	MinAmount          int
+	MaxAmount          int
+	MinVehicleAgeYears int
+	MaxVehicleAgeYears int
+	AdminFee           int
+	ProvisionFeeRate   float64
+	InsuranceFeeRate   float64
+	LateFee            int
+	LatePenaltyRate    float64
+	PenaltyGraceDays   int
+	TerminationFeeRate float64
+	TerminationMinFee  int
+	IsActive           bool
+	Tenures            []int
+	Rates              []*LoanProductRateRow
+	RequiredDocuments  []string
+}
+
+type LoanProductStore struct {
+	db *sql.DB
+}
+
+func NewLoanProductStore(db *sql.DB) *LoanProductStore {
+	return &LoanProductStore{
+		db: db,
+	}
+}
+
+func (s *LoanProductStore) GetAllLoanProducts() ([]*LoanProductRow, error) {
+	rows, err := s.db.Query(sqlGetAllLoanProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*LoanProductRow
+	for rows.Next() {
+		product, err := scanLoanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		if err := s.loadProductDetails(product); err != nil {
+			return nil, err
+		}
+	}
+	return products, nil
+}
+
+func (s *LoanProductStore) GetLoanProductById(productID string) (*LoanProductRow, error) {
+	product, err := scanLoanProduct(s.db.QueryRow(sqlGetLoanProductById, productID))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadProductDetails(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// UpsertLoanProduct saves the product and replaces its tenures, rate table and
+// required documents.
+func (s *LoanProductStore) UpsertLoanProduct(product *LoanProductRow) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var productID string
+	err = tx.QueryRow(sqlUpsertLoanProduct,
+		product.ProductID,
+		product.Code,
+		product.Name,
+		product.VehicleType,
+		product.IsCommercial,
+		product.MinAmount,
+		product.MaxAmount,
+		product.MinVehicleAgeYears,
+		product.MaxVehicleAgeYears,
+		product.AdminFee,
+		product.ProvisionFeeRate,
+		product.InsuranceFeeRate,
+		product.LateFee,
+		product.LatePenaltyRate,
+		product.PenaltyGraceDays,
+		product.TerminationFeeRate,
+		product.TerminationMinFee,
+		product.IsActive,
+	).Scan(&productID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(sqlDeleteLoanProductTenures, productID); err != nil {
+		return "", err
+	}
+	for _, tenure := range product.Tenures {
+		if _, err := tx.Exec(sqlInsertLoanProductTenure, productID, tenure); err != nil {
+			return "", fmt.Errorf("tenure %d: %w", tenure, err)
+		}
+	}
+
+	if _, err := tx.Exec(sqlDeleteLoanProductRates, productID); err != nil {
+		return "", err
+	}
+	for _, rate := range product.Rates {
+		_, err := tx.Exec(sqlInsertLoanProductRate, productID, rate.MaxTenureMonth, rate.MaxVehicleAgeYears, rate.AnnualInterestRate)
+		if err != nil {
+			return "", fmt.Errorf("rate %d months/%d years: %w", rate.MaxTenureMonth, rate.MaxVehicleAgeYears, err)
+		}
+	}
+
+	if _, err := tx.Exec(sqlDeleteLoanProductRequiredDocuments, productID); err != nil {
+		return "", err
+	}
+	for _, documentType := range product.RequiredDocuments {
+		if _, err := tx.Exec(sqlInsertLoanProductRequiredDocument, productID, documentType); err != nil {
+			return "", fmt.Errorf("required document %s: %w", documentType, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return productID, nil
+}
+
+// DeactivateLoanProduct retires a product; it is kept because submissions
+// reference it.
+func (s *LoanProductStore) DeactivateLoanProduct(productID string) error {
+	result, err := s.db.Exec(sqlDeactivateLoanProduct, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no rows affected")
+	}
+	return nil
+}
+
+func (s *LoanProductStore) loadProductDetails(product *LoanProductRow) error {
+	tenureRows, err := s.db.Query(sqlGetLoanProductTenures, product.ProductID)
+	if err != nil {
+		return err
+	}
+	defer tenureRows.Close()
+
+	product.Tenures = nil
+	for tenureRows.Next() {
+		var tenure int
+		if err := tenureRows.Scan(&tenure); err != nil {
+			return err
+		}
+		product.Tenures = append(product.Tenures, tenure)
+	}
+	if err := tenureRows.Err(); err != nil {
+		return err
+	}
+
+	rateRows, err := s.db.Query(sqlGetLoanProductRates, product.ProductID)
+	if err != nil {
+		return err
+	}
+	defer rateRows.Close()
+
+	product.Rates = nil
+	for rateRows.Next() {
+		rate := &LoanProductRateRow{}
+		if err := rateRows.Scan(&rate.MaxTenureMonth, &rate.MaxVehicleAgeYears, &rate.AnnualInterestRate); err != nil {
+			return err
+		}
+		product.Rates = append(product.Rates, rate)
+	}
+	if err := rateRows.Err(); err != nil {
+		return err
+	}
+
+	documentRows, err := s.db.Query(sqlGetLoanProductRequiredDocuments, product.ProductID)
+	if err != nil {
+		return err
+	}
+	defer documentRows.Close()
+
+	product.RequiredDocuments = nil
+	for documentRows.Next() {
+		var documentType string
+		if err := documentRows.Scan(&documentType); err != nil {
+			return err
+		}
+		product.RequiredDocuments = append(product.RequiredDocuments, documentType)
+	}
+	return documentRows.Err()
+}
+
+func scanLoanProduct(row rowScanner) (*LoanProductRow, error) {
+	product := &LoanProductRow{}
+	err := row.Scan(
+		&product.ProductID,
+		&product.Code,
+		&product.Name,
+		&product.VehicleType,
+		&product.IsCommercial,
+		&product.MinAmount,
+		&product.MaxAmount,
+		&product.MinVehicleAgeYears,
+		&product.MaxVehicleAgeYears,
+		&product.AdminFee,
+		&product.ProvisionFeeRate,
+		&product.InsuranceFeeRate,
+		&product.LateFee,
+		&product.LatePenaltyRate,
+		&product.PenaltyGraceDays,
+		&product.TerminationFeeRate,
+		&product.TerminationMinFee,
+		&product.IsActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
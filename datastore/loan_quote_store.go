@@ -0,0 +1,126 @@
+package datastore
+
+import (
+	"database/sql"
+)
+
+const sqlUpsertQuote = `
+INSERT INTO loan_submission_quotes (
+    submission_id,
+    product_id,
+    annual_interest_rate,
+    tenure_month,
+    principal,
+    monthly_installment,
+    total_interest,
+    admin_fee,
+    provision_fee,
+    insurance_fee,
+    total_fees,
+    total_cost,
+    quoted_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) ON CONFLICT (submission_id) DO UPDATE SET
+    product_id = EXCLUDED.product_id,
+    annual_interest_rate = EXCLUDED.annual_interest_rate,
+    tenure_month = EXCLUDED.tenure_month,
+    principal = EXCLUDED.principal,
+    monthly_installment = EXCLUDED.monthly_installment,
+    total_interest = EXCLUDED.total_interest,
+    admin_fee = EXCLUDED.admin_fee,
+    provision_fee = EXCLUDED.provision_fee,
+    insurance_fee = EXCLUDED.insurance_fee,
+    total_fees = EXCLUDED.total_fees,
+    total_cost = EXCLUDED.total_cost,
+    quoted_at = EXCLUDED.quoted_at;`
+
+const sqlGetQuoteBySubmissionId = `
+SELECT
+    submission_id,
+    product_id,
+    annual_interest_rate,
+    tenure_month,
+    principal,
+    monthly_installment,
+    total_interest,
+    admin_fee,
+    provision_fee,
+    insurance_fee,
+    total_fees,
+    total_cost,
+    quoted_at
+FROM loan_submission_quotes
+WHERE submission_id = $1;`
+
+type LoanQuoteRow struct {
+	SubmissionID       string
+	ProductID          string
+	AnnualInterestRate float64
+	TenureMonth        int
+	Principal          int
+	MonthlyInstallment int
+	TotalInterest      int
+	AdminFee           int
+	ProvisionFee       int
+	InsuranceFee       int
+	TotalFees          int
+	TotalCost          int
+	QuotedAt           int64
+}
+
+type LoanQuoteStore struct {
+	db *sql.DB
+}
+
+func NewLoanQuoteStore(db *sql.DB) *LoanQuoteStore {
+	return &LoanQuoteStore{
+		db: db,
+	}
+}
+
+func (s *LoanQuoteStore) UpsertQuote(quote *LoanQuoteRow) error {
+	return upsertQuote(s.db, quote)
+}
+
+func upsertQuote(db execer, quote *LoanQuoteRow) error {
+	_, err := db.Exec(sqlUpsertQuote,
+		quote.SubmissionID,
+		quote.ProductID,
+		quote.AnnualInterestRate,
+		quote.TenureMonth,
+		quote.Principal,
+		quote.MonthlyInstallment,
+		quote.TotalInterest,
+		quote.AdminFee,
+		quote.ProvisionFee,
+		quote.InsuranceFee,
+		quote.TotalFees,
+		quote.TotalCost,
+		quote.QuotedAt,
+	)
+	return err
+}
+
+func (s *LoanQuoteStore) GetQuoteBySubmissionId(submissionID string) (*LoanQuoteRow, error) {
+	quote := &LoanQuoteRow{}
+	err := s.db.QueryRow(sqlGetQuoteBySubmissionId, submissionID).Scan(
+		&quote.SubmissionID,
+		&quote.ProductID,
+		&quote.AnnualInterestRate,
+		&quote.TenureMonth,
+		&quote.Principal,
+		&quote.MonthlyInstallment,
+		&quote.TotalInterest,
+		&quote.AdminFee,
+		&quote.ProvisionFee,
+		&quote.InsuranceFee,
+		&quote.TotalFees,
+		&quote.TotalCost,
+		&quote.QuotedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
@@ -0,0 +1,394 @@
+package datastore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const sqlInsertInstallment = `
+INSERT INTO loan_installments (
+    submission_id,
+    installment_number,
+    due_date,
+    principal_due,
+    interest_due,
+    fee_due
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);`
+
+const sqlGetInstallmentsBySubmissionId = `
+SELECT
+    submission_id,
+    installment_number,
+    due_date,
+    principal_due,
+    interest_due,
+    fee_due,
+    penalty_due,
+    principal_paid,
+    interest_paid,
+    fee_paid,
+    penalty_paid,
+    overdue_since,
+    penalty_accrued_through
+FROM loan_installments
+WHERE submission_id = $1
+ORDER BY installment_number;`
+
+const sqlApplyInstallmentPayment = `
+UPDATE loan_installments
+SET fee_paid = fee_paid + $1,
+    interest_paid = interest_paid + $2,
+    principal_paid = principal_paid + $3,
+    penalty_paid = penalty_paid + $4
+WHERE submission_id = $5
+AND installment_number = $6;`
+
+var (
+	ErrPaymentConflict = errors.New("payment conflicts with the repayment schedule")
+	ErrScheduleExists  = errors.New("repayment schedule already exists")
+)
+
+const sqlInsertPayment = `
+INSERT INTO loan_payments (
+    payment_id,
+    submission_id,
+    amount,
+    applied_amount,
+    unapplied_amount,
+    paid_on,
+    reference,
+    payoff_quote_id,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+);`
+
+const sqlInsertPaymentAllocation = `
+INSERT INTO loan_payment_allocations (
+    payment_id,
+    installment_number,
+    fee_amount,
+    interest_amount,
+    principal_amount,
+    penalty_amount
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);`
+
+const sqlSelectPayments = `
+SELECT
+    payment_id,
+    submission_id,
+    amount,
+    applied_amount,
+    unapplied_amount,
+    paid_on,
+    reference,
+    payoff_quote_id,
+    created_at
+FROM loan_payments
+`
+
+const sqlGetPaymentsBySubmissionId = sqlSelectPayments + `
+WHERE submission_id = $1
+ORDER BY paid_on, created_at;`
+
+const sqlGetPaymentByReference = sqlSelectPayments + `
+WHERE submission_id = $1
+AND reference = $2;`
+
+const sqlGetPaymentAllocations = `
+SELECT
+    payment_id,
+    installment_number,
+    fee_amount,
+    interest_amount,
+    principal_amount,
+    penalty_amount
+FROM loan_payment_allocations
+WHERE payment_id = $1
+ORDER BY installment_number;`
+
+type LoanInstallmentRow struct {
+	SubmissionID      string
+	InstallmentNumber int
+	DueDate           string
+	PrincipalDue      int
+	InterestDue       int
+	FeeDue            int
+	PenaltyDue        int
+	PrincipalPaid     int
+	InterestPaid      int
+	FeePaid           int
+	PenaltyPaid       int
+	OverdueSince      sql.NullString
+	// PenaltyAccruedThrough is the last day late penalties were charged for.
+	PenaltyAccruedThrough sql.NullString
+}
+
+type LoanPaymentRow struct {
+	PaymentID       string
+	SubmissionID    string
+	Amount          int
+	AppliedAmount   int
+	UnappliedAmount int
+	PaidOn          string
+	Reference       sql.NullString
+	PayoffQuoteID   sql.NullString
+	CreatedAt       int64
+	Allocations     []*LoanPaymentAllocationRow
+}
+
+type LoanPaymentAllocationRow struct {
+	PaymentID         string
+	InstallmentNumber int
+	FeeAmount         int
+	InterestAmount    int
+	PrincipalAmount   int
+	PenaltyAmount     int
+}
+
+type LoanRepaymentStore struct {
+	db *sql.DB
+}
+
+func NewLoanRepaymentStore(db *sql.DB) *LoanRepaymentStore {
+	return &LoanRepaymentStore{
+		db: db,
+	}
+}
+
+// InsertInstallments stores a generated schedule; it fails if the submission
+// already has one.
+func (s *LoanRepaymentStore) InsertInstallments(installments []*LoanInstallmentRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, installment := range installments {
+		_, err := tx.Exec(sqlInsertInstallment,
+			installment.SubmissionID,
+			installment.InstallmentNumber,
+			installment.DueDate,
+			installment.PrincipalDue,
+			installment.InterestDue,
+			installment.FeeDue,
+		)
+		if isConstraintError(err) {
+			return ErrScheduleExists
+		}
+		if err != nil {
+			return fmt.Errorf("installment %d: %w", installment.InstallmentNumber, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *LoanRepaymentStore) GetInstallmentsBySubmissionId(submissionID string) ([]*LoanInstallmentRow, error) {
+	rows, err := s.db.Query(sqlGetInstallmentsBySubmissionId, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var installments []*LoanInstallmentRow
+	for rows.Next() {
+		installment := &LoanInstallmentRow{}
+		err := rows.Scan(
+			&installment.SubmissionID,
+			&installment.InstallmentNumber,
+			&installment.DueDate,
+			&installment.PrincipalDue,
+			&installment.InterestDue,
+			&installment.FeeDue,
+			&installment.PenaltyDue,
+			&installment.PrincipalPaid,
+			&installment.InterestPaid,
+			&installment.FeePaid,
+			&installment.PenaltyPaid,
+			&installment.OverdueSince,
+			&installment.PenaltyAccruedThrough,
+		)
+		if err != nil {
+			return nil, err
+		}
+		installments = append(installments, installment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return installments, nil
+}
+
+// InsertPayment records the payment with its allocations and adds the
+// allocated amounts to the installments. The paid <= due check on the
+// installment table rejects the payment if a concurrent one got there first.
+// InsertPayment records a payment and applies its allocations. It returns
+// ErrPaymentConflict when the allocations no longer fit the schedule, as when
+// a concurrent payment settled the same installments, or when a payment with
+// the same reference was recorded meanwhile.
+func (s *LoanRepaymentStore) InsertPayment(payment *LoanPaymentRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = insertPayment(tx, payment)
+	for _, allocation := range payment.Allocations {
+		if err != nil {
+			break
+		}
+		err = applyAllocation(tx, payment.SubmissionID, allocation)
+	}
+	if isConstraintError(err) {
+		return fmt.Errorf("%w: %v", ErrPaymentConflict, err)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *LoanRepaymentStore) GetPaymentsBySubmissionId(submissionID string) ([]*LoanPaymentRow, error) {
+	rows, err := s.db.Query(sqlGetPaymentsBySubmissionId, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*LoanPaymentRow
+	for rows.Next() {
+		payment, err := scanLoanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		if err := s.loadPaymentAllocations(payment); err != nil {
+			return nil, err
+		}
+	}
+	return payments, nil
+}
+
+func (s *LoanRepaymentStore) GetPaymentByReference(submissionID, reference string) (*LoanPaymentRow, error) {
+	payment, err := scanLoanPayment(s.db.QueryRow(sqlGetPaymentByReference, submissionID, reference))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadPaymentAllocations(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *LoanRepaymentStore) loadPaymentAllocations(payment *LoanPaymentRow) error {
+	rows, err := s.db.Query(sqlGetPaymentAllocations, payment.PaymentID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	payment.Allocations = nil
+	for rows.Next() {
+		allocation := &LoanPaymentAllocationRow{}
+		err := rows.Scan(
+			&allocation.PaymentID,
+			&allocation.InstallmentNumber,
+			&allocation.FeeAmount,
+			&allocation.InterestAmount,
+			&allocation.PrincipalAmount,
+			&allocation.PenaltyAmount,
+		)
+		if err != nil {
+			return err
+		}
+		payment.Allocations = append(payment.Allocations, allocation)
+	}
+	return rows.Err()
+}
+
+func insertPayment(tx *sql.Tx, payment *LoanPaymentRow) error {
+	_, err := tx.Exec(sqlInsertPayment,
+		payment.PaymentID,
+		payment.SubmissionID,
+		payment.Amount,
+		payment.AppliedAmount,
+		payment.UnappliedAmount,
+		payment.PaidOn,
+		payment.Reference,
+		payment.PayoffQuoteID,
+		payment.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, allocation := range payment.Allocations {
+		_, err := tx.Exec(sqlInsertPaymentAllocation,
+			payment.PaymentID,
+			allocation.InstallmentNumber,
+			allocation.FeeAmount,
+			allocation.InterestAmount,
+			allocation.PrincipalAmount,
+			allocation.PenaltyAmount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyAllocation(tx *sql.Tx, submissionID string, allocation *LoanPaymentAllocationRow) error {
+	result, err := tx.Exec(sqlApplyInstallmentPayment,
+		allocation.FeeAmount,
+		allocation.InterestAmount,
+		allocation.PrincipalAmount,
+		allocation.PenaltyAmount,
+		submissionID,
+		allocation.InstallmentNumber,
+	)
+	if err != nil {
+		return fmt.Errorf("installment %d: %w", allocation.InstallmentNumber, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: installment %d not found", ErrPaymentConflict, allocation.InstallmentNumber)
+	}
+	return nil
+}
+
+func scanLoanPayment(row rowScanner) (*LoanPaymentRow, error) {
+	payment := &LoanPaymentRow{}
+	err := row.Scan(
+		&payment.PaymentID,
+		&payment.SubmissionID,
+		&payment.Amount,
+		&payment.AppliedAmount,
+		&payment.UnappliedAmount,
+		&payment.PaidOn,
+		&payment.Reference,
+		&payment.PayoffQuoteID,
+		&payment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
@@ -2,6 +2,10 @@ package datastore
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/alphaloan/vehicle/events"
 )
 
 const sqlUpsertSubmission = `
@@ -19,9 +23,14 @@ const sqlUpsertSubmission = `
         is_commercial_vehicle,
         created_at,
         updated_at,
-        customer_id
+        customer_id,
+        vehicle_license_key,
+        is_duplicate_collateral,
+        product_id,
+        dealer_id,
+        agent_id
     ) VALUES (
-        $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+        $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
     ) ON CONFLICT (submission_id) DO UPDATE SET
         vehicle_type = EXCLUDED.vehicle_type,
         vehicle_brand = EXCLUDED.vehicle_brand,
@@ -35,11 +44,16 @@ const sqlUpsertSubmission = `
         is_commercial_vehicle = EXCLUDED.is_commercial_vehicle,
         created_at = EXCLUDED.created_at,
         updated_at = EXCLUDED.updated_at,
-        customer_id = EXCLUDED.customer_id
+        customer_id = EXCLUDED.customer_id,
+        vehicle_license_key = EXCLUDED.vehicle_license_key,
+        is_duplicate_collateral = EXCLUDED.is_duplicate_collateral,
+        product_id = EXCLUDED.product_id,
+        dealer_id = EXCLUDED.dealer_id,
+        agent_id = EXCLUDED.agent_id
     RETURNING submission_id;
 `
 
-const sqlGetAllLoanSubmissions = `
+const sqlSelectLoanSubmissions = `
 SELECT
 	submission_id, vehicle_type,
 	vehicle_brand, vehicle_model,
@@ -47,36 +61,208 @@ SELECT
 	manufacturing_year, proposed_loan_amount,
 	proposed_loan_tenure_month, loan_status,
 	is_commercial_vehicle, created_at,
-	updated_at, customer_id
+	updated_at, customer_id,
+	vehicle_license_key, is_duplicate_collateral,
+	product_id, dealer_id,
+	agent_id
 FROM loan_submissions
+`
+
+const sqlGetAllLoanSubmissions = sqlSelectLoanSubmissions + `
 ORDER BY created_at DESC;
 `
 
-const sqlGetLoanSubmissionById = `
+const sqlGetLoanSubmissionById = sqlSelectLoanSubmissions + `
+WHERE submission_id = $1;`
+
+const sqlGetLoanSubmissionsByIds = sqlSelectLoanSubmissions + `
+WHERE submission_id IN (SELECT value FROM json_each($1));`
+
+// The submissions every listed customer is a party to, with their role.
+const sqlGetPartySubmissionsByCustomerIds = `
 SELECT
-	submission_id, vehicle_type,
-	vehicle_brand, vehicle_model,
-	vehicle_license_number, vehicle_odometer,
-	manufacturing_year, proposed_loan_amount,
-	proposed_loan_tenure_month, is_commercial_vehicle
+	party.customer_id, party.party_role,
+	submission.submission_id, submission.vehicle_type,
+	submission.vehicle_brand, submission.vehicle_model,
+	submission.vehicle_license_number, submission.vehicle_odometer,
+	submission.manufacturing_year, submission.proposed_loan_amount,
+	submission.proposed_loan_tenure_month, submission.loan_status,
+	submission.is_commercial_vehicle, submission.created_at,
+	submission.updated_at, submission.customer_id,
+	submission.vehicle_license_key, submission.is_duplicate_collateral,
+	submission.product_id, submission.dealer_id,
+	submission.agent_id
+FROM submission_parties party
+INNER JOIN loan_submissions submission
+ON submission.submission_id = party.submission_id
+WHERE party.customer_id IN (SELECT value FROM json_each($1))
+ORDER BY submission.created_at;`
+
+const sqlGetLoanSubmissionsByDealerId = sqlSelectLoanSubmissions + `
+WHERE dealer_id = $1
+ORDER BY created_at DESC;`
+
+// Empty filter values match everything; created_at bounds of 0 are open.
+const sqlExportLoanSubmissions = sqlSelectLoanSubmissions + `
+WHERE ($1 = '' OR loan_status = $1)
+AND ($2 = '' OR dealer_id = $2)
+AND ($3 = '' OR product_id = $3)
+AND ($4 = 0 OR created_at >= $4)
+AND ($5 = 0 OR created_at < $5)
+ORDER BY created_at, submission_id;`
+
+const sqlGetDuplicateCollateralSubmissions = sqlSelectLoanSubmissions + `
+WHERE submission_id <> $1
+AND vehicle_license_key <> ''
+AND vehicle_license_key = (SELECT vehicle_license_key FROM loan_submissions WHERE submission_id = $1)
+AND customer_id <> (SELECT customer_id FROM loan_submissions WHERE submission_id = $1)
+AND loan_status NOT IN (` + inactiveLoanStatusList + `)
+ORDER BY created_at DESC;`
+
+const sqlFlagDuplicateCollateral = `
+UPDATE loan_submissions
+SET is_duplicate_collateral = TRUE
+WHERE submission_id = $1
+OR submission_id IN (
+	SELECT other.submission_id
+	FROM loan_submissions submission
+	INNER JOIN loan_submissions other
+	ON other.vehicle_license_key = submission.vehicle_license_key
+	AND other.customer_id <> submission.customer_id
+	WHERE submission.submission_id = $1
+	AND submission.vehicle_license_key <> ''
+	AND other.loan_status NOT IN (` + inactiveLoanStatusList + `)
+);`
+
+// Clears the flag of active submissions pledging the vehicle that no longer
+// collide with another customer's active submission, and sets it on those
+// that do.
+const sqlRefreshDuplicateCollateral = `
+UPDATE loan_submissions
+SET is_duplicate_collateral = EXISTS (
+	SELECT 1
+	FROM loan_submissions other
+	WHERE other.vehicle_license_key = loan_submissions.vehicle_license_key
+	AND other.customer_id <> loan_submissions.customer_id
+	AND other.loan_status NOT IN (` + inactiveLoanStatusList + `)
+)
+WHERE vehicle_license_key = $1
+AND vehicle_license_key <> ''
+AND loan_status NOT IN (` + inactiveLoanStatusList + `);`
+
+const sqlGetSubmissionLicenseKey = `
+SELECT vehicle_license_key
 FROM loan_submissions
 WHERE submission_id = $1;`
 
+const sqlRejectSubmission = `
+UPDATE loan_submissions
+SET loan_status = 'REJECTED',
+    updated_at = $1
+WHERE submission_id = $2
+AND loan_status = 'NEW';`
+
+// Imported loans were approved in the system they come from; their last
+// update there is the closest record of when.
+const sqlStampImportedApproval = `
+UPDATE loan_submissions
+SET approved_at = updated_at
+WHERE submission_id = $1
+AND approved_at IS NULL
+AND loan_status IN (` + repayableLoanStatusList + `);`
+
+const sqlInsertSubmissionDecision = `
+INSERT INTO submission_decisions (
+    submission_id,
+    decision,
+    decided_by,
+    decided_at
+) VALUES (
+    $1, $2, $3, $4
+);`
+
+var ErrSubmissionNotPending = errors.New("submission is not awaiting a decision")
+
+// Submissions in these statuses no longer pledge their vehicle and are
+// ignored by duplicate collateral detection.
+const inactiveLoanStatusList = `'REJECTED', 'CANCELLED', 'CLOSED'`
+
+func IsInactiveLoanStatus(status string) bool {
+	return status == "REJECTED" || status == "CANCELLED" || status == "CLOSED"
+}
+
+// Submissions in these statuses have approved terms that are being repaid.
+const repayableLoanStatusList = `'APPROVED', 'DISBURSED'`
+
+func IsRepayableLoanStatus(status string) bool {
+	return status == "APPROVED" || status == "DISBURSED"
+}
+
 type LoanSubmissionRow struct {
-	SubmissionID         string
-	VehicleType          string
-	VehicleBrand         string
-	VehicleModel         string
-	VehicleLicenseNumber string
-	VehicleOdometer      int
-	ManufacturingYear    int
-	ProposedLoanAmount   int
-	ProposedLoanTenure   int
-	LoanStatus           string
-	IsCommercialVehicle  bool
-	CreatedAt            int64
-	UpdatedAt            int64
-	CustomerID           string
+	SubmissionID          string
+	VehicleType           string
+	VehicleBrand          string
+	VehicleModel          string
+	VehicleLicenseNumber  string
+	VehicleOdometer       int
+	ManufacturingYear     int
+	ProposedLoanAmount    int
+	ProposedLoanTenure    int
+	LoanStatus            string
+	IsCommercialVehicle   bool
+	CreatedAt             int64
+	UpdatedAt             int64
+	CustomerID            string
+	VehicleLicenseKey     string
+	IsDuplicateCollateral bool
+	ProductID             sql.NullString
+	DealerID              sql.NullString
+	AgentID               sql.NullString
+}
+
+// LoanImportRecord is one loan brought over from another system. The quote
+// and valuation are optional.
+type LoanImportRecord struct {
+	Customer   *LoanCustomerRow
+	Submission *LoanSubmissionRow
+	Quote      *LoanQuoteRow
+	Valuation  *LoanValuationRow
+}
+
+// LoanSubmitRecord is everything a submission through the API saves: the
+// primary applicant, the submission, the co-applicants and guarantors, and the
+// quote and optional valuation.
+type LoanSubmitRecord struct {
+	Customer   *LoanCustomerRow
+	Submission *LoanSubmissionRow
+	Parties    []*SubmissionPartyWithCustomerRow
+	Quote      *LoanQuoteRow
+	Valuation  *LoanValuationRow
+}
+
+// LoanSubmitResult holds the ids a submission was saved under, its parties
+// with the primary applicant first, and whether its vehicle is pledged by
+// another customer too.
+type LoanSubmitResult struct {
+	CustomerID            string
+	SubmissionID          string
+	Parties               []*SubmissionPartyRow
+	IsDuplicateCollateral bool
+}
+
+// PartySubmissionRow is a submission seen from one of its parties.
+type PartySubmissionRow struct {
+	CustomerID string
+	PartyRole  string
+	Submission *LoanSubmissionRow
+}
+
+type SubmissionExportFilter struct {
+	LoanStatus  string
+	DealerID    string
+	ProductID   string
+	CreatedFrom int64
+	CreatedTo   int64
 }
 
 type LoanSubmissionStore struct {
@@ -89,10 +275,55 @@ func NewLoanSubmissionStore(db *sql.DB) *LoanSubmissionStore {
 	}
 }
 
+// UpsertSubmission saves the submission and queues submission.created and the
+// received notification for a new submission, or submission.status_changed
+// when an update moves its status.
 func (s *LoanSubmissionStore) UpsertSubmission(submission *LoanSubmissionRow) (string, error) {
-	var submissionID string
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
 
-	err := s.db.QueryRow(sqlUpsertSubmission,
+	submissionID, isNew, err := upsertSubmission(tx, submission)
+	if err != nil {
+		return "", err
+	}
+	if isNew {
+		err = queueSubmissionNotification(tx, NotificationSubmissionReceived, submissionID, submission.UpdatedAt)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return submissionID, nil
+}
+
+// upsertSubmission saves the submission and queues its events. It reports
+// whether the submission was new. An update refreshes the duplicate collateral
+// flags of the submissions that pledged the same vehicle as it did before, as
+// the update may have ended their collision; the caller flags the submission's
+// current collisions.
+func upsertSubmission(tx *sql.Tx, submission *LoanSubmissionRow) (string, bool, error) {
+	var previousStatus, customerID string
+	var dealerID sql.NullString
+	err := tx.QueryRow(sqlGetSubmissionEventFields, submission.SubmissionID).Scan(&previousStatus, &customerID, &dealerID)
+	isNew := errors.Is(err, sql.ErrNoRows)
+	if err != nil && !isNew {
+		return "", false, err
+	}
+	var previousLicenseKey string
+	if !isNew {
+		if err := tx.QueryRow(sqlGetSubmissionLicenseKey, submission.SubmissionID).Scan(&previousLicenseKey); err != nil {
+			return "", false, err
+		}
+	}
+
+	var submissionID string
+	err = tx.QueryRow(sqlUpsertSubmission,
 		submission.SubmissionID,
 		submission.VehicleType,
 		submission.VehicleBrand,
@@ -107,26 +338,212 @@ func (s *LoanSubmissionStore) UpsertSubmission(submission *LoanSubmissionRow) (s
 		submission.CreatedAt,
 		submission.UpdatedAt,
 		submission.CustomerID,
+		submission.VehicleLicenseKey,
+		submission.IsDuplicateCollateral,
+		submission.ProductID,
+		submission.DealerID,
+		submission.AgentID,
 	).Scan(&submissionID)
 
 	if err != nil {
-		return "", err
+		return "", false, err
+	}
+	if previousLicenseKey != "" {
+		if _, err := tx.Exec(sqlRefreshDuplicateCollateral, previousLicenseKey); err != nil {
+			return "", false, err
+		}
 	}
 
-	return submissionID, nil
+	switch {
+	case isNew:
+		err = queueEvent(tx, events.TypeSubmissionCreated, submissionID, submission.UpdatedAt, submissionCreatedEvent(submission))
+	case previousStatus != submission.LoanStatus:
+		err = queueSubmissionStatusChanged(tx, submissionID, submission.CustomerID, submission.DealerID,
+			previousStatus, submission.LoanStatus, submission.UpdatedAt)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return submissionID, isNew, nil
+}
+
+// SubmitLoan saves a submission made through the API in one transaction, so
+// a failure part way leaves nothing behind: the customers, the submission and
+// its events, the parties, the duplicate collateral flags, the quote and the
+// valuation are saved together or not at all. The customer is sent the
+// received notification when the submission is new. Co-applicants and
+// guarantors who are customers already are linked as they are.
+func (s *LoanSubmissionStore) SubmitLoan(record *LoanSubmitRecord) (*LoanSubmitResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	customerID, err := upsertCustomer(tx, record.Customer)
+	if err != nil {
+		return nil, fmt.Errorf("customer: %w", err)
+	}
+
+	record.Submission.CustomerID = customerID
+	submissionID, isNew, err := upsertSubmission(tx, record.Submission)
+	if err != nil {
+		return nil, fmt.Errorf("submission: %w", err)
+	}
+	if isNew {
+		err = queueSubmissionNotification(tx, NotificationSubmissionReceived, submissionID, record.Submission.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parties := []*SubmissionPartyRow{{
+		SubmissionID: submissionID,
+		CustomerID:   customerID,
+		PartyRole:    PartyRolePrimary,
+	}}
+	for _, party := range record.Parties {
+		partyCustomerID, err := linkCustomer(tx, party.LoanCustomerRow)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", party.PartyRole, err)
+		}
+		parties = append(parties, &SubmissionPartyRow{
+			SubmissionID: submissionID,
+			CustomerID:   partyCustomerID,
+			PartyRole:    party.PartyRole,
+		})
+	}
+	if err := replaceSubmissionParties(tx, submissionID, parties); err != nil {
+		return nil, err
+	}
+
+	isDuplicate, err := flagDuplicateCollateral(tx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("duplicate collateral: %w", err)
+	}
+
+	if record.Quote != nil {
+		record.Quote.SubmissionID = submissionID
+		if err := upsertQuote(tx, record.Quote); err != nil {
+			return nil, fmt.Errorf("quote: %w", err)
+		}
+	}
+	if record.Valuation != nil {
+		record.Valuation.SubmissionID = submissionID
+		if err := upsertValuation(tx, record.Valuation); err != nil {
+			return nil, fmt.Errorf("valuation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &LoanSubmitResult{
+		CustomerID:            customerID,
+		SubmissionID:          submissionID,
+		Parties:               parties,
+		IsDuplicateCollateral: isDuplicate,
+	}, nil
+}
+
+// ImportLoans saves a batch of historical loans in one transaction, so either
+// every record of the batch is imported or none is. Each record goes through
+// the same upserts as a submission made through the API, primary party,
+// duplicate collateral flag and events included, but customers are not sent
+// the received notification for loans they applied for long ago.
+func (s *LoanSubmissionStore) ImportLoans(records []*LoanImportRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, record := range records {
+		customerID, err := upsertCustomer(tx, record.Customer)
+		if err != nil {
+			return fmt.Errorf("customer %s: %w", record.Customer.IDCardNumber, err)
+		}
+
+		record.Submission.CustomerID = customerID
+		submissionID, _, err := upsertSubmission(tx, record.Submission)
+		if err != nil {
+			return fmt.Errorf("submission %s: %w", record.Submission.SubmissionID, err)
+		}
+		if _, err := tx.Exec(sqlStampImportedApproval, submissionID); err != nil {
+			return fmt.Errorf("submission %s: %w", submissionID, err)
+		}
+
+		err = replaceSubmissionParties(tx, submissionID, []*SubmissionPartyRow{{
+			SubmissionID: submissionID,
+			CustomerID:   customerID,
+			PartyRole:    PartyRolePrimary,
+		}})
+		if err != nil {
+			return err
+		}
+		if _, err := flagDuplicateCollateral(tx, submissionID); err != nil {
+			return err
+		}
+
+		if record.Quote != nil {
+			record.Quote.SubmissionID = submissionID
+			if err := upsertQuote(tx, record.Quote); err != nil {
+				return err
+			}
+		}
+		if record.Valuation != nil {
+			record.Valuation.SubmissionID = submissionID
+			if err := upsertValuation(tx, record.Valuation); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func submissionCreatedEvent(submission *LoanSubmissionRow) events.SubmissionCreatedV1 {
+	return events.SubmissionCreatedV1{
+		SubmissionID:       submission.SubmissionID,
+		CustomerID:         submission.CustomerID,
+		ProductID:          nullStringPtr(submission.ProductID),
+		DealerID:           nullStringPtr(submission.DealerID),
+		AgentID:            nullStringPtr(submission.AgentID),
+		VehicleType:        submission.VehicleType,
+		VehicleBrand:       submission.VehicleBrand,
+		VehicleModel:       submission.VehicleModel,
+		ManufacturingYear:  submission.ManufacturingYear,
+		ProposedLoanAmount: submission.ProposedLoanAmount,
+		ProposedLoanTenure: submission.ProposedLoanTenure,
+		LoanStatus:         submission.LoanStatus,
+	}
 }
 
 func (s *LoanSubmissionStore) GetAllLoanSubmissions() ([]*LoanSubmissionRow, error) {
-	rows, err := s.db.Query(sqlGetAllLoanSubmissions)
+	return s.querySubmissions(sqlGetAllLoanSubmissions)
+}
+
+// GetLoanSubmissionsByIds returns the submissions that exist among ids, in
+// no particular order.
+func (s *LoanSubmissionStore) GetLoanSubmissionsByIds(ids []string) ([]*LoanSubmissionRow, error) {
+	return s.querySubmissions(sqlGetLoanSubmissionsByIds, idList(ids))
+}
+
+// GetPartySubmissionsByCustomerIds returns, oldest first, the submissions
+// each of the customers is a party to, in a single query.
+func (s *LoanSubmissionStore) GetPartySubmissionsByCustomerIds(customerIDs []string) ([]*PartySubmissionRow, error) {
+	rows, err := s.db.Query(sqlGetPartySubmissionsByCustomerIds, idList(customerIDs))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var submissions []*LoanSubmissionRow
+	var partySubmissions []*PartySubmissionRow
 	for rows.Next() {
-		submission := &LoanSubmissionRow{}
+		partySubmission := &PartySubmissionRow{Submission: &LoanSubmissionRow{}}
+		submission := partySubmission.Submission
 		err := rows.Scan(
+			&partySubmission.CustomerID,
+			&partySubmission.PartyRole,
 			&submission.SubmissionID,
 			&submission.VehicleType,
 			&submission.VehicleBrand,
@@ -141,10 +558,98 @@ func (s *LoanSubmissionStore) GetAllLoanSubmissions() ([]*LoanSubmissionRow, err
 			&submission.CreatedAt,
 			&submission.UpdatedAt,
 			&submission.CustomerID,
+			&submission.VehicleLicenseKey,
+			&submission.IsDuplicateCollateral,
+			&submission.ProductID,
+			&submission.DealerID,
+			&submission.AgentID,
 		)
 		if err != nil {
 			return nil, err
 		}
+		partySubmissions = append(partySubmissions, partySubmission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return partySubmissions, nil
+}
+
+func (s *LoanSubmissionStore) GetLoanSubmissionsByDealerId(dealerID string) ([]*LoanSubmissionRow, error) {
+	return s.querySubmissions(sqlGetLoanSubmissionsByDealerId, dealerID)
+}
+
+// GetDuplicateCollateralSubmissions lists the active submissions of other
+// customers that pledge the same vehicle as the given submission.
+func (s *LoanSubmissionStore) GetDuplicateCollateralSubmissions(submissionID string) ([]*LoanSubmissionRow, error) {
+	return s.querySubmissions(sqlGetDuplicateCollateralSubmissions, submissionID)
+}
+
+// FlagDuplicateCollateral marks the submission and every conflicting active
+// submission as duplicate collateral, returning the conflicts found.
+func (s *LoanSubmissionStore) FlagDuplicateCollateral(submissionID string) ([]*LoanSubmissionRow, error) {
+	duplicates, err := s.GetDuplicateCollateralSubmissions(submissionID)
+	if err != nil || len(duplicates) == 0 {
+		return duplicates, err
+	}
+
+	if _, err := s.db.Exec(sqlFlagDuplicateCollateral, submissionID); err != nil {
+		return nil, err
+	}
+	return duplicates, nil
+}
+
+// flagDuplicateCollateral is FlagDuplicateCollateral inside a transaction,
+// reporting whether there were duplicates instead of reading them back.
+func flagDuplicateCollateral(tx *sql.Tx, submissionID string) (bool, error) {
+	rows, err := tx.Query(sqlGetDuplicateCollateralSubmissions, submissionID)
+	if err != nil {
+		return false, err
+	}
+	hasDuplicates := rows.Next()
+	rows.Close()
+	if err := rows.Err(); err != nil || !hasDuplicates {
+		return false, err
+	}
+
+	_, err = tx.Exec(sqlFlagDuplicateCollateral, submissionID)
+	return err == nil, err
+}
+
+func (s *LoanSubmissionStore) RejectSubmission(submissionID, decidedBy string, rejectedAt int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	changed, err := changeSubmissionStatus(tx, sqlRejectSubmission, submissionID, rejectedAt)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrSubmissionNotPending
+	}
+	if _, err := tx.Exec(sqlInsertSubmissionDecision, submissionID, "REJECTED", decidedBy, rejectedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *LoanSubmissionStore) querySubmissions(query string, args ...any) ([]*LoanSubmissionRow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submissions []*LoanSubmissionRow
+	for rows.Next() {
+		submission, err := scanLoanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
 		submissions = append(submissions, submission)
 	}
 
@@ -155,10 +660,41 @@ func (s *LoanSubmissionStore) GetAllLoanSubmissions() ([]*LoanSubmissionRow, err
 	return submissions, nil
 }
 
+// ExportLoanSubmissions hands the matching submissions to fn one at a time,
+// oldest first, straight from the cursor so that exports of any size run in
+// constant memory. An error from fn stops the export and is returned.
+func (s *LoanSubmissionStore) ExportLoanSubmissions(filter SubmissionExportFilter, fn func(*LoanSubmissionRow) error) error {
+	rows, err := s.db.Query(sqlExportLoanSubmissions,
+		filter.LoanStatus,
+		filter.DealerID,
+		filter.ProductID,
+		filter.CreatedFrom,
+		filter.CreatedTo,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		submission, err := scanLoanSubmission(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(submission); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (s *LoanSubmissionStore) GetLoanSubmissionById(id string) (*LoanSubmissionRow, error) {
-	submission := &LoanSubmissionRow{}
+	return scanLoanSubmission(s.db.QueryRow(sqlGetLoanSubmissionById, id))
+}
 
-	err := s.db.QueryRow(sqlGetLoanSubmissionById, id).Scan(
+func scanLoanSubmission(row rowScanner) (*LoanSubmissionRow, error) {
+	submission := &LoanSubmissionRow{}
+	err := row.Scan(
 		&submission.SubmissionID,
 		&submission.VehicleType,
 		&submission.VehicleBrand,
@@ -168,7 +704,16 @@ func (s *LoanSubmissionStore) GetLoanSubmissionById(id string) (*LoanSubmissionR
 		&submission.ManufacturingYear,
 		&submission.ProposedLoanAmount,
 		&submission.ProposedLoanTenure,
+		&submission.LoanStatus,
 		&submission.IsCommercialVehicle,
+		&submission.CreatedAt,
+		&submission.UpdatedAt,
+		&submission.CustomerID,
+		&submission.VehicleLicenseKey,
+		&submission.IsDuplicateCollateral,
+		&submission.ProductID,
+		&submission.DealerID,
+		&submission.AgentID,
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,152 @@
+package datastore_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/google/uuid"
+)
+
+const testProductID = "8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002"
+
+func testCustomer(idCardNumber, name string) *datastore.LoanCustomerRow {
+	return &datastore.LoanCustomerRow{
+		CustomerID: uuid.New().String(), IDCardNumber: idCardNumber, FullName: name, BirthDate: "1990-01-01",
+		PhoneNumber: "0812", AddressStreet: "Jl. Sudirman", AddressCity: "Jakarta",
+	}
+}
+
+func testSubmitRecord(idCardNumber, licenseKey string) *datastore.LoanSubmitRecord {
+	submissionID := uuid.New().String()
+	now := time.Now().Unix()
+	return &datastore.LoanSubmitRecord{
+		Customer: testCustomer(idCardNumber, "Budi"),
+		Submission: &datastore.LoanSubmissionRow{
+			SubmissionID: submissionID, VehicleType: "Car", VehicleBrand: "Toyota", VehicleModel: "Avanza",
+			VehicleLicenseNumber: licenseKey, VehicleLicenseKey: licenseKey, ManufacturingYear: 2020,
+			ProposedLoanAmount: 12000, ProposedLoanTenure: 12, LoanStatus: "NEW", CreatedAt: now, UpdatedAt: now,
+			ProductID: sql.NullString{String: testProductID, Valid: true},
+		},
+		Quote: &datastore.LoanQuoteRow{
+			ProductID: testProductID, AnnualInterestRate: 0.12, TenureMonth: 12, Principal: 12000,
+			MonthlyInstallment: 1066, TotalInterest: 792, TotalCost: 12792, QuotedAt: now,
+		},
+	}
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSubmitLoanSavesEverything(t *testing.T) {
+	db := datastoretest.Open(t)
+	store := datastore.NewLoanSubmissionStore(db)
+	first, err := store.SubmitLoan(testSubmitRecord("3171000000000001", "B1234XYZ"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.IsDuplicateCollateral {
+		t.Error("the first pledge of a vehicle is flagged as a duplicate")
+	}
+
+	record := testSubmitRecord("3171000000000002", "B1234XYZ")
+	record.Parties = []*datastore.SubmissionPartyWithCustomerRow{{
+		PartyRole: datastore.PartyRoleGuarantor, LoanCustomerRow: testCustomer("3171000000000003", "Siti"),
+	}}
+	second, err := store.SubmitLoan(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.IsDuplicateCollateral || len(second.Parties) != 2 || second.Parties[0].PartyRole != datastore.PartyRolePrimary {
+		t.Errorf("result = %+v, want a duplicate with the primary applicant and a guarantor", second)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM loan_submissions WHERE is_duplicate_collateral`); n != 2 {
+		t.Errorf("%d submissions flagged, want both", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM loan_submission_quotes WHERE submission_id = $1`, second.SubmissionID); n != 1 {
+		t.Errorf("%d quotes stored, want 1", n)
+	}
+}
+
+func TestSubmitLoanSavesNothingOnFailure(t *testing.T) {
+	db := datastoretest.Open(t)
+	record := testSubmitRecord("3171000000000001", "B1234XYZ")
+	record.Parties = []*datastore.SubmissionPartyWithCustomerRow{{
+		PartyRole: datastore.PartyRoleGuarantor, LoanCustomerRow: testCustomer("3171000000000003", "Siti"),
+	}}
+	record.Quote.ProductID = uuid.New().String()
+
+	if _, err := datastore.NewLoanSubmissionStore(db).SubmitLoan(record); err == nil {
+		t.Fatal("a quote for an unknown product was stored")
+	}
+	for _, table := range []string{"loan_customers", "loan_submissions", "submission_parties", "loan_submission_quotes", "event_outbox"} {
+		if n := count(t, db, `SELECT COUNT(*) FROM `+table); n != 0 {
+			t.Errorf("%d rows left in %s, want none", n, table)
+		}
+	}
+}
+
+func TestSubmitLoanClearsFlagsOfTheGroupItLeaves(t *testing.T) {
+	db := datastoretest.Open(t)
+	store := datastore.NewLoanSubmissionStore(db)
+	first, err := store.SubmitLoan(testSubmitRecord("3171000000000001", "B1234XYZ"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := testSubmitRecord("3171000000000002", "B1234XYZ")
+	if _, err := store.SubmitLoan(record); err != nil {
+		t.Fatal(err)
+	}
+
+	record.Submission.VehicleLicenseNumber, record.Submission.VehicleLicenseKey = "B9999ABC", "B9999ABC"
+	resubmitted, err := store.SubmitLoan(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resubmitted.IsDuplicateCollateral {
+		t.Error("the resubmission for another vehicle is still a duplicate")
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM loan_submissions WHERE is_duplicate_collateral`); n != 0 {
+		t.Errorf("%d submissions still flagged, want none", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM loan_submissions WHERE submission_id = $1`, first.SubmissionID); n != 1 {
+		t.Fatal("the first submission is gone")
+	}
+}
+
+func TestSubmitLoanLinksExistingParties(t *testing.T) {
+	db := datastoretest.Open(t)
+	store := datastore.NewLoanSubmissionStore(db)
+	existing, err := store.SubmitLoan(testSubmitRecord("3171000000000003", "B1111AAA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guarantor := testCustomer("3171000000000003", "Someone Else")
+	guarantor.PhoneNumber = "0899"
+	record := testSubmitRecord("3171000000000001", "B1234XYZ")
+	record.Parties = []*datastore.SubmissionPartyWithCustomerRow{{PartyRole: datastore.PartyRoleGuarantor, LoanCustomerRow: guarantor}}
+	result, err := store.SubmitLoan(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Parties[1].CustomerID != existing.CustomerID {
+		t.Errorf("guarantor = %s, want the existing customer %s", result.Parties[1].CustomerID, existing.CustomerID)
+	}
+
+	customer, err := datastore.NewLoanCustomerStore(db).GetLoanCustomerById(existing.CustomerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if customer.FullName != "Budi" || customer.PhoneNumber != "0812" {
+		t.Errorf("existing customer = %s %s, want their own details kept", customer.FullName, customer.PhoneNumber)
+	}
+}
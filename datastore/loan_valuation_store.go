@@ -0,0 +1,108 @@
+package datastore
+
+import (
+	"database/sql"
+)
+
+const sqlUpsertValuation = `
+INSERT INTO loan_submission_valuations (
+    submission_id,
+    model_id,
+    base_price,
+    vehicle_age_years,
+    age_factor,
+    mileage_factor,
+    commercial_factor,
+    estimated_value,
+    loan_to_value,
+    valued_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) ON CONFLICT (submission_id) DO UPDATE SET
+    model_id = EXCLUDED.model_id,
+    base_price = EXCLUDED.base_price,
+    vehicle_age_years = EXCLUDED.vehicle_age_years,
+    age_factor = EXCLUDED.age_factor,
+    mileage_factor = EXCLUDED.mileage_factor,
+    commercial_factor = EXCLUDED.commercial_factor,
+    estimated_value = EXCLUDED.estimated_value,
+    loan_to_value = EXCLUDED.loan_to_value,
+    valued_at = EXCLUDED.valued_at;`
+
+const sqlGetValuationBySubmissionId = `
+SELECT
+    submission_id,
+    model_id,
+    base_price,
+    vehicle_age_years,
+    age_factor,
+    mileage_factor,
+    commercial_factor,
+    estimated_value,
+    loan_to_value,
+    valued_at
+FROM loan_submission_valuations
+WHERE submission_id = $1;`
+
+type LoanValuationRow struct {
+	SubmissionID     string
+	ModelID          sql.NullString
+	BasePrice        int
+	VehicleAgeYears  int
+	AgeFactor        float64
+	MileageFactor    float64
+	CommercialFactor float64
+	EstimatedValue   int
+	LoanToValue      float64
+	ValuedAt         int64
+}
+
+type LoanValuationStore struct {
+	db *sql.DB
+}
+
+func NewLoanValuationStore(db *sql.DB) *LoanValuationStore {
+	return &LoanValuationStore{
+		db: db,
+	}
+}
+
+func (s *LoanValuationStore) UpsertValuation(valuation *LoanValuationRow) error {
+	return upsertValuation(s.db, valuation)
+}
+
+func upsertValuation(db execer, valuation *LoanValuationRow) error {
+	_, err := db.Exec(sqlUpsertValuation,
+		valuation.SubmissionID,
+		valuation.ModelID,
+		valuation.BasePrice,
+		valuation.VehicleAgeYears,
+		valuation.AgeFactor,
+		valuation.MileageFactor,
+		valuation.CommercialFactor,
+		valuation.EstimatedValue,
+		valuation.LoanToValue,
+		valuation.ValuedAt,
+	)
+	return err
+}
+
+func (s *LoanValuationStore) GetValuationBySubmissionId(submissionID string) (*LoanValuationRow, error) {
+	valuation := &LoanValuationRow{}
+	err := s.db.QueryRow(sqlGetValuationBySubmissionId, submissionID).Scan(
+		&valuation.SubmissionID,
+		&valuation.ModelID,
+		&valuation.BasePrice,
+		&valuation.VehicleAgeYears,
+		&valuation.AgeFactor,
+		&valuation.MileageFactor,
+		&valuation.CommercialFactor,
+		&valuation.EstimatedValue,
+		&valuation.LoanToValue,
+		&valuation.ValuedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return valuation, nil
+}
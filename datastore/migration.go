@@ -1,27 +1,162 @@
 package datastore
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func InitializeDatabase(migrationFolder, driver string) {
-	log.Println("Applying database migrations...")
+var ErrSchemaDirty = errors.New("database schema is dirty")
+var ErrSchemaAhead = errors.New("database schema is ahead of the binary")
 
-	m, err := migrate.New(
-		"file://"+migrationFolder,
-		driver,
-	)
+type Migrator struct {
+	m             *migrate.Migrate
+	latestVersion uint
+}
+
+func NewMigrator(migrationFolder, databaseURL string) (*Migrator, error) {
+	src, err := source.Open("file://" + migrationFolder)
 	if err != nil {
-		log.Fatalf("Failed to initialize migrations: %v", err)
+		return nil, fmt.Errorf("open migration source: %w", err)
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("Migration failed: %v", err)
+	latestVersion, err := lastSourceVersion(src)
+	if err != nil {
+		src.Close()
+		return nil, err
 	}
 
-	log.Println("Database migrations applied successfully!")
+	m, err := migrate.NewWithSourceInstance("file", src, databaseURL)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("initialize migrations: %w", err)
+	}
+
+	return &Migrator{
+		m:             m,
+		latestVersion: latestVersion,
+	}, nil
+}
+
+func lastSourceVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read migration source: %w", err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read migration source: %w", err)
+		}
+		version = next
+	}
+}
+
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+func (mg *Migrator) LatestVersion() uint {
+	return mg.latestVersion
+}
+
+func (mg *Migrator) Up() error {
+	return ignoreNoChange(mg.m.Up())
+}
+
+func (mg *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be greater than zero, got %d", steps)
+	}
+	return ignoreNoChange(mg.m.Steps(-steps))
+}
+
+func (mg *Migrator) Goto(version uint) error {
+	return ignoreNoChange(mg.m.Migrate(version))
+}
+
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Version returns 0 with no error when no migration has been applied yet.
+func (mg *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func (mg *Migrator) CheckSchema() error {
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d, fix it and run migrate force", ErrSchemaDirty, version)
+	}
+	if version > mg.latestVersion {
+		return fmt.Errorf("%w: database at version %d, binary knows up to %d", ErrSchemaAhead, version, mg.latestVersion)
+	}
+	return nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// CreateMigration writes an empty up/down pair numbered after the last
+// migration in the folder, following the N_name.{up,down}.sql layout.
+func CreateMigration(migrationFolder, name string) ([]string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+	name = strings.ReplaceAll(strings.ToLower(name), " ", "_")
+
+	src, err := source.Open("file://" + migrationFolder)
+	if err != nil {
+		return nil, fmt.Errorf("open migration source: %w", err)
+	}
+	latestVersion, err := lastSourceVersion(src)
+	src.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	version := latestVersion + 1
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(migrationFolder, fmt.Sprintf("%d_%s.%s.sql", version, name, direction))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err != nil {
+			return files, err
+		}
+		f.Close()
+		files = append(files, path)
+	}
+	return files, nil
 }
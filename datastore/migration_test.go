@@ -0,0 +1,160 @@
+package datastore_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/google/uuid"
+)
+
+// insertRawSubmission stores a submission the way rows written before the
+// license plate key was computed in Go look.
+func insertRawSubmission(t *testing.T, db *sql.DB, plate, key, status string) string {
+	t.Helper()
+	customerID, submissionID := uuid.New().String(), uuid.New().String()
+	_, err := db.Exec(`INSERT INTO loan_customers (customer_id, id_card_number, full_name, birth_date, phone_number, address_street, address_city)
+		VALUES ($1, $2, 'Budi', '1990-01-01', '0812', 'Jl. Sudirman', 'Jakarta')`, customerID, customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO loan_submissions (submission_id, vehicle_type, vehicle_brand, vehicle_model, vehicle_license_number,
+		manufacturing_year, proposed_loan_amount, proposed_loan_tenure_month, loan_status, created_at, updated_at, customer_id,
+		vehicle_license_key)
+		VALUES ($1, 'Car', 'Toyota', 'Avanza', $2, 2020, 12000, 12, $3, 0, 0, $4, $5)`,
+		submissionID, plate, status, customerID, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return submissionID
+}
+
+func TestLicensePlateKeysAreBackfilledLikeSubmissions(t *testing.T) {
+	migrator, db := datastoretest.Migrator(t)
+	if err := migrator.Goto(20); err != nil {
+		t.Fatal(err)
+	}
+	slashed := insertRawSubmission(t, db, "B/1234 xyz", "B/1234XYZ", "NEW")
+	spaced := insertRawSubmission(t, db, "B 1234 XYZ", "B1234XYZ", "APPROVED")
+	rejected := insertRawSubmission(t, db, "b_1234_xyz", "B_1234_XYZ", "REJECTED")
+	other := insertRawSubmission(t, db, "D 1 A", "D1A", "NEW")
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		submissionID string
+		key          string
+		duplicate    bool
+	}{
+		{slashed, "B1234XYZ", true},
+		{spaced, "B1234XYZ", true},
+		{rejected, "B1234XYZ", false},
+		{other, "D1A", false},
+	}
+	for _, test := range tests {
+		var key string
+		var duplicate bool
+		err := db.QueryRow(`SELECT vehicle_license_key, is_duplicate_collateral FROM loan_submissions WHERE submission_id = $1`,
+			test.submissionID).Scan(&key, &duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != test.key || duplicate != test.duplicate {
+			t.Errorf("submission keyed %q, duplicate %v, want %q, %v", key, duplicate, test.key, test.duplicate)
+		}
+	}
+}
+
+func migratedTo(t *testing.T, migrator *datastore.Migrator, want uint) {
+	t.Helper()
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != want || dirty {
+		t.Fatalf("schema at version %d, dirty %v, want %d", version, dirty, want)
+	}
+}
+
+func TestMigratorMovesBetweenVersions(t *testing.T) {
+	migrator, db := datastoretest.Migrator(t)
+	disbursements := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'loan_disbursements'`
+
+	migratedTo(t, migrator, 0)
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	latest := migrator.LatestVersion()
+	migratedTo(t, migrator, latest)
+	if err := migrator.Up(); err != nil {
+		t.Errorf("migrating an up to date schema: %v", err)
+	}
+
+	if err := migrator.Down(0); err == nil {
+		t.Error("rolling back zero steps succeeded")
+	}
+	migratedTo(t, migrator, latest)
+	if err := migrator.Down(2); err != nil {
+		t.Fatal(err)
+	}
+	migratedTo(t, migrator, latest-2)
+
+	if err := migrator.Goto(10); err != nil {
+		t.Fatal(err)
+	}
+	migratedTo(t, migrator, 10)
+	if n := count(t, db, disbursements); n != 0 {
+		t.Error("loan_disbursements survived rolling back past its migration")
+	}
+	if err := migrator.Goto(10); err != nil {
+		t.Errorf("going to the current version: %v", err)
+	}
+	if err := migrator.Goto(latest); err != nil {
+		t.Fatal(err)
+	}
+	migratedTo(t, migrator, latest)
+	if n := count(t, db, disbursements); n != 1 {
+		t.Error("loan_disbursements missing after migrating back up")
+	}
+}
+
+func TestCheckSchemaRefusesDirtyAndNewerSchemas(t *testing.T) {
+	migrator, db := datastoretest.Migrator(t)
+	if err := migrator.CheckSchema(); err != nil {
+		t.Errorf("empty database: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	latest := migrator.LatestVersion()
+	if err := migrator.CheckSchema(); err != nil {
+		t.Errorf("migrated database: %v", err)
+	}
+
+	// A migration that fails half way leaves the version it was applying
+	// marked dirty.
+	if _, err := db.Exec(`UPDATE schema_migrations SET dirty = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckSchema(); !errors.Is(err, datastore.ErrSchemaDirty) {
+		t.Errorf("dirty schema: err = %v, want ErrSchemaDirty", err)
+	}
+	if err := migrator.Force(int(latest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckSchema(); err != nil {
+		t.Errorf("schema forced clean: %v", err)
+	}
+
+	// A newer binary migrated the database further than this one knows.
+	if err := migrator.Force(int(latest) + 1); err != nil {
+		t.Fatal(err)
+	}
+	migratedTo(t, migrator, latest+1)
+	if err := migrator.CheckSchema(); !errors.Is(err, datastore.ErrSchemaAhead) {
+		t.Errorf("schema ahead of the binary: err = %v, want ErrSchemaAhead", err)
+	}
+}
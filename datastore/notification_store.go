@@ -0,0 +1,401 @@
+package datastore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationSubmissionReceived = "submission_received"
+	NotificationSubmissionApproved = "submission_approved"
+	NotificationSubmissionRejected = "submission_rejected"
+	NotificationInstallmentDue     = "installment_due"
+	NotificationInstallmentOverdue = "installment_overdue"
+)
+
+const (
+	NotificationChannelEmail = "EMAIL"
+	NotificationChannelSMS   = "SMS"
+)
+
+const (
+	NotificationPending = "PENDING"
+	NotificationSent    = "SENT"
+	NotificationFailed  = "FAILED"
+)
+
+// A notification is queued once per dedupe key and channel, so repeating the
+// event that caused it, or the reminder run that found it, is a no-op.
+const sqlInsertNotification = `
+INSERT INTO notifications (
+    notification_id,
+    customer_id,
+    submission_id,
+    template,
+    channel,
+    locale,
+    recipient,
+    data,
+    dedupe_key,
+    status,
+    next_attempt_at,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, 'PENDING', $10, $10
+) ON CONFLICT (dedupe_key, channel) DO NOTHING;`
+
+const sqlGetNotificationRecipient = `
+SELECT full_name, phone_number, email, locale
+FROM loan_customers
+WHERE customer_id = $1;`
+
+const sqlGetSubmissionNotificationFields = `
+SELECT
+    customer_id,
+    vehicle_brand,
+    vehicle_model,
+    manufacturing_year,
+    proposed_loan_amount,
+    proposed_loan_tenure_month
+FROM loan_submissions
+WHERE submission_id = $1;`
+
+const sqlSelectNotifications = `
+SELECT
+    notification_id,
+    customer_id,
+    submission_id,
+    template,
+    channel,
+    locale,
+    recipient,
+    data,
+    dedupe_key,
+    status,
+    attempts,
+    next_attempt_at,
+    subject,
+    body,
+    last_error,
+    created_at,
+    sent_at
+FROM notifications
+`
+
+const sqlGetDueNotifications = sqlSelectNotifications + `
+WHERE status = 'PENDING'
+AND channel = $1
+AND next_attempt_at <= $2
+ORDER BY next_attempt_at, rowid
+LIMIT $3;`
+
+const sqlGetNotifications = sqlSelectNotifications + `
+WHERE ($1 = '' OR customer_id = $1)
+AND ($2 = '' OR submission_id = $2)
+AND ($3 = '' OR status = $3)
+AND ($4 = '' OR channel = $4)
+ORDER BY created_at DESC, rowid DESC
+LIMIT $5;`
+
+const sqlRecordNotificationAttempt = `
+UPDATE notifications
+SET status = $1,
+    attempts = attempts + 1,
+    next_attempt_at = $2,
+    subject = $3,
+    body = $4,
+    last_error = $5,
+    sent_at = $6
+WHERE notification_id = $7
+AND status = 'PENDING';`
+
+// Amounts include the penalties assessed so far.
+const sqlGetInstallmentReminders = `
+SELECT
+    installment.submission_id,
+    submission.customer_id,
+    installment.installment_number,
+    installment.due_date,
+    installment.principal_due + installment.interest_due + installment.fee_due + installment.penalty_due
+        - installment.principal_paid - installment.interest_paid - installment.fee_paid - installment.penalty_paid
+FROM loan_installments installment
+INNER JOIN loan_submissions submission
+ON submission.submission_id = installment.submission_id
+WHERE submission.loan_status NOT IN (` + inactiveLoanStatusList + `)
+AND installment.due_date <= $1
+AND installment.principal_paid + installment.interest_paid + installment.fee_paid + installment.penalty_paid
+    < installment.principal_due + installment.interest_due + installment.fee_due + installment.penalty_due
+ORDER BY installment.due_date, installment.submission_id, installment.installment_number;`
+
+type NotificationRow struct {
+	NotificationID string
+	CustomerID     string
+	SubmissionID   sql.NullString
+	Template       string
+	Channel        string
+	Locale         string
+	Recipient      string
+	Data           string
+	DedupeKey      string
+	Status         string
+	Attempts       int
+	NextAttemptAt  int64
+	Subject        sql.NullString
+	Body           sql.NullString
+	LastError      sql.NullString
+	CreatedAt      int64
+	SentAt         sql.NullInt64
+}
+
+// NotificationRequest asks for a template to be sent to a customer on every
+// channel the customer can be reached on. The customer name is added to
+// Data.
+type NotificationRequest struct {
+	CustomerID   string
+	SubmissionID sql.NullString
+	Template     string
+	DedupeKey    string
+	Data         map[string]any
+	CreatedAt    int64
+}
+
+type InstallmentReminderRow struct {
+	SubmissionID      string
+	CustomerID        string
+	InstallmentNumber int
+	DueDate           string
+	AmountDue         int
+}
+
+type NotificationFilter struct {
+	CustomerID   string
+	SubmissionID string
+	Status       string
+	Channel      string
+	Limit        int
+}
+
+type NotificationStore struct {
+	db *sql.DB
+}
+
+func NewNotificationStore(db *sql.DB) *NotificationStore {
+	return &NotificationStore{
+		db: db,
+	}
+}
+
+// QueueNotification reports how many channels the notification was queued
+// on, 0 when it was queued before.
+func (s *NotificationStore) QueueNotification(request *NotificationRequest) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	queued, err := queueNotification(tx, request)
+	if err != nil {
+		return 0, err
+	}
+	return queued, tx.Commit()
+}
+
+func (s *NotificationStore) GetDueNotifications(channel string, now int64, limit int) ([]*NotificationRow, error) {
+	return s.queryNotifications(sqlGetDueNotifications, channel, now, limit)
+}
+
+// GetNotifications is the send log, newest first. Empty filter fields match
+// everything.
+func (s *NotificationStore) GetNotifications(filter NotificationFilter) ([]*NotificationRow, error) {
+	return s.queryNotifications(sqlGetNotifications,
+		filter.CustomerID,
+		filter.SubmissionID,
+		filter.Status,
+		filter.Channel,
+		filter.Limit,
+	)
+}
+
+func (s *NotificationStore) queryNotifications(query string, args ...any) ([]*NotificationRow, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*NotificationRow
+	for rows.Next() {
+		notification := &NotificationRow{}
+		err := rows.Scan(
+			&notification.NotificationID,
+			&notification.CustomerID,
+			&notification.SubmissionID,
+			&notification.Template,
+			&notification.Channel,
+			&notification.Locale,
+			&notification.Recipient,
+			&notification.Data,
+			&notification.DedupeKey,
+			&notification.Status,
+			&notification.Attempts,
+			&notification.NextAttemptAt,
+			&notification.Subject,
+			&notification.Body,
+			&notification.LastError,
+			&notification.CreatedAt,
+			&notification.SentAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// RecordNotificationAttempt stores the outcome of a send together with the
+// message as rendered. A notification left PENDING is tried again at
+// nextAttemptAt.
+func (s *NotificationStore) RecordNotificationAttempt(notification *NotificationRow, nextAttemptAt int64) error {
+	_, err := s.db.Exec(sqlRecordNotificationAttempt,
+		notification.Status,
+		nextAttemptAt,
+		notification.Subject,
+		notification.Body,
+		notification.LastError,
+		notification.SentAt,
+		notification.NotificationID,
+	)
+	return err
+}
+
+// GetInstallmentReminders returns the unpaid installments of active loans
+// due on or before dueBy, overdue ones included.
+func (s *NotificationStore) GetInstallmentReminders(dueBy string) ([]*InstallmentReminderRow, error) {
+	rows, err := s.db.Query(sqlGetInstallmentReminders, dueBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []*InstallmentReminderRow
+	for rows.Next() {
+		reminder := &InstallmentReminderRow{}
+		err := rows.Scan(
+			&reminder.SubmissionID,
+			&reminder.CustomerID,
+			&reminder.InstallmentNumber,
+			&reminder.DueDate,
+			&reminder.AmountDue,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// queueNotification writes one pending notification per channel the customer
+// has an address for: email when an email is on file, SMS to the phone
+// number.
+func queueNotification(tx *sql.Tx, request *NotificationRequest) (int, error) {
+	var fullName, phoneNumber, locale string
+	var email sql.NullString
+	err := tx.QueryRow(sqlGetNotificationRecipient, request.CustomerID).Scan(&fullName, &phoneNumber, &email, &locale)
+	if err != nil {
+		return 0, err
+	}
+
+	data := map[string]any{"customer_name": fullName}
+	for key, value := range request.Data {
+		data[key] = value
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+
+	recipients := map[string]string{}
+	if email.Valid && email.String != "" {
+		recipients[NotificationChannelEmail] = email.String
+	}
+	if phoneNumber != "" {
+		recipients[NotificationChannelSMS] = phoneNumber
+	}
+
+	queued := 0
+	for _, channel := range []string{NotificationChannelEmail, NotificationChannelSMS} {
+		recipient, ok := recipients[channel]
+		if !ok {
+			continue
+		}
+		result, err := tx.Exec(sqlInsertNotification,
+			uuid.New().String(),
+			request.CustomerID,
+			request.SubmissionID,
+			request.Template,
+			channel,
+			locale,
+			recipient,
+			string(payload),
+			request.DedupeKey,
+			request.CreatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		queued += int(rows)
+	}
+	return queued, nil
+}
+
+// queueSubmissionNotification tells the primary applicant about a
+// submission, at most once per template.
+func queueSubmissionNotification(tx *sql.Tx, template, submissionID string, at int64) error {
+	var customerID, brand, model string
+	var year, amount, tenure int
+	err := tx.QueryRow(sqlGetSubmissionNotificationFields, submissionID).Scan(
+		&customerID,
+		&brand,
+		&model,
+		&year,
+		&amount,
+		&tenure,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = queueNotification(tx, &NotificationRequest{
+		CustomerID:   customerID,
+		SubmissionID: sql.NullString{String: submissionID, Valid: true},
+		Template:     template,
+		DedupeKey:    template + ":" + submissionID,
+		Data: map[string]any{
+			"submission_id":      submissionID,
+			"vehicle_brand":      brand,
+			"vehicle_model":      model,
+			"manufacturing_year": year,
+			"loan_amount":        amount,
+			"tenure_month":       tenure,
+		},
+		CreatedAt: at,
+	})
+	return err
+}
@@ -0,0 +1,155 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	ReportByDay   = "day"
+	ReportByWeek  = "week"
+	ReportByMonth = "month"
+)
+
+// reportDimensions are the SQL expressions submissions can be grouped by,
+// each read back as text. Weeks start on Monday and are labelled with that
+// date; all periods are in UTC.
+var reportDimensions = map[string]string{
+	ReportByDay:             `date(submission.created_at, 'unixepoch')`,
+	ReportByWeek:            `date(submission.created_at, 'unixepoch', 'weekday 0', '-6 days')`,
+	ReportByMonth:           `strftime('%Y-%m', submission.created_at, 'unixepoch')`,
+	"vehicle_type":          `submission.vehicle_type`,
+	"vehicle_brand":         `submission.vehicle_brand`,
+	"address_city":          `customer.address_city`,
+	"loan_status":           `submission.loan_status`,
+	"is_commercial_vehicle": `CASE WHEN submission.is_commercial_vehicle THEN 'true' ELSE 'false' END`,
+}
+
+// ReportDimensions lists what a report can be grouped by, periods first.
+var ReportDimensions = []string{
+	ReportByDay,
+	ReportByWeek,
+	ReportByMonth,
+	"vehicle_type",
+	"vehicle_brand",
+	"address_city",
+	"loan_status",
+	"is_commercial_vehicle",
+}
+
+// Funnel stages are read off the current status: a cancelled loan was
+// approved first, and a loan counts as disbursed once it is DISBURSED or
+// CLOSED or has an approved disbursement, which covers loans cancelled after
+// the money went out.
+const sqlSubmissionReportMetrics = `
+    COUNT(*),
+    COALESCE(SUM(submission.proposed_loan_amount), 0),
+    COUNT(CASE WHEN submission.loan_status = 'NEW' THEN 1 END),
+    COUNT(CASE WHEN submission.loan_status IN ('APPROVED', 'DISBURSED', 'CLOSED', 'CANCELLED') THEN 1 END),
+    COALESCE(SUM(CASE WHEN submission.loan_status IN ('APPROVED', 'DISBURSED', 'CLOSED', 'CANCELLED')
+        THEN submission.proposed_loan_amount END), 0),
+    COUNT(CASE WHEN submission.loan_status = 'REJECTED' THEN 1 END),
+    COUNT(CASE WHEN submission.loan_status = 'CANCELLED' THEN 1 END),
+    COUNT(CASE WHEN submission.loan_status IN ('DISBURSED', 'CLOSED') OR EXISTS (
+        SELECT 1 FROM loan_disbursements disbursement
+        WHERE disbursement.submission_id = submission.submission_id
+        AND disbursement.status = 'APPROVED'
+    ) THEN 1 END),
+    COUNT(CASE WHEN submission.loan_status = 'CLOSED' THEN 1 END)
+FROM loan_submissions submission
+INNER JOIN loan_customers customer
+ON customer.customer_id = submission.customer_id
+WHERE ($1 = 0 OR submission.created_at >= $1)
+AND ($2 = 0 OR submission.created_at < $2)
+AND ($3 = '' OR submission.dealer_id = $3)
+AND ($4 = '' OR submission.product_id = $4)
+`
+
+type SubmissionReportQuery struct {
+	GroupBy     []string
+	CreatedFrom int64
+	CreatedTo   int64
+	DealerID    string
+	ProductID   string
+}
+
+// SubmissionReportRow holds the metrics of one group. Group values are in the
+// order of SubmissionReportQuery.GroupBy.
+type SubmissionReportRow struct {
+	Group                   []string
+	SubmissionCount         int
+	TotalProposedLoanAmount int
+	PendingCount            int
+	ApprovedCount           int
+	ApprovedLoanAmount      int
+	RejectedCount           int
+	CancelledCount          int
+	DisbursedCount          int
+	ClosedCount             int
+}
+
+type ReportStore struct {
+	db *sql.DB
+}
+
+func NewReportStore(db *sql.DB) *ReportStore {
+	return &ReportStore{
+		db: db,
+	}
+}
+
+// GetSubmissionReport aggregates submissions created in the query's range,
+// one row per combination of the GroupBy dimensions ordered by them, or a
+// single row for everything when GroupBy is empty.
+func (s *ReportStore) GetSubmissionReport(query SubmissionReportQuery) ([]*SubmissionReportRow, error) {
+	var selects, positions []string
+	for i, dimension := range query.GroupBy {
+		expression, ok := reportDimensions[dimension]
+		if !ok {
+			return nil, fmt.Errorf("unknown report dimension %q", dimension)
+		}
+		selects = append(selects, expression+",")
+		positions = append(positions, fmt.Sprint(i+1))
+	}
+
+	statement := "SELECT " + strings.Join(selects, " ") + sqlSubmissionReportMetrics
+	if len(positions) > 0 {
+		statement += "GROUP BY " + strings.Join(positions, ", ") + "\nORDER BY " + strings.Join(positions, ", ")
+	}
+
+	rows, err := s.db.Query(statement, query.CreatedFrom, query.CreatedTo, query.DealerID, query.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []*SubmissionReportRow
+	for rows.Next() {
+		row := &SubmissionReportRow{Group: make([]string, len(query.GroupBy))}
+		dest := make([]any, 0, len(query.GroupBy)+9)
+		for i := range row.Group {
+			dest = append(dest, &row.Group[i])
+		}
+		dest = append(dest,
+			&row.SubmissionCount,
+			&row.TotalProposedLoanAmount,
+			&row.PendingCount,
+			&row.ApprovedCount,
+			&row.ApprovedLoanAmount,
+			&row.RejectedCount,
+			&row.CancelledCount,
+			&row.DisbursedCount,
+			&row.ClosedCount,
+		)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
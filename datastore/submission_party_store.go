@@ -0,0 +1,160 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const (
+	PartyRolePrimary     = "PRIMARY"
+	PartyRoleCoApplicant = "CO_APPLICANT"
+	PartyRoleGuarantor   = "GUARANTOR"
+)
+
+const sqlDeleteSubmissionParties = `
+DELETE FROM submission_parties
+WHERE submission_id = $1;`
+
+const sqlInsertSubmissionParty = `
+INSERT INTO submission_parties (submission_id, customer_id, party_role)
+VALUES ($1, $2, $3);`
+
+const sqlGetSubmissionParties = `
+SELECT
+    party.party_role,
+    customer.customer_id,
+    customer.id_card_number,
+    customer.full_name,
+    customer.birth_date,
+    customer.phone_number,
+    customer.email,
+    customer.monthly_income,
+    customer.address_street,
+    customer.address_city,
+    customer.locale
+FROM submission_parties party
+INNER JOIN loan_customers customer
+ON customer.customer_id = party.customer_id
+WHERE party.submission_id = $1
+ORDER BY CASE party.party_role
+    WHEN 'PRIMARY' THEN 0
+    WHEN 'CO_APPLICANT' THEN 1
+    ELSE 2
+END, customer.full_name;`
+
+const sqlGetSubmissionPartiesBySubmissionIds = `
+SELECT submission_id, customer_id, party_role
+FROM submission_parties
+WHERE submission_id IN (SELECT value FROM json_each($1))
+ORDER BY submission_id, CASE party_role
+    WHEN 'PRIMARY' THEN 0
+    WHEN 'CO_APPLICANT' THEN 1
+    ELSE 2
+END;`
+
+type SubmissionPartyRow struct {
+	SubmissionID string
+	CustomerID   string
+	PartyRole    string
+}
+
+type SubmissionPartyWithCustomerRow struct {
+	PartyRole       string
+	LoanCustomerRow *LoanCustomerRow
+}
+
+type SubmissionPartyStore struct {
+	db *sql.DB
+}
+
+func NewSubmissionPartyStore(db *sql.DB) *SubmissionPartyStore {
+	return &SubmissionPartyStore{
+		db: db,
+	}
+}
+
+// ReplaceSubmissionParties swaps the full party list of a submission, so a
+// resubmission drops co-applicants and guarantors that are no longer named.
+func (s *SubmissionPartyStore) ReplaceSubmissionParties(submissionID string, parties []*SubmissionPartyRow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceSubmissionParties(tx, submissionID, parties); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceSubmissionParties(tx *sql.Tx, submissionID string, parties []*SubmissionPartyRow) error {
+	if _, err := tx.Exec(sqlDeleteSubmissionParties, submissionID); err != nil {
+		return err
+	}
+	for _, party := range parties {
+		if _, err := tx.Exec(sqlInsertSubmissionParty, submissionID, party.CustomerID, party.PartyRole); err != nil {
+			return fmt.Errorf("party %s as %s: %w", party.CustomerID, party.PartyRole, err)
+		}
+	}
+	return nil
+}
+
+func (s *SubmissionPartyStore) GetSubmissionParties(submissionID string) ([]*SubmissionPartyWithCustomerRow, error) {
+	rows, err := s.db.Query(sqlGetSubmissionParties, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parties []*SubmissionPartyWithCustomerRow
+	for rows.Next() {
+		party := &SubmissionPartyWithCustomerRow{LoanCustomerRow: &LoanCustomerRow{}}
+		err := rows.Scan(
+			&party.PartyRole,
+			&party.LoanCustomerRow.CustomerID,
+			&party.LoanCustomerRow.IDCardNumber,
+			&party.LoanCustomerRow.FullName,
+			&party.LoanCustomerRow.BirthDate,
+			&party.LoanCustomerRow.PhoneNumber,
+			&party.LoanCustomerRow.Email,
+			&party.LoanCustomerRow.MonthlyIncome,
+			&party.LoanCustomerRow.AddressStreet,
+			&party.LoanCustomerRow.AddressCity,
+			&party.LoanCustomerRow.Locale,
+		)
+		if err != nil {
+			return nil, err
+		}
+		parties = append(parties, party)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return parties, nil
+}
+
+// GetSubmissionPartiesBySubmissionIds returns the parties of all the
+// submissions, primary applicant first, without reading their customers.
+func (s *SubmissionPartyStore) GetSubmissionPartiesBySubmissionIds(submissionIDs []string) ([]*SubmissionPartyRow, error) {
+	rows, err := s.db.Query(sqlGetSubmissionPartiesBySubmissionIds, idList(submissionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parties []*SubmissionPartyRow
+	for rows.Next() {
+		party := &SubmissionPartyRow{}
+		if err := rows.Scan(&party.SubmissionID, &party.CustomerID, &party.PartyRole); err != nil {
+			return nil, err
+		}
+		parties = append(parties, party)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return parties, nil
+}
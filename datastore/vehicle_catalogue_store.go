@@ -0,0 +1,396 @@
+package datastore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const sqlGetAllVehicleTypes = `
+SELECT type_id, name
+FROM vehicle_types
+ORDER BY name;`
+
+const sqlUpsertVehicleType = `
+INSERT INTO vehicle_types (type_id, name)
+VALUES ($1, $2)
+ON CONFLICT (type_id) DO UPDATE SET
+    name = EXCLUDED.name
+RETURNING type_id;`
+
+const sqlDeleteVehicleType = `
+DELETE FROM vehicle_types
+WHERE type_id = $1;`
+
+const sqlGetAllVehicleBrands = `
+SELECT brand_id, name
+FROM vehicle_brands
+ORDER BY name;`
+
+const sqlUpsertVehicleBrand = `
+INSERT INTO vehicle_brands (brand_id, name)
+VALUES ($1, $2)
+ON CONFLICT (brand_id) DO UPDATE SET
+    name = EXCLUDED.name
+RETURNING brand_id;`
+
+const sqlDeleteVehicleBrand = `
+DELETE FROM vehicle_brands
+WHERE brand_id = $1;`
+
+const sqlGetVehicleBrandByName = `
+SELECT brand_id, name
+FROM vehicle_brands
+WHERE name = $1;`
+
+const sqlSelectVehicleModels = `
+SELECT
+    model.model_id,
+    model.brand_id,
+    brand.name,
+    model.type_id,
+    type.name,
+    model.name,
+    model.year_from,
+    model.year_to,
+    model.is_commercial,
+    model.base_price
+FROM vehicle_models model
+INNER JOIN vehicle_brands brand ON brand.brand_id = model.brand_id
+INNER JOIN vehicle_types type ON type.type_id = model.type_id
+`
+
+const sqlGetAllVehicleModels = sqlSelectVehicleModels + `
+ORDER BY brand.name, model.name;`
+
+const sqlGetVehicleModelByName = sqlSelectVehicleModels + `
+WHERE model.brand_id = $1 AND model.name = $2;`
+
+const sqlUpsertVehicleModel = `
+INSERT INTO vehicle_models (
+    model_id,
+    brand_id,
+    type_id,
+    name,
+    year_from,
+    year_to,
+    is_commercial,
+    base_price
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) ON CONFLICT (model_id) DO UPDATE SET
+    brand_id = EXCLUDED.brand_id,
+    type_id = EXCLUDED.type_id,
+    name = EXCLUDED.name,
+    year_from = EXCLUDED.year_from,
+    year_to = EXCLUDED.year_to,
+    is_commercial = EXCLUDED.is_commercial,
+    base_price = EXCLUDED.base_price
+RETURNING model_id;`
+
+const sqlDeleteVehicleModel = `
+DELETE FROM vehicle_models
+WHERE model_id = $1;`
+
+const sqlImportVehicleType = `
+INSERT INTO vehicle_types (type_id, name)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET
+    name = vehicle_types.name
+RETURNING type_id;`
+
+const sqlImportVehicleBrand = `
+INSERT INTO vehicle_brands (brand_id, name)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET
+    name = vehicle_brands.name
+RETURNING brand_id;`
+
+const sqlImportVehicleModel = `
+INSERT INTO vehicle_models (
+    model_id,
+    brand_id,
+    type_id,
+    name,
+    year_from,
+    year_to,
+    is_commercial,
+    base_price
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) ON CONFLICT (brand_id, name) DO UPDATE SET
+    type_id = EXCLUDED.type_id,
+    year_from = EXCLUDED.year_from,
+    year_to = EXCLUDED.year_to,
+    is_commercial = EXCLUDED.is_commercial,
+    base_price = EXCLUDED.base_price
+RETURNING model_id;`
+
+type VehicleTypeRow struct {
+	TypeID string
+	Name   string
+}
+
+type VehicleBrandRow struct {
+	BrandID string
+	Name    string
+}
+
+type VehicleModelRow struct {
+	ModelID      string
+	BrandID      string
+	BrandName    string
+	TypeID       string
+	TypeName     string
+	Name         string
+	YearFrom     int
+	YearTo       sql.NullInt64
+	IsCommercial bool
+	BasePrice    sql.NullInt64
+}
+
+// VehicleCatalogueEntry is one line of a bulk catalogue import. Types, brands
+// and models are matched by name, keeping the spelling already stored, and
+// created when missing.
+type VehicleCatalogueEntry struct {
+	TypeName     string
+	BrandName    string
+	ModelName    string
+	YearFrom     int
+	YearTo       sql.NullInt64
+	IsCommercial bool
+	BasePrice    sql.NullInt64
+}
+
+type VehicleCatalogueStore struct {
+	db *sql.DB
+}
+
+func NewVehicleCatalogueStore(db *sql.DB) *VehicleCatalogueStore {
+	return &VehicleCatalogueStore{
+		db: db,
+	}
+}
+
+func (s *VehicleCatalogueStore) GetAllVehicleTypes() ([]*VehicleTypeRow, error) {
+	rows, err := s.db.Query(sqlGetAllVehicleTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []*VehicleTypeRow
+	for rows.Next() {
+		vehicleType := &VehicleTypeRow{}
+		if err := rows.Scan(&vehicleType.TypeID, &vehicleType.Name); err != nil {
+			return nil, err
+		}
+		types = append(types, vehicleType)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (s *VehicleCatalogueStore) UpsertVehicleType(vehicleType *VehicleTypeRow) (string, error) {
+	var typeID string
+	err := s.db.QueryRow(sqlUpsertVehicleType, vehicleType.TypeID, vehicleType.Name).Scan(&typeID)
+	if err != nil {
+		return "", err
+	}
+	return typeID, nil
+}
+
+func (s *VehicleCatalogueStore) DeleteVehicleType(typeID string) error {
+	return s.deleteByID(sqlDeleteVehicleType, typeID)
+}
+
+func (s *VehicleCatalogueStore) GetAllVehicleBrands() ([]*VehicleBrandRow, error) {
+	rows, err := s.db.Query(sqlGetAllVehicleBrands)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brands []*VehicleBrandRow
+	for rows.Next() {
+		brand := &VehicleBrandRow{}
+		if err := rows.Scan(&brand.BrandID, &brand.Name); err != nil {
+			return nil, err
+		}
+		brands = append(brands, brand)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return brands, nil
+}
+
+func (s *VehicleCatalogueStore) GetVehicleBrandByName(name string) (*VehicleBrandRow, error) {
+	brand := &VehicleBrandRow{}
+	err := s.db.QueryRow(sqlGetVehicleBrandByName, name).Scan(&brand.BrandID, &brand.Name)
+	if err != nil {
+		return nil, err
+	}
+	return brand, nil
+}
+
+func (s *VehicleCatalogueStore) UpsertVehicleBrand(brand *VehicleBrandRow) (string, error) {
+	var brandID string
+	err := s.db.QueryRow(sqlUpsertVehicleBrand, brand.BrandID, brand.Name).Scan(&brandID)
+	if err != nil {
+		return "", err
+	}
+	return brandID, nil
+}
+
+func (s *VehicleCatalogueStore) DeleteVehicleBrand(brandID string) error {
+	return s.deleteByID(sqlDeleteVehicleBrand, brandID)
+}
+
+func (s *VehicleCatalogueStore) GetAllVehicleModels() ([]*VehicleModelRow, error) {
+	rows, err := s.db.Query(sqlGetAllVehicleModels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var models []*VehicleModelRow
+	for rows.Next() {
+		model, err := scanVehicleModel(rows)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, model)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+func (s *VehicleCatalogueStore) GetVehicleModelByName(brandID, name string) (*VehicleModelRow, error) {
+	return scanVehicleModel(s.db.QueryRow(sqlGetVehicleModelByName, brandID, name))
+}
+
+func (s *VehicleCatalogueStore) UpsertVehicleModel(model *VehicleModelRow) (string, error) {
+	var modelID string
+	err := s.db.QueryRow(sqlUpsertVehicleModel,
+		model.ModelID,
+		model.BrandID,
+		model.TypeID,
+		model.Name,
+		model.YearFrom,
+		model.YearTo,
+		model.IsCommercial,
+		model.BasePrice,
+	).Scan(&modelID)
+	if err != nil {
+		return "", err
+	}
+	return modelID, nil
+}
+
+func (s *VehicleCatalogueStore) DeleteVehicleModel(modelID string) error {
+	return s.deleteByID(sqlDeleteVehicleModel, modelID)
+}
+
+// ImportCatalogue upserts every entry in a single transaction; newID is used
+// for types, brands and models that do not exist yet.
+func (s *VehicleCatalogueStore) ImportCatalogue(entries []*VehicleCatalogueEntry, newID func() string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, entry := range entries {
+		var typeID, brandID string
+		if err := tx.QueryRow(sqlImportVehicleType, newID(), entry.TypeName).Scan(&typeID); err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if err := tx.QueryRow(sqlImportVehicleBrand, newID(), entry.BrandName).Scan(&brandID); err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		_, err := tx.Exec(sqlImportVehicleModel,
+			newID(),
+			brandID,
+			typeID,
+			entry.ModelName,
+			entry.YearFrom,
+			entry.YearTo,
+			entry.IsCommercial,
+			entry.BasePrice,
+		)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *VehicleCatalogueStore) deleteByID(query, id string) error {
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no rows affected")
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// isConstraintError reports whether SQLite refused a write for breaking a
+// CHECK, UNIQUE or foreign key constraint.
+func isConstraintError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint
+}
+
+// idList binds a list of ids as one JSON array parameter, which queries
+// expand with json_each so that their SQL does not depend on the list length.
+func idList(ids []string) string {
+	list, _ := json.Marshal(ids)
+	return string(list)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func scanVehicleModel(row rowScanner) (*VehicleModelRow, error) {
+	model := &VehicleModelRow{}
+	err := row.Scan(
+		&model.ModelID,
+		&model.BrandID,
+		&model.BrandName,
+		&model.TypeID,
+		&model.TypeName,
+		&model.Name,
+		&model.YearFrom,
+		&model.YearTo,
+		&model.IsCommercial,
+		&model.BasePrice,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
@@ -0,0 +1,620 @@
+package datastore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/events"
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliverySucceeded = "SUCCEEDED"
+	WebhookDeliveryDead      = "DEAD"
+)
+
+const sqlInsertWebhookSubscription = `
+INSERT INTO webhook_subscriptions (
+    subscription_id,
+    dealer_id,
+    url,
+    secret,
+    event_types,
+    description,
+    is_active,
+    created_at,
+    updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+);`
+
+const sqlUpdateWebhookSubscription = `
+UPDATE webhook_subscriptions
+SET url = $1,
+    event_types = $2,
+    description = $3,
+    is_active = $4,
+    updated_at = $5
+WHERE subscription_id = $6
+AND dealer_id = $7;`
+
+const sqlDeactivateWebhookSubscription = `
+UPDATE webhook_subscriptions
+SET is_active = FALSE,
+    updated_at = $1
+WHERE subscription_id = $2
+AND dealer_id = $3;`
+
+const sqlSelectWebhookSubscriptions = `
+SELECT
+    subscription_id,
+    dealer_id,
+    url,
+    secret,
+    event_types,
+    description,
+    is_active,
+    created_at,
+    updated_at
+FROM webhook_subscriptions
+`
+
+const sqlGetWebhookSubscriptionsByDealerId = sqlSelectWebhookSubscriptions + `
+WHERE dealer_id = $1
+ORDER BY created_at, subscription_id;`
+
+const sqlGetWebhookSubscriptionById = sqlSelectWebhookSubscriptions + `
+WHERE subscription_id = $1
+AND dealer_id = $2;`
+
+// event_types is a comma separated list, padding it with commas matches whole
+// entries only.
+const sqlGetSubscribedWebhooks = `
+SELECT subscription_id
+FROM webhook_subscriptions
+WHERE dealer_id = $1
+AND is_active
+AND (',' || event_types || ',') LIKE ('%,' || $2 || ',%')
+ORDER BY created_at, subscription_id;`
+
+const sqlGetSubmissionWebhookFields = `
+SELECT agent_id, proposed_loan_amount, proposed_loan_tenure_month
+FROM loan_submissions
+WHERE submission_id = $1;`
+
+const sqlInsertWebhookDelivery = `
+INSERT INTO webhook_deliveries (
+    delivery_id,
+    subscription_id,
+    event_id,
+    event_type,
+    payload,
+    status,
+    next_attempt_at,
+    redelivery_of,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, 'PENDING', $6, $7, $6
+);`
+
+const sqlSelectWebhookDeliveries = `
+SELECT
+    delivery.delivery_id,
+    delivery.subscription_id,
+    delivery.event_id,
+    delivery.event_type,
+    delivery.payload,
+    delivery.status,
+    delivery.attempts,
+    delivery.next_attempt_at,
+    delivery.last_response_code,
+    delivery.last_error,
+    delivery.redelivery_of,
+    delivery.created_at,
+    delivery.completed_at
+FROM webhook_deliveries delivery
+`
+
+const sqlGetWebhookDeliveries = sqlSelectWebhookDeliveries + `
+WHERE delivery.subscription_id = $1
+AND ($2 = '' OR delivery.status = $2)
+ORDER BY delivery.created_at DESC, delivery.rowid DESC
+LIMIT $3;`
+
+const sqlGetWebhookDeliveryById = sqlSelectWebhookDeliveries + `
+WHERE delivery.delivery_id = $1
+AND delivery.subscription_id = $2;`
+
+const sqlGetDueWebhookDeliveries = `
+SELECT
+    delivery.delivery_id,
+    delivery.subscription_id,
+    delivery.event_id,
+    delivery.event_type,
+    delivery.payload,
+    delivery.status,
+    delivery.attempts,
+    delivery.next_attempt_at,
+    delivery.last_response_code,
+    delivery.last_error,
+    delivery.redelivery_of,
+    delivery.created_at,
+    delivery.completed_at,
+    subscription.url,
+    subscription.secret,
+    subscription.is_active
+FROM webhook_deliveries delivery
+JOIN webhook_subscriptions subscription
+ON subscription.subscription_id = delivery.subscription_id
+WHERE delivery.status = 'PENDING'
+AND delivery.next_attempt_at <= $1
+ORDER BY delivery.next_attempt_at, delivery.rowid
+LIMIT $2;`
+
+const sqlGetWebhookDeliveryAttempts = `
+SELECT
+    delivery_id,
+    attempt_number,
+    attempted_at,
+    response_code,
+    error,
+    duration_ms
+FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempt_number;`
+
+const sqlInsertWebhookDeliveryAttempt = `
+INSERT INTO webhook_delivery_attempts (
+    delivery_id,
+    attempt_number,
+    attempted_at,
+    response_code,
+    error,
+    duration_ms
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);`
+
+// The attempts guard keeps two dispatchers from recording the same attempt.
+const sqlRecordWebhookDeliveryAttempt = `
+UPDATE webhook_deliveries
+SET status = $1,
+    attempts = $2,
+    next_attempt_at = $3,
+    last_response_code = $4,
+    last_error = $5,
+    completed_at = CASE WHEN $1 = 'PENDING' THEN NULL ELSE $6 END
+WHERE delivery_id = $7
+AND status = 'PENDING'
+AND attempts = $2 - 1;`
+
+type WebhookSubscriptionRow struct {
+	SubscriptionID string
+	DealerID       string
+	URL            string
+	Secret         string
+	EventTypes     []string
+	Description    sql.NullString
+	IsActive       bool
+	CreatedAt      int64
+	UpdatedAt      int64
+}
+
+type WebhookDeliveryRow struct {
+	DeliveryID       string
+	SubscriptionID   string
+	EventID          string
+	EventType        string
+	Payload          string
+	Status           string
+	Attempts         int
+	NextAttemptAt    int64
+	LastResponseCode sql.NullInt64
+	LastError        sql.NullString
+	RedeliveryOf     sql.NullString
+	CreatedAt        int64
+	CompletedAt      sql.NullInt64
+
+	AttemptLog []*WebhookDeliveryAttemptRow
+}
+
+// WebhookDueDeliveryRow carries what the dispatcher needs to send a delivery.
+type WebhookDueDeliveryRow struct {
+	WebhookDeliveryRow
+	URL                string
+	Secret             string
+	SubscriptionActive bool
+}
+
+type WebhookDeliveryAttemptRow struct {
+	DeliveryID    string
+	AttemptNumber int
+	AttemptedAt   int64
+	ResponseCode  sql.NullInt64
+	Error         sql.NullString
+	DurationMs    int64
+}
+
+type WebhookStore struct {
+	db *sql.DB
+}
+
+func NewWebhookStore(db *sql.DB) *WebhookStore {
+	return &WebhookStore{
+		db: db,
+	}
+}
+
+func (s *WebhookStore) InsertWebhookSubscription(subscription *WebhookSubscriptionRow) error {
+	_, err := s.db.Exec(sqlInsertWebhookSubscription,
+		subscription.SubscriptionID,
+		subscription.DealerID,
+		subscription.URL,
+		subscription.Secret,
+		strings.Join(subscription.EventTypes, ","),
+		subscription.Description,
+		subscription.IsActive,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+	return err
+}
+
+// UpdateWebhookSubscription keeps the secret, it is only ever issued once.
+func (s *WebhookStore) UpdateWebhookSubscription(subscription *WebhookSubscriptionRow) error {
+	result, err := s.db.Exec(sqlUpdateWebhookSubscription,
+		subscription.URL,
+		strings.Join(subscription.EventTypes, ","),
+		subscription.Description,
+		subscription.IsActive,
+		subscription.UpdatedAt,
+		subscription.SubscriptionID,
+		subscription.DealerID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *WebhookStore) DeactivateWebhookSubscription(dealerID, subscriptionID string, updatedAt int64) error {
+	result, err := s.db.Exec(sqlDeactivateWebhookSubscription, updatedAt, subscriptionID, dealerID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no webhook subscription found with id %s", subscriptionID)
+	}
+	return nil
+}
+
+func (s *WebhookStore) GetWebhookSubscriptionsByDealerId(dealerID string) ([]*WebhookSubscriptionRow, error) {
+	rows, err := s.db.Query(sqlGetWebhookSubscriptionsByDealerId, dealerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*WebhookSubscriptionRow
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// GetWebhookSubscriptionById only finds subscriptions of dealerID.
+func (s *WebhookStore) GetWebhookSubscriptionById(dealerID, subscriptionID string) (*WebhookSubscriptionRow, error) {
+	return scanWebhookSubscription(s.db.QueryRow(sqlGetWebhookSubscriptionById, subscriptionID, dealerID))
+}
+
+func scanWebhookSubscription(row rowScanner) (*WebhookSubscriptionRow, error) {
+	subscription := &WebhookSubscriptionRow{}
+	var eventTypes string
+	err := row.Scan(
+		&subscription.SubscriptionID,
+		&subscription.DealerID,
+		&subscription.URL,
+		&subscription.Secret,
+		&eventTypes,
+		&subscription.Description,
+		&subscription.IsActive,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	subscription.EventTypes = strings.Split(eventTypes, ",")
+	return subscription, nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a subscription with
+// their attempts, optionally only those in status.
+func (s *WebhookStore) GetWebhookDeliveries(subscriptionID, status string, limit int) ([]*WebhookDeliveryRow, error) {
+	rows, err := s.db.Query(sqlGetWebhookDeliveries, subscriptionID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDeliveryRow
+	for rows.Next() {
+		delivery := &WebhookDeliveryRow{}
+		if err := rows.Scan(webhookDeliveryFields(delivery)...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, delivery := range deliveries {
+		if err := s.loadDeliveryAttempts(delivery); err != nil {
+			return nil, err
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookStore) GetWebhookDeliveryById(subscriptionID, deliveryID string) (*WebhookDeliveryRow, error) {
+	delivery := &WebhookDeliveryRow{}
+	err := s.db.QueryRow(sqlGetWebhookDeliveryById, deliveryID, subscriptionID).Scan(webhookDeliveryFields(delivery)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadDeliveryAttempts(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *WebhookStore) loadDeliveryAttempts(delivery *WebhookDeliveryRow) error {
+	rows, err := s.db.Query(sqlGetWebhookDeliveryAttempts, delivery.DeliveryID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	delivery.AttemptLog = nil
+	for rows.Next() {
+		attempt := &WebhookDeliveryAttemptRow{}
+		err := rows.Scan(
+			&attempt.DeliveryID,
+			&attempt.AttemptNumber,
+			&attempt.AttemptedAt,
+			&attempt.ResponseCode,
+			&attempt.Error,
+			&attempt.DurationMs,
+		)
+		if err != nil {
+			return err
+		}
+		delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+	}
+	return rows.Err()
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due at
+// now, oldest first.
+func (s *WebhookStore) GetDueDeliveries(now int64, limit int) ([]*WebhookDueDeliveryRow, error) {
+	rows, err := s.db.Query(sqlGetDueWebhookDeliveries, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDueDeliveryRow
+	for rows.Next() {
+		delivery := &WebhookDueDeliveryRow{}
+		fields := append(webhookDeliveryFields(&delivery.WebhookDeliveryRow),
+			&delivery.URL,
+			&delivery.Secret,
+			&delivery.SubscriptionActive,
+		)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordDeliveryAttempt logs an attempt and moves the delivery to status.
+// A delivery left PENDING is tried again at nextAttemptAt. It reports false
+// when the delivery was no longer pending or the attempt was already recorded.
+func (s *WebhookStore) RecordDeliveryAttempt(attempt *WebhookDeliveryAttemptRow, status string, nextAttemptAt int64) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(sqlRecordWebhookDeliveryAttempt,
+		status,
+		attempt.AttemptNumber,
+		nextAttemptAt,
+		attempt.ResponseCode,
+		attempt.Error,
+		attempt.AttemptedAt,
+		attempt.DeliveryID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(sqlInsertWebhookDeliveryAttempt,
+		attempt.DeliveryID,
+		attempt.AttemptNumber,
+		attempt.AttemptedAt,
+		attempt.ResponseCode,
+		attempt.Error,
+		attempt.DurationMs,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// InsertRedelivery queues the payload of an earlier delivery again under a
+// new delivery id. The event id is kept so receivers can deduplicate.
+func (s *WebhookStore) InsertRedelivery(original *WebhookDeliveryRow, createdAt int64) (*WebhookDeliveryRow, error) {
+	delivery := &WebhookDeliveryRow{
+		DeliveryID:     uuid.New().String(),
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  createdAt,
+		RedeliveryOf:   sql.NullString{String: original.DeliveryID, Valid: true},
+		CreatedAt:      createdAt,
+	}
+	_, err := s.db.Exec(sqlInsertWebhookDelivery,
+		delivery.DeliveryID,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.CreatedAt,
+		delivery.RedeliveryOf,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func webhookDeliveryFields(delivery *WebhookDeliveryRow) []any {
+	return []any{
+		&delivery.DeliveryID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastResponseCode,
+		&delivery.LastError,
+		&delivery.RedeliveryOf,
+		&delivery.CreatedAt,
+		&delivery.CompletedAt,
+	}
+}
+
+// queueWebhookDeliveries writes a pending delivery for every active
+// subscription of the dealer that asked for the decision event, in the
+// transaction of the decision itself.
+func queueWebhookDeliveries(tx *sql.Tx, submissionID string, dealerID sql.NullString, toStatus string, at int64) error {
+	var eventType string
+	switch {
+	case !dealerID.Valid:
+		return nil
+	case toStatus == "APPROVED":
+		eventType = events.TypeSubmissionApproved
+	case toStatus == "REJECTED":
+		eventType = events.TypeSubmissionRejected
+	default:
+		return nil
+	}
+
+	subscriptionIDs, err := getSubscribedWebhooks(tx, dealerID.String, eventType)
+	if err != nil || len(subscriptionIDs) == 0 {
+		return err
+	}
+
+	data := events.SubmissionDecidedV1{
+		SubmissionID: submissionID,
+		DealerID:     dealerID.String,
+		LoanStatus:   toStatus,
+	}
+	var agentID sql.NullString
+	err = tx.QueryRow(sqlGetSubmissionWebhookFields, submissionID).Scan(
+		&agentID,
+		&data.ProposedLoanAmount,
+		&data.ProposedLoanTenure,
+	)
+	if err != nil {
+		return err
+	}
+	data.AgentID = nullStringPtr(agentID)
+
+	envelope, err := events.New(eventType, submissionID, time.Unix(at, 0), data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	for _, subscriptionID := range subscriptionIDs {
+		_, err := tx.Exec(sqlInsertWebhookDelivery,
+			uuid.New().String(),
+			subscriptionID,
+			envelope.EventID,
+			envelope.EventType,
+			string(payload),
+			at,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getSubscribedWebhooks(tx *sql.Tx, dealerID, eventType string) ([]string, error) {
+	rows, err := tx.Query(sqlGetSubscribedWebhooks, dealerID, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptionIDs []string
+	for rows.Next() {
+		var subscriptionID string
+		if err := rows.Scan(&subscriptionID); err != nil {
+			return nil, err
+		}
+		subscriptionIDs = append(subscriptionIDs, subscriptionID)
+	}
+	return subscriptionIDs, rows.Err()
+}
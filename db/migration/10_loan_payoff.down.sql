@@ -0,0 +1,4 @@
+ALTER TABLE loan_payments DROP COLUMN payoff_quote_id;
+DROP TABLE IF EXISTS loan_payoff_quotes;
+ALTER TABLE loan_products DROP COLUMN early_termination_min_fee;
+ALTER TABLE loan_products DROP COLUMN early_termination_fee_rate;
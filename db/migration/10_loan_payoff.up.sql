@@ -0,0 +1,31 @@
+ALTER TABLE loan_products ADD COLUMN early_termination_fee_rate REAL NOT NULL DEFAULT 0;
+ALTER TABLE loan_products ADD COLUMN early_termination_min_fee INTEGER NOT NULL DEFAULT 0;
+
+UPDATE loan_products SET early_termination_fee_rate = 0.02, early_termination_min_fee = 100
+WHERE product_id IN ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', '8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002');
+UPDATE loan_products SET early_termination_fee_rate = 0.01, early_termination_min_fee = 25
+WHERE product_id = '8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003';
+UPDATE loan_products SET early_termination_fee_rate = 0.03, early_termination_min_fee = 250
+WHERE product_id = '8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004';
+
+CREATE TABLE IF NOT EXISTS loan_payoff_quotes (
+    quote_id TEXT NOT NULL PRIMARY KEY,
+    submission_id TEXT NOT NULL,
+    as_of TEXT NOT NULL,
+    expires_on TEXT NOT NULL,
+    principal INTEGER NOT NULL,
+    accrued_interest INTEGER NOT NULL,
+    fee INTEGER NOT NULL,
+    penalty INTEGER NOT NULL,
+    termination_fee INTEGER NOT NULL,
+    waived INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    settled_at INTEGER,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_payoff_quotes_submission_id ON loan_payoff_quotes (submission_id);
+
+ALTER TABLE loan_payments ADD COLUMN payoff_quote_id TEXT REFERENCES loan_payoff_quotes(quote_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS loan_disbursements (
+    disbursement_id TEXT NOT NULL PRIMARY KEY,
+    submission_id TEXT NOT NULL,
+    payee_type TEXT NOT NULL CHECK (payee_type IN ('DEALER', 'CUSTOMER')),
+    payee_name TEXT NOT NULL,
+    bank_code TEXT NOT NULL,
+    account_number TEXT NOT NULL,
+    account_holder TEXT NOT NULL,
+    loan_amount INTEGER NOT NULL,
+    deducted_fees INTEGER NOT NULL DEFAULT 0,
+    net_amount INTEGER NOT NULL CHECK (net_amount > 0),
+    status TEXT NOT NULL CHECK (status IN ('PENDING_APPROVAL', 'APPROVED', 'REJECTED')),
+    created_by TEXT NOT NULL,
+    reviewed_by TEXT CHECK (reviewed_by <> created_by),
+    review_note TEXT,
+    payment_file TEXT,
+    created_at INTEGER NOT NULL,
+    reviewed_at INTEGER,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loan_disbursements_open_submission
+ON loan_disbursements (submission_id) WHERE status <> 'REJECTED';
+
+CREATE INDEX IF NOT EXISTS idx_loan_disbursements_status ON loan_disbursements (status, created_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_loan_submissions_dealer_id;
+ALTER TABLE loan_submissions DROP COLUMN agent_id;
+ALTER TABLE loan_submissions DROP COLUMN dealer_id;
+DROP TABLE IF EXISTS dealer_api_credentials;
+DROP TABLE IF EXISTS dealer_agents;
+DROP TABLE IF EXISTS dealer_branches;
+DROP TABLE IF EXISTS dealers;
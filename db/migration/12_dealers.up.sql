@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS dealers (
+    dealer_id TEXT NOT NULL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    name TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    email TEXT,
+    address_city TEXT NOT NULL,
+    commission_rate REAL NOT NULL DEFAULT 0 CHECK (commission_rate >= 0 AND commission_rate < 1),
+    commission_flat_fee INTEGER NOT NULL DEFAULT 0 CHECK (commission_flat_fee >= 0),
+    commission_cap INTEGER CHECK (commission_cap > 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dealer_branches (
+    branch_id TEXT NOT NULL PRIMARY KEY,
+    dealer_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    address_city TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INTEGER NOT NULL,
+    UNIQUE (dealer_id, name),
+    FOREIGN KEY(dealer_id) REFERENCES dealers(dealer_id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS dealer_agents (
+    agent_id TEXT NOT NULL PRIMARY KEY,
+    dealer_id TEXT NOT NULL,
+    branch_id TEXT,
+    full_name TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    email TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY(dealer_id) REFERENCES dealers(dealer_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(branch_id) REFERENCES dealer_branches(branch_id)
+    ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dealer_agents_dealer_id ON dealer_agents (dealer_id);
+
+CREATE TABLE IF NOT EXISTS dealer_api_credentials (
+    credential_id TEXT NOT NULL PRIMARY KEY,
+    dealer_id TEXT NOT NULL,
+    key_prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL,
+    last_used_at INTEGER,
+    revoked_at INTEGER,
+    FOREIGN KEY(dealer_id) REFERENCES dealers(dealer_id)
+    ON DELETE CASCADE
+);
+
+ALTER TABLE loan_submissions ADD COLUMN dealer_id TEXT REFERENCES dealers(dealer_id);
+ALTER TABLE loan_submissions ADD COLUMN agent_id TEXT REFERENCES dealer_agents(agent_id);
+
+CREATE INDEX IF NOT EXISTS idx_loan_submissions_dealer_id ON loan_submissions (dealer_id, created_at);
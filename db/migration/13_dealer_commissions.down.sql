@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_commission_ledger_statement;
+DROP TABLE IF EXISTS commission_ledger;
+DROP TABLE IF EXISTS dealer_commission_tiers;
+ALTER TABLE dealers DROP COLUMN clawback_months;
+ALTER TABLE dealers DROP COLUMN agent_commission_rate;
@@ -0,0 +1,34 @@
+ALTER TABLE dealers ADD COLUMN agent_commission_rate REAL NOT NULL DEFAULT 0 CHECK (agent_commission_rate >= 0 AND agent_commission_rate < 1);
+ALTER TABLE dealers ADD COLUMN clawback_months INTEGER NOT NULL DEFAULT 0 CHECK (clawback_months >= 0);
+
+CREATE TABLE IF NOT EXISTS dealer_commission_tiers (
+    dealer_id TEXT NOT NULL,
+    min_monthly_approvals INTEGER NOT NULL CHECK (min_monthly_approvals > 0),
+    commission_rate REAL NOT NULL CHECK (commission_rate >= 0 AND commission_rate < 1),
+    PRIMARY KEY (dealer_id, min_monthly_approvals),
+    FOREIGN KEY(dealer_id) REFERENCES dealers(dealer_id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS commission_ledger (
+    entry_id TEXT NOT NULL PRIMARY KEY,
+    submission_id TEXT NOT NULL,
+    dealer_id TEXT NOT NULL,
+    agent_id TEXT,
+    beneficiary_type TEXT NOT NULL CHECK (beneficiary_type IN ('DEALER', 'AGENT')),
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('ACCRUAL', 'CLAWBACK')),
+    statement_month TEXT NOT NULL,
+    loan_amount INTEGER NOT NULL,
+    commission_rate REAL NOT NULL,
+    monthly_approvals INTEGER,
+    amount INTEGER NOT NULL,
+    reason TEXT,
+    created_at INTEGER NOT NULL,
+    UNIQUE (submission_id, beneficiary_type, entry_type),
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(dealer_id) REFERENCES dealers(dealer_id),
+    FOREIGN KEY(agent_id) REFERENCES dealer_agents(agent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commission_ledger_statement ON commission_ledger (dealer_id, statement_month);
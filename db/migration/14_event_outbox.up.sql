@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS event_outbox (
+    event_id TEXT NOT NULL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    schema_version INTEGER NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_error TEXT,
+    published_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending
+ON event_outbox (created_at) WHERE published_at IS NULL;
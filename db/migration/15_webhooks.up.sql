@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id TEXT NOT NULL PRIMARY KEY,
+    dealer_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    FOREIGN KEY(dealer_id) REFERENCES dealers(dealer_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_dealer_id ON webhook_subscriptions (dealer_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id TEXT NOT NULL PRIMARY KEY,
+    subscription_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'SUCCEEDED', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_response_code INTEGER,
+    last_error TEXT,
+    redelivery_of TEXT,
+    created_at INTEGER NOT NULL,
+    completed_at INTEGER,
+    FOREIGN KEY(subscription_id) REFERENCES webhook_subscriptions(subscription_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, created_at);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    delivery_id TEXT NOT NULL,
+    attempt_number INTEGER NOT NULL,
+    attempted_at INTEGER NOT NULL,
+    response_code INTEGER,
+    error TEXT,
+    duration_ms INTEGER NOT NULL,
+    PRIMARY KEY (delivery_id, attempt_number),
+    FOREIGN KEY(delivery_id) REFERENCES webhook_deliveries(delivery_id)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_customer_id;
+DROP INDEX IF EXISTS idx_notifications_due;
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE loan_customers DROP COLUMN locale;
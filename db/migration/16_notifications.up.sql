@@ -0,0 +1,29 @@
+ALTER TABLE loan_customers ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
+
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id TEXT NOT NULL PRIMARY KEY,
+    customer_id TEXT NOT NULL,
+    submission_id TEXT,
+    template TEXT NOT NULL,
+    channel TEXT NOT NULL CHECK (channel IN ('EMAIL', 'SMS')),
+    locale TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    data TEXT NOT NULL,
+    dedupe_key TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'SENT', 'FAILED')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    subject TEXT,
+    body TEXT,
+    last_error TEXT,
+    created_at INTEGER NOT NULL,
+    sent_at INTEGER,
+    UNIQUE (dedupe_key, channel),
+    FOREIGN KEY(customer_id) REFERENCES loan_customers(customer_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due
+ON notifications (channel, next_attempt_at) WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_customer_id ON notifications (customer_id, created_at);
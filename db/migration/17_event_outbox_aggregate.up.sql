@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_event_outbox_aggregate_id
+ON event_outbox (aggregate_id, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE loan_disbursements DROP COLUMN payment_file_delivered_at;
+ALTER TABLE loan_disbursements DROP COLUMN payment_file_status;
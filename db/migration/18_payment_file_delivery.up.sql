@@ -0,0 +1,12 @@
+ALTER TABLE loan_disbursements ADD COLUMN payment_file_status TEXT
+CHECK (payment_file_status IN ('PENDING', 'DELIVERED', 'FAILED'));
+
+ALTER TABLE loan_disbursements ADD COLUMN payment_file_delivered_at INTEGER;
+
+-- Payment files of disbursements approved so far were either written with the
+-- approval or retried by approving again, so they have reached the outbox.
+UPDATE loan_disbursements
+SET payment_file_status = 'DELIVERED',
+    payment_file_delivered_at = reviewed_at
+WHERE status = 'APPROVED'
+AND payment_file IS NOT NULL;
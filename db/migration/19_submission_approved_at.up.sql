@@ -0,0 +1,12 @@
+ALTER TABLE loan_submissions ADD COLUMN approved_at INTEGER;
+
+-- Loans approved so far kept no approval time. The commission accrual is
+-- written with the approval; loans without one fall back to their last update.
+UPDATE loan_submissions
+SET approved_at = COALESCE((
+    SELECT MIN(entry.created_at)
+    FROM commission_ledger entry
+    WHERE entry.submission_id = loan_submissions.submission_id
+    AND entry.entry_type = 'ACCRUAL'
+), updated_at)
+WHERE loan_status IN ('APPROVED', 'DISBURSED');
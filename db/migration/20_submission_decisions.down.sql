@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_submission_decisions_submission_id;
+DROP TABLE IF EXISTS submission_decisions;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS submission_decisions (
+    submission_id TEXT NOT NULL,
+    decision TEXT NOT NULL CHECK (decision IN ('APPROVED', 'REJECTED', 'CANCELLED')),
+    decided_by TEXT NOT NULL,
+    decided_at INTEGER NOT NULL,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_submission_decisions_submission_id ON submission_decisions (submission_id, decided_at);
@@ -0,0 +1,2 @@
+-- The keys and flags recomputed by the up migration are those submitting
+-- writes, so there is nothing to undo.
@@ -0,0 +1,30 @@
+-- Migration 4 only stripped spaces, dashes and dots from the plates it keyed,
+-- where submissions key a plate by keeping nothing but its ASCII letters and
+-- digits, upper cased. Key every plate that way.
+UPDATE loan_submissions
+SET vehicle_license_key = (
+    WITH RECURSIVE plate(rest, key) AS (
+        SELECT loan_submissions.vehicle_license_number, ''
+        UNION ALL
+        SELECT substr(rest, 2), key || CASE
+            WHEN substr(rest, 1, 1) GLOB '[A-Za-z0-9]' THEN upper(substr(rest, 1, 1))
+            ELSE ''
+        END
+        FROM plate
+        WHERE rest <> ''
+    )
+    SELECT key FROM plate WHERE rest = ''
+);
+
+-- Flag the active submissions pledging a vehicle another customer has
+-- pledged, as submitting does, which neither migration 4 nor the old keys did.
+UPDATE loan_submissions
+SET is_duplicate_collateral = EXISTS (
+    SELECT 1
+    FROM loan_submissions other
+    WHERE other.vehicle_license_key = loan_submissions.vehicle_license_key
+    AND other.customer_id <> loan_submissions.customer_id
+    AND other.loan_status NOT IN ('REJECTED', 'CANCELLED', 'CLOSED')
+)
+WHERE vehicle_license_key <> ''
+AND loan_status NOT IN ('REJECTED', 'CANCELLED', 'CLOSED');
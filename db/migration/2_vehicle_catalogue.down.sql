@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS vehicle_models;
+DROP TABLE IF EXISTS vehicle_brands;
+DROP TABLE IF EXISTS vehicle_types;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS vehicle_types (
+    type_id TEXT NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS vehicle_brands (
+    brand_id TEXT NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS vehicle_models (
+    model_id TEXT NOT NULL PRIMARY KEY,
+    brand_id TEXT NOT NULL,
+    type_id TEXT NOT NULL,
+    name TEXT NOT NULL COLLATE NOCASE,
+    year_from INTEGER NOT NULL,
+    year_to INTEGER,
+    is_commercial BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (brand_id, name),
+    FOREIGN KEY(brand_id) REFERENCES vehicle_brands(brand_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(type_id) REFERENCES vehicle_types(type_id)
+    ON DELETE RESTRICT
+);
+
+INSERT INTO vehicle_types (type_id, name) VALUES
+    ('5b0f3c6e-3f4a-4c1e-9a55-0c1d2e3f4a01', 'Car'),
+    ('5b0f3c6e-3f4a-4c1e-9a55-0c1d2e3f4a02', 'Motorcycle'),
+    ('5b0f3c6e-3f4a-4c1e-9a55-0c1d2e3f4a03', 'Truck')
+ON CONFLICT (name) DO NOTHING;
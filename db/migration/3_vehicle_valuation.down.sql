@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS loan_submission_valuations;
+ALTER TABLE vehicle_models DROP COLUMN base_price;
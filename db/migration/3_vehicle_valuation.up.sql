@@ -0,0 +1,18 @@
+ALTER TABLE vehicle_models ADD COLUMN base_price INTEGER;
+
+CREATE TABLE IF NOT EXISTS loan_submission_valuations (
+    submission_id TEXT NOT NULL PRIMARY KEY,
+    model_id TEXT,
+    base_price INTEGER NOT NULL,
+    vehicle_age_years INTEGER NOT NULL,
+    age_factor REAL NOT NULL,
+    mileage_factor REAL NOT NULL,
+    commercial_factor REAL NOT NULL,
+    estimated_value INTEGER NOT NULL,
+    loan_to_value REAL NOT NULL,
+    valued_at INTEGER NOT NULL,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(model_id) REFERENCES vehicle_models(model_id)
+    ON DELETE SET NULL
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_loan_submissions_vehicle_license_key;
+ALTER TABLE loan_submissions DROP COLUMN is_duplicate_collateral;
+ALTER TABLE loan_submissions DROP COLUMN vehicle_license_key;
@@ -0,0 +1,8 @@
+ALTER TABLE loan_submissions ADD COLUMN vehicle_license_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE loan_submissions ADD COLUMN is_duplicate_collateral BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE loan_submissions
+SET vehicle_license_key = UPPER(REPLACE(REPLACE(REPLACE(vehicle_license_number, ' ', ''), '-', ''), '.', ''));
+
+CREATE INDEX IF NOT EXISTS idx_loan_submissions_vehicle_license_key
+ON loan_submissions (vehicle_license_key);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS loan_submission_quotes;
+ALTER TABLE loan_submissions DROP COLUMN product_id;
+DROP TABLE IF EXISTS loan_product_rates;
+DROP TABLE IF EXISTS loan_product_tenures;
+DROP TABLE IF EXISTS loan_products;
@@ -0,0 +1,80 @@
+CREATE TABLE IF NOT EXISTS loan_products (
+    product_id TEXT NOT NULL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    vehicle_type TEXT COLLATE NOCASE,
+    is_commercial BOOLEAN NOT NULL DEFAULT FALSE,
+    min_amount INTEGER NOT NULL DEFAULT 0,
+    max_amount INTEGER NOT NULL,
+    min_vehicle_age_years INTEGER NOT NULL DEFAULT 0,
+    max_vehicle_age_years INTEGER NOT NULL,
+    admin_fee INTEGER NOT NULL DEFAULT 0,
+    provision_fee_rate REAL NOT NULL DEFAULT 0,
+    insurance_fee_rate REAL NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS loan_product_tenures (
+    product_id TEXT NOT NULL,
+    tenure_month INTEGER NOT NULL,
+    PRIMARY KEY (product_id, tenure_month),
+    FOREIGN KEY(product_id) REFERENCES loan_products(product_id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS loan_product_rates (
+    product_id TEXT NOT NULL,
+    max_tenure_month INTEGER NOT NULL,
+    max_vehicle_age_years INTEGER NOT NULL,
+    annual_interest_rate REAL NOT NULL,
+    PRIMARY KEY (product_id, max_tenure_month, max_vehicle_age_years),
+    FOREIGN KEY(product_id) REFERENCES loan_products(product_id)
+    ON DELETE CASCADE
+);
+
+ALTER TABLE loan_submissions ADD COLUMN product_id TEXT REFERENCES loan_products(product_id);
+
+CREATE TABLE IF NOT EXISTS loan_submission_quotes (
+    submission_id TEXT NOT NULL PRIMARY KEY,
+    product_id TEXT NOT NULL,
+    annual_interest_rate REAL NOT NULL,
+    tenure_month INTEGER NOT NULL,
+    principal INTEGER NOT NULL,
+    monthly_installment INTEGER NOT NULL,
+    total_interest INTEGER NOT NULL,
+    admin_fee INTEGER NOT NULL,
+    provision_fee INTEGER NOT NULL,
+    insurance_fee INTEGER NOT NULL,
+    total_fees INTEGER NOT NULL,
+    total_cost INTEGER NOT NULL,
+    quoted_at INTEGER NOT NULL,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(product_id) REFERENCES loan_products(product_id)
+);
+
+INSERT INTO loan_products (product_id, code, name, vehicle_type, is_commercial, min_amount, max_amount,
+    min_vehicle_age_years, max_vehicle_age_years, admin_fee, provision_fee_rate, insurance_fee_rate) VALUES
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 'NEW_CAR', 'New Car Loan', 'Car', FALSE, 5000, 1000000, 0, 1, 250, 0.01, 0.025),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 'USED_CAR', 'Used Car Loan', 'Car', FALSE, 2500, 500000, 1, 12, 250, 0.015, 0.03),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 'MOTORCYCLE', 'Motorcycle Loan', 'Motorcycle', FALSE, 500, 50000, 0, 8, 50, 0.01, 0.02),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 'COMMERCIAL', 'Commercial Vehicle Loan', NULL, TRUE, 10000, 2000000, 0, 10, 500, 0.02, 0.035);
+
+INSERT INTO loan_product_tenures (product_id, tenure_month) VALUES
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 12), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 24),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 36), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 48),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 60),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 12), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 24),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 36), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 48),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 6), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 12),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 24), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 36),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 12), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 24),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 36), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 48);
+
+INSERT INTO loan_product_rates (product_id, max_tenure_month, max_vehicle_age_years, annual_interest_rate) VALUES
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 24, 1, 0.065), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 60, 1, 0.075),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 24, 5, 0.095), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 48, 5, 0.105),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 24, 12, 0.12), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 48, 12, 0.13),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 12, 8, 0.14), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 36, 8, 0.16),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 24, 5, 0.11), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 48, 5, 0.12),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 24, 10, 0.13), ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 48, 10, 0.14);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS loan_product_required_documents;
+DROP TABLE IF EXISTS documents;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS documents (
+    document_id TEXT NOT NULL PRIMARY KEY,
+    customer_id TEXT NOT NULL,
+    submission_id TEXT,
+    document_type TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    uploaded_at INTEGER NOT NULL,
+    FOREIGN KEY(customer_id) REFERENCES loan_customers(customer_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_customer_id ON documents (customer_id);
+CREATE INDEX IF NOT EXISTS idx_documents_submission_id ON documents (submission_id);
+
+CREATE TABLE IF NOT EXISTS loan_product_required_documents (
+    product_id TEXT NOT NULL,
+    document_type TEXT NOT NULL,
+    PRIMARY KEY (product_id, document_type),
+    FOREIGN KEY(product_id) REFERENCES loan_products(product_id)
+    ON DELETE CASCADE
+);
+
+INSERT INTO loan_product_required_documents (product_id, document_type) VALUES
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 'ID_CARD'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', 'PROOF_OF_INCOME'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 'ID_CARD'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 'PROOF_OF_INCOME'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 'STNK'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 'BPKB'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002', 'VEHICLE_PHOTO'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 'ID_CARD'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003', 'STNK'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 'ID_CARD'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 'PROOF_OF_INCOME'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 'STNK'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 'BPKB'),
+    ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004', 'VEHICLE_PHOTO');
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS submission_parties (
+    submission_id TEXT NOT NULL,
+    customer_id TEXT NOT NULL,
+    party_role TEXT NOT NULL CHECK (party_role IN ('PRIMARY', 'CO_APPLICANT', 'GUARANTOR')),
+    PRIMARY KEY (submission_id, customer_id),
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE,
+    FOREIGN KEY(customer_id) REFERENCES loan_customers(customer_id)
+    ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_submission_parties_primary
+ON submission_parties (submission_id) WHERE party_role = 'PRIMARY';
+
+CREATE INDEX IF NOT EXISTS idx_submission_parties_customer_id
+ON submission_parties (customer_id);
+
+INSERT INTO submission_parties (submission_id, customer_id, party_role)
+SELECT submission_id, customer_id, 'PRIMARY'
+FROM loan_submissions;
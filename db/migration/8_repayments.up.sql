@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS loan_installments (
+    submission_id TEXT NOT NULL,
+    installment_number INTEGER NOT NULL,
+    due_date TEXT NOT NULL,
+    principal_due INTEGER NOT NULL,
+    interest_due INTEGER NOT NULL,
+    fee_due INTEGER NOT NULL,
+    principal_paid INTEGER NOT NULL DEFAULT 0,
+    interest_paid INTEGER NOT NULL DEFAULT 0,
+    fee_paid INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (submission_id, installment_number),
+    CHECK (principal_paid <= principal_due AND interest_paid <= interest_due AND fee_paid <= fee_due),
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_installments_due_date ON loan_installments (due_date);
+
+CREATE TABLE IF NOT EXISTS loan_payments (
+    payment_id TEXT NOT NULL PRIMARY KEY,
+    submission_id TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    applied_amount INTEGER NOT NULL,
+    unapplied_amount INTEGER NOT NULL DEFAULT 0,
+    paid_on TEXT NOT NULL,
+    reference TEXT,
+    created_at INTEGER NOT NULL,
+    UNIQUE (submission_id, reference),
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_payments_submission_id ON loan_payments (submission_id);
+
+CREATE TABLE IF NOT EXISTS loan_payment_allocations (
+    payment_id TEXT NOT NULL,
+    installment_number INTEGER NOT NULL,
+    fee_amount INTEGER NOT NULL DEFAULT 0,
+    interest_amount INTEGER NOT NULL DEFAULT 0,
+    principal_amount INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (payment_id, installment_number),
+    FOREIGN KEY(payment_id) REFERENCES loan_payments(payment_id)
+    ON DELETE CASCADE
+);
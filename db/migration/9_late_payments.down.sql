@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS loan_delinquencies;
+ALTER TABLE loan_products DROP COLUMN penalty_grace_days;
+ALTER TABLE loan_products DROP COLUMN late_penalty_daily_rate;
+ALTER TABLE loan_products DROP COLUMN late_fee;
+ALTER TABLE loan_payment_allocations DROP COLUMN penalty_amount;
+ALTER TABLE loan_installments DROP COLUMN penalty_accrued_through;
+ALTER TABLE loan_installments DROP COLUMN overdue_since;
+ALTER TABLE loan_installments DROP COLUMN penalty_paid;
+ALTER TABLE loan_installments DROP COLUMN penalty_due;
@@ -0,0 +1,33 @@
+ALTER TABLE loan_installments ADD COLUMN penalty_due INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE loan_installments ADD COLUMN penalty_paid INTEGER NOT NULL DEFAULT 0 CHECK (penalty_paid <= penalty_due);
+ALTER TABLE loan_installments ADD COLUMN overdue_since TEXT;
+ALTER TABLE loan_installments ADD COLUMN penalty_accrued_through TEXT;
+
+ALTER TABLE loan_payment_allocations ADD COLUMN penalty_amount INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE loan_products ADD COLUMN late_fee INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE loan_products ADD COLUMN late_penalty_daily_rate REAL NOT NULL DEFAULT 0;
+ALTER TABLE loan_products ADD COLUMN penalty_grace_days INTEGER NOT NULL DEFAULT 0;
+
+UPDATE loan_products SET late_fee = 25, late_penalty_daily_rate = 0.001, penalty_grace_days = 3
+WHERE product_id IN ('8d2c1a40-6b1e-4f0a-9c7d-2e5f00000001', '8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002');
+UPDATE loan_products SET late_fee = 10, late_penalty_daily_rate = 0.001, penalty_grace_days = 3
+WHERE product_id = '8d2c1a40-6b1e-4f0a-9c7d-2e5f00000003';
+UPDATE loan_products SET late_fee = 50, late_penalty_daily_rate = 0.0015, penalty_grace_days = 5
+WHERE product_id = '8d2c1a40-6b1e-4f0a-9c7d-2e5f00000004';
+
+CREATE TABLE IF NOT EXISTS loan_delinquencies (
+    submission_id TEXT NOT NULL PRIMARY KEY,
+    as_of TEXT NOT NULL,
+    days_past_due INTEGER NOT NULL,
+    bucket TEXT NOT NULL,
+    overdue_amount INTEGER NOT NULL,
+    overdue_installments INTEGER NOT NULL,
+    outstanding_amount INTEGER NOT NULL,
+    penalty_outstanding INTEGER NOT NULL,
+    evaluated_at INTEGER NOT NULL,
+    FOREIGN KEY(submission_id) REFERENCES loan_submissions(submission_id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_delinquencies_bucket ON loan_delinquencies (bucket);
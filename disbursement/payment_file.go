@@ -0,0 +1,76 @@
+package disbursement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	bankCodePattern      = regexp.MustCompile(`^[A-Z0-9]{3,11}$`)
+	accountNumberPattern = regexp.MustCompile(`^[0-9]{6,20}$`)
+)
+
+var paymentFileHeader = []string{
+	"reference",
+	"value_date",
+	"bank_code",
+	"account_number",
+	"account_holder",
+	"amount",
+	"narrative",
+}
+
+// PaymentInstruction is one credit transfer in a bank payment file.
+type PaymentInstruction struct {
+	Reference     string
+	ValueDate     time.Time
+	BankCode      string
+	AccountNumber string
+	AccountHolder string
+	Amount        int
+	Narrative     string
+}
+
+func ValidateBankAccount(bankCode, accountNumber string) error {
+	if !bankCodePattern.MatchString(bankCode) {
+		return fmt.Errorf("bank_code must be 3 to 11 upper case letters or digits")
+	}
+	if !accountNumberPattern.MatchString(accountNumber) {
+		return fmt.Errorf("account_number must be 6 to 20 digits")
+	}
+	return nil
+}
+
+// PaymentFileName is the outbox key of the payment file of a disbursement.
+func PaymentFileName(reference string, valueDate time.Time) string {
+	return fmt.Sprintf("%s/payment-%s.csv", valueDate.Format("20060102"), reference)
+}
+
+// WritePaymentFile writes the instructions as the CSV layout the bank's bulk
+// transfer upload accepts, amounts in whole currency units.
+func WritePaymentFile(w io.Writer, instructions []PaymentInstruction) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(paymentFileHeader); err != nil {
+		return err
+	}
+	for _, instruction := range instructions {
+		err := writer.Write([]string{
+			instruction.Reference,
+			instruction.ValueDate.Format(time.DateOnly),
+			instruction.BankCode,
+			instruction.AccountNumber,
+			instruction.AccountHolder,
+			strconv.Itoa(instruction.Amount),
+			instruction.Narrative,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
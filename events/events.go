@@ -0,0 +1,110 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeCustomerUpserted        = "customer.upserted"
+	TypeCustomerDeleted         = "customer.deleted"
+	TypeSubmissionCreated       = "submission.created"
+	TypeSubmissionStatusChanged = "submission.status_changed"
+
+	// Dealer webhooks receive these instead of submission.status_changed.
+	TypeSubmissionApproved = "submission.approved"
+	TypeSubmissionRejected = "submission.rejected"
+)
+
+// SchemaVersions holds the current data schema of every event type. Adding an
+// optional field keeps the version; removing a field or changing its meaning
+// needs a new version and a new file under schemas.
+var SchemaVersions = map[string]int{
+	TypeCustomerUpserted:        1,
+	TypeCustomerDeleted:         1,
+	TypeSubmissionCreated:       1,
+	TypeSubmissionStatusChanged: 1,
+	TypeSubmissionApproved:      1,
+	TypeSubmissionRejected:      1,
+}
+
+// Envelope is what sinks receive. Delivery is at least once, so consumers
+// should ignore event ids they have already processed.
+type Envelope struct {
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int             `json:"schema_version"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    string          `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+type CustomerUpsertedV1 struct {
+	CustomerID  string  `json:"customer_id"`
+	FullName    string  `json:"full_name"`
+	PhoneNumber string  `json:"phone_number"`
+	Email       *string `json:"email"`
+	AddressCity string  `json:"address_city"`
+}
+
+type CustomerDeletedV1 struct {
+	CustomerID string `json:"customer_id"`
+}
+
+type SubmissionCreatedV1 struct {
+	SubmissionID       string  `json:"submission_id"`
+	CustomerID         string  `json:"customer_id"`
+	ProductID          *string `json:"product_id"`
+	DealerID           *string `json:"dealer_id"`
+	AgentID            *string `json:"agent_id"`
+	VehicleType        string  `json:"vehicle_type"`
+	VehicleBrand       string  `json:"vehicle_brand"`
+	VehicleModel       string  `json:"vehicle_model"`
+	ManufacturingYear  int     `json:"manufacturing_year"`
+	ProposedLoanAmount int     `json:"proposed_loan_amount"`
+	ProposedLoanTenure int     `json:"proposed_loan_tenure_month"`
+	LoanStatus         string  `json:"loan_status"`
+}
+
+type SubmissionStatusChangedV1 struct {
+	SubmissionID string  `json:"submission_id"`
+	CustomerID   string  `json:"customer_id"`
+	DealerID     *string `json:"dealer_id"`
+	FromStatus   string  `json:"from_status"`
+	ToStatus     string  `json:"to_status"`
+}
+
+// SubmissionDecidedV1 is the data of submission.approved and
+// submission.rejected.
+type SubmissionDecidedV1 struct {
+	SubmissionID       string  `json:"submission_id"`
+	DealerID           string  `json:"dealer_id"`
+	AgentID            *string `json:"agent_id"`
+	LoanStatus         string  `json:"loan_status"`
+	ProposedLoanAmount int     `json:"proposed_loan_amount"`
+	ProposedLoanTenure int     `json:"proposed_loan_tenure_month"`
+}
+
+// New wraps data in an envelope carrying the current schema version of
+// eventType.
+func New(eventType, aggregateID string, occurredAt time.Time, data any) (*Envelope, error) {
+	version, ok := SchemaVersions[eventType]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		EventID:       uuid.New().String(),
+		EventType:     eventType,
+		SchemaVersion: version,
+		AggregateID:   aggregateID,
+		OccurredAt:    occurredAt.UTC().Format(time.RFC3339),
+		Data:          raw,
+	}, nil
+}
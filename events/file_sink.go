@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink appends messages to a newline-delimited JSON file, mostly for local
+// development and replaying into tools that read files.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Publish(_ context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := append(append([]byte{}, message.Body...), '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSink publishes on <prefix>.<event type> with the NATS client. Each
+// publish is flushed, so it only counts once the server has answered the PING
+// that follows it and processed the message. The client reconnects on its
+// own; messages are not buffered while it does, so the relay retries them.
+type NATSSink struct {
+	Prefix  string
+	Timeout time.Duration
+
+	server  string
+	options []nats.Option
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+// NewNATSSink reads the sink from a nats:// or tls:// URL. The user info
+// holds a user and password or a token, the path the subject prefix, and the
+// query optionally:
+//
+//	ca=<file>              trusts the CA certificates in file, implies TLS
+//	cert=<file>&key=<file> authenticates with a client certificate
+//	creds=<file>           authenticates with a user credentials file
+func NewNATSSink(u *url.URL) (*NATSSink, error) {
+	query := u.Query()
+	server := &url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	sink := &NATSSink{
+		Prefix:  strings.Trim(strings.ReplaceAll(u.Path, "/", "."), "."),
+		Timeout: 10 * time.Second,
+		server:  server.String(),
+		options: []nats.Option{
+			nats.Name("alphaloan-vehicle"),
+			nats.MaxReconnects(-1),
+			nats.ReconnectBufSize(-1),
+		},
+	}
+
+	if ca := query.Get("ca"); ca != "" {
+		sink.options = append(sink.options, nats.RootCAs(ca))
+	}
+	cert, key := query.Get("cert"), query.Get("key")
+	if (cert == "") != (key == "") {
+		return nil, errors.New("nats sink needs both cert and key for a client certificate")
+	}
+	if cert != "" {
+		sink.options = append(sink.options, nats.ClientCert(cert, key))
+	}
+	if creds := query.Get("creds"); creds != "" {
+		sink.options = append(sink.options, nats.UserCredentials(creds))
+	}
+	return sink, nil
+}
+
+func (s *NATSSink) Subject(eventType string) string {
+	if s.Prefix == "" {
+		return eventType
+	}
+	return s.Prefix + "." + eventType
+}
+
+func (s *NATSSink) Publish(ctx context.Context, message Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+	if err := conn.Publish(s.Subject(message.EventType), message.Body); err != nil {
+		return err
+	}
+	return conn.FlushWithContext(ctx)
+}
+
+// connection connects on first use, so the service starts while the server
+// is down. Once connected the client takes care of reconnecting.
+func (s *NATSSink) connection() (*nats.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil && !s.conn.IsClosed() {
+		return s.conn, nil
+	}
+
+	conn, err := nats.Connect(s.server, append(s.options, nats.Timeout(s.Timeout))...)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type natsPublish struct {
+	Subject string
+	Body    string
+}
+
+// fakeNATSServer speaks enough of the NATS protocol for a publisher: it
+// greets with INFO, answers PING with PONG and reports every PUB. Drop closes
+// the open connections, as a server restart would.
+type fakeNATSServer struct {
+	listener  net.Listener
+	connects  chan string
+	published chan natsPublish
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newFakeNATSServer(t *testing.T) *fakeNATSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeNATSServer{
+		listener:  listener,
+		connects:  make(chan string, 10),
+		published: make(chan natsPublish, 10),
+	}
+	t.Cleanup(func() {
+		listener.Close()
+		server.Drop()
+	})
+	go server.serve()
+	return server
+}
+
+func (s *fakeNATSServer) URL(userinfo, prefix string) *url.URL {
+	u, _ := url.Parse(fmt.Sprintf("nats://%s%s/%s", userinfo, s.listener.Addr(), prefix))
+	return u
+}
+
+func (s *fakeNATSServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNATSServer) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576,\"headers\":true}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch verb, args, _ := strings.Cut(line, " "); verb {
+		case "CONNECT":
+			s.connects <- args
+		case "PING":
+			conn.Write([]byte("PONG\r\n"))
+		case "PUB":
+			fields := strings.Fields(args)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			body := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, body); err != nil {
+				return
+			}
+			s.published <- natsPublish{Subject: fields[0], Body: string(body[:size])}
+		}
+	}
+}
+
+func (s *fakeNATSServer) Drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func newTestNATSSink(t *testing.T, u *url.URL) *NATSSink {
+	sink, err := NewNATSSink(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Timeout = 2 * time.Second
+	sink.options = append(sink.options, nats.ReconnectWait(10*time.Millisecond))
+	t.Cleanup(func() { sink.Close() })
+	return sink
+}
+
+func TestNATSSinkPublishes(t *testing.T) {
+	server := newFakeNATSServer(t)
+	sink := newTestNATSSink(t, server.URL("s3cret@", "alphaloan/events"))
+
+	err := sink.Publish(context.Background(), Message{EventType: TypeSubmissionCreated, Body: []byte(`{"a":1}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connect := <-server.connects; !strings.Contains(connect, `"auth_token":"s3cret"`) {
+		t.Errorf("CONNECT %s, want the token from the URL", connect)
+	}
+	published := <-server.published
+	if want := "alphaloan.events." + TypeSubmissionCreated; published.Subject != want || published.Body != `{"a":1}` {
+		t.Errorf("published %+v, want %s with the message body", published, want)
+	}
+}
+
+func TestNATSSinkReconnects(t *testing.T) {
+	server := newFakeNATSServer(t)
+	sink := newTestNATSSink(t, server.URL("", ""))
+	message := Message{EventType: TypeSubmissionCreated, Body: []byte(`{}`)}
+	if err := sink.Publish(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+	<-server.published
+
+	server.Drop()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := sink.Publish(context.Background(), message)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("publishing after the server dropped the connection: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	select {
+	case <-server.published:
+	case <-time.After(time.Second):
+		t.Fatal("nothing was published after reconnecting")
+	}
+}
+
+func TestNATSSinkFailsWithoutServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("nats://" + listener.Addr().String())
+	listener.Close()
+
+	sink := newTestNATSSink(t, u)
+	if err := sink.Publish(context.Background(), Message{EventType: TypeSubmissionCreated}); err == nil {
+		t.Fatal("published without a server")
+	}
+}
+
+func TestNewNATSSinkNeedsCertAndKey(t *testing.T) {
+	u, _ := url.Parse("tls://nats.internal:4222/alphaloan?cert=client.pem")
+	if _, err := NewNATSSink(u); err == nil {
+		t.Error("a client certificate without a key was accepted")
+	}
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+type SchemaInfo struct {
+	EventType     string
+	SchemaVersion int
+}
+
+// Schema returns the JSON Schema of the data of an event type at a version.
+func Schema(eventType string, version int) ([]byte, error) {
+	return schemaFiles.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+}
+
+// CurrentSchemas lists the event types with the version currently emitted.
+func CurrentSchemas() []SchemaInfo {
+	schemas := make([]SchemaInfo, 0, len(SchemaVersions))
+	for eventType, version := range SchemaVersions {
+		schemas = append(schemas, SchemaInfo{EventType: eventType, SchemaVersion: version})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].EventType < schemas[j].EventType })
+	return schemas
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const maskRune = '*'
+
+// MaskTail hides all but the last keep characters, e.g. the id card number
+// 3171001234 becomes ******1234.
+func MaskTail(value string, keep int) string {
+	length := utf8.RuneCountInString(value)
+	if length <= keep {
+		return strings.Repeat(string(maskRune), length)
+	}
+	runes := []rune(value)
+	return strings.Repeat(string(maskRune), length-keep) + string(runes[length-keep:])
+}
+
+// MaskName keeps the initial of every word: "Ani Rahma" becomes "A** R****".
+func MaskName(value string) string {
+	words := strings.Fields(value)
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(runes[0]) + strings.Repeat(string(maskRune), len(runes)-1)
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskEmail keeps the first character of the local part and the domain, so
+// ani@example.com becomes a**@example.com.
+func MaskEmail(value string) string {
+	local, domain, ok := strings.Cut(value, "@")
+	if !ok {
+		return MaskTail(value, 0)
+	}
+	return MaskName(local) + "@" + domain
+}
+
+// MaskDate keeps the year of a YYYY-MM-DD date.
+func MaskDate(value string) string {
+	if len(value) < 4 {
+		return MaskTail(value, 0)
+	}
+	return value[:4] + "-**-**"
+}
+
+// MaskAll hides the value entirely but keeps its length visible.
+func MaskAll(value string) string {
+	return MaskTail(value, 0)
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+var Formats = []string{FormatCSV, FormatXLSX, FormatNDJSON}
+
+// Writer streams a table one row at a time. Values are strings, integers,
+// floats, booleans or nil for an empty cell. Close must be called to finish
+// the file; nothing is buffered beyond the underlying writer's own buffer.
+type Writer interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// NewWriter starts a file in format with a header of columns.
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{writer: bufio.NewWriter(w), columns: columns}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, record: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	for i, value := range values {
+		c.record[i] = formatValue(value)
+	}
+	return c.writer.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// ndjsonWriter writes one JSON object per line with the keys in column
+// order, which encoding a map would not keep.
+type ndjsonWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+func (n *ndjsonWriter) WriteRow(values []any) error {
+	n.writer.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			n.writer.WriteByte(',')
+		}
+		key, _ := json.Marshal(n.columns[i])
+		n.writer.Write(key)
+		n.writer.WriteByte(':')
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		n.writer.Write(encoded)
+	}
+	// bufio keeps the first write error, so a client that went away stops
+	// the export at the next row.
+	_, err := n.writer.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.writer.Flush()
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,165 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	DefaultMaxDepth      = 10
+	DefaultMaxComplexity = 10000
+
+	// listEstimate is the number of items a list without a page size, such
+	// as the documents of a submission, is assumed to hold.
+	listEstimate = 10
+)
+
+// queryCost measures an operation before it runs. Its depth is the deepest
+// nesting of fields and its complexity the number of fields it is expected
+// to resolve: a field counts once, times the page size for the fields under
+// a list query and times listEstimate under other lists. Introspection is
+// bounded by the schema and is not counted.
+type queryCost struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	defaults  map[string]ast.Value
+}
+
+// checkLimits returns an error when the operation to run is nested deeper
+// than maxDepth or more complex than maxComplexity. A limit of 0 is off.
+func checkLimits(schema *graphql.Schema, document *ast.Document, operationName string, variables map[string]any,
+	maxDepth, maxComplexity int) error {
+	cost := &queryCost{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		defaults:  make(map[string]ast.Value),
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			cost.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	// An operation that cannot be chosen is left for the executor to refuse.
+	if operation == nil {
+		return nil
+	}
+	for _, variable := range operation.VariableDefinitions {
+		cost.defaults[variable.Variable.Name.Value] = variable.DefaultValue
+	}
+
+	depth, complexity := cost.selectionSet(schema.QueryType(), operation.SelectionSet, 0)
+	if maxDepth > 0 && depth > maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxDepth)
+	}
+	if maxComplexity > 0 && complexity > maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, maxComplexity)
+	}
+	return nil
+}
+
+// selectionSet returns the depth and complexity of the selections on parent.
+// pageSize is the size of the lists directly under parent, 0 when they are
+// not paginated.
+func (c *queryCost) selectionSet(parent *graphql.Object, set *ast.SelectionSet, pageSize int) (int, int) {
+	if parent == nil || set == nil {
+		return 0, 0
+	}
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, n int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, n = c.field(parent, selection, pageSize)
+		case *ast.InlineFragment:
+			d, n = c.selectionSet(c.typeCondition(parent, selection.TypeCondition), selection.SelectionSet, pageSize)
+		case *ast.FragmentSpread:
+			if fragment := c.fragments[selection.Name.Value]; fragment != nil {
+				d, n = c.selectionSet(c.typeCondition(parent, fragment.TypeCondition), fragment.SelectionSet, pageSize)
+			}
+		}
+		depth = max(depth, d)
+		complexity += n
+	}
+	return depth, complexity
+}
+
+func (c *queryCost) field(parent *graphql.Object, field *ast.Field, pageSize int) (int, int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	definition := parent.Fields()[field.Name.Value]
+	if definition == nil {
+		return 0, 0
+	}
+
+	childPageSize := 0
+	for _, arg := range definition.Args {
+		if arg.Name() == "first" {
+			childPageSize = c.pageSize(field)
+		}
+	}
+	object, _ := graphql.GetNamed(definition.Type).(*graphql.Object)
+	depth, complexity := c.selectionSet(object, field.SelectionSet, childPageSize)
+
+	items := 1
+	if isList(definition.Type) {
+		items = listEstimate
+		if pageSize > 0 {
+			items = pageSize
+		}
+	}
+	return depth + 1, 1 + items*complexity
+}
+
+// pageSize is the first argument of a list query as it will be applied.
+func (c *queryCost) pageSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		value := arg.Value
+		if variable, ok := value.(*ast.Variable); ok {
+			switch given := c.variables[variable.Name.Value].(type) {
+			case float64:
+				return min(max(int(given), 0), MaxPageSize)
+			case int:
+				return min(max(given, 0), MaxPageSize)
+			}
+			value = c.defaults[variable.Name.Value]
+		}
+		if literal, ok := value.(*ast.IntValue); ok {
+			if first, err := strconv.Atoi(literal.Value); err == nil {
+				return min(max(first, 0), MaxPageSize)
+			}
+		}
+	}
+	return DefaultPageSize
+}
+
+func (c *queryCost) typeCondition(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := c.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+func isList(fieldType graphql.Type) bool {
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	_, ok := fieldType.(*graphql.List)
+	return ok
+}
@@ -0,0 +1,153 @@
+package graphqlapi
+
+import (
+	"context"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+// loader batches the keys asked for while one level of a query resolves and
+// fetches them with a single call when the first of them is needed. The
+// executor completes a level breadth first before it calls any thunk of the
+// next, so every sibling has registered its key by then. A loader belongs
+// to one query and is not safe for concurrent use.
+type loader[V any] struct {
+	fetch   func(keys []string) (map[string]V, error)
+	pending []string
+	queued  map[string]bool
+	values  map[string]V
+	errs    map[string]error
+}
+
+func newLoader[V any](fetch func(keys []string) (map[string]V, error)) *loader[V] {
+	return &loader[V]{
+		fetch:  fetch,
+		queued: make(map[string]bool),
+		values: make(map[string]V),
+		errs:   make(map[string]error),
+	}
+}
+
+// load queues key and returns a function that fetches the batch it is in
+// unless that has happened already. Keys that are not found resolve to the
+// zero value.
+func (l *loader[V]) load(key string) func() (V, error) {
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	return func() (V, error) {
+		if len(l.pending) > 0 {
+			l.dispatch()
+		}
+		return l.values[key], l.errs[key]
+	}
+}
+
+// then turns a load into the thunk the executor resolves breadth first,
+// converting the loaded value with fn.
+func then[V any](load func() (V, error), fn func(V) any) func() (any, error) {
+	return func() (any, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return fn(value), nil
+	}
+}
+
+// prime stores a value read by other means, such as a list query, so that
+// it is not fetched again.
+func (l *loader[V]) prime(key string, value V) {
+	l.queued[key] = true
+	l.values[key] = value
+}
+
+func (l *loader[V]) dispatch() {
+	keys := l.pending
+	l.pending = nil
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
+
+// loaders holds the loaders of one query.
+type loaders struct {
+	customers           *loader[*datastore.LoanCustomerRow]
+	submissions         *loader[*datastore.LoanSubmissionRow]
+	customerSubmissions *loader[[]*datastore.PartySubmissionRow]
+	parties             *loader[[]*datastore.SubmissionPartyRow]
+	customerDocuments   *loader[[]*datastore.DocumentRow]
+	submissionDocuments *loader[[]*datastore.DocumentRow]
+	statusHistory       *loader[[]*datastore.SubmissionStatusChangeRow]
+}
+
+func (s *Server) newLoaders() *loaders {
+	return &loaders{
+		customers: newLoader(func(ids []string) (map[string]*datastore.LoanCustomerRow, error) {
+			rows, err := s.CustomerStore.GetLoanCustomersByIds(ids)
+			return indexBy(rows, err, func(row *datastore.LoanCustomerRow) string { return row.CustomerID })
+		}),
+		submissions: newLoader(func(ids []string) (map[string]*datastore.LoanSubmissionRow, error) {
+			rows, err := s.SubmissionStore.GetLoanSubmissionsByIds(ids)
+			return indexBy(rows, err, func(row *datastore.LoanSubmissionRow) string { return row.SubmissionID })
+		}),
+		customerSubmissions: newLoader(func(ids []string) (map[string][]*datastore.PartySubmissionRow, error) {
+			rows, err := s.SubmissionStore.GetPartySubmissionsByCustomerIds(ids)
+			return groupBy(rows, err, func(row *datastore.PartySubmissionRow) string { return row.CustomerID })
+		}),
+		parties: newLoader(func(ids []string) (map[string][]*datastore.SubmissionPartyRow, error) {
+			rows, err := s.PartyStore.GetSubmissionPartiesBySubmissionIds(ids)
+			return groupBy(rows, err, func(row *datastore.SubmissionPartyRow) string { return row.SubmissionID })
+		}),
+		customerDocuments: newLoader(func(ids []string) (map[string][]*datastore.DocumentRow, error) {
+			rows, err := s.DocumentStore.GetDocumentsByCustomerIds(ids)
+			return groupBy(rows, err, func(row *datastore.DocumentRow) string { return row.CustomerID })
+		}),
+		submissionDocuments: newLoader(func(ids []string) (map[string][]*datastore.DocumentRow, error) {
+			rows, err := s.DocumentStore.GetDocumentsBySubmissionIds(ids)
+			return groupBy(rows, err, func(row *datastore.DocumentRow) string { return row.SubmissionID.String })
+		}),
+		statusHistory: newLoader(func(ids []string) (map[string][]*datastore.SubmissionStatusChangeRow, error) {
+			rows, err := s.OutboxStore.GetSubmissionStatusHistory(ids)
+			return groupBy(rows, err, func(row *datastore.SubmissionStatusChangeRow) string { return row.SubmissionID })
+		}),
+	}
+}
+
+func indexBy[V any](rows []V, err error, key func(V) string) (map[string]V, error) {
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]V, len(rows))
+	for _, row := range rows {
+		index[key(row)] = row
+	}
+	return index, nil
+}
+
+func groupBy[V any](rows []V, err error, key func(V) string) (map[string][]V, error) {
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string][]V)
+	for _, row := range rows {
+		groups[key(row)] = append(groups[key(row)], row)
+	}
+	return groups, nil
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,522 @@
+package graphqlapi
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/export"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// submissionNode is the source of a Submission. PartyRole is the role of the
+// customer the submission was reached through, if any.
+type submissionNode struct {
+	row       *datastore.LoanSubmissionRow
+	partyRole *string
+}
+
+type connection struct {
+	nodes       any
+	totalCount  int
+	endCursor   *string
+	hasNextPage bool
+}
+
+// longType carries amounts and unix timestamps, which Int, being 32 bits,
+// cannot hold in full.
+var longType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Long",
+	Description: "A 64-bit integer.",
+	Serialize: func(value any) any {
+		switch value := value.(type) {
+		case int:
+			return int64(value)
+		case int64:
+			return value
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		switch value := value.(type) {
+		case int:
+			return int64(value)
+		case int64:
+			return value
+		case float64:
+			if value == math.Trunc(value) && math.Abs(value) <= 1<<53 {
+				return int64(value)
+			}
+		}
+		return nil
+	},
+	ParseLiteral: func(value ast.Value) any {
+		if value, ok := value.(*ast.IntValue); ok {
+			if n, err := strconv.ParseInt(value.Value, 10, 64); err == nil {
+				return n
+			}
+		}
+		return nil
+	},
+})
+
+func (s *Server) buildSchema() (graphql.Schema, error) {
+	var customerType, submissionType *graphql.Object
+
+	documentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Document",
+		Fields: graphql.Fields{
+			"document_id":   fieldOf(graphql.NewNonNull(graphql.ID), func(row *datastore.DocumentRow) any { return row.DocumentID }),
+			"customer_id":   fieldOf(graphql.NewNonNull(graphql.ID), func(row *datastore.DocumentRow) any { return row.CustomerID }),
+			"submission_id": fieldOf(graphql.ID, func(row *datastore.DocumentRow) any { return nullString(row.SubmissionID) }),
+			"document_type": fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.DocumentRow) any { return row.DocumentType }),
+			"file_name":     fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.DocumentRow) any { return row.FileName }),
+			"content_type":  fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.DocumentRow) any { return row.ContentType }),
+			"size_bytes":    fieldOf(graphql.NewNonNull(longType), func(row *datastore.DocumentRow) any { return row.SizeBytes }),
+			"sha256":        fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.DocumentRow) any { return row.SHA256 }),
+			"uploaded_at":   fieldOf(graphql.NewNonNull(longType), func(row *datastore.DocumentRow) any { return row.UploadedAt }),
+		},
+	})
+
+	statusChangeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "StatusChange",
+		Description: "A status a submission moved to. from_status is null for the status it was created with.",
+		Fields: graphql.Fields{
+			"from_status": fieldOf(graphql.String, func(row *datastore.SubmissionStatusChangeRow) any { return nullString(row.FromStatus) }),
+			"to_status":   fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.SubmissionStatusChangeRow) any { return row.ToStatus }),
+			"changed_at":  fieldOf(graphql.NewNonNull(longType), func(row *datastore.SubmissionStatusChangeRow) any { return row.ChangedAt }),
+		},
+	})
+
+	partyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SubmissionParty",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"party_role":  fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.SubmissionPartyRow) any { return row.PartyRole }),
+				"customer_id": fieldOf(graphql.NewNonNull(graphql.ID), func(row *datastore.SubmissionPartyRow) any { return row.CustomerID }),
+				"customer": {
+					Type: customerType,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						party := p.Source.(*datastore.SubmissionPartyRow)
+						return then(loadersFrom(p.Context).customers.load(party.CustomerID), identity), nil
+					},
+				},
+			}
+		}),
+	})
+
+	customerType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Customer",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"customer_id": fieldOf(graphql.NewNonNull(graphql.ID), func(row *datastore.LoanCustomerRow) any { return row.CustomerID }),
+				"id_card_number": personalField(graphql.NewNonNull(graphql.String), func(value string) string { return export.MaskTail(value, 4) },
+					func(row *datastore.LoanCustomerRow) *string { return &row.IDCardNumber }),
+				"full_name": personalField(graphql.NewNonNull(graphql.String), export.MaskName,
+					func(row *datastore.LoanCustomerRow) *string { return &row.FullName }),
+				"birth_date": personalField(graphql.NewNonNull(graphql.String), export.MaskDate,
+					func(row *datastore.LoanCustomerRow) *string { return &row.BirthDate }),
+				"phone_number": personalField(graphql.NewNonNull(graphql.String), func(value string) string { return export.MaskTail(value, 3) },
+					func(row *datastore.LoanCustomerRow) *string { return &row.PhoneNumber }),
+				"email": personalField(graphql.String, export.MaskEmail,
+					func(row *datastore.LoanCustomerRow) *string { return nullString(row.Email) }),
+				"monthly_income": fieldOf(graphql.NewNonNull(graphql.Float), func(row *datastore.LoanCustomerRow) any { return row.MonthlyIncome }),
+				"address_street": personalField(graphql.NewNonNull(graphql.String), export.MaskAll,
+					func(row *datastore.LoanCustomerRow) *string { return &row.AddressStreet }),
+				"address_city": fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.LoanCustomerRow) any { return row.AddressCity }),
+				"locale":       fieldOf(graphql.NewNonNull(graphql.String), func(row *datastore.LoanCustomerRow) any { return row.Locale }),
+				"submissions": {
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(submissionType))),
+					Description: "The submissions the customer is a party to, oldest first.",
+					Args: graphql.FieldConfigArgument{
+						"loan_status": {Type: graphql.String},
+					},
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						customer := p.Source.(*datastore.LoanCustomerRow)
+						loanStatus, _ := p.Args["loan_status"].(string)
+						load := loadersFrom(p.Context).customerSubmissions.load(customer.CustomerID)
+						return then(load, func(rows []*datastore.PartySubmissionRow) any {
+							nodes := []*submissionNode{}
+							for _, row := range rows {
+								if loanStatus != "" && !strings.EqualFold(row.Submission.LoanStatus, loanStatus) {
+									continue
+								}
+								partyRole := row.PartyRole
+								nodes = append(nodes, &submissionNode{row: row.Submission, partyRole: &partyRole})
+							}
+							return nodes
+						}), nil
+					},
+				},
+				"documents": {
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(documentType))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						customer := p.Source.(*datastore.LoanCustomerRow)
+						return then(loadersFrom(p.Context).customerDocuments.load(customer.CustomerID), identity), nil
+					},
+				},
+			}
+		}),
+	})
+
+	submissionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Submission",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"submission_id":              submissionField(graphql.NewNonNull(graphql.ID), func(row *datastore.LoanSubmissionRow) any { return row.SubmissionID }),
+				"customer_id":                submissionField(graphql.NewNonNull(graphql.ID), func(row *datastore.LoanSubmissionRow) any { return row.CustomerID }),
+				"vehicle_type":               submissionField(graphql.NewNonNull(graphql.String), func(row *datastore.LoanSubmissionRow) any { return row.VehicleType }),
+				"vehicle_brand":              submissionField(graphql.NewNonNull(graphql.String), func(row *datastore.LoanSubmissionRow) any { return row.VehicleBrand }),
+				"vehicle_model":              submissionField(graphql.NewNonNull(graphql.String), func(row *datastore.LoanSubmissionRow) any { return row.VehicleModel }),
+				"vehicle_license_number":     submissionField(graphql.NewNonNull(graphql.String), func(row *datastore.LoanSubmissionRow) any { return row.VehicleLicenseNumber }),
+				"vehicle_odometer":           submissionField(graphql.NewNonNull(graphql.Int), func(row *datastore.LoanSubmissionRow) any { return row.VehicleOdometer }),
+				"manufacturing_year":         submissionField(graphql.NewNonNull(graphql.Int), func(row *datastore.LoanSubmissionRow) any { return row.ManufacturingYear }),
+				"proposed_loan_amount":       submissionField(graphql.NewNonNull(longType), func(row *datastore.LoanSubmissionRow) any { return row.ProposedLoanAmount }),
+				"proposed_loan_tenure_month": submissionField(graphql.NewNonNull(graphql.Int), func(row *datastore.LoanSubmissionRow) any { return row.ProposedLoanTenure }),
+				"is_commercial_vehicle":      submissionField(graphql.NewNonNull(graphql.Boolean), func(row *datastore.LoanSubmissionRow) any { return row.IsCommercialVehicle }),
+				"is_duplicate_collateral":    submissionField(graphql.NewNonNull(graphql.Boolean), func(row *datastore.LoanSubmissionRow) any { return row.IsDuplicateCollateral }),
+				"loan_status":                submissionField(graphql.NewNonNull(graphql.String), func(row *datastore.LoanSubmissionRow) any { return row.LoanStatus }),
+				"product_id":                 submissionField(graphql.ID, func(row *datastore.LoanSubmissionRow) any { return nullString(row.ProductID) }),
+				"dealer_id":                  submissionField(graphql.ID, func(row *datastore.LoanSubmissionRow) any { return nullString(row.DealerID) }),
+				"agent_id":                   submissionField(graphql.ID, func(row *datastore.LoanSubmissionRow) any { return nullString(row.AgentID) }),
+				"created_at":                 submissionField(graphql.NewNonNull(longType), func(row *datastore.LoanSubmissionRow) any { return row.CreatedAt }),
+				"updated_at":                 submissionField(graphql.NewNonNull(longType), func(row *datastore.LoanSubmissionRow) any { return row.UpdatedAt }),
+				"party_role": {
+					Type:        graphql.String,
+					Description: "The role of the customer the submission was listed under, null outside Customer.submissions.",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(*submissionNode).partyRole, nil
+					},
+				},
+				"customer": {
+					Type:        customerType,
+					Description: "The primary applicant.",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						node := p.Source.(*submissionNode)
+						return then(loadersFrom(p.Context).customers.load(node.row.CustomerID), identity), nil
+					},
+				},
+				"parties": {
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(partyType))),
+					Description: "Every party to the submission, primary applicant first.",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						node := p.Source.(*submissionNode)
+						return then(loadersFrom(p.Context).parties.load(node.row.SubmissionID), identity), nil
+					},
+				},
+				"documents": {
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(documentType))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						node := p.Source.(*submissionNode)
+						return then(loadersFrom(p.Context).submissionDocuments.load(node.row.SubmissionID), identity), nil
+					},
+				},
+				"status_history": {
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(statusChangeType))),
+					Description: "The statuses the submission went through, oldest first. Submissions created " +
+						"before status changes were recorded have no history.",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						node := p.Source.(*submissionNode)
+						return then(loadersFrom(p.Context).statusHistory.load(node.row.SubmissionID), identity), nil
+					},
+				},
+			}
+		}),
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"end_cursor":    fieldOf(graphql.String, func(c *connection) any { return c.endCursor }),
+			"has_next_page": fieldOf(graphql.NewNonNull(graphql.Boolean), func(c *connection) any { return c.hasNextPage }),
+		},
+	})
+	connectionType := func(name string, nodeType *graphql.Object) *graphql.Object {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name: name,
+			Fields: graphql.Fields{
+				"nodes":       fieldOf(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nodeType))), func(c *connection) any { return c.nodes }),
+				"total_count": fieldOf(graphql.NewNonNull(graphql.Int), func(c *connection) any { return c.totalCount }),
+				"page_info":   fieldOf(graphql.NewNonNull(pageInfoType), func(c *connection) any { return c }),
+			},
+		})
+	}
+
+	customerFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CustomerFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"address_city": {Type: graphql.String},
+			"locale":       {Type: graphql.String},
+			"search": {
+				Type:        graphql.String,
+				Description: "Matches part of the full name, phone number or ID card number.",
+			},
+		},
+	})
+	submissionFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "SubmissionFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"loan_status": {Type: graphql.String},
+			"customer_id": {Type: graphql.ID, Description: "The primary applicant."},
+			"dealer_id":   {Type: graphql.ID},
+			"product_id":  {Type: graphql.ID},
+			"created_from": {
+				Type:        longType,
+				Description: "Unix time from which submissions were created, inclusive.",
+			},
+			"created_to": {
+				Type:        longType,
+				Description: "Unix time before which submissions were created.",
+			},
+		},
+	})
+	pageArgs := func(filterType *graphql.InputObject) graphql.FieldConfigArgument {
+		return graphql.FieldConfigArgument{
+			"filter": {Type: filterType},
+			"first": {
+				Type:        graphql.Int,
+				Description: fmt.Sprintf("Page size, %d by default and at most %d.", DefaultPageSize, MaxPageSize),
+			},
+			"after": {
+				Type:        graphql.String,
+				Description: "The end_cursor of the previous page.",
+			},
+		}
+	}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"customer": {
+				Type: customerType,
+				Args: graphql.FieldConfigArgument{
+					"customer_id": {Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					customerID := p.Args["customer_id"].(string)
+					if _, err := uuid.Parse(customerID); err != nil {
+						return nil, errors.New("Invalid customer ID: " + customerID)
+					}
+					return then(loadersFrom(p.Context).customers.load(customerID), identity), nil
+				},
+			},
+			"customers": {
+				Type:        graphql.NewNonNull(connectionType("CustomerConnection", customerType)),
+				Description: "Customers by full name.",
+				Args:        pageArgs(customerFilterType),
+				Resolve:     s.resolveCustomers,
+			},
+			"submission": {
+				Type: submissionType,
+				Args: graphql.FieldConfigArgument{
+					"submission_id": {Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					submissionID := p.Args["submission_id"].(string)
+					if _, err := uuid.Parse(submissionID); err != nil {
+						return nil, errors.New("Invalid loan_submission_id: " + submissionID)
+					}
+					load := loadersFrom(p.Context).submissions.load(submissionID)
+					return then(load, func(row *datastore.LoanSubmissionRow) any {
+						if row == nil {
+							return nil
+						}
+						return &submissionNode{row: row}
+					}), nil
+				},
+			},
+			"submissions": {
+				Type:        graphql.NewNonNull(connectionType("SubmissionConnection", submissionType)),
+				Description: "Submissions, newest first.",
+				Args:        pageArgs(submissionFilterType),
+				Resolve:     s.resolveSubmissions,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func (s *Server) resolveCustomers(p graphql.ResolveParams) (any, error) {
+	offset, limit, err := readPage(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter, _ := p.Args["filter"].(map[string]any)
+	addressCity, _ := filter["address_city"].(string)
+	locale, _ := filter["locale"].(string)
+	search, _ := filter["search"].(string)
+	search = strings.ToLower(search)
+
+	rows, err := s.CustomerStore.GetAllLoanCustomers()
+	if err != nil {
+		return nil, errors.New("Failed to get all loan customers")
+	}
+	var matched []*datastore.LoanCustomerRow
+	for _, row := range rows {
+		if addressCity != "" && !strings.EqualFold(row.AddressCity, addressCity) {
+			continue
+		}
+		if locale != "" && row.Locale != locale {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(row.FullName), search) &&
+			!strings.Contains(row.PhoneNumber, search) && !strings.Contains(strings.ToLower(row.IDCardNumber), search) {
+			continue
+		}
+		matched = append(matched, row)
+	}
+	slices.SortFunc(matched, func(a, b *datastore.LoanCustomerRow) int {
+		if order := strings.Compare(a.FullName, b.FullName); order != 0 {
+			return order
+		}
+		return strings.Compare(a.CustomerID, b.CustomerID)
+	})
+
+	page, c := paginate(matched, offset, limit)
+	customers := loadersFrom(p.Context).customers
+	for _, row := range page {
+		customers.prime(row.CustomerID, row)
+	}
+	c.nodes = page
+	return c, nil
+}
+
+func (s *Server) resolveSubmissions(p graphql.ResolveParams) (any, error) {
+	offset, limit, err := readPage(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter, _ := p.Args["filter"].(map[string]any)
+	loanStatus, _ := filter["loan_status"].(string)
+	customerID, _ := filter["customer_id"].(string)
+	dealerID, _ := filter["dealer_id"].(string)
+	productID, _ := filter["product_id"].(string)
+	createdFrom, _ := filter["created_from"].(int64)
+	createdTo, _ := filter["created_to"].(int64)
+
+	var rows []*datastore.LoanSubmissionRow
+	if dealerID != "" {
+		rows, err = s.SubmissionStore.GetLoanSubmissionsByDealerId(dealerID)
+	} else {
+		rows, err = s.SubmissionStore.GetAllLoanSubmissions()
+	}
+	if err != nil {
+		return nil, errors.New("Failed to get all loan submissions")
+	}
+	var matched []*datastore.LoanSubmissionRow
+	for _, row := range rows {
+		if loanStatus != "" && !strings.EqualFold(row.LoanStatus, loanStatus) {
+			continue
+		}
+		if customerID != "" && row.CustomerID != customerID {
+			continue
+		}
+		if productID != "" && row.ProductID.String != productID {
+			continue
+		}
+		if (createdFrom != 0 && row.CreatedAt < createdFrom) || (createdTo != 0 && row.CreatedAt >= createdTo) {
+			continue
+		}
+		matched = append(matched, row)
+	}
+
+	page, c := paginate(matched, offset, limit)
+	submissions := loadersFrom(p.Context).submissions
+	nodes := make([]*submissionNode, 0, len(page))
+	for _, row := range page {
+		submissions.prime(row.SubmissionID, row)
+		nodes = append(nodes, &submissionNode{row: row})
+	}
+	c.nodes = nodes
+	return c, nil
+}
+
+// readPage reads the first and after arguments of a list query.
+func readPage(args map[string]any) (int, int, error) {
+	limit := DefaultPageSize
+	if first, ok := args["first"].(int); ok {
+		if first < 0 {
+			return 0, 0, errors.New("first must not be negative")
+		}
+		limit = min(first, MaxPageSize)
+	}
+
+	after, _ := args["after"].(string)
+	if after == "" {
+		return 0, limit, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(after)
+	offset, _ := strings.CutPrefix(string(decoded), "offset:")
+	n, convErr := strconv.Atoi(offset)
+	if err != nil || convErr != nil || n < 0 {
+		return 0, 0, errors.New("Invalid cursor: " + after)
+	}
+	return n, limit, nil
+}
+
+// paginate cuts a page out of rows. Cursors are opaque to clients but are
+// offsets, so a page may repeat or skip rows created since the previous one.
+func paginate[T any](rows []T, offset, limit int) ([]T, *connection) {
+	c := &connection{totalCount: len(rows)}
+	if offset >= len(rows) {
+		return []T{}, c
+	}
+	end := min(offset+limit, len(rows))
+	cursor := base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(end)))
+	c.endCursor = &cursor
+	c.hasNextPage = end < len(rows)
+	return rows[offset:end], c
+}
+
+func fieldOf[T any](fieldType graphql.Output, value func(T) any) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return value(p.Source.(T)), nil
+		},
+	}
+}
+
+// personalField masks a customer's personal data the way exports do, unless
+// the query runs WithPersonalData.
+func personalField(fieldType graphql.Output, mask func(string) string, value func(*datastore.LoanCustomerRow) *string) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			data := value(p.Source.(*datastore.LoanCustomerRow))
+			if data == nil || seesPersonalData(p.Context) {
+				return data, nil
+			}
+			return mask(*data), nil
+		},
+	}
+}
+
+func submissionField(fieldType graphql.Output, value func(*datastore.LoanSubmissionRow) any) *graphql.Field {
+	return fieldOf(fieldType, func(node *submissionNode) any { return value(node.row) })
+}
+
+func identity[V any](value V) any {
+	return value
+}
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
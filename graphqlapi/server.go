@@ -0,0 +1,91 @@
+// Package graphqlapi is a read-only GraphQL view of customers and their
+// submissions for the back office. Nested fields are loaded in batches, one
+// query per field and level of the graph, however many customers or
+// submissions a response holds.
+package graphqlapi
+
+import (
+	"context"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+type Server struct {
+	CustomerStore   datastore.LoanCustomerStore
+	SubmissionStore datastore.LoanSubmissionStore
+	PartyStore      datastore.SubmissionPartyStore
+	DocumentStore   datastore.DocumentStore
+	OutboxStore     datastore.EventOutboxStore
+	// Queries nested deeper than MaxDepth or more complex than MaxComplexity
+	// are refused before anything is loaded; 0 disables a limit.
+	MaxDepth      int
+	MaxComplexity int
+
+	schema graphql.Schema
+}
+
+func NewServer(customerStore datastore.LoanCustomerStore, submissionStore datastore.LoanSubmissionStore,
+	partyStore datastore.SubmissionPartyStore, documentStore datastore.DocumentStore,
+	outboxStore datastore.EventOutboxStore) *Server {
+	s := &Server{
+		CustomerStore:   customerStore,
+		SubmissionStore: submissionStore,
+		PartyStore:      partyStore,
+		DocumentStore:   documentStore,
+		OutboxStore:     outboxStore,
+		MaxDepth:        DefaultMaxDepth,
+		MaxComplexity:   DefaultMaxComplexity,
+	}
+	schema, err := s.buildSchema()
+	if err != nil {
+		// The schema is fixed, so this is a programming error.
+		panic("graphqlapi: " + err.Error())
+	}
+	s.schema = schema
+	return s
+}
+
+type personalDataKey struct{}
+
+// WithPersonalData lets the queries run with ctx read customers' personal
+// data unmasked.
+func WithPersonalData(ctx context.Context) context.Context {
+	return context.WithValue(ctx, personalDataKey{}, true)
+}
+
+func seesPersonalData(ctx context.Context) bool {
+	unmasked, _ := ctx.Value(personalDataKey{}).(bool)
+	return unmasked
+}
+
+// Execute runs a query and reports whether it ran. A query that does not
+// parse, does not validate against the schema or exceeds a limit is refused
+// with its errors and no data; one that ran may still carry errors of the
+// fields that failed to resolve.
+func (s *Server) Execute(ctx context.Context, query, operationName string, variables map[string]any) (*graphql.Result, bool) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+	validation := graphql.ValidateDocument(&s.schema, document, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}, false
+	}
+	if err := checkLimits(&s.schema, document, operationName, variables, s.MaxDepth, s.MaxComplexity); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           document,
+		OperationName: operationName,
+		Args:          variables,
+		Context:       withLoaders(ctx, s.newLoaders()),
+	}), true
+}
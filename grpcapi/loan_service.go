@@ -0,0 +1,205 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/grpcapi/loanpb"
+	"github.com/alphaloan/vehicle/handler"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+func (s *LoanServer) GetCustomer(ctx context.Context, request *loanpb.GetCustomerRequest) (*loanpb.GetCustomerResponse, error) {
+	customerID := request.GetCustomerId()
+	if !handler.IsValidUUID(customerID) {
+		return nil, statusError(http.StatusBadRequest, "Invalid customer ID: "+customerID)
+	}
+
+	// The customer is read with its submissions; one without any is not
+	// returned by that query and is read on its own.
+	withSubmissions, err := s.CustomerStore.GetCustomerByCustomerId(customerID)
+	if err == nil {
+		response := &loanpb.GetCustomerResponse{Customer: convertCustomerRow(withSubmissions.LoanCustomerRow)}
+		for _, row := range withSubmissions.LoanSubmissions {
+			submission := convertSubmissionRow(row)
+			partyRole := withSubmissions.PartyRoles[row.SubmissionID]
+			submission.PartyRole = &partyRole
+			response.Submissions = append(response.Submissions, submission)
+		}
+		return response, nil
+	}
+
+	row, err := s.CustomerStore.GetLoanCustomerById(customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, statusError(http.StatusNotFound, "Customer not found: "+customerID)
+	}
+	if err != nil {
+		return nil, statusError(http.StatusInternalServerError, "Failed to get customer "+customerID)
+	}
+	return &loanpb.GetCustomerResponse{Customer: convertCustomerRow(row)}, nil
+}
+
+func (s *LoanServer) ListCustomers(ctx context.Context, request *loanpb.ListCustomersRequest) (*loanpb.ListCustomersResponse, error) {
+	offset, limit, err := readPage(request.GetPageSize(), request.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.CustomerStore.GetAllLoanCustomers()
+	if err != nil {
+		return nil, statusError(http.StatusInternalServerError, "Failed to get all loan customers")
+	}
+
+	response := &loanpb.ListCustomersResponse{}
+	matched := 0
+	for _, row := range rows {
+		if request.GetAddressCity() != "" && !strings.EqualFold(row.AddressCity, request.GetAddressCity()) {
+			continue
+		}
+		matched++
+		if matched <= offset {
+			continue
+		}
+		if len(response.Customers) == limit {
+			response.NextPageToken = strconv.Itoa(offset + limit)
+			break
+		}
+		response.Customers = append(response.Customers, convertCustomerRow(row))
+	}
+	return response, nil
+}
+
+func (s *LoanServer) GetSubmission(ctx context.Context, request *loanpb.GetSubmissionRequest) (*loanpb.Submission, error) {
+	submissionID := request.GetSubmissionId()
+	if !handler.IsValidUUID(submissionID) {
+		return nil, statusError(http.StatusBadRequest, "Invalid loan_submission_id: "+submissionID)
+	}
+
+	row, err := s.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, statusError(http.StatusNotFound, "Submission not found: "+submissionID)
+	}
+	if err != nil {
+		return nil, statusError(http.StatusInternalServerError, "Failed to get submission "+submissionID)
+	}
+	return convertSubmissionRow(row), nil
+}
+
+func (s *LoanServer) ListSubmissions(ctx context.Context, request *loanpb.ListSubmissionsRequest) (*loanpb.ListSubmissionsResponse, error) {
+	offset, limit, err := readPage(request.GetPageSize(), request.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	for name, id := range map[string]string{"customer_id": request.GetCustomerId(), "dealer_id": request.GetDealerId()} {
+		if id != "" && !handler.IsValidUUID(id) {
+			return nil, statusError(http.StatusBadRequest, "Invalid "+name+": "+id)
+		}
+	}
+
+	var rows []*datastore.LoanSubmissionRow
+	if request.GetDealerId() != "" {
+		rows, err = s.SubmissionStore.GetLoanSubmissionsByDealerId(request.GetDealerId())
+	} else {
+		rows, err = s.SubmissionStore.GetAllLoanSubmissions()
+	}
+	if err != nil {
+		return nil, statusError(http.StatusInternalServerError, "Failed to get all loan submissions")
+	}
+
+	response := &loanpb.ListSubmissionsResponse{}
+	matched := 0
+	for _, row := range rows {
+		if request.GetCustomerId() != "" && row.CustomerID != request.GetCustomerId() {
+			continue
+		}
+		if request.GetLoanStatus() != "" && !strings.EqualFold(row.LoanStatus, request.GetLoanStatus()) {
+			continue
+		}
+		matched++
+		if matched <= offset {
+			continue
+		}
+		if len(response.Submissions) == limit {
+			response.NextPageToken = strconv.Itoa(offset + limit)
+			break
+		}
+		response.Submissions = append(response.Submissions, convertSubmissionRow(row))
+	}
+	return response, nil
+}
+
+// SubmitLoan runs the submit flow of the HTTP API: the request is validated,
+// the vehicle valued and the loan priced exactly as for POST /api/v1/submissions.
+func (s *LoanServer) SubmitLoan(ctx context.Context, request *loanpb.SubmitLoanRequest) (*loanpb.SubmitLoanResponse, error) {
+	if request.GetCustomer() == nil || request.GetProposedLoan() == nil {
+		return nil, statusError(http.StatusBadRequest, "customer and proposed_loan are required")
+	}
+	submitRequest := &handler.LoanSubmitRequest{
+		Customer:     convertCustomer(request.GetCustomer()),
+		ProposedLoad: convertProposal(request.GetProposedLoan()),
+	}
+	for _, party := range request.GetParties() {
+		if party.GetCustomer() == nil {
+			return nil, statusError(http.StatusBadRequest, "every party needs a customer")
+		}
+		customer := convertCustomer(party.GetCustomer())
+		submitRequest.Parties = append(submitRequest.Parties, handler.SubmissionParty{Role: party.GetRole(), Customer: &customer})
+	}
+
+	submitted, err := s.Submitter.SubmitLoan(ctx, submitRequest)
+	var submitErr *handler.SubmitError
+	if errors.As(err, &submitErr) {
+		return nil, statusError(submitErr.Status, submitErr.Message)
+	}
+	if err != nil {
+		return nil, statusError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := &loanpb.SubmitLoanResponse{
+		CustomerId:            *submitted.CustomerID,
+		SubmissionId:          *submitted.SubmissionID,
+		IsDuplicateCollateral: submitted.IsDuplicateCollateral,
+	}
+	if submitted.Valuation != nil {
+		response.Valuation = convertValuation(submitted.Valuation)
+	}
+	if submitted.Quote != nil {
+		response.Quote = convertQuote(submitted.Quote)
+	}
+	if submitted.Parties != nil {
+		for _, party := range *submitted.Parties {
+			response.Parties = append(response.Parties, &loanpb.SubmissionParty{Role: party.Role, CustomerId: party.CustomerID})
+		}
+	}
+	return response, nil
+}
+
+// readPage decodes the offset a page token carries and bounds the page size.
+func readPage(pageSize int32, pageToken string) (int, int, error) {
+	if pageSize < 0 {
+		return 0, 0, statusError(http.StatusBadRequest, "page_size must not be negative")
+	}
+	limit := int(pageSize)
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+
+	if pageToken == "" {
+		return 0, limit, nil
+	}
+	offset, err := strconv.Atoi(pageToken)
+	if err != nil || offset < 0 {
+		return 0, 0, statusError(http.StatusBadRequest, "Invalid page_token: "+pageToken)
+	}
+	return offset, limit, nil
+}
@@ -0,0 +1,1383 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: alphaloan/loan/v1/loan.proto
+
+package loanpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Customer struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	CustomerId   string                 `protobuf:"bytes,1,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	IdCardNumber string                 `protobuf:"bytes,2,opt,name=id_card_number,json=idCardNumber,proto3" json:"id_card_number,omitempty"`
+	FullName     string                 `protobuf:"bytes,3,opt,name=full_name,json=fullName,proto3" json:"full_name,omitempty"`
+	// YYYY-MM-DD
+	BirthDate     string  `protobuf:"bytes,4,opt,name=birth_date,json=birthDate,proto3" json:"birth_date,omitempty"`
+	PhoneNumber   string  `protobuf:"bytes,5,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
+	Email         *string `protobuf:"bytes,6,opt,name=email,proto3,oneof" json:"email,omitempty"`
+	MonthlyIncome float64 `protobuf:"fixed64,7,opt,name=monthly_income,json=monthlyIncome,proto3" json:"monthly_income,omitempty"`
+	AddressStreet string  `protobuf:"bytes,8,opt,name=address_street,json=addressStreet,proto3" json:"address_street,omitempty"`
+	AddressCity   string  `protobuf:"bytes,9,opt,name=address_city,json=addressCity,proto3" json:"address_city,omitempty"`
+	Locale        string  `protobuf:"bytes,10,opt,name=locale,proto3" json:"locale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Customer) Reset() {
+	*x = Customer{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Customer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Customer) ProtoMessage() {}
+
+func (x *Customer) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Customer.ProtoReflect.Descriptor instead.
+func (*Customer) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Customer) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+func (x *Customer) GetIdCardNumber() string {
+	if x != nil {
+		return x.IdCardNumber
+	}
+	return ""
+}
+
+func (x *Customer) GetFullName() string {
+	if x != nil {
+		return x.FullName
+	}
+	return ""
+}
+
+func (x *Customer) GetBirthDate() string {
+	if x != nil {
+		return x.BirthDate
+	}
+	return ""
+}
+
+func (x *Customer) GetPhoneNumber() string {
+	if x != nil {
+		return x.PhoneNumber
+	}
+	return ""
+}
+
+func (x *Customer) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+func (x *Customer) GetMonthlyIncome() float64 {
+	if x != nil {
+		return x.MonthlyIncome
+	}
+	return 0
+}
+
+func (x *Customer) GetAddressStreet() string {
+	if x != nil {
+		return x.AddressStreet
+	}
+	return ""
+}
+
+func (x *Customer) GetAddressCity() string {
+	if x != nil {
+		return x.AddressCity
+	}
+	return ""
+}
+
+func (x *Customer) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+type Submission struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId            string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	CustomerId              string                 `protobuf:"bytes,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	VehicleType             string                 `protobuf:"bytes,3,opt,name=vehicle_type,json=vehicleType,proto3" json:"vehicle_type,omitempty"`
+	VehicleBrand            string                 `protobuf:"bytes,4,opt,name=vehicle_brand,json=vehicleBrand,proto3" json:"vehicle_brand,omitempty"`
+	VehicleModel            string                 `protobuf:"bytes,5,opt,name=vehicle_model,json=vehicleModel,proto3" json:"vehicle_model,omitempty"`
+	VehicleLicenseNumber    string                 `protobuf:"bytes,6,opt,name=vehicle_license_number,json=vehicleLicenseNumber,proto3" json:"vehicle_license_number,omitempty"`
+	VehicleOdometer         int32                  `protobuf:"varint,7,opt,name=vehicle_odometer,json=vehicleOdometer,proto3" json:"vehicle_odometer,omitempty"`
+	ManufacturingYear       int32                  `protobuf:"varint,8,opt,name=manufacturing_year,json=manufacturingYear,proto3" json:"manufacturing_year,omitempty"`
+	ProposedLoanAmount      int64                  `protobuf:"varint,9,opt,name=proposed_loan_amount,json=proposedLoanAmount,proto3" json:"proposed_loan_amount,omitempty"`
+	ProposedLoanTenureMonth int32                  `protobuf:"varint,10,opt,name=proposed_loan_tenure_month,json=proposedLoanTenureMonth,proto3" json:"proposed_loan_tenure_month,omitempty"`
+	IsCommercialVehicle     bool                   `protobuf:"varint,11,opt,name=is_commercial_vehicle,json=isCommercialVehicle,proto3" json:"is_commercial_vehicle,omitempty"`
+	IsDuplicateCollateral   bool                   `protobuf:"varint,12,opt,name=is_duplicate_collateral,json=isDuplicateCollateral,proto3" json:"is_duplicate_collateral,omitempty"`
+	LoanStatus              string                 `protobuf:"bytes,13,opt,name=loan_status,json=loanStatus,proto3" json:"loan_status,omitempty"`
+	ProductId               *string                `protobuf:"bytes,14,opt,name=product_id,json=productId,proto3,oneof" json:"product_id,omitempty"`
+	// Read only: SubmitLoan refuses them, as submissions over gRPC are not
+	// made by a dealer.
+	DealerId *string `protobuf:"bytes,15,opt,name=dealer_id,json=dealerId,proto3,oneof" json:"dealer_id,omitempty"`
+	AgentId  *string `protobuf:"bytes,16,opt,name=agent_id,json=agentId,proto3,oneof" json:"agent_id,omitempty"`
+	// The role the customer holds on the submission, set on the submissions of
+	// GetCustomerResponse.
+	PartyRole *string `protobuf:"bytes,17,opt,name=party_role,json=partyRole,proto3,oneof" json:"party_role,omitempty"`
+	// Unix seconds.
+	CreatedAt     int64 `protobuf:"varint,18,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     int64 `protobuf:"varint,19,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Submission) Reset() {
+	*x = Submission{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Submission) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Submission) ProtoMessage() {}
+
+func (x *Submission) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Submission.ProtoReflect.Descriptor instead.
+func (*Submission) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Submission) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *Submission) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+func (x *Submission) GetVehicleType() string {
+	if x != nil {
+		return x.VehicleType
+	}
+	return ""
+}
+
+func (x *Submission) GetVehicleBrand() string {
+	if x != nil {
+		return x.VehicleBrand
+	}
+	return ""
+}
+
+func (x *Submission) GetVehicleModel() string {
+	if x != nil {
+		return x.VehicleModel
+	}
+	return ""
+}
+
+func (x *Submission) GetVehicleLicenseNumber() string {
+	if x != nil {
+		return x.VehicleLicenseNumber
+	}
+	return ""
+}
+
+func (x *Submission) GetVehicleOdometer() int32 {
+	if x != nil {
+		return x.VehicleOdometer
+	}
+	return 0
+}
+
+func (x *Submission) GetManufacturingYear() int32 {
+	if x != nil {
+		return x.ManufacturingYear
+	}
+	return 0
+}
+
+func (x *Submission) GetProposedLoanAmount() int64 {
+	if x != nil {
+		return x.ProposedLoanAmount
+	}
+	return 0
+}
+
+func (x *Submission) GetProposedLoanTenureMonth() int32 {
+	if x != nil {
+		return x.ProposedLoanTenureMonth
+	}
+	return 0
+}
+
+func (x *Submission) GetIsCommercialVehicle() bool {
+	if x != nil {
+		return x.IsCommercialVehicle
+	}
+	return false
+}
+
+func (x *Submission) GetIsDuplicateCollateral() bool {
+	if x != nil {
+		return x.IsDuplicateCollateral
+	}
+	return false
+}
+
+func (x *Submission) GetLoanStatus() string {
+	if x != nil {
+		return x.LoanStatus
+	}
+	return ""
+}
+
+func (x *Submission) GetProductId() string {
+	if x != nil && x.ProductId != nil {
+		return *x.ProductId
+	}
+	return ""
+}
+
+func (x *Submission) GetDealerId() string {
+	if x != nil && x.DealerId != nil {
+		return *x.DealerId
+	}
+	return ""
+}
+
+func (x *Submission) GetAgentId() string {
+	if x != nil && x.AgentId != nil {
+		return *x.AgentId
+	}
+	return ""
+}
+
+func (x *Submission) GetPartyRole() string {
+	if x != nil && x.PartyRole != nil {
+		return *x.PartyRole
+	}
+	return ""
+}
+
+func (x *Submission) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Submission) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type SubmissionParty struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// CO_APPLICANT or GUARANTOR when submitting; PRIMARY in responses too.
+	Role          string    `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	CustomerId    string    `protobuf:"bytes,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	Customer      *Customer `protobuf:"bytes,3,opt,name=customer,proto3" json:"customer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmissionParty) Reset() {
+	*x = SubmissionParty{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmissionParty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmissionParty) ProtoMessage() {}
+
+func (x *SubmissionParty) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmissionParty.ProtoReflect.Descriptor instead.
+func (*SubmissionParty) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubmissionParty) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *SubmissionParty) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+func (x *SubmissionParty) GetCustomer() *Customer {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+type Valuation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ModelId          *string                `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3,oneof" json:"model_id,omitempty"`
+	BasePrice        int64                  `protobuf:"varint,2,opt,name=base_price,json=basePrice,proto3" json:"base_price,omitempty"`
+	VehicleAgeYears  int32                  `protobuf:"varint,3,opt,name=vehicle_age_years,json=vehicleAgeYears,proto3" json:"vehicle_age_years,omitempty"`
+	AgeFactor        float64                `protobuf:"fixed64,4,opt,name=age_factor,json=ageFactor,proto3" json:"age_factor,omitempty"`
+	MileageFactor    float64                `protobuf:"fixed64,5,opt,name=mileage_factor,json=mileageFactor,proto3" json:"mileage_factor,omitempty"`
+	CommercialFactor float64                `protobuf:"fixed64,6,opt,name=commercial_factor,json=commercialFactor,proto3" json:"commercial_factor,omitempty"`
+	EstimatedValue   int64                  `protobuf:"varint,7,opt,name=estimated_value,json=estimatedValue,proto3" json:"estimated_value,omitempty"`
+	LoanToValue      float64                `protobuf:"fixed64,8,opt,name=loan_to_value,json=loanToValue,proto3" json:"loan_to_value,omitempty"`
+	ValuedAt         int64                  `protobuf:"varint,9,opt,name=valued_at,json=valuedAt,proto3" json:"valued_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Valuation) Reset() {
+	*x = Valuation{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Valuation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Valuation) ProtoMessage() {}
+
+func (x *Valuation) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Valuation.ProtoReflect.Descriptor instead.
+func (*Valuation) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Valuation) GetModelId() string {
+	if x != nil && x.ModelId != nil {
+		return *x.ModelId
+	}
+	return ""
+}
+
+func (x *Valuation) GetBasePrice() int64 {
+	if x != nil {
+		return x.BasePrice
+	}
+	return 0
+}
+
+func (x *Valuation) GetVehicleAgeYears() int32 {
+	if x != nil {
+		return x.VehicleAgeYears
+	}
+	return 0
+}
+
+func (x *Valuation) GetAgeFactor() float64 {
+	if x != nil {
+		return x.AgeFactor
+	}
+	return 0
+}
+
+func (x *Valuation) GetMileageFactor() float64 {
+	if x != nil {
+		return x.MileageFactor
+	}
+	return 0
+}
+
+func (x *Valuation) GetCommercialFactor() float64 {
+	if x != nil {
+		return x.CommercialFactor
+	}
+	return 0
+}
+
+func (x *Valuation) GetEstimatedValue() int64 {
+	if x != nil {
+		return x.EstimatedValue
+	}
+	return 0
+}
+
+func (x *Valuation) GetLoanToValue() float64 {
+	if x != nil {
+		return x.LoanToValue
+	}
+	return 0
+}
+
+func (x *Valuation) GetValuedAt() int64 {
+	if x != nil {
+		return x.ValuedAt
+	}
+	return 0
+}
+
+type Quote struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ProductId          string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	AnnualInterestRate float64                `protobuf:"fixed64,2,opt,name=annual_interest_rate,json=annualInterestRate,proto3" json:"annual_interest_rate,omitempty"`
+	TenureMonth        int32                  `protobuf:"varint,3,opt,name=tenure_month,json=tenureMonth,proto3" json:"tenure_month,omitempty"`
+	Principal          int64                  `protobuf:"varint,4,opt,name=principal,proto3" json:"principal,omitempty"`
+	MonthlyInstallment int64                  `protobuf:"varint,5,opt,name=monthly_installment,json=monthlyInstallment,proto3" json:"monthly_installment,omitempty"`
+	TotalInterest      int64                  `protobuf:"varint,6,opt,name=total_interest,json=totalInterest,proto3" json:"total_interest,omitempty"`
+	AdminFee           int64                  `protobuf:"varint,7,opt,name=admin_fee,json=adminFee,proto3" json:"admin_fee,omitempty"`
+	ProvisionFee       int64                  `protobuf:"varint,8,opt,name=provision_fee,json=provisionFee,proto3" json:"provision_fee,omitempty"`
+	InsuranceFee       int64                  `protobuf:"varint,9,opt,name=insurance_fee,json=insuranceFee,proto3" json:"insurance_fee,omitempty"`
+	TotalFees          int64                  `protobuf:"varint,10,opt,name=total_fees,json=totalFees,proto3" json:"total_fees,omitempty"`
+	TotalCost          int64                  `protobuf:"varint,11,opt,name=total_cost,json=totalCost,proto3" json:"total_cost,omitempty"`
+	QuotedAt           int64                  `protobuf:"varint,12,opt,name=quoted_at,json=quotedAt,proto3" json:"quoted_at,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Quote) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Quote) GetAnnualInterestRate() float64 {
+	if x != nil {
+		return x.AnnualInterestRate
+	}
+	return 0
+}
+
+func (x *Quote) GetTenureMonth() int32 {
+	if x != nil {
+		return x.TenureMonth
+	}
+	return 0
+}
+
+func (x *Quote) GetPrincipal() int64 {
+	if x != nil {
+		return x.Principal
+	}
+	return 0
+}
+
+func (x *Quote) GetMonthlyInstallment() int64 {
+	if x != nil {
+		return x.MonthlyInstallment
+	}
+	return 0
+}
+
+func (x *Quote) GetTotalInterest() int64 {
+	if x != nil {
+		return x.TotalInterest
+	}
+	return 0
+}
+
+func (x *Quote) GetAdminFee() int64 {
+	if x != nil {
+		return x.AdminFee
+	}
+	return 0
+}
+
+func (x *Quote) GetProvisionFee() int64 {
+	if x != nil {
+		return x.ProvisionFee
+	}
+	return 0
+}
+
+func (x *Quote) GetInsuranceFee() int64 {
+	if x != nil {
+		return x.InsuranceFee
+	}
+	return 0
+}
+
+func (x *Quote) GetTotalFees() int64 {
+	if x != nil {
+		return x.TotalFees
+	}
+	return 0
+}
+
+func (x *Quote) GetTotalCost() int64 {
+	if x != nil {
+		return x.TotalCost
+	}
+	return 0
+}
+
+func (x *Quote) GetQuotedAt() int64 {
+	if x != nil {
+		return x.QuotedAt
+	}
+	return 0
+}
+
+type GetCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CustomerId    string                 `protobuf:"bytes,1,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCustomerRequest) Reset() {
+	*x = GetCustomerRequest{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCustomerRequest) ProtoMessage() {}
+
+func (x *GetCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCustomerRequest.ProtoReflect.Descriptor instead.
+func (*GetCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCustomerRequest) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+type GetCustomerResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Customer *Customer              `protobuf:"bytes,1,opt,name=customer,proto3" json:"customer,omitempty"`
+	// Every submission the customer is a party to.
+	Submissions   []*Submission `protobuf:"bytes,2,rep,name=submissions,proto3" json:"submissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCustomerResponse) Reset() {
+	*x = GetCustomerResponse{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCustomerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCustomerResponse) ProtoMessage() {}
+
+func (x *GetCustomerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCustomerResponse.ProtoReflect.Descriptor instead.
+func (*GetCustomerResponse) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetCustomerResponse) GetCustomer() *Customer {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+func (x *GetCustomerResponse) GetSubmissions() []*Submission {
+	if x != nil {
+		return x.Submissions
+	}
+	return nil
+}
+
+type ListCustomersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to 50, at most 500.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous page.
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	AddressCity   string `protobuf:"bytes,3,opt,name=address_city,json=addressCity,proto3" json:"address_city,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCustomersRequest) Reset() {
+	*x = ListCustomersRequest{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCustomersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCustomersRequest) ProtoMessage() {}
+
+func (x *ListCustomersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCustomersRequest.ProtoReflect.Descriptor instead.
+func (*ListCustomersRequest) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListCustomersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListCustomersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListCustomersRequest) GetAddressCity() string {
+	if x != nil {
+		return x.AddressCity
+	}
+	return ""
+}
+
+type ListCustomersResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Customers []*Customer            `protobuf:"bytes,1,rep,name=customers,proto3" json:"customers,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCustomersResponse) Reset() {
+	*x = ListCustomersResponse{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCustomersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCustomersResponse) ProtoMessage() {}
+
+func (x *ListCustomersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCustomersResponse.ProtoReflect.Descriptor instead.
+func (*ListCustomersResponse) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListCustomersResponse) GetCustomers() []*Customer {
+	if x != nil {
+		return x.Customers
+	}
+	return nil
+}
+
+func (x *ListCustomersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetSubmissionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId  string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSubmissionRequest) Reset() {
+	*x = GetSubmissionRequest{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubmissionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubmissionRequest) ProtoMessage() {}
+
+func (x *GetSubmissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubmissionRequest.ProtoReflect.Descriptor instead.
+func (*GetSubmissionRequest) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetSubmissionRequest) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+type ListSubmissionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	CustomerId    string                 `protobuf:"bytes,3,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	DealerId      string                 `protobuf:"bytes,4,opt,name=dealer_id,json=dealerId,proto3" json:"dealer_id,omitempty"`
+	LoanStatus    string                 `protobuf:"bytes,5,opt,name=loan_status,json=loanStatus,proto3" json:"loan_status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubmissionsRequest) Reset() {
+	*x = ListSubmissionsRequest{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubmissionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubmissionsRequest) ProtoMessage() {}
+
+func (x *ListSubmissionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubmissionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubmissionsRequest) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListSubmissionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListSubmissionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListSubmissionsRequest) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+func (x *ListSubmissionsRequest) GetDealerId() string {
+	if x != nil {
+		return x.DealerId
+	}
+	return ""
+}
+
+func (x *ListSubmissionsRequest) GetLoanStatus() string {
+	if x != nil {
+		return x.LoanStatus
+	}
+	return ""
+}
+
+type ListSubmissionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Submissions   []*Submission          `protobuf:"bytes,1,rep,name=submissions,proto3" json:"submissions,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubmissionsResponse) Reset() {
+	*x = ListSubmissionsResponse{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubmissionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubmissionsResponse) ProtoMessage() {}
+
+func (x *ListSubmissionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubmissionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSubmissionsResponse) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListSubmissionsResponse) GetSubmissions() []*Submission {
+	if x != nil {
+		return x.Submissions
+	}
+	return nil
+}
+
+func (x *ListSubmissionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type SubmitLoanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Customer      *Customer              `protobuf:"bytes,1,opt,name=customer,proto3" json:"customer,omitempty"`
+	Parties       []*SubmissionParty     `protobuf:"bytes,2,rep,name=parties,proto3" json:"parties,omitempty"`
+	ProposedLoan  *Submission            `protobuf:"bytes,3,opt,name=proposed_loan,json=proposedLoan,proto3" json:"proposed_loan,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitLoanRequest) Reset() {
+	*x = SubmitLoanRequest{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitLoanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitLoanRequest) ProtoMessage() {}
+
+func (x *SubmitLoanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitLoanRequest.ProtoReflect.Descriptor instead.
+func (*SubmitLoanRequest) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SubmitLoanRequest) GetCustomer() *Customer {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+func (x *SubmitLoanRequest) GetParties() []*SubmissionParty {
+	if x != nil {
+		return x.Parties
+	}
+	return nil
+}
+
+func (x *SubmitLoanRequest) GetProposedLoan() *Submission {
+	if x != nil {
+		return x.ProposedLoan
+	}
+	return nil
+}
+
+type SubmitLoanResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	CustomerId   string                 `protobuf:"bytes,1,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	SubmissionId string                 `protobuf:"bytes,2,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	// Unset when the catalogue has no base price for the model.
+	Valuation             *Valuation         `protobuf:"bytes,3,opt,name=valuation,proto3" json:"valuation,omitempty"`
+	Quote                 *Quote             `protobuf:"bytes,4,opt,name=quote,proto3" json:"quote,omitempty"`
+	Parties               []*SubmissionParty `protobuf:"bytes,5,rep,name=parties,proto3" json:"parties,omitempty"`
+	IsDuplicateCollateral bool               `protobuf:"varint,6,opt,name=is_duplicate_collateral,json=isDuplicateCollateral,proto3" json:"is_duplicate_collateral,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *SubmitLoanResponse) Reset() {
+	*x = SubmitLoanResponse{}
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitLoanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitLoanResponse) ProtoMessage() {}
+
+func (x *SubmitLoanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_alphaloan_loan_v1_loan_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitLoanResponse.ProtoReflect.Descriptor instead.
+func (*SubmitLoanResponse) Descriptor() ([]byte, []int) {
+	return file_alphaloan_loan_v1_loan_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SubmitLoanResponse) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+func (x *SubmitLoanResponse) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *SubmitLoanResponse) GetValuation() *Valuation {
+	if x != nil {
+		return x.Valuation
+	}
+	return nil
+}
+
+func (x *SubmitLoanResponse) GetQuote() *Quote {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+func (x *SubmitLoanResponse) GetParties() []*SubmissionParty {
+	if x != nil {
+		return x.Parties
+	}
+	return nil
+}
+
+func (x *SubmitLoanResponse) GetIsDuplicateCollateral() bool {
+	if x != nil {
+		return x.IsDuplicateCollateral
+	}
+	return false
+}
+
+var File_alphaloan_loan_v1_loan_proto protoreflect.FileDescriptor
+
+const file_alphaloan_loan_v1_loan_proto_rawDesc = "" +
+	"\n" +
+	"\x1calphaloan/loan/v1/loan.proto\x12\x11alphaloan.loan.v1\"\xde\x02\n" +
+	"\bCustomer\x12\x1f\n" +
+	"\vcustomer_id\x18\x01 \x01(\tR\n" +
+	"customerId\x12$\n" +
+	"\x0eid_card_number\x18\x02 \x01(\tR\fidCardNumber\x12\x1b\n" +
+	"\tfull_name\x18\x03 \x01(\tR\bfullName\x12\x1d\n" +
+	"\n" +
+	"birth_date\x18\x04 \x01(\tR\tbirthDate\x12!\n" +
+	"\fphone_number\x18\x05 \x01(\tR\vphoneNumber\x12\x19\n" +
+	"\x05email\x18\x06 \x01(\tH\x00R\x05email\x88\x01\x01\x12%\n" +
+	"\x0emonthly_income\x18\a \x01(\x01R\rmonthlyIncome\x12%\n" +
+	"\x0eaddress_street\x18\b \x01(\tR\raddressStreet\x12!\n" +
+	"\faddress_city\x18\t \x01(\tR\vaddressCity\x12\x16\n" +
+	"\x06locale\x18\n" +
+	" \x01(\tR\x06localeB\b\n" +
+	"\x06_email\"\xcc\x06\n" +
+	"\n" +
+	"Submission\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x1f\n" +
+	"\vcustomer_id\x18\x02 \x01(\tR\n" +
+	"customerId\x12!\n" +
+	"\fvehicle_type\x18\x03 \x01(\tR\vvehicleType\x12#\n" +
+	"\rvehicle_brand\x18\x04 \x01(\tR\fvehicleBrand\x12#\n" +
+	"\rvehicle_model\x18\x05 \x01(\tR\fvehicleModel\x124\n" +
+	"\x16vehicle_license_number\x18\x06 \x01(\tR\x14vehicleLicenseNumber\x12)\n" +
+	"\x10vehicle_odometer\x18\a \x01(\x05R\x0fvehicleOdometer\x12-\n" +
+	"\x12manufacturing_year\x18\b \x01(\x05R\x11manufacturingYear\x120\n" +
+	"\x14proposed_loan_amount\x18\t \x01(\x03R\x12proposedLoanAmount\x12;\n" +
+	"\x1aproposed_loan_tenure_month\x18\n" +
+	" \x01(\x05R\x17proposedLoanTenureMonth\x122\n" +
+	"\x15is_commercial_vehicle\x18\v \x01(\bR\x13isCommercialVehicle\x126\n" +
+	"\x17is_duplicate_collateral\x18\f \x01(\bR\x15isDuplicateCollateral\x12\x1f\n" +
+	"\vloan_status\x18\r \x01(\tR\n" +
+	"loanStatus\x12\"\n" +
+	"\n" +
+	"product_id\x18\x0e \x01(\tH\x00R\tproductId\x88\x01\x01\x12 \n" +
+	"\tdealer_id\x18\x0f \x01(\tH\x01R\bdealerId\x88\x01\x01\x12\x1e\n" +
+	"\bagent_id\x18\x10 \x01(\tH\x02R\aagentId\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"party_role\x18\x11 \x01(\tH\x03R\tpartyRole\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x12 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x13 \x01(\x03R\tupdatedAtB\r\n" +
+	"\v_product_idB\f\n" +
+	"\n" +
+	"_dealer_idB\v\n" +
+	"\t_agent_idB\r\n" +
+	"\v_party_role\"\x7f\n" +
+	"\x0fSubmissionParty\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x1f\n" +
+	"\vcustomer_id\x18\x02 \x01(\tR\n" +
+	"customerId\x127\n" +
+	"\bcustomer\x18\x03 \x01(\v2\x1b.alphaloan.loan.v1.CustomerR\bcustomer\"\xe0\x02\n" +
+	"\tValuation\x12\x1e\n" +
+	"\bmodel_id\x18\x01 \x01(\tH\x00R\amodelId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"base_price\x18\x02 \x01(\x03R\tbasePrice\x12*\n" +
+	"\x11vehicle_age_years\x18\x03 \x01(\x05R\x0fvehicleAgeYears\x12\x1d\n" +
+	"\n" +
+	"age_factor\x18\x04 \x01(\x01R\tageFactor\x12%\n" +
+	"\x0emileage_factor\x18\x05 \x01(\x01R\rmileageFactor\x12+\n" +
+	"\x11commercial_factor\x18\x06 \x01(\x01R\x10commercialFactor\x12'\n" +
+	"\x0festimated_value\x18\a \x01(\x03R\x0eestimatedValue\x12\"\n" +
+	"\rloan_to_value\x18\b \x01(\x01R\vloanToValue\x12\x1b\n" +
+	"\tvalued_at\x18\t \x01(\x03R\bvaluedAtB\v\n" +
+	"\t_model_id\"\xb3\x03\n" +
+	"\x05Quote\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x120\n" +
+	"\x14annual_interest_rate\x18\x02 \x01(\x01R\x12annualInterestRate\x12!\n" +
+	"\ftenure_month\x18\x03 \x01(\x05R\vtenureMonth\x12\x1c\n" +
+	"\tprincipal\x18\x04 \x01(\x03R\tprincipal\x12/\n" +
+	"\x13monthly_installment\x18\x05 \x01(\x03R\x12monthlyInstallment\x12%\n" +
+	"\x0etotal_interest\x18\x06 \x01(\x03R\rtotalInterest\x12\x1b\n" +
+	"\tadmin_fee\x18\a \x01(\x03R\badminFee\x12#\n" +
+	"\rprovision_fee\x18\b \x01(\x03R\fprovisionFee\x12#\n" +
+	"\rinsurance_fee\x18\t \x01(\x03R\finsuranceFee\x12\x1d\n" +
+	"\n" +
+	"total_fees\x18\n" +
+	" \x01(\x03R\ttotalFees\x12\x1d\n" +
+	"\n" +
+	"total_cost\x18\v \x01(\x03R\ttotalCost\x12\x1b\n" +
+	"\tquoted_at\x18\f \x01(\x03R\bquotedAt\"5\n" +
+	"\x12GetCustomerRequest\x12\x1f\n" +
+	"\vcustomer_id\x18\x01 \x01(\tR\n" +
+	"customerId\"\x8f\x01\n" +
+	"\x13GetCustomerResponse\x127\n" +
+	"\bcustomer\x18\x01 \x01(\v2\x1b.alphaloan.loan.v1.CustomerR\bcustomer\x12?\n" +
+	"\vsubmissions\x18\x02 \x03(\v2\x1d.alphaloan.loan.v1.SubmissionR\vsubmissions\"u\n" +
+	"\x14ListCustomersRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12!\n" +
+	"\faddress_city\x18\x03 \x01(\tR\vaddressCity\"z\n" +
+	"\x15ListCustomersResponse\x129\n" +
+	"\tcustomers\x18\x01 \x03(\v2\x1b.alphaloan.loan.v1.CustomerR\tcustomers\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\";\n" +
+	"\x14GetSubmissionRequest\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\"\xb3\x01\n" +
+	"\x16ListSubmissionsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x1f\n" +
+	"\vcustomer_id\x18\x03 \x01(\tR\n" +
+	"customerId\x12\x1b\n" +
+	"\tdealer_id\x18\x04 \x01(\tR\bdealerId\x12\x1f\n" +
+	"\vloan_status\x18\x05 \x01(\tR\n" +
+	"loanStatus\"\x82\x01\n" +
+	"\x17ListSubmissionsResponse\x12?\n" +
+	"\vsubmissions\x18\x01 \x03(\v2\x1d.alphaloan.loan.v1.SubmissionR\vsubmissions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xce\x01\n" +
+	"\x11SubmitLoanRequest\x127\n" +
+	"\bcustomer\x18\x01 \x01(\v2\x1b.alphaloan.loan.v1.CustomerR\bcustomer\x12<\n" +
+	"\aparties\x18\x02 \x03(\v2\".alphaloan.loan.v1.SubmissionPartyR\aparties\x12B\n" +
+	"\rproposed_loan\x18\x03 \x01(\v2\x1d.alphaloan.loan.v1.SubmissionR\fproposedLoan\"\xbc\x02\n" +
+	"\x12SubmitLoanResponse\x12\x1f\n" +
+	"\vcustomer_id\x18\x01 \x01(\tR\n" +
+	"customerId\x12#\n" +
+	"\rsubmission_id\x18\x02 \x01(\tR\fsubmissionId\x12:\n" +
+	"\tvaluation\x18\x03 \x01(\v2\x1c.alphaloan.loan.v1.ValuationR\tvaluation\x12.\n" +
+	"\x05quote\x18\x04 \x01(\v2\x18.alphaloan.loan.v1.QuoteR\x05quote\x12<\n" +
+	"\aparties\x18\x05 \x03(\v2\".alphaloan.loan.v1.SubmissionPartyR\aparties\x126\n" +
+	"\x17is_duplicate_collateral\x18\x06 \x01(\bR\x15isDuplicateCollateral2\xed\x03\n" +
+	"\vLoanService\x12\\\n" +
+	"\vGetCustomer\x12%.alphaloan.loan.v1.GetCustomerRequest\x1a&.alphaloan.loan.v1.GetCustomerResponse\x12b\n" +
+	"\rListCustomers\x12'.alphaloan.loan.v1.ListCustomersRequest\x1a(.alphaloan.loan.v1.ListCustomersResponse\x12W\n" +
+	"\rGetSubmission\x12'.alphaloan.loan.v1.GetSubmissionRequest\x1a\x1d.alphaloan.loan.v1.Submission\x12h\n" +
+	"\x0fListSubmissions\x12).alphaloan.loan.v1.ListSubmissionsRequest\x1a*.alphaloan.loan.v1.ListSubmissionsResponse\x12Y\n" +
+	"\n" +
+	"SubmitLoan\x12$.alphaloan.loan.v1.SubmitLoanRequest\x1a%.alphaloan.loan.v1.SubmitLoanResponseB-Z+github.com/alphaloan/vehicle/grpcapi/loanpbb\x06proto3"
+
+var (
+	file_alphaloan_loan_v1_loan_proto_rawDescOnce sync.Once
+	file_alphaloan_loan_v1_loan_proto_rawDescData []byte
+)
+
+func file_alphaloan_loan_v1_loan_proto_rawDescGZIP() []byte {
+	file_alphaloan_loan_v1_loan_proto_rawDescOnce.Do(func() {
+		file_alphaloan_loan_v1_loan_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_alphaloan_loan_v1_loan_proto_rawDesc), len(file_alphaloan_loan_v1_loan_proto_rawDesc)))
+	})
+	return file_alphaloan_loan_v1_loan_proto_rawDescData
+}
+
+var file_alphaloan_loan_v1_loan_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_alphaloan_loan_v1_loan_proto_goTypes = []any{
+	(*Customer)(nil),                // 0: alphaloan.loan.v1.Customer
+	(*Submission)(nil),              // 1: alphaloan.loan.v1.Submission
+	(*SubmissionParty)(nil),         // 2: alphaloan.loan.v1.SubmissionParty
+	(*Valuation)(nil),               // 3: alphaloan.loan.v1.Valuation
+	(*Quote)(nil),                   // 4: alphaloan.loan.v1.Quote
+	(*GetCustomerRequest)(nil),      // 5: alphaloan.loan.v1.GetCustomerRequest
+	(*GetCustomerResponse)(nil),     // 6: alphaloan.loan.v1.GetCustomerResponse
+	(*ListCustomersRequest)(nil),    // 7: alphaloan.loan.v1.ListCustomersRequest
+	(*ListCustomersResponse)(nil),   // 8: alphaloan.loan.v1.ListCustomersResponse
+	(*GetSubmissionRequest)(nil),    // 9: alphaloan.loan.v1.GetSubmissionRequest
+	(*ListSubmissionsRequest)(nil),  // 10: alphaloan.loan.v1.ListSubmissionsRequest
+	(*ListSubmissionsResponse)(nil), // 11: alphaloan.loan.v1.ListSubmissionsResponse
+	(*SubmitLoanRequest)(nil),       // 12: alphaloan.loan.v1.SubmitLoanRequest
+	(*SubmitLoanResponse)(nil),      // 13: alphaloan.loan.v1.SubmitLoanResponse
+}
+var file_alphaloan_loan_v1_loan_proto_depIdxs = []int32{
+	0,  // 0: alphaloan.loan.v1.SubmissionParty.customer:type_name -> alphaloan.loan.v1.Customer
+	0,  // 1: alphaloan.loan.v1.GetCustomerResponse.customer:type_name -> alphaloan.loan.v1.Customer
+	1,  // 2: alphaloan.loan.v1.GetCustomerResponse.submissions:type_name -> alphaloan.loan.v1.Submission
+	0,  // 3: alphaloan.loan.v1.ListCustomersResponse.customers:type_name -> alphaloan.loan.v1.Customer
+	1,  // 4: alphaloan.loan.v1.ListSubmissionsResponse.submissions:type_name -> alphaloan.loan.v1.Submission
+	0,  // 5: alphaloan.loan.v1.SubmitLoanRequest.customer:type_name -> alphaloan.loan.v1.Customer
+	2,  // 6: alphaloan.loan.v1.SubmitLoanRequest.parties:type_name -> alphaloan.loan.v1.SubmissionParty
+	1,  // 7: alphaloan.loan.v1.SubmitLoanRequest.proposed_loan:type_name -> alphaloan.loan.v1.Submission
+	3,  // 8: alphaloan.loan.v1.SubmitLoanResponse.valuation:type_name -> alphaloan.loan.v1.Valuation
+	4,  // 9: alphaloan.loan.v1.SubmitLoanResponse.quote:type_name -> alphaloan.loan.v1.Quote
+	2,  // 10: alphaloan.loan.v1.SubmitLoanResponse.parties:type_name -> alphaloan.loan.v1.SubmissionParty
+	5,  // 11: alphaloan.loan.v1.LoanService.GetCustomer:input_type -> alphaloan.loan.v1.GetCustomerRequest
+	7,  // 12: alphaloan.loan.v1.LoanService.ListCustomers:input_type -> alphaloan.loan.v1.ListCustomersRequest
+	9,  // 13: alphaloan.loan.v1.LoanService.GetSubmission:input_type -> alphaloan.loan.v1.GetSubmissionRequest
+	10, // 14: alphaloan.loan.v1.LoanService.ListSubmissions:input_type -> alphaloan.loan.v1.ListSubmissionsRequest
+	12, // 15: alphaloan.loan.v1.LoanService.SubmitLoan:input_type -> alphaloan.loan.v1.SubmitLoanRequest
+	6,  // 16: alphaloan.loan.v1.LoanService.GetCustomer:output_type -> alphaloan.loan.v1.GetCustomerResponse
+	8,  // 17: alphaloan.loan.v1.LoanService.ListCustomers:output_type -> alphaloan.loan.v1.ListCustomersResponse
+	1,  // 18: alphaloan.loan.v1.LoanService.GetSubmission:output_type -> alphaloan.loan.v1.Submission
+	11, // 19: alphaloan.loan.v1.LoanService.ListSubmissions:output_type -> alphaloan.loan.v1.ListSubmissionsResponse
+	13, // 20: alphaloan.loan.v1.LoanService.SubmitLoan:output_type -> alphaloan.loan.v1.SubmitLoanResponse
+	16, // [16:21] is the sub-list for method output_type
+	11, // [11:16] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_alphaloan_loan_v1_loan_proto_init() }
+func file_alphaloan_loan_v1_loan_proto_init() {
+	if File_alphaloan_loan_v1_loan_proto != nil {
+		return
+	}
+	file_alphaloan_loan_v1_loan_proto_msgTypes[0].OneofWrappers = []any{}
+	file_alphaloan_loan_v1_loan_proto_msgTypes[1].OneofWrappers = []any{}
+	file_alphaloan_loan_v1_loan_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_alphaloan_loan_v1_loan_proto_rawDesc), len(file_alphaloan_loan_v1_loan_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_alphaloan_loan_v1_loan_proto_goTypes,
+		DependencyIndexes: file_alphaloan_loan_v1_loan_proto_depIdxs,
+		MessageInfos:      file_alphaloan_loan_v1_loan_proto_msgTypes,
+	}.Build()
+	File_alphaloan_loan_v1_loan_proto = out.File
+	file_alphaloan_loan_v1_loan_proto_goTypes = nil
+	file_alphaloan_loan_v1_loan_proto_depIdxs = nil
+}
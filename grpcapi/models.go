@@ -0,0 +1,116 @@
+package grpcapi
+
+import (
+	"database/sql"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/grpcapi/loanpb"
+	"github.com/alphaloan/vehicle/handler"
+)
+
+func convertCustomerRow(row *datastore.LoanCustomerRow) *loanpb.Customer {
+	return &loanpb.Customer{
+		CustomerId:    row.CustomerID,
+		IdCardNumber:  row.IDCardNumber,
+		FullName:      row.FullName,
+		BirthDate:     row.BirthDate,
+		PhoneNumber:   row.PhoneNumber,
+		Email:         nullStringPtr(row.Email),
+		MonthlyIncome: row.MonthlyIncome,
+		AddressStreet: row.AddressStreet,
+		AddressCity:   row.AddressCity,
+		Locale:        row.Locale,
+	}
+}
+
+func convertSubmissionRow(row *datastore.LoanSubmissionRow) *loanpb.Submission {
+	return &loanpb.Submission{
+		SubmissionId:            row.SubmissionID,
+		CustomerId:              row.CustomerID,
+		VehicleType:             row.VehicleType,
+		VehicleBrand:            row.VehicleBrand,
+		VehicleModel:            row.VehicleModel,
+		VehicleLicenseNumber:    row.VehicleLicenseNumber,
+		VehicleOdometer:         int32(row.VehicleOdometer),
+		ManufacturingYear:       int32(row.ManufacturingYear),
+		ProposedLoanAmount:      int64(row.ProposedLoanAmount),
+		ProposedLoanTenureMonth: int32(row.ProposedLoanTenure),
+		IsCommercialVehicle:     row.IsCommercialVehicle,
+		IsDuplicateCollateral:   row.IsDuplicateCollateral,
+		LoanStatus:              row.LoanStatus,
+		ProductId:               nullStringPtr(row.ProductID),
+		DealerId:                nullStringPtr(row.DealerID),
+		AgentId:                 nullStringPtr(row.AgentID),
+		CreatedAt:               row.CreatedAt,
+		UpdatedAt:               row.UpdatedAt,
+	}
+}
+
+func convertCustomer(customer *loanpb.Customer) handler.LoanCustomer {
+	return handler.LoanCustomer{
+		IDCardNumber:  customer.GetIdCardNumber(),
+		FullName:      customer.GetFullName(),
+		BirthDate:     customer.GetBirthDate(),
+		PhoneNumber:   customer.GetPhoneNumber(),
+		Email:         customer.Email,
+		MonthlyIncome: customer.GetMonthlyIncome(),
+		AddressStreet: customer.GetAddressStreet(),
+		AddressCity:   customer.GetAddressCity(),
+		Locale:        customer.GetLocale(),
+	}
+}
+
+func convertProposal(submission *loanpb.Submission) handler.LoanSubmission {
+	return handler.LoanSubmission{
+		VehicleType:             submission.GetVehicleType(),
+		VehicleBrand:            submission.GetVehicleBrand(),
+		VehicleModel:            submission.GetVehicleModel(),
+		VehicleLicenseNumber:    submission.GetVehicleLicenseNumber(),
+		VehicleOdometer:         int(submission.GetVehicleOdometer()),
+		ManufacturingYear:       int(submission.GetManufacturingYear()),
+		ProposedLoanAmount:      int(submission.GetProposedLoanAmount()),
+		ProposedLoanTenureMonth: int(submission.GetProposedLoanTenureMonth()),
+		IsCommercialVehicle:     submission.GetIsCommercialVehicle(),
+		ProductID:               submission.ProductId,
+		DealerID:                submission.DealerId,
+		AgentID:                 submission.AgentId,
+	}
+}
+
+func convertValuation(valuation *handler.LoanValuation) *loanpb.Valuation {
+	return &loanpb.Valuation{
+		ModelId:          valuation.ModelID,
+		BasePrice:        int64(valuation.BasePrice),
+		VehicleAgeYears:  int32(valuation.VehicleAgeYears),
+		AgeFactor:        valuation.AgeFactor,
+		MileageFactor:    valuation.MileageFactor,
+		CommercialFactor: valuation.CommercialFactor,
+		EstimatedValue:   int64(valuation.EstimatedValue),
+		LoanToValue:      valuation.LoanToValue,
+		ValuedAt:         valuation.ValuedAt,
+	}
+}
+
+func convertQuote(quote *handler.LoanQuote) *loanpb.Quote {
+	return &loanpb.Quote{
+		ProductId:          quote.ProductID,
+		AnnualInterestRate: quote.AnnualInterestRate,
+		TenureMonth:        int32(quote.TenureMonth),
+		Principal:          int64(quote.Principal),
+		MonthlyInstallment: int64(quote.MonthlyInstallment),
+		TotalInterest:      int64(quote.TotalInterest),
+		AdminFee:           int64(quote.AdminFee),
+		ProvisionFee:       int64(quote.ProvisionFee),
+		InsuranceFee:       int64(quote.InsuranceFee),
+		TotalFees:          int64(quote.TotalFees),
+		TotalCost:          int64(quote.TotalCost),
+		QuotedAt:           quote.QuotedAt,
+	}
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
@@ -0,0 +1,193 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/alphaloan/vehicle/grpcapi/loanpb"
+	"github.com/alphaloan/vehicle/handler"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testCarTypeID = "5b0f3c6e-3f4a-4c1e-9a55-0c1d2e3f4a01"
+	testProductID = "8d2c1a40-6b1e-4f0a-9c7d-2e5f00000002"
+)
+
+// newTestClient serves the loan service on a migrated database with a Toyota
+// Avanza in the catalogue and returns a client dialed in process.
+func newTestClient(t *testing.T) loanpb.LoanServiceClient {
+	t.Helper()
+	db := datastoretest.Open(t)
+	catalogueStore := datastore.NewVehicleCatalogueStore(db)
+	brandID, err := catalogueStore.UpsertVehicleBrand(&datastore.VehicleBrandRow{BrandID: uuid.New().String(), Name: "Toyota"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = catalogueStore.UpsertVehicleModel(&datastore.VehicleModelRow{
+		ModelID: uuid.New().String(), BrandID: brandID, TypeID: testCarTypeID, Name: "Avanza", YearFrom: 2010,
+		BasePrice: sql.NullInt64{Int64: 200000000, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customerStore, submissionStore := datastore.NewLoanCustomerStore(db), datastore.NewLoanSubmissionStore(db)
+	submitter := handler.NewLoanSubmitHandler(*submissionStore, *catalogueStore,
+		*datastore.NewLoanProductStore(db), *datastore.NewDealerStore(db))
+	server := NewServer(NewLoanServer(*customerStore, *submissionStore, submitter))
+	conn, err := DialInProcess(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+	return loanpb.NewLoanServiceClient(conn)
+}
+
+func testSubmitLoanRequest(idCardNumber, brand string) *loanpb.SubmitLoanRequest {
+	productID := testProductID
+	return &loanpb.SubmitLoanRequest{
+		Customer: &loanpb.Customer{
+			IdCardNumber: idCardNumber, FullName: "Budi", BirthDate: "1990-01-01", PhoneNumber: "0812",
+			MonthlyIncome: 10000, AddressStreet: "Jl. Sudirman", AddressCity: "Jakarta",
+		},
+		ProposedLoan: &loanpb.Submission{
+			VehicleType: "Car", VehicleBrand: brand, VehicleModel: "Avanza", VehicleLicenseNumber: "B 1234 XYZ",
+			VehicleOdometer: 10000, ManufacturingYear: 2020, ProposedLoanAmount: 20000, ProposedLoanTenureMonth: 12,
+			ProductId: &productID,
+		},
+	}
+}
+
+func TestErrorCodesFollowTheHTTPStatus(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	unknownID := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		call       func() error
+		httpStatus int
+	}{
+		{"GetCustomer with an invalid id", func() error {
+			_, err := client.GetCustomer(ctx, &loanpb.GetCustomerRequest{CustomerId: "42"})
+			return err
+		}, http.StatusBadRequest},
+		{"GetCustomer of an unknown customer", func() error {
+			_, err := client.GetCustomer(ctx, &loanpb.GetCustomerRequest{CustomerId: unknownID})
+			return err
+		}, http.StatusNotFound},
+		{"ListCustomers with a negative page size", func() error {
+			_, err := client.ListCustomers(ctx, &loanpb.ListCustomersRequest{PageSize: -1})
+			return err
+		}, http.StatusBadRequest},
+		{"ListCustomers with an invalid page token", func() error {
+			_, err := client.ListCustomers(ctx, &loanpb.ListCustomersRequest{PageToken: "next"})
+			return err
+		}, http.StatusBadRequest},
+		{"GetSubmission with an invalid id", func() error {
+			_, err := client.GetSubmission(ctx, &loanpb.GetSubmissionRequest{SubmissionId: "42"})
+			return err
+		}, http.StatusBadRequest},
+		{"GetSubmission of an unknown submission", func() error {
+			_, err := client.GetSubmission(ctx, &loanpb.GetSubmissionRequest{SubmissionId: unknownID})
+			return err
+		}, http.StatusNotFound},
+		{"ListSubmissions with an invalid customer id", func() error {
+			_, err := client.ListSubmissions(ctx, &loanpb.ListSubmissionsRequest{CustomerId: "42"})
+			return err
+		}, http.StatusBadRequest},
+		{"ListSubmissions with an invalid page token", func() error {
+			_, err := client.ListSubmissions(ctx, &loanpb.ListSubmissionsRequest{PageToken: "-5"})
+			return err
+		}, http.StatusBadRequest},
+		{"SubmitLoan without a proposed loan", func() error {
+			request := testSubmitLoanRequest("3171000000000001", "Toyota")
+			request.ProposedLoan = nil
+			_, err := client.SubmitLoan(ctx, request)
+			return err
+		}, http.StatusBadRequest},
+		{"SubmitLoan of a brand not in the catalogue", func() error {
+			_, err := client.SubmitLoan(ctx, testSubmitLoanRequest("3171000000000001", "Tesla"))
+			return err
+		}, http.StatusUnprocessableEntity},
+		{"SubmitLoan attributed to a dealer", func() error {
+			request := testSubmitLoanRequest("3171000000000001", "Toyota")
+			dealerID := uuid.New().String()
+			request.ProposedLoan.DealerId = &dealerID
+			_, err := client.SubmitLoan(ctx, request)
+			return err
+		}, http.StatusUnprocessableEntity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.call()
+			if want := CodeForHTTPStatus(test.httpStatus); status.Code(err) != want {
+				t.Errorf("code = %v (%v), want %v", status.Code(err), err, want)
+			}
+		})
+	}
+}
+
+func TestSubmitLoanThenRead(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	submitted, err := client.SubmitLoan(ctx, testSubmitLoanRequest("3171000000000001", "toyota"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submitted.GetQuote().GetProductId() != testProductID || submitted.GetValuation() == nil {
+		t.Errorf("response = %v, want the loan quoted and the vehicle valued", submitted)
+	}
+
+	customer, err := client.GetCustomer(ctx, &loanpb.GetCustomerRequest{CustomerId: submitted.GetCustomerId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(customer.GetSubmissions()) != 1 || customer.GetSubmissions()[0].GetPartyRole() != datastore.PartyRolePrimary {
+		t.Errorf("customer submissions = %v, want the one submitted as primary applicant", customer.GetSubmissions())
+	}
+
+	submission, err := client.GetSubmission(ctx, &loanpb.GetSubmissionRequest{SubmissionId: submitted.GetSubmissionId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submission.GetVehicleBrand() != "Toyota" || submission.GetLoanStatus() == "" {
+		t.Errorf("submission = %s in %q, want the catalogue's brand and a status", submission.GetVehicleBrand(), submission.GetLoanStatus())
+	}
+
+	if _, err := client.SubmitLoan(ctx, testSubmitLoanRequest("3171000000000002", "Toyota")); err != nil {
+		t.Fatal(err)
+	}
+	page, err := client.ListSubmissions(ctx, &loanpb.ListSubmissionsRequest{PageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.GetSubmissions()) != 1 || page.GetNextPageToken() != "1" {
+		t.Fatalf("first page = %d submissions, token %q, want 1 and a token", len(page.GetSubmissions()), page.GetNextPageToken())
+	}
+	filtered, err := client.ListSubmissions(ctx, &loanpb.ListSubmissionsRequest{CustomerId: submitted.GetCustomerId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.GetSubmissions()) != 1 || filtered.GetSubmissions()[0].GetSubmissionId() != submitted.GetSubmissionId() {
+		t.Errorf("submissions of the customer = %v, want only theirs", filtered.GetSubmissions())
+	}
+
+	customers, err := client.ListCustomers(ctx, &loanpb.ListCustomersRequest{AddressCity: "jakarta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(customers.GetCustomers()) != 2 {
+		t.Errorf("%d customers in Jakarta, want 2", len(customers.GetCustomers()))
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/collections"
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/repayment"
+)
+
+type CollectionsHandler struct {
+	DelinquencyStore datastore.LoanDelinquencyStore
+	Job              *collections.Job
+}
+
+func NewCollectionsHandler(delinquencyStore datastore.LoanDelinquencyStore, job *collections.Job) *CollectionsHandler {
+	return &CollectionsHandler{
+		DelinquencyStore: delinquencyStore,
+		Job:              job,
+	}
+}
+
+func (h *CollectionsHandler) HandleGetAgingReport(w http.ResponseWriter, r *http.Request) {
+	addressCity := strings.TrimSpace(r.URL.Query().Get("address_city"))
+	vehicleType := strings.TrimSpace(r.URL.Query().Get("vehicle_type"))
+	rows, err := h.DelinquencyStore.GetAgingReport(addressCity, vehicleType)
+	if err != nil {
+		errMsg := "Failed to get aging report"
+		writeJSON(w, http.StatusInternalServerError, GetAgingReportResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	report := AgingReport{
+		Buckets: make([]AgingBucket, 0, len(repayment.Buckets)),
+		Loans:   make([]AgingLoan, 0, len(rows)),
+	}
+	if addressCity != "" {
+		report.AddressCity = &addressCity
+	}
+	if vehicleType != "" {
+		report.VehicleType = &vehicleType
+	}
+
+	bucketIndex := make(map[string]int, len(repayment.Buckets))
+	for i, bucket := range repayment.Buckets {
+		bucketIndex[bucket] = i
+		report.Buckets = append(report.Buckets, AgingBucket{Bucket: bucket})
+	}
+	for _, row := range rows {
+		if i, ok := bucketIndex[row.Bucket]; ok {
+			report.Buckets[i].LoanCount++
+			report.Buckets[i].OverdueAmount += row.OverdueAmount
+			report.Buckets[i].OutstandingAmount += row.OutstandingAmount
+		}
+		report.Loans = append(report.Loans, AgingLoan{
+			SubmissionID:         row.SubmissionID,
+			CustomerID:           row.CustomerID,
+			FullName:             row.FullName,
+			PhoneNumber:          row.PhoneNumber,
+			AddressCity:          row.AddressCity,
+			VehicleType:          row.VehicleType,
+			VehicleLicenseNumber: row.VehicleLicenseNumber,
+			Bucket:               row.Bucket,
+			DaysPastDue:          row.DaysPastDue,
+			OverdueAmount:        row.OverdueAmount,
+			OverdueInstallments:  row.OverdueInstallments,
+			OutstandingAmount:    row.OutstandingAmount,
+			PenaltyOutstanding:   row.PenaltyOutstanding,
+			AsOf:                 row.AsOf,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, GetAgingReportResponse{Data: &report})
+}
+
+// HandleRunCollections runs the collections job on demand, e.g. to backfill
+// a day the scheduler missed.
+func (h *CollectionsHandler) HandleRunCollections(w http.ResponseWriter, r *http.Request) {
+	var asOfParam *string
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		asOfParam = &value
+	}
+	asOf, err := parseDate(asOfParam, collections.Today())
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, RunCollectionsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	summary, err := h.Job.Run(asOf)
+	if err != nil {
+		errMsg := "Collections run failed: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, RunCollectionsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RunCollectionsResponse{Data: &CollectionsRunSummary{
+		AsOf:                summary.AsOf.Format(time.DateOnly),
+		LoansAssessed:       summary.LoansAssessed,
+		LoansFailed:         summary.LoansFailed,
+		OverdueLoans:        summary.OverdueLoans,
+		PenaltyCharged:      summary.PenaltyCharged,
+		CommissionClawbacks: summary.CommissionClawbacks,
+		RemindersQueued:     summary.RemindersQueued,
+	}})
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/collections"
+	"github.com/alphaloan/vehicle/commission"
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+type CommissionHandler struct {
+	SubmissionStore datastore.LoanSubmissionStore
+	DealerStore     datastore.DealerStore
+	CommissionStore datastore.CommissionStore
+	Ledger          *commission.Ledger
+}
+
+func NewCommissionHandler(
+	submissionStore datastore.LoanSubmissionStore,
+	dealerStore datastore.DealerStore,
+	commissionStore datastore.CommissionStore,
+	ledger *commission.Ledger) *CommissionHandler {
+	return &CommissionHandler{
+		SubmissionStore: submissionStore,
+		DealerStore:     dealerStore,
+		CommissionStore: commissionStore,
+		Ledger:          ledger,
+	}
+}
+
+// HandleSubmissionDecision approves, rejects or cancels a submission on
+// behalf of the operator of the request, who is recorded with the decision.
+// Approval accrues the dealer and agent commission, cancellation claws it back
+// when it falls inside the dealer's clawback window.
+func (h *CommissionHandler) HandleSubmissionDecision(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	var request SubmissionDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	decision := strings.ToUpper(strings.TrimSpace(request.Decision))
+	operator, _ := operatorFromContext(r.Context())
+
+	submission, err := h.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	now := time.Now()
+	var entries []*datastore.CommissionEntryRow
+	switch decision {
+	case "APPROVED":
+		entries, err = h.Ledger.Accruals(submission, now)
+		if err != nil {
+			log.Printf("commission accrual for submission %s failed: %v\n", submissionID, err)
+			errMsg := "Failed to calculate commission"
+			writeJSON(w, http.StatusInternalServerError, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+			return
+		}
+		entries, err = h.CommissionStore.ApproveSubmission(submissionID, operator, now.Unix(), entries)
+	case "REJECTED":
+		err = h.SubmissionStore.RejectSubmission(submissionID, operator, now.Unix())
+	case "CANCELLED":
+		entries, err = h.Ledger.Clawbacks(submissionID, commission.ReasonCancelled, now)
+		if err != nil {
+			log.Printf("commission clawback for submission %s failed: %v\n", submissionID, err)
+			errMsg := "Failed to calculate commission clawback"
+			writeJSON(w, http.StatusInternalServerError, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+			return
+		}
+		entries, err = h.CommissionStore.CancelSubmission(submissionID, operator, now.Unix(), entries)
+	default:
+		errMsg := fmt.Sprintf("decision must be APPROVED, REJECTED or CANCELLED, got %q", request.Decision)
+		writeJSON(w, http.StatusBadRequest, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if errors.Is(err, datastore.ErrSubmissionNotPending) || errors.Is(err, datastore.ErrSubmissionNotCancellable) {
+		errMsg := fmt.Sprintf("Cannot move submission from %s to %s: %v", submission.LoanStatus, decision, err)
+		writeJSON(w, http.StatusConflict, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		log.Printf("recording decision %s on submission %s failed: %v\n", decision, submissionID, err)
+		errMsg := "Failed to record decision"
+		writeJSON(w, http.StatusInternalServerError, SubmissionDecisionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	result := SubmissionDecision{
+		SubmissionID: submissionID,
+		LoanStatus:   decision,
+		DecidedBy:    operator,
+		Commissions:  make([]CommissionEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		result.Commissions = append(result.Commissions, convertCommissionEntryRow(entry))
+	}
+	writeJSON(w, http.StatusOK, SubmissionDecisionResponse{Data: &result})
+}
+
+// HandleGetCommissionStatement returns the commission statement of a dealer
+// for ?month=YYYY-MM, the current month by default. Finance gets it as CSV
+// with ?format=csv or an Accept: text/csv header.
+func (h *CommissionHandler) HandleGetCommissionStatement(w http.ResponseWriter, r *http.Request) {
+	dealerID := r.PathValue("dealerID")
+	if !IsValidUUID(dealerID) {
+		errMsg := "Invalid dealer ID: " + dealerID
+		writeJSON(w, http.StatusBadRequest, CommissionStatementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	h.writeCommissionStatement(w, r, dealerID)
+}
+
+func (h *CommissionHandler) HandleGetOwnCommissionStatement(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+	h.writeCommissionStatement(w, r, dealerID)
+}
+
+func (h *CommissionHandler) writeCommissionStatement(w http.ResponseWriter, r *http.Request, dealerID string) {
+	month := commission.StatementMonth(collections.Today())
+	if value := strings.TrimSpace(r.URL.Query().Get("month")); value != "" {
+		if _, err := time.Parse("2006-01", value); err != nil {
+			errMsg := fmt.Sprintf("invalid month %q, expected YYYY-MM", value)
+			writeJSON(w, http.StatusBadRequest, CommissionStatementResponse{ErrorMessage: &errMsg})
+			return
+		}
+		month = value
+	}
+
+	dealer, err := h.DealerStore.GetDealerById(dealerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Dealer not found: " + dealerID
+		writeJSON(w, http.StatusNotFound, CommissionStatementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get dealer " + dealerID
+		writeJSON(w, http.StatusInternalServerError, CommissionStatementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.CommissionStore.GetCommissionStatement(dealerID, month)
+	if err != nil {
+		errMsg := "Failed to get commission statement"
+		writeJSON(w, http.StatusInternalServerError, CommissionStatementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=\"commission-%s-%s.csv\"", strings.ToLower(dealer.Code), month))
+		if err := commission.WriteStatementCSV(w, rows); err != nil {
+			log.Printf("writing commission statement of dealer %s failed: %v\n", dealerID, err)
+		}
+		return
+	}
+
+	statement := buildCommissionStatement(dealer, month, rows)
+	writeJSON(w, http.StatusOK, CommissionStatementResponse{Data: &statement})
+}
@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alphaloan/vehicle/openapi"
+)
+
+// MaxValidatedBodyBytes bounds the JSON bodies ContractValidator reads ahead
+// of the handlers; larger ones are refused with 413.
+const MaxValidatedBodyBytes = 1 << 20
+
+// ContractViolationHeader is set on responses that break the OpenAPI
+// document while responses are validated.
+const ContractViolationHeader = "X-Contract-Violation"
+
+// ContractValidator checks requests against the OpenAPI document before the
+// mux serves them. Requests with wrong parameters, or with a JSON body that
+// has unknown fields, wrong types or missing required properties, are
+// answered with 400 and a JSON pointer to every violation. Legacy aliases are
+// validated as their successors.
+//
+// Requests to an operation that requires credentials are not validated when
+// they carry no Authorization header: the handler refuses them with 401
+// whatever they hold, and the document says so on every such operation.
+//
+// ValidateResponses is meant for development: JSON responses are held back
+// and checked against the documented status, and violations are logged and
+// flagged with ContractViolationHeader. The response itself is sent as is.
+type ContractValidator struct {
+	Document          *openapi.Document
+	Mux               *http.ServeMux
+	ValidateRequests  bool
+	ValidateResponses bool
+}
+
+func NewContractValidator(document *openapi.Document, mux *http.ServeMux) *ContractValidator {
+	return &ContractValidator{
+		Document:         document,
+		Mux:              mux,
+		ValidateRequests: true,
+	}
+}
+
+func (v *ContractValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := v.findOperation(r)
+	if target == nil {
+		v.Mux.ServeHTTP(w, r)
+		return
+	}
+
+	// Requests without the credentials an operation needs are left to the
+	// handler, so that they are told to authenticate rather than what is wrong
+	// with a request they may not send.
+	if v.ValidateRequests && (!requiresCredentials(target.operation) || r.Header.Get("Authorization") != "") {
+		violations, status := v.validateRequest(w, r, target.operation, target.params)
+		if len(violations) > 0 {
+			target.renameLegacyParams(violations)
+			errMsg := "Request does not match the API: " + describeViolation(violations[0])
+			if len(violations) > 1 {
+				errMsg += fmt.Sprintf(" (and %d more)", len(violations)-1)
+			}
+			writeJSON(w, status, RequestValidationResponse{ErrorMessage: &errMsg, Errors: violations})
+			return
+		}
+	}
+
+	if !v.ValidateResponses {
+		v.Mux.ServeHTTP(w, r)
+		return
+	}
+	recorder := &contractRecorder{ResponseWriter: w, validator: v, request: r, operation: target.documented}
+	v.Mux.ServeHTTP(recorder, r)
+	recorder.finish()
+}
+
+// contractTarget is what a request is checked against: the operation its
+// request is validated by, with the path parameters the mux matched, and the
+// operation its response is documented by. Both are the same except for a
+// legacy alias, whose request is validated as its successor's.
+type contractTarget struct {
+	operation  *openapi.Operation
+	params     map[string]string
+	documented *openapi.Operation
+	legacy     *LegacyRoute
+}
+
+// findOperation looks up the documented operation of the pattern the mux
+// routes the request to. A legacy alias resolves to the operation of its
+// successor, with the path values it fills from the query; its response is
+// documented by its own deprecated operation, which adds 410 Gone.
+func (v *ContractValidator) findOperation(r *http.Request) *contractTarget {
+	_, pattern := v.Mux.Handler(r)
+	path := pattern
+	if _, routed, ok := strings.Cut(pattern, " "); ok {
+		path = routed
+	}
+	params, ok := matchPath(path, r.URL.Path)
+	if !ok {
+		return nil
+	}
+	target := &contractTarget{params: params, documented: v.documentedOperation(r.Method, path)}
+	target.operation = target.documented
+
+	if i := slices.IndexFunc(LegacyRoutes, func(route LegacyRoute) bool { return route.Pattern == pattern }); i >= 0 {
+		target.legacy = &LegacyRoutes[i]
+		query := r.URL.Query()
+		for name, param := range target.legacy.QueryParams {
+			if query.Has(param) {
+				params[name] = query.Get(param)
+			}
+		}
+		method, successorPath, _ := strings.Cut(target.legacy.Successor, " ")
+		target.operation = v.documentedOperation(method, successorPath)
+		if target.documented == nil {
+			target.documented = target.operation
+		}
+	}
+	if target.operation == nil {
+		return nil
+	}
+	return target
+}
+
+// renameLegacyParams reports the path values a legacy alias takes from its
+// query under the query parameters the caller sent.
+func (t *contractTarget) renameLegacyParams(violations []RequestValidationError) {
+	if t.legacy == nil {
+		return
+	}
+	for i, violation := range violations {
+		if param, ok := t.legacy.QueryParams[violation.Parameter]; ok && violation.In == "path" {
+			violations[i].In, violations[i].Parameter = "query", param
+		}
+	}
+}
+
+func (v *ContractValidator) documentedOperation(method, path string) *openapi.Operation {
+	item := v.Document.Paths[path]
+	if item == nil {
+		return nil
+	}
+	return item.Operations()[method]
+}
+
+// matchPath reads the wildcards of pattern out of path. Paths the mux only
+// redirects, such as /docs for /docs/, do not match.
+func matchPath(pattern, path string) (map[string]string, bool) {
+	patternSegments := strings.Split(pattern, "/")
+	segments := strings.Split(path, "/")
+	if len(segments) < len(patternSegments) ||
+		(len(segments) > len(patternSegments) && !strings.HasSuffix(pattern, "/")) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range patternSegments {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			params[strings.TrimSuffix(name, "}")] = segments[i]
+		}
+	}
+	return params, true
+}
+
+func requiresCredentials(operation *openapi.Operation) bool {
+	for _, requirement := range operation.Security {
+		if len(requirement) == 0 {
+			return false
+		}
+	}
+	return len(operation.Security) > 0
+}
+
+// validateRequest returns the violations of the request and the status to
+// refuse it with.
+func (v *ContractValidator) validateRequest(w http.ResponseWriter, r *http.Request, operation *openapi.Operation,
+	params map[string]string) ([]RequestValidationError, int) {
+	var violations []RequestValidationError
+	query := r.URL.Query()
+	for _, param := range operation.Parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			if value, ok := params[param.Name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[param.Name]
+		case "header":
+			values = r.Header.Values(param.Name)
+		}
+		if len(values) == 0 {
+			if param.Required {
+				violations = append(violations, RequestValidationError{In: param.In, Parameter: param.Name,
+					Message: "required parameter is missing"})
+			}
+			continue
+		}
+		for _, value := range values {
+			if err := openapi.ValidateParameter(param.Schema, value); err != nil {
+				violations = append(violations, RequestValidationError{In: param.In, Parameter: param.Name,
+					Message: fmt.Sprintf("%v, got %q", err, value)})
+			}
+		}
+	}
+
+	media := jsonRequestBody(r, operation)
+	if media == nil {
+		return violations, http.StatusBadRequest
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxValidatedBodyBytes))
+	r.Body.Close()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		violations = append(violations, RequestValidationError{In: "body",
+			Message: fmt.Sprintf("body is larger than %d bytes", MaxValidatedBodyBytes)})
+		return violations, http.StatusRequestEntityTooLarge
+	}
+	if err != nil {
+		violations = append(violations, RequestValidationError{In: "body", Message: "failed to read the body"})
+		return violations, http.StatusBadRequest
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			violations = append(violations, RequestValidationError{In: "body", Message: "request body is required"})
+		}
+		return violations, http.StatusBadRequest
+	}
+	value, err := openapi.DecodeJSON(body)
+	if err != nil {
+		violations = append(violations, RequestValidationError{In: "body", Message: "invalid JSON: " + err.Error()})
+		return violations, http.StatusBadRequest
+	}
+	for _, violation := range v.Document.ValidateRequest(media.Schema, value) {
+		violations = append(violations, RequestValidationError{In: "body", Pointer: violation.Pointer,
+			Message: violation.Message})
+	}
+	return violations, http.StatusBadRequest
+}
+
+// jsonRequestBody returns the JSON body of the operation unless the request
+// is sent as another documented type, such as a multipart upload. Handlers
+// decode JSON whatever the Content-Type says, so a body sent without one, or
+// as a form by default, is validated as JSON too.
+func jsonRequestBody(r *http.Request, operation *openapi.Operation) *openapi.MediaType {
+	if operation.RequestBody == nil {
+		return nil
+	}
+	media := operation.RequestBody.Content[openapi.ContentTypeJSON]
+	if media == nil {
+		return nil
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != openapi.ContentTypeJSON && operation.RequestBody.Content[contentType] != nil {
+		return nil
+	}
+	return media
+}
+
+func describeViolation(violation RequestValidationError) string {
+	switch {
+	case violation.Parameter != "":
+		return fmt.Sprintf("%s parameter %s: %s", violation.In, violation.Parameter, violation.Message)
+	case violation.Pointer != "":
+		return violation.Pointer + ": " + violation.Message
+	}
+	return violation.Message
+}
+
+// contractRecorder passes responses through, except that JSON bodies are
+// held back until the handler returns so that they can be validated whole.
+type contractRecorder struct {
+	http.ResponseWriter
+	validator   *ContractValidator
+	request     *http.Request
+	operation   *openapi.Operation
+	status      int
+	wroteHeader bool
+	body        *bytes.Buffer
+}
+
+func (rec *contractRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+
+	response := rec.documentedResponse()
+	if response == nil {
+		rec.ResponseWriter.WriteHeader(status)
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if contentType == openapi.ContentTypeJSON {
+		rec.body = &bytes.Buffer{}
+		return
+	}
+	if contentType != "" && response.Content != nil && response.Content[contentType] == nil {
+		rec.violation(fmt.Sprintf("%d is not documented as %s", status, contentType))
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *contractRecorder) Write(p []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.body != nil {
+		return rec.body.Write(p)
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *contractRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// documentedResponse flags a status the operation does not document. 405 is
+// answered by every handler to the methods it does not serve.
+func (rec *contractRecorder) documentedResponse() *openapi.Response {
+	response := rec.operation.Responses[strconv.Itoa(rec.status)]
+	if response == nil && rec.status != http.StatusMethodNotAllowed {
+		rec.violation(fmt.Sprintf("status %d is not documented", rec.status))
+	}
+	return response
+}
+
+// finish validates and sends a held back JSON body.
+func (rec *contractRecorder) finish() {
+	if rec.body == nil {
+		return
+	}
+	media := rec.operation.Responses[strconv.Itoa(rec.status)].Content[openapi.ContentTypeJSON]
+	if media == nil {
+		rec.violation(fmt.Sprintf("%d is not documented as JSON", rec.status))
+	} else if value, err := openapi.DecodeJSON(rec.body.Bytes()); err != nil {
+		rec.violation("invalid JSON: " + err.Error())
+	} else {
+		for _, violation := range rec.validator.Document.ValidateResponse(media.Schema, value) {
+			rec.violation(violation.Error())
+		}
+	}
+	rec.ResponseWriter.WriteHeader(rec.status)
+	rec.ResponseWriter.Write(rec.body.Bytes())
+}
+
+func (rec *contractRecorder) violation(message string) {
+	log.Printf("Contract violation in response to %s %s: %s", rec.request.Method, rec.request.URL.Path, message)
+	rec.Header().Add(ContractViolationHeader, message)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+const dealerKeyPrefix = "dk_"
+
+type dealerContextKey struct{}
+
+// RequireDealerKey authenticates the dealer API key sent as a bearer token and
+// hands the dealer id to next through the request context. Handlers behind it
+// only ever see the calling dealer's data.
+func RequireDealerKey(store datastore.DealerStore, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(key, dealerKeyPrefix) {
+			writeUnauthorized(w, "A dealer API key is required")
+			return
+		}
+
+		dealerID, err := store.AuthenticateDealer(hashAPIKey(key), time.Now().Unix())
+		if errors.Is(err, sql.ErrNoRows) {
+			writeUnauthorized(w, "Invalid or revoked dealer API key")
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to authenticate dealer", http.StatusInternalServerError)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), dealerContextKey{}, dealerID)))
+	}
+}
+
+// AcceptDealerKey authenticates a dealer API key as RequireDealerKey does when
+// the request sends credentials, and serves requests without any as they are.
+func AcceptDealerKey(store datastore.DealerStore, next http.HandlerFunc) http.HandlerFunc {
+	authenticated := RequireDealerKey(store, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next(w, r)
+			return
+		}
+		authenticated(w, r)
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter, errMsg string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeJSON(w, http.StatusUnauthorized, ErrorResponse{ErrorMessage: &errMsg})
+}
+
+func dealerIDFromContext(ctx context.Context) (string, bool) {
+	dealerID, ok := ctx.Value(dealerContextKey{}).(string)
+	return dealerID, ok
+}
+
+// newDealerKey returns a random API key and the short prefix kept in clear to
+// tell keys apart.
+func newDealerKey() (string, string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	key := dealerKeyPrefix + hex.EncodeToString(secret)
+	return key, key[:len(dealerKeyPrefix)+8], nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,530 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/collections"
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/google/uuid"
+)
+
+type DealerHandler struct {
+	DealerStore     datastore.DealerStore
+	SubmissionStore datastore.LoanSubmissionStore
+}
+
+func NewDealerHandler(dealerStore datastore.DealerStore, submissionStore datastore.LoanSubmissionStore) *DealerHandler {
+	return &DealerHandler{
+		DealerStore:     dealerStore,
+		SubmissionStore: submissionStore,
+	}
+}
+
+func (h *DealerHandler) HandleGetDealers(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.DealerStore.GetAllDealers()
+	if err != nil {
+		errMsg := "Failed to get dealers"
+		writeJSON(w, http.StatusInternalServerError, GetAllDealersResponse{ErrorMessage: &errMsg})
+		return
+	}
+	dealers := make([]Dealer, 0, len(rows))
+	for _, row := range rows {
+		dealers = append(dealers, convertDealerRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetAllDealersResponse{Data: &dealers})
+}
+
+func (h *DealerHandler) HandleCreateDealer(w http.ResponseWriter, r *http.Request) {
+	h.upsertDealer(w, r, uuid.New().String(), http.StatusCreated)
+}
+
+func (h *DealerHandler) HandleGetDealerById(w http.ResponseWriter, r *http.Request) {
+	dealerID := r.PathValue("dealerID")
+	if !IsValidUUID(dealerID) {
+		errMsg := "Invalid dealer ID: " + dealerID
+		writeJSON(w, http.StatusBadRequest, GetDealerByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	row, err := h.DealerStore.GetDealerById(dealerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Dealer not found: " + dealerID
+		writeJSON(w, http.StatusNotFound, GetDealerByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get dealer " + dealerID
+		writeJSON(w, http.StatusInternalServerError, GetDealerByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+	dealer := convertDealerRow(row)
+	writeJSON(w, http.StatusOK, GetDealerByIdResponse{Data: &dealer})
+}
+
+func (h *DealerHandler) HandleUpdateDealer(w http.ResponseWriter, r *http.Request) {
+	dealerID := r.PathValue("dealerID")
+	if !IsValidUUID(dealerID) {
+		errMsg := "Invalid dealer ID: " + dealerID
+		writeJSON(w, http.StatusBadRequest, GetDealerByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	h.upsertDealer(w, r, dealerID, http.StatusOK)
+}
+
+func (h *DealerHandler) HandleDeactivateDealer(w http.ResponseWriter, r *http.Request) {
+	dealerID := r.PathValue("dealerID")
+	if !IsValidUUID(dealerID) {
+		errMsg := "Invalid dealer ID: " + dealerID
+		writeJSON(w, http.StatusBadRequest, GetDealerByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if err := h.DealerStore.DeactivateDealer(dealerID, time.Now().Unix()); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusOK, DeactivateDealerResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, http.StatusOK, DeactivateDealerResponse{DealerID: &dealerID, Deactivated: true})
+}
+
+func (h *DealerHandler) upsertDealer(w http.ResponseWriter, r *http.Request, dealerID string, status int) {
+	var request Dealer
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().Unix()
+	row := &datastore.DealerRow{
+		DealerID:            dealerID,
+		Code:                strings.ToUpper(normaliseCatalogueName(request.Code)),
+		Name:                normaliseCatalogueName(request.Name),
+		PhoneNumber:         strings.TrimSpace(request.PhoneNumber),
+		AddressCity:         normaliseCatalogueName(request.AddressCity),
+		CommissionRate:      request.CommissionRate,
+		CommissionFlatFee:   request.CommissionFlatFee,
+		AgentCommissionRate: request.AgentCommissionRate,
+		ClawbackMonths:      request.ClawbackMonths,
+		IsActive:            request.IsActive,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if request.Email != nil && strings.TrimSpace(*request.Email) != "" {
+		row.Email = sql.NullString{String: strings.TrimSpace(*request.Email), Valid: true}
+	}
+	if request.CommissionCap != nil {
+		row.CommissionCap = sql.NullInt64{Int64: *request.CommissionCap, Valid: true}
+	}
+	for _, tier := range request.CommissionTiers {
+		row.CommissionTiers = append(row.CommissionTiers, &datastore.DealerCommissionTierRow{
+			MinMonthlyApprovals: tier.MinMonthlyApprovals,
+			CommissionRate:      tier.CommissionRate,
+		})
+	}
+
+	if err := validateDealerRow(row); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, UpsertDealerResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	id, err := h.DealerStore.UpsertDealer(row)
+	if err != nil {
+		errMsg := "Failed to save dealer: " + err.Error()
+		writeJSON(w, http.StatusBadRequest, UpsertDealerResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, status, UpsertDealerResponse{DealerID: &id})
+}
+
+func validateDealerRow(row *datastore.DealerRow) error {
+	if row.Code == "" || row.Name == "" {
+		return errors.New("code and name are required")
+	}
+	if row.PhoneNumber == "" || row.AddressCity == "" {
+		return errors.New("phone_number and address_city are required")
+	}
+	if row.CommissionRate < 0 || row.CommissionRate >= 1 {
+		return fmt.Errorf("commission_rate %.4f must be at least 0 and below 1", row.CommissionRate)
+	}
+	if row.CommissionFlatFee < 0 {
+		return errors.New("commission_flat_fee must not be negative")
+	}
+	if row.CommissionCap.Valid && row.CommissionCap.Int64 <= 0 {
+		return errors.New("commission_cap must be positive when set")
+	}
+	if row.AgentCommissionRate < 0 || row.AgentCommissionRate >= 1 {
+		return fmt.Errorf("agent_commission_rate %.4f must be at least 0 and below 1", row.AgentCommissionRate)
+	}
+	if row.ClawbackMonths < 0 {
+		return errors.New("clawback_months must not be negative")
+	}
+	seen := make(map[int]bool)
+	for _, tier := range row.CommissionTiers {
+		if tier.MinMonthlyApprovals <= 0 {
+			return errors.New("commission tier min_monthly_approvals must be positive")
+		}
+		if seen[tier.MinMonthlyApprovals] {
+			return fmt.Errorf("duplicate commission tier for %d monthly approvals", tier.MinMonthlyApprovals)
+		}
+		seen[tier.MinMonthlyApprovals] = true
+		if tier.CommissionRate < 0 || tier.CommissionRate >= 1 {
+			return fmt.Errorf("commission tier rate %.4f must be at least 0 and below 1", tier.CommissionRate)
+		}
+	}
+	return nil
+}
+
+func (h *DealerHandler) HandleGetDealerBranches(w http.ResponseWriter, r *http.Request) {
+	dealerID, status, err := h.requireDealer(r.PathValue("dealerID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DealerBranchesResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.DealerStore.GetDealerBranches(dealerID)
+	if err != nil {
+		errMsg := "Failed to get branches of dealer " + dealerID
+		writeJSON(w, http.StatusInternalServerError, DealerBranchesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	branches := make([]DealerBranch, 0, len(rows))
+	for _, row := range rows {
+		branches = append(branches, convertDealerBranchRow(row))
+	}
+	writeJSON(w, http.StatusOK, DealerBranchesResponse{DealerID: &dealerID, Data: &branches})
+}
+
+func (h *DealerHandler) HandleCreateDealerBranch(w http.ResponseWriter, r *http.Request) {
+	dealerID, status, err := h.requireDealer(r.PathValue("dealerID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DealerBranchesResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	var request DealerBranch
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	row := &datastore.DealerBranchRow{
+		BranchID:    uuid.New().String(),
+		DealerID:    dealerID,
+		Name:        normaliseCatalogueName(request.Name),
+		AddressCity: normaliseCatalogueName(request.AddressCity),
+		IsActive:    true,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if row.Name == "" || row.AddressCity == "" {
+		errMsg := "name and address_city are required"
+		writeJSON(w, http.StatusBadRequest, DealerBranchResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err := h.DealerStore.InsertDealerBranch(row); err != nil {
+		errMsg := "Failed to save branch: " + err.Error()
+		writeJSON(w, http.StatusConflict, DealerBranchResponse{ErrorMessage: &errMsg})
+		return
+	}
+	branch := convertDealerBranchRow(row)
+	writeJSON(w, http.StatusCreated, DealerBranchResponse{Data: &branch})
+}
+
+func (h *DealerHandler) HandleGetDealerAgents(w http.ResponseWriter, r *http.Request) {
+	dealerID, status, err := h.requireDealer(r.PathValue("dealerID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DealerAgentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	h.writeDealerAgents(w, dealerID)
+}
+
+func (h *DealerHandler) HandleCreateDealerAgent(w http.ResponseWriter, r *http.Request) {
+	dealerID, status, err := h.requireDealer(r.PathValue("dealerID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DealerAgentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	h.createDealerAgent(w, r, dealerID)
+}
+
+func (h *DealerHandler) writeDealerAgents(w http.ResponseWriter, dealerID string) {
+	rows, err := h.DealerStore.GetDealerAgents(dealerID)
+	if err != nil {
+		errMsg := "Failed to get agents of dealer " + dealerID
+		writeJSON(w, http.StatusInternalServerError, DealerAgentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	agents := make([]DealerAgent, 0, len(rows))
+	for _, row := range rows {
+		agents = append(agents, convertDealerAgentRow(row))
+	}
+	writeJSON(w, http.StatusOK, DealerAgentsResponse{DealerID: &dealerID, Data: &agents})
+}
+
+func (h *DealerHandler) createDealerAgent(w http.ResponseWriter, r *http.Request, dealerID string) {
+	var request DealerAgent
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	row := &datastore.DealerAgentRow{
+		AgentID:     uuid.New().String(),
+		DealerID:    dealerID,
+		FullName:    normaliseCatalogueName(request.FullName),
+		PhoneNumber: strings.TrimSpace(request.PhoneNumber),
+		IsActive:    true,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if row.FullName == "" || row.PhoneNumber == "" {
+		errMsg := "full_name and phone_number are required"
+		writeJSON(w, http.StatusBadRequest, DealerAgentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if request.Email != nil && strings.TrimSpace(*request.Email) != "" {
+		row.Email = sql.NullString{String: strings.TrimSpace(*request.Email), Valid: true}
+	}
+
+	if request.BranchID != nil {
+		branches, err := h.DealerStore.GetDealerBranches(dealerID)
+		if err != nil {
+			errMsg := "Failed to get branches of dealer " + dealerID
+			writeJSON(w, http.StatusInternalServerError, DealerAgentResponse{ErrorMessage: &errMsg})
+			return
+		}
+		for _, branch := range branches {
+			if branch.BranchID == *request.BranchID {
+				row.BranchID = sql.NullString{String: branch.BranchID, Valid: true}
+			}
+		}
+		if !row.BranchID.Valid {
+			errMsg := fmt.Sprintf("Branch %s does not belong to dealer %s", *request.BranchID, dealerID)
+			writeJSON(w, http.StatusBadRequest, DealerAgentResponse{ErrorMessage: &errMsg})
+			return
+		}
+	}
+
+	if err := h.DealerStore.InsertDealerAgent(row); err != nil {
+		errMsg := "Failed to save agent: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, DealerAgentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	agent := convertDealerAgentRow(row)
+	writeJSON(w, http.StatusCreated, DealerAgentResponse{Data: &agent})
+}
+
+func (h *DealerHandler) HandleGetDealerCredentials(w http.ResponseWriter, r *http.Request) {
+	dealerID, status, err := h.requireDealer(r.PathValue("dealerID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DealerCredentialsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.DealerStore.GetDealerCredentials(dealerID)
+	if err != nil {
+		errMsg := "Failed to get credentials of dealer " + dealerID
+		writeJSON(w, http.StatusInternalServerError, DealerCredentialsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	credentials := make([]DealerCredential, 0, len(rows))
+	for _, row := range rows {
+		credentials = append(credentials, convertDealerCredentialRow(row))
+	}
+	writeJSON(w, http.StatusOK, DealerCredentialsResponse{DealerID: &dealerID, Data: &credentials})
+}
+
+// HandleIssueDealerCredential issues a new API key to a dealer. The key is
+// shown once in the response, only its hash is kept.
+func (h *DealerHandler) HandleIssueDealerCredential(w http.ResponseWriter, r *http.Request) {
+	dealerID, status, err := h.requireDealer(r.PathValue("dealerID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DealerCredentialsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	key, prefix, err := newDealerKey()
+	if err != nil {
+		errMsg := "Failed to generate API key"
+		writeJSON(w, http.StatusInternalServerError, DealerCredentialResponse{ErrorMessage: &errMsg})
+		return
+	}
+	row := &datastore.DealerCredentialRow{
+		CredentialID: uuid.New().String(),
+		DealerID:     dealerID,
+		KeyPrefix:    prefix,
+		KeyHash:      hashAPIKey(key),
+		CreatedAt:    time.Now().Unix(),
+	}
+	if err := h.DealerStore.InsertDealerCredential(row); err != nil {
+		errMsg := "Failed to save API key"
+		writeJSON(w, http.StatusInternalServerError, DealerCredentialResponse{ErrorMessage: &errMsg})
+		return
+	}
+	credential := convertDealerCredentialRow(row)
+	credential.APIKey = &key
+	writeJSON(w, http.StatusCreated, DealerCredentialResponse{Data: &credential})
+}
+
+func (h *DealerHandler) HandleRevokeDealerCredential(w http.ResponseWriter, r *http.Request) {
+	dealerID := r.PathValue("dealerID")
+	credentialID := r.PathValue("credentialID")
+	if !IsValidUUID(dealerID) || !IsValidUUID(credentialID) {
+		errMsg := "Invalid dealer or credential ID"
+		writeJSON(w, http.StatusBadRequest, DealerCredentialResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err := h.DealerStore.RevokeDealerCredential(dealerID, credentialID, time.Now().Unix()); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusNotFound, DealerCredentialResponse{ErrorMessage: &errMsg})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetDealerSummaries reports volume and approval rate per dealer for
+// submissions created between the optional from and to dates, inclusive.
+func (h *DealerHandler) HandleGetDealerSummaries(w http.ResponseWriter, r *http.Request) {
+	dealerID := strings.TrimSpace(r.URL.Query().Get("dealer_id"))
+	if dealerID != "" && !IsValidUUID(dealerID) {
+		errMsg := "Invalid dealer ID: " + dealerID
+		writeJSON(w, http.StatusBadRequest, GetDealerSummariesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	h.writeDealerSummaries(w, r, dealerID)
+}
+
+func (h *DealerHandler) writeDealerSummaries(w http.ResponseWriter, r *http.Request, dealerID string) {
+	response := GetDealerSummariesResponse{}
+	from := time.Unix(0, 0).UTC()
+	to := collections.Today()
+	if value := r.URL.Query().Get("from"); value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			errMsg := fmt.Sprintf("invalid from date %q, expected YYYY-MM-DD", value)
+			writeJSON(w, http.StatusBadRequest, GetDealerSummariesResponse{ErrorMessage: &errMsg})
+			return
+		}
+		from = date
+		response.From = &value
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			errMsg := fmt.Sprintf("invalid to date %q, expected YYYY-MM-DD", value)
+			writeJSON(w, http.StatusBadRequest, GetDealerSummariesResponse{ErrorMessage: &errMsg})
+			return
+		}
+		to = date
+		response.To = &value
+	}
+
+	rows, err := h.DealerStore.GetDealerSummaries(dealerID, from.Unix(), to.AddDate(0, 0, 1).Unix())
+	if err != nil {
+		errMsg := "Failed to get dealer summaries"
+		writeJSON(w, http.StatusInternalServerError, GetDealerSummariesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	summaries := make([]DealerSummary, 0, len(rows))
+	for _, row := range rows {
+		summaries = append(summaries, convertDealerSummaryRow(row))
+	}
+	response.Data = &summaries
+	writeJSON(w, http.StatusOK, response)
+}
+
+// The handlers below serve the dealer portal and sit behind RequireDealerKey.
+
+func (h *DealerHandler) HandleGetOwnSubmissions(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+	rows, err := h.SubmissionStore.GetLoanSubmissionsByDealerId(dealerID)
+	if err != nil {
+		errMsg := "Failed to get loan submissions"
+		writeJSON(w, http.StatusInternalServerError, GetAllLoanSubmissionsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	submissions := make([]LoanSubmission, 0, len(rows))
+	for _, row := range rows {
+		submissions = append(submissions, convertLoanSubmissionRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetAllLoanSubmissionsResponse{Data: &submissions})
+}
+
+// HandleGetOwnSubmissionById answers 404 for submissions of other dealers so
+// their existence is not revealed.
+func (h *DealerHandler) HandleGetOwnSubmissionById(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetLoanSubmissionsByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	row, err := h.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && row.DealerID.String != dealerID) {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, GetLoanSubmissionsByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetLoanSubmissionsByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+	submission := convertLoanSubmissionRow(row)
+	writeJSON(w, http.StatusOK, GetLoanSubmissionsByIdResponse{Data: &submission})
+}
+
+func (h *DealerHandler) HandleGetOwnAgents(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+	h.writeDealerAgents(w, dealerID)
+}
+
+func (h *DealerHandler) HandleGetOwnSummary(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+	h.writeDealerSummaries(w, r, dealerID)
+}
+
+func (h *DealerHandler) requireDealer(dealerID string) (string, int, error) {
+	if !IsValidUUID(dealerID) {
+		return "", http.StatusBadRequest, fmt.Errorf("Invalid dealer ID: %s", dealerID)
+	}
+	_, err := h.DealerStore.GetDealerById(dealerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", http.StatusNotFound, fmt.Errorf("Dealer not found: %s", dealerID)
+	}
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("Failed to get dealer %s", dealerID)
+	}
+	return dealerID, http.StatusOK, nil
+}
@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/disbursement"
+	"github.com/alphaloan/vehicle/storage"
+	"github.com/google/uuid"
+)
+
+type DisbursementHandler struct {
+	DisbursementStore datastore.LoanDisbursementStore
+	SubmissionStore   datastore.LoanSubmissionStore
+	QuoteStore        datastore.LoanQuoteStore
+	// Outbox receives the bank payment file of every approved disbursement.
+	Outbox storage.BlobStore
+}
+
+func NewDisbursementHandler(
+	disbursementStore datastore.LoanDisbursementStore,
+	submissionStore datastore.LoanSubmissionStore,
+	quoteStore datastore.LoanQuoteStore,
+	outbox storage.BlobStore) *DisbursementHandler {
+	return &DisbursementHandler{
+		DisbursementStore: disbursementStore,
+		SubmissionStore:   submissionStore,
+		QuoteStore:        quoteStore,
+		Outbox:            outbox,
+	}
+}
+
+func (h *DisbursementHandler) HandleGetDisbursements(w http.ResponseWriter, r *http.Request) {
+	status := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("status")))
+	submissionID := strings.TrimSpace(r.URL.Query().Get("submission_id"))
+	if submissionID != "" && !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetDisbursementsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.DisbursementStore.GetDisbursements(status, submissionID)
+	if err != nil {
+		errMsg := "Failed to get disbursements"
+		writeJSON(w, http.StatusInternalServerError, GetDisbursementsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	disbursements := make([]Disbursement, 0, len(rows))
+	for _, row := range rows {
+		disbursements = append(disbursements, convertLoanDisbursementRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetDisbursementsResponse{Data: &disbursements})
+}
+
+func (h *DisbursementHandler) HandleCreateDisbursement(w http.ResponseWriter, r *http.Request) {
+	var request CreateDisbursementRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	if !IsValidUUID(request.SubmissionID) {
+		errMsg := "Invalid submission ID: " + request.SubmissionID
+		writeJSON(w, http.StatusBadRequest, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err := validateDisbursementRequest(&request); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	submission, err := h.SubmissionStore.GetLoanSubmissionById(request.SubmissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Submission not found: " + request.SubmissionID
+		writeJSON(w, http.StatusNotFound, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get submission " + request.SubmissionID
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if submission.LoanStatus != "APPROVED" {
+		errMsg := fmt.Sprintf("Submission %s is %s, only APPROVED submissions can be disbursed", request.SubmissionID, submission.LoanStatus)
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	// The payout is the financed principal of the approved terms. Fees that
+	// are withheld from it are given explicitly, fees collected through the
+	// installments are not deducted again.
+	quote, err := h.QuoteStore.GetQuoteBySubmissionId(request.SubmissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "No approved terms for submission " + request.SubmissionID
+		writeJSON(w, http.StatusUnprocessableEntity, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get quote for submission " + request.SubmissionID
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if request.DeductedFees >= quote.Principal {
+		errMsg := fmt.Sprintf("deducted_fees must be less than the loan amount %d", quote.Principal)
+		writeJSON(w, http.StatusBadRequest, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	operator, _ := operatorFromContext(r.Context())
+	row := &datastore.LoanDisbursementRow{
+		DisbursementID: uuid.New().String(),
+		SubmissionID:   request.SubmissionID,
+		PayeeType:      request.PayeeType,
+		PayeeName:      request.PayeeName,
+		BankCode:       request.BankCode,
+		AccountNumber:  request.AccountNumber,
+		AccountHolder:  request.AccountHolder,
+		LoanAmount:     quote.Principal,
+		DeductedFees:   request.DeductedFees,
+		NetAmount:      quote.Principal - request.DeductedFees,
+		Status:         datastore.DisbursementStatusPending,
+		CreatedBy:      operator,
+		CreatedAt:      time.Now().Unix(),
+	}
+	err = h.DisbursementStore.InsertDisbursement(row)
+	if errors.Is(err, datastore.ErrDisbursementOpen) {
+		errMsg := "Submission " + request.SubmissionID + " already has an open disbursement"
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		log.Println("failed to insert disbursement for submission", request.SubmissionID, err)
+		errMsg := "Failed to create disbursement for submission " + request.SubmissionID
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	disbursement := convertLoanDisbursementRow(row)
+	writeJSON(w, http.StatusCreated, DisbursementResponse{Data: &disbursement})
+}
+
+func (h *DisbursementHandler) HandleGetDisbursementById(w http.ResponseWriter, r *http.Request) {
+	row, status, err := h.loadDisbursement(r.PathValue("disbursementID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	disbursement := convertLoanDisbursementRow(row)
+	writeJSON(w, http.StatusOK, DisbursementResponse{Data: &disbursement})
+}
+
+// HandleApproveDisbursement is the checker step. A different operator than
+// the maker approves, the submission becomes DISBURSED and the payment file is
+// dropped in the outbox. The approval is committed first so that of two
+// concurrent approvals only the one that won writes the file; should writing
+// it fail, a checker retries it with HandleRetryPaymentFile.
+func (h *DisbursementHandler) HandleApproveDisbursement(w http.ResponseWriter, r *http.Request) {
+	row, request, status, err := h.prepareReview(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	reviewedAt := time.Now()
+	row.Status = datastore.DisbursementStatusApproved
+	row.ReviewedBy = sql.NullString{String: request.reviewedBy, Valid: true}
+	row.ReviewNote = reviewNote(request.Note)
+	row.PaymentFile = sql.NullString{String: disbursement.PaymentFileName(row.DisbursementID, valueDate(reviewedAt)), Valid: true}
+	row.PaymentFileStatus = sql.NullString{String: datastore.PaymentFilePending, Valid: true}
+	row.ReviewedAt = sql.NullInt64{Int64: reviewedAt.Unix(), Valid: true}
+
+	err = h.DisbursementStore.ApproveDisbursement(row)
+	if errors.Is(err, datastore.ErrDisbursementReviewed) {
+		errMsg := "Disbursement " + row.DisbursementID + " was already reviewed"
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if errors.Is(err, datastore.ErrSubmissionNotApproved) {
+		errMsg := "Submission " + row.SubmissionID + " is no longer APPROVED"
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to approve disbursement " + row.DisbursementID
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	h.writePaymentFile(w, row)
+}
+
+// HandleRetryPaymentFile writes the payment file of an approved disbursement
+// again after writing it failed. Like the approval it is a checker step, and
+// it is refused once the file was delivered to the outbox: the bank
+// integration removes files it picked up, so writing one again would pay the
+// disbursement twice.
+func (h *DisbursementHandler) HandleRetryPaymentFile(w http.ResponseWriter, r *http.Request) {
+	operator, _ := operatorFromContext(r.Context())
+	row, status, err := h.loadDisbursement(r.PathValue("disbursementID"))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if row.Status != datastore.DisbursementStatusApproved {
+		errMsg := fmt.Sprintf("Disbursement %s is %s, only the payment file of an APPROVED disbursement can be retried", row.DisbursementID, row.Status)
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if strings.EqualFold(row.CreatedBy, operator) {
+		errMsg := "A payment file must be retried by a different operator than the one who created the disbursement"
+		writeJSON(w, http.StatusForbidden, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	err = h.DisbursementStore.RetryPaymentFile(row.DisbursementID)
+	if errors.Is(err, datastore.ErrPaymentFileNotFailed) {
+		errMsg := fmt.Sprintf("The payment file of disbursement %s is %s, only a FAILED payment file can be retried",
+			row.DisbursementID, row.PaymentFileStatus.String)
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to retry the payment file of disbursement " + row.DisbursementID
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	row.PaymentFileStatus = sql.NullString{String: datastore.PaymentFilePending, Valid: true}
+
+	h.writePaymentFile(w, row)
+}
+
+// writePaymentFile puts the PENDING payment file of an approved disbursement
+// in the outbox under the key recorded with the approval and records whether
+// it was delivered.
+func (h *DisbursementHandler) writePaymentFile(w http.ResponseWriter, row *datastore.LoanDisbursementRow) {
+	var paymentFile bytes.Buffer
+	err := disbursement.WritePaymentFile(&paymentFile, []disbursement.PaymentInstruction{{
+		Reference:     row.DisbursementID,
+		ValueDate:     valueDate(time.Unix(row.ReviewedAt.Int64, 0)),
+		BankCode:      row.BankCode,
+		AccountNumber: row.AccountNumber,
+		AccountHolder: row.AccountHolder,
+		Amount:        row.NetAmount,
+		Narrative:     "Loan disbursement " + row.SubmissionID,
+	}})
+	if err == nil {
+		_, err = h.Outbox.Put(row.PaymentFile.String, &paymentFile)
+	}
+	if err != nil {
+		log.Println("failed to write payment file", row.PaymentFile.String, err)
+		if err := h.DisbursementStore.MarkPaymentFileFailed(row.DisbursementID); err != nil {
+			log.Println("failed to record payment file", row.PaymentFile.String, "as failed:", err)
+		}
+		errMsg := "Disbursement " + row.DisbursementID + " is approved but its payment file could not be written, retry the payment file"
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	deliveredAt := time.Now().Unix()
+	if err := h.DisbursementStore.MarkPaymentFileDelivered(row.DisbursementID, deliveredAt); err != nil {
+		log.Println("failed to record payment file", row.PaymentFile.String, "as delivered:", err)
+		errMsg := "The payment file of disbursement " + row.DisbursementID + " was written but its delivery could not be recorded"
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	row.PaymentFileStatus = sql.NullString{String: datastore.PaymentFileDelivered, Valid: true}
+	row.PaymentFileDeliveredAt = sql.NullInt64{Int64: deliveredAt, Valid: true}
+
+	disbursement := convertLoanDisbursementRow(row)
+	writeJSON(w, http.StatusOK, DisbursementResponse{Data: &disbursement})
+}
+
+func (h *DisbursementHandler) HandleRejectDisbursement(w http.ResponseWriter, r *http.Request) {
+	row, request, status, err := h.prepareReview(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	row.Status = datastore.DisbursementStatusRejected
+	row.ReviewedBy = sql.NullString{String: request.reviewedBy, Valid: true}
+	row.ReviewNote = reviewNote(request.Note)
+	row.ReviewedAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+
+	err = h.DisbursementStore.RejectDisbursement(row)
+	if errors.Is(err, datastore.ErrDisbursementReviewed) {
+		errMsg := "Disbursement " + row.DisbursementID + " was already reviewed"
+		writeJSON(w, http.StatusConflict, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to reject disbursement " + row.DisbursementID
+		writeJSON(w, http.StatusInternalServerError, DisbursementResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	disbursement := convertLoanDisbursementRow(row)
+	writeJSON(w, http.StatusOK, DisbursementResponse{Data: &disbursement})
+}
+
+// reviewRequest is a review with the operator making it.
+type reviewRequest struct {
+	ReviewDisbursementRequest
+	reviewedBy string
+}
+
+// prepareReview loads a pending disbursement and enforces that the reviewing
+// operator is not the one who created it.
+func (h *DisbursementHandler) prepareReview(r *http.Request) (*datastore.LoanDisbursementRow, *reviewRequest, int, error) {
+	request := &reviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request.ReviewDisbursementRequest); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, http.StatusBadRequest, errors.New("Bad request body")
+	}
+	request.reviewedBy, _ = operatorFromContext(r.Context())
+
+	row, status, err := h.loadDisbursement(r.PathValue("disbursementID"))
+	if err != nil {
+		return nil, nil, status, err
+	}
+	if row.Status != datastore.DisbursementStatusPending {
+		return nil, nil, http.StatusConflict, fmt.Errorf("Disbursement %s is already %s", row.DisbursementID, row.Status)
+	}
+	if strings.EqualFold(row.CreatedBy, request.reviewedBy) {
+		return nil, nil, http.StatusForbidden, errors.New("A disbursement must be reviewed by a different operator than the one who created it")
+	}
+	return row, request, http.StatusOK, nil
+}
+
+func (h *DisbursementHandler) loadDisbursement(disbursementID string) (*datastore.LoanDisbursementRow, int, error) {
+	if !IsValidUUID(disbursementID) {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid disbursement ID: %s", disbursementID)
+	}
+	row, err := h.DisbursementStore.GetDisbursementById(disbursementID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, fmt.Errorf("Disbursement not found: %s", disbursementID)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to get disbursement %s", disbursementID)
+	}
+	return row, http.StatusOK, nil
+}
+
+// valueDate is the UTC day a payment file approved at reviewedAt is paid on.
+func valueDate(reviewedAt time.Time) time.Time {
+	reviewedAt = reviewedAt.UTC()
+	return time.Date(reviewedAt.Year(), reviewedAt.Month(), reviewedAt.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func reviewNote(note *string) sql.NullString {
+	if note == nil || strings.TrimSpace(*note) == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: strings.TrimSpace(*note), Valid: true}
+}
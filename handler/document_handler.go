@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	DocumentTypeIDCard        = "ID_CARD"
+	DocumentTypeSTNK          = "STNK"
+	DocumentTypeBPKB          = "BPKB"
+	DocumentTypeProofOfIncome = "PROOF_OF_INCOME"
+	DocumentTypeVehiclePhoto  = "VEHICLE_PHOTO"
+)
+
+const DefaultMaxDocumentBytes = 10 << 20
+
+var imageContentTypes = []string{"image/jpeg", "image/png"}
+var scanContentTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+var allowedDocumentContentTypes = map[string][]string{
+	DocumentTypeIDCard:        scanContentTypes,
+	DocumentTypeSTNK:          scanContentTypes,
+	DocumentTypeBPKB:          scanContentTypes,
+	DocumentTypeProofOfIncome: scanContentTypes,
+	DocumentTypeVehiclePhoto:  imageContentTypes,
+}
+
+type DocumentHandler struct {
+	DocumentStore   datastore.DocumentStore
+	CustomerStore   datastore.LoanCustomerStore
+	SubmissionStore datastore.LoanSubmissionStore
+	ProductStore    datastore.LoanProductStore
+	BlobStore       storage.BlobStore
+	MaxUploadBytes  int64
+}
+
+func NewDocumentHandler(
+	documentStore datastore.DocumentStore,
+	customerStore datastore.LoanCustomerStore,
+	submissionStore datastore.LoanSubmissionStore,
+	productStore datastore.LoanProductStore,
+	blobStore storage.BlobStore) *DocumentHandler {
+	return &DocumentHandler{
+		DocumentStore:   documentStore,
+		CustomerStore:   customerStore,
+		SubmissionStore: submissionStore,
+		ProductStore:    productStore,
+		BlobStore:       blobStore,
+		MaxUploadBytes:  DefaultMaxDocumentBytes,
+	}
+}
+
+func (h *DocumentHandler) HandleGetCustomerDocuments(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("customerID")
+	if !h.lookupCustomer(w, customerID) {
+		return
+	}
+	rows, err := h.DocumentStore.GetDocumentsByCustomerId(customerID)
+	writeDocuments(w, rows, err)
+}
+
+func (h *DocumentHandler) HandleUploadCustomerDocument(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("customerID")
+	if !h.lookupCustomer(w, customerID) {
+		return
+	}
+	h.uploadDocument(w, r, customerID, sql.NullString{})
+}
+
+func (h *DocumentHandler) HandleGetSubmissionDocuments(w http.ResponseWriter, r *http.Request) {
+	submission, ok := h.lookupSubmission(w, r.PathValue("submissionID"))
+	if !ok {
+		return
+	}
+	rows, err := h.DocumentStore.GetDocumentsBySubmissionId(submission.SubmissionID)
+	writeDocuments(w, rows, err)
+}
+
+func (h *DocumentHandler) HandleUploadSubmissionDocument(w http.ResponseWriter, r *http.Request) {
+	submission, ok := h.lookupSubmission(w, r.PathValue("submissionID"))
+	if !ok {
+		return
+	}
+	h.uploadDocument(w, r, submission.CustomerID, sql.NullString{String: submission.SubmissionID, Valid: true})
+}
+
+// HandleGetDocumentChecklist reports which documents the submission's product
+// requires and whether each has been provided, either on the submission itself
+// or as a customer level document.
+func (h *DocumentHandler) HandleGetDocumentChecklist(w http.ResponseWriter, r *http.Request) {
+	submission, ok := h.lookupSubmission(w, r.PathValue("submissionID"))
+	if !ok {
+		return
+	}
+
+	checklist, err := h.buildChecklist(submission)
+	if err != nil {
+		errMsg := "Failed to build document checklist for submission " + submission.SubmissionID
+		writeJSON(w, http.StatusInternalServerError, GetDocumentChecklistResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, http.StatusOK, GetDocumentChecklistResponse{Data: checklist})
+}
+
+func (h *DocumentHandler) HandleDownloadDocument(w http.ResponseWriter, r *http.Request) {
+	document, ok := h.lookupDocument(w, r.PathValue("documentID"))
+	if !ok {
+		return
+	}
+
+	blob, err := h.BlobStore.Get(document.StorageKey)
+	if err != nil {
+		http.Error(w, "Failed to read document content", http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(document.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	w.Header().Set("X-Checksum-SHA256", document.SHA256)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+func (h *DocumentHandler) HandleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	document, ok := h.lookupDocument(w, r.PathValue("documentID"))
+	if !ok {
+		return
+	}
+
+	if err := h.DocumentStore.DeleteDocument(document.DocumentID); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusOK, DeleteDocumentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err := h.BlobStore.Delete(document.StorageKey); err != nil {
+		log.Printf("Failed to delete blob %s: %v", document.StorageKey, err)
+	}
+	writeJSON(w, http.StatusOK, DeleteDocumentResponse{DocumentID: &document.DocumentID, Deleted: true})
+}
+
+func (h *DocumentHandler) lookupCustomer(w http.ResponseWriter, customerID string) bool {
+	if !IsValidUUID(customerID) {
+		errMsg := "Invalid customer ID: " + customerID
+		writeJSON(w, http.StatusBadRequest, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return false
+	}
+
+	if _, err := h.CustomerStore.GetLoanCustomerById(customerID); errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Customer not found: " + customerID
+		writeJSON(w, http.StatusNotFound, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return false
+	} else if err != nil {
+		errMsg := "Failed to get customer " + customerID
+		writeJSON(w, http.StatusInternalServerError, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return false
+	}
+	return true
+}
+
+func (h *DocumentHandler) lookupDocument(w http.ResponseWriter, documentID string) (*datastore.DocumentRow, bool) {
+	if !IsValidUUID(documentID) {
+		errMsg := "Invalid document ID: " + documentID
+		writeJSON(w, http.StatusBadRequest, DeleteDocumentResponse{ErrorMessage: &errMsg})
+		return nil, false
+	}
+
+	document, err := h.DocumentStore.GetDocumentById(documentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Document not found: " + documentID
+		writeJSON(w, http.StatusNotFound, DeleteDocumentResponse{ErrorMessage: &errMsg})
+		return nil, false
+	}
+	if err != nil {
+		errMsg := "Failed to get document " + documentID
+		writeJSON(w, http.StatusInternalServerError, DeleteDocumentResponse{ErrorMessage: &errMsg})
+		return nil, false
+	}
+	return document, true
+}
+
+func (h *DocumentHandler) lookupSubmission(w http.ResponseWriter, submissionID string) (*datastore.LoanSubmissionRow, bool) {
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return nil, false
+	}
+
+	submission, err := h.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return nil, false
+	}
+	if err != nil {
+		errMsg := "Failed to get submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return nil, false
+	}
+	return submission, true
+}
+
+func (h *DocumentHandler) uploadDocument(w http.ResponseWriter, r *http.Request, customerID string, submissionID sql.NullString) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeUploadError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the limit of %d bytes", h.MaxUploadBytes))
+			return
+		}
+		writeUploadError(w, http.StatusBadRequest, "Expected a multipart/form-data body")
+		return
+	}
+
+	documentType := r.FormValue("document_type")
+	allowedContentTypes, ok := allowedDocumentContentTypes[documentType]
+	if !ok {
+		writeUploadError(w, http.StatusBadRequest, fmt.Sprintf("Invalid document_type: %q", documentType))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeUploadError(w, http.StatusBadRequest, "Missing file field")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.MaxUploadBytes {
+		writeUploadError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the limit of %d bytes", h.MaxUploadBytes))
+		return
+	}
+	if header.Size == 0 {
+		writeUploadError(w, http.StatusBadRequest, "File is empty")
+		return
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		writeUploadError(w, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+	sniff = sniff[:n]
+	contentType := http.DetectContentType(sniff)
+	contentType, _, _ = strings.Cut(contentType, ";")
+	if !slices.Contains(allowedContentTypes, contentType) {
+		writeUploadError(w, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Content type %s is not allowed for %s, expected one of %v", contentType, documentType, allowedContentTypes))
+		return
+	}
+
+	documentID := uuid.New().String()
+	storageKey := fmt.Sprintf("customers/%s/%s", customerID, documentID)
+	hash := sha256.New()
+	size, err := h.BlobStore.Put(storageKey, io.TeeReader(io.MultiReader(bytes.NewReader(sniff), file), hash))
+	if err != nil {
+		writeUploadError(w, http.StatusInternalServerError, "Failed to store document")
+		return
+	}
+
+	document := &datastore.DocumentRow{
+		DocumentID:   documentID,
+		CustomerID:   customerID,
+		SubmissionID: submissionID,
+		DocumentType: documentType,
+		FileName:     filepath.Base(header.Filename),
+		ContentType:  contentType,
+		SizeBytes:    size,
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:   storageKey,
+		UploadedAt:   time.Now().Unix(),
+	}
+	if err := h.DocumentStore.InsertDocument(document); err != nil {
+		h.BlobStore.Delete(storageKey)
+		writeUploadError(w, http.StatusInternalServerError, "Failed to save document")
+		return
+	}
+
+	uploaded := convertDocumentRow(document)
+	writeJSON(w, http.StatusCreated, UploadDocumentResponse{Data: &uploaded})
+}
+
+func (h *DocumentHandler) buildChecklist(submission *datastore.LoanSubmissionRow) (*DocumentChecklist, error) {
+	checklist := &DocumentChecklist{
+		SubmissionID: submission.SubmissionID,
+		ProductID:    nullStringPtr(submission.ProductID),
+		Items:        []DocumentChecklistItem{},
+		Complete:     true,
+	}
+	if !submission.ProductID.Valid {
+		return checklist, nil
+	}
+
+	product, err := h.ProductStore.GetLoanProductById(submission.ProductID.String)
+	if err != nil {
+		return nil, err
+	}
+	customerDocuments, err := h.DocumentStore.GetDocumentsByCustomerId(submission.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, documentType := range product.RequiredDocuments {
+		item := DocumentChecklistItem{DocumentType: documentType, DocumentIDs: []string{}}
+		for _, document := range customerDocuments {
+			if document.DocumentType != documentType {
+				continue
+			}
+			if !document.SubmissionID.Valid || document.SubmissionID.String == submission.SubmissionID {
+				item.DocumentIDs = append(item.DocumentIDs, document.DocumentID)
+			}
+		}
+		item.Provided = len(item.DocumentIDs) > 0
+		checklist.Complete = checklist.Complete && item.Provided
+		checklist.Items = append(checklist.Items, item)
+	}
+	return checklist, nil
+}
+
+func writeDocuments(w http.ResponseWriter, rows []*datastore.DocumentRow, err error) {
+	if err != nil {
+		errMsg := "Failed to get documents"
+		writeJSON(w, http.StatusInternalServerError, GetDocumentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	documents := make([]Document, 0, len(rows))
+	for _, row := range rows {
+		documents = append(documents, convertDocumentRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetDocumentsResponse{Data: &documents})
+}
+
+func writeUploadError(w http.ResponseWriter, status int, errMsg string) {
+	writeJSON(w, status, UploadDocumentResponse{ErrorMessage: &errMsg})
+}
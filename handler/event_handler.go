@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/events"
+)
+
+type EventHandler struct {
+	OutboxStore datastore.EventOutboxStore
+}
+
+func NewEventHandler(outboxStore datastore.EventOutboxStore) *EventHandler {
+	return &EventHandler{
+		OutboxStore: outboxStore,
+	}
+}
+
+// HandleGetEventSchemas lists the event types with the schema version
+// currently published.
+func (h *EventHandler) HandleGetEventSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas := []EventSchema{}
+	for _, schema := range events.CurrentSchemas() {
+		schemas = append(schemas, EventSchema{
+			EventType:     schema.EventType,
+			SchemaVersion: schema.SchemaVersion,
+			SchemaURL:     fmt.Sprintf("/api/events/schemas/%s/%d", schema.EventType, schema.SchemaVersion),
+		})
+	}
+	writeJSON(w, http.StatusOK, GetEventSchemasResponse{Data: &schemas})
+}
+
+// HandleGetEventSchema serves the JSON Schema of the data of one event type
+// at one version, older versions included.
+func (h *EventHandler) HandleGetEventSchema(w http.ResponseWriter, r *http.Request) {
+	eventType := r.PathValue("eventType")
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version <= 0 {
+		errMsg := "Invalid schema version: " + r.PathValue("version")
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{ErrorMessage: &errMsg})
+		return
+	}
+	schema, err := events.Schema(eventType, version)
+	if err != nil {
+		errMsg := fmt.Sprintf("No schema for %s version %d", eventType, version)
+		writeJSON(w, http.StatusNotFound, ErrorResponse{ErrorMessage: &errMsg})
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
+}
+
+func (h *EventHandler) HandleGetOutboxStats(w http.ResponseWriter, r *http.Request) {
+	row, err := h.OutboxStore.GetOutboxStats()
+	if err != nil {
+		errMsg := "Failed to get outbox stats"
+		writeJSON(w, http.StatusInternalServerError, GetOutboxStatsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	stats := OutboxStats{
+		Pending:   row.Pending,
+		Failing:   row.Failing,
+		Published: row.Published,
+	}
+	if row.OldestPendingAt.Valid {
+		stats.OldestPendingAt = &row.OldestPendingAt.Int64
+	}
+	if row.LastPublishedAt.Valid {
+		stats.LastPublishedAt = &row.LastPublishedAt.Int64
+	}
+	writeJSON(w, http.StatusOK, GetOutboxStatsResponse{Data: &stats})
+}
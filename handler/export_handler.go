@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/export"
+	"github.com/alphaloan/vehicle/notification"
+)
+
+// PIIMaskedHeader tells the caller whether personal data in an export or a
+// GraphQL response was masked.
+const PIIMaskedHeader = "X-PII-Masked"
+
+// An export column reads one value off a row. Mask is set on columns holding
+// personal data and is applied unless the caller may see it.
+type submissionExportColumn struct {
+	Name  string
+	Mask  func(string) string
+	Value func(*datastore.LoanSubmissionRow) any
+}
+
+type customerExportColumn struct {
+	Name  string
+	Mask  func(string) string
+	Value func(*datastore.LoanCustomerRow) any
+}
+
+var submissionExportColumns = []submissionExportColumn{
+	{Name: "submission_id", Value: func(row *datastore.LoanSubmissionRow) any { return row.SubmissionID }},
+	{Name: "customer_id", Value: func(row *datastore.LoanSubmissionRow) any { return row.CustomerID }},
+	{Name: "loan_status", Value: func(row *datastore.LoanSubmissionRow) any { return row.LoanStatus }},
+	{Name: "vehicle_type", Value: func(row *datastore.LoanSubmissionRow) any { return row.VehicleType }},
+	{Name: "vehicle_brand", Value: func(row *datastore.LoanSubmissionRow) any { return row.VehicleBrand }},
+	{Name: "vehicle_model", Value: func(row *datastore.LoanSubmissionRow) any { return row.VehicleModel }},
+	{Name: "vehicle_license_number", Mask: func(value string) string { return export.MaskTail(value, 3) },
+		Value: func(row *datastore.LoanSubmissionRow) any { return row.VehicleLicenseNumber }},
+	{Name: "vehicle_odometer", Value: func(row *datastore.LoanSubmissionRow) any { return row.VehicleOdometer }},
+	{Name: "manufacturing_year", Value: func(row *datastore.LoanSubmissionRow) any { return row.ManufacturingYear }},
+	{Name: "is_commercial_vehicle", Value: func(row *datastore.LoanSubmissionRow) any { return row.IsCommercialVehicle }},
+	{Name: "is_duplicate_collateral", Value: func(row *datastore.LoanSubmissionRow) any { return row.IsDuplicateCollateral }},
+	{Name: "proposed_loan_amount", Value: func(row *datastore.LoanSubmissionRow) any { return row.ProposedLoanAmount }},
+	{Name: "proposed_loan_tenure_month", Value: func(row *datastore.LoanSubmissionRow) any { return row.ProposedLoanTenure }},
+	{Name: "product_id", Value: func(row *datastore.LoanSubmissionRow) any { return exportNullString(row.ProductID) }},
+	{Name: "dealer_id", Value: func(row *datastore.LoanSubmissionRow) any { return exportNullString(row.DealerID) }},
+	{Name: "agent_id", Value: func(row *datastore.LoanSubmissionRow) any { return exportNullString(row.AgentID) }},
+	{Name: "created_at", Value: func(row *datastore.LoanSubmissionRow) any { return exportTime(row.CreatedAt) }},
+	{Name: "updated_at", Value: func(row *datastore.LoanSubmissionRow) any { return exportTime(row.UpdatedAt) }},
+}
+
+var customerExportColumns = []customerExportColumn{
+	{Name: "customer_id", Value: func(row *datastore.LoanCustomerRow) any { return row.CustomerID }},
+	{Name: "id_card_number", Mask: func(value string) string { return export.MaskTail(value, 4) },
+		Value: func(row *datastore.LoanCustomerRow) any { return row.IDCardNumber }},
+	{Name: "full_name", Mask: export.MaskName, Value: func(row *datastore.LoanCustomerRow) any { return row.FullName }},
+	{Name: "birth_date", Mask: export.MaskDate, Value: func(row *datastore.LoanCustomerRow) any { return row.BirthDate }},
+	{Name: "phone_number", Mask: func(value string) string { return export.MaskTail(value, 3) },
+		Value: func(row *datastore.LoanCustomerRow) any { return row.PhoneNumber }},
+	{Name: "email", Mask: export.MaskEmail, Value: func(row *datastore.LoanCustomerRow) any { return exportNullString(row.Email) }},
+	{Name: "monthly_income", Value: func(row *datastore.LoanCustomerRow) any { return row.MonthlyIncome }},
+	{Name: "address_street", Mask: export.MaskAll, Value: func(row *datastore.LoanCustomerRow) any { return row.AddressStreet }},
+	{Name: "address_city", Value: func(row *datastore.LoanCustomerRow) any { return row.AddressCity }},
+	{Name: "locale", Value: func(row *datastore.LoanCustomerRow) any { return row.Locale }},
+}
+
+type ExportHandler struct {
+	SubmissionStore datastore.LoanSubmissionStore
+	CustomerStore   datastore.LoanCustomerStore
+	// PIIToken unmasks personal data for callers sending it as a bearer
+	// token. Empty masks every export.
+	PIIToken string
+}
+
+func NewExportHandler(submissionStore datastore.LoanSubmissionStore, customerStore datastore.LoanCustomerStore) *ExportHandler {
+	return &ExportHandler{
+		SubmissionStore: submissionStore,
+		CustomerStore:   customerStore,
+	}
+}
+
+// HandleExportSubmissions streams submissions filtered by the optional
+// loan_status, dealer_id, product_id and from/to creation dates, oldest first.
+// format is csv (the default), xlsx or ndjson and columns a comma separated
+// subset of the columns, all of them by default.
+func (h *ExportHandler) HandleExportSubmissions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format, err := exportFormat(r)
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	names := make([]string, len(submissionExportColumns))
+	for i, column := range submissionExportColumns {
+		names[i] = column.Name
+	}
+	selected, err := selectExportColumns(names, query.Get("columns"))
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+
+	filter := datastore.SubmissionExportFilter{
+		LoanStatus: strings.ToUpper(strings.TrimSpace(query.Get("loan_status"))),
+		DealerID:   strings.TrimSpace(query.Get("dealer_id")),
+		ProductID:  strings.TrimSpace(query.Get("product_id")),
+	}
+	if filter.LoanStatus != "" && !slices.Contains(loanStatuses, filter.LoanStatus) {
+		writeExportError(w, fmt.Errorf("invalid loan_status %q, expected one of %s", filter.LoanStatus, strings.Join(loanStatuses, ", ")))
+		return
+	}
+	if (filter.DealerID != "" && !IsValidUUID(filter.DealerID)) || (filter.ProductID != "" && !IsValidUUID(filter.ProductID)) {
+		writeExportError(w, errors.New("dealer_id and product_id must be UUIDs"))
+		return
+	}
+	if filter.CreatedFrom, filter.CreatedTo, err = exportDateRange(query.Get("from"), query.Get("to")); err != nil {
+		writeExportError(w, err)
+		return
+	}
+
+	masked := !h.canSeePII(r)
+	writer := startExport(w, format, "submissions", selectedNames(names, selected), masked)
+	if writer == nil {
+		return
+	}
+	values := make([]any, len(selected))
+	err = h.SubmissionStore.ExportLoanSubmissions(filter, func(row *datastore.LoanSubmissionRow) error {
+		for i, index := range selected {
+			column := submissionExportColumns[index]
+			values[i] = maskExportValue(column.Value(row), column.Mask, masked)
+		}
+		return writer.WriteRow(values)
+	})
+	finishExport(writer, "submissions", err)
+}
+
+// HandleExportCustomers streams customers filtered by the optional
+// address_city and locale, with the same format and columns parameters as
+// the submission export.
+func (h *ExportHandler) HandleExportCustomers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format, err := exportFormat(r)
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	names := make([]string, len(customerExportColumns))
+	for i, column := range customerExportColumns {
+		names[i] = column.Name
+	}
+	selected, err := selectExportColumns(names, query.Get("columns"))
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+
+	filter := datastore.CustomerExportFilter{
+		AddressCity: strings.TrimSpace(query.Get("address_city")),
+		Locale:      strings.ToLower(strings.TrimSpace(query.Get("locale"))),
+	}
+	if filter.Locale != "" && !notification.IsSupportedLocale(filter.Locale) {
+		writeExportError(w, fmt.Errorf("unsupported locale %q, expected one of %s", filter.Locale, strings.Join(notification.Locales, ", ")))
+		return
+	}
+
+	masked := !h.canSeePII(r)
+	writer := startExport(w, format, "customers", selectedNames(names, selected), masked)
+	if writer == nil {
+		return
+	}
+	values := make([]any, len(selected))
+	err = h.CustomerStore.ExportLoanCustomers(filter, func(row *datastore.LoanCustomerRow) error {
+		for i, index := range selected {
+			column := customerExportColumns[index]
+			values[i] = maskExportValue(column.Value(row), column.Mask, masked)
+		}
+		return writer.WriteRow(values)
+	})
+	finishExport(writer, "customers", err)
+}
+
+func (h *ExportHandler) canSeePII(r *http.Request) bool {
+	return bearerTokenMatches(r, h.PIIToken)
+}
+
+// bearerTokenMatches reports whether the request carries token as its bearer
+// token. An empty token matches no request.
+func bearerTokenMatches(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+// exportFormat takes the format parameter, or failing that an Accept header
+// naming one of the export content types.
+func exportFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = export.FormatCSV
+		for _, candidate := range export.Formats {
+			if strings.Contains(r.Header.Get("Accept"), export.ContentType(candidate)) {
+				format = candidate
+				break
+			}
+		}
+	}
+	if !slices.Contains(export.Formats, format) {
+		return "", fmt.Errorf("invalid format %q, expected one of %s", format, strings.Join(export.Formats, ", "))
+	}
+	return format, nil
+}
+
+// selectExportColumns returns the indexes of the requested columns in the
+// order asked for, or of every column when none are requested.
+func selectExportColumns(names []string, requested string) ([]int, error) {
+	if strings.TrimSpace(requested) == "" {
+		selected := make([]int, len(names))
+		for i := range names {
+			selected[i] = i
+		}
+		return selected, nil
+	}
+
+	var selected []int
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		index := slices.Index(names, name)
+		if index < 0 {
+			return nil, fmt.Errorf("unknown column %q, expected some of %s", name, strings.Join(names, ", "))
+		}
+		if slices.Contains(selected, index) {
+			return nil, fmt.Errorf("column %q is requested twice", name)
+		}
+		selected = append(selected, index)
+	}
+	return selected, nil
+}
+
+func selectedNames(names []string, selected []int) []string {
+	columns := make([]string, len(selected))
+	for i, index := range selected {
+		columns[i] = names[index]
+	}
+	return columns
+}
+
+// exportDateRange turns the inclusive from and to dates into created_at
+// bounds, 0 leaving a side open.
+func exportDateRange(from, to string) (int64, int64, error) {
+	var createdFrom, createdTo int64
+	if from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid from %q, expected YYYY-MM-DD", from)
+		}
+		createdFrom = parsed.Unix()
+	}
+	if to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid to %q, expected YYYY-MM-DD", to)
+		}
+		createdTo = parsed.AddDate(0, 0, 1).Unix()
+	}
+	if createdFrom != 0 && createdTo != 0 && createdTo <= createdFrom {
+		return 0, 0, fmt.Errorf("to %s is before from %s", to, from)
+	}
+	return createdFrom, createdTo, nil
+}
+
+func maskExportValue(value any, mask func(string) string, masked bool) any {
+	text, ok := value.(string)
+	if !masked || mask == nil || !ok {
+		return value
+	}
+	return mask(text)
+}
+
+// startExport commits to a 200 response, after which failures can only cut
+// the file short.
+func startExport(w http.ResponseWriter, format, name string, columns []string, masked bool) export.Writer {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s-%s.%s\"", name, time.Now().Format("20060102"), format))
+	w.Header().Set(PIIMaskedHeader, fmt.Sprint(masked))
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := export.NewWriter(format, w, columns)
+	if err != nil {
+		log.Printf("starting %s export failed: %v\n", name, err)
+		return nil
+	}
+	return writer
+}
+
+func finishExport(writer export.Writer, name string, err error) {
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("%s export failed: %v\n", name, err)
+	}
+}
+
+func writeExportError(w http.ResponseWriter, err error) {
+	errMsg := err.Error()
+	writeJSON(w, http.StatusBadRequest, ErrorResponse{ErrorMessage: &errMsg})
+}
+
+func exportNullString(value sql.NullString) any {
+	if !value.Valid {
+		return nil
+	}
+	return value.String
+}
+
+func exportTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
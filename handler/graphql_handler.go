@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/alphaloan/vehicle/graphqlapi"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+type GraphQLHandler struct {
+	Server *graphqlapi.Server
+	// PIIToken unmasks customers' personal data, as for exports. Empty masks
+	// every response.
+	PIIToken string
+}
+
+func NewGraphQLHandler(server *graphqlapi.Server) *GraphQLHandler {
+	return &GraphQLHandler{
+		Server: server,
+	}
+}
+
+// HandleGraphQL answers a query that ran with 200, even when some fields
+// failed to resolve, and a query refused before it ran with 400. Personal
+// data is masked unless the PII token is sent.
+func (h *GraphQLHandler) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request GraphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, GraphQLResponse{Errors: []GraphQLError{{Message: "Invalid request body"}}})
+		return
+	}
+	if request.Query == "" {
+		writeJSON(w, http.StatusBadRequest, GraphQLResponse{Errors: []GraphQLError{{Message: "query is required"}}})
+		return
+	}
+
+	var operationName string
+	if request.OperationName != nil {
+		operationName = *request.OperationName
+	}
+	ctx, masked := r.Context(), !bearerTokenMatches(r, h.PIIToken)
+	if !masked {
+		ctx = graphqlapi.WithPersonalData(ctx)
+	}
+	w.Header().Set(PIIMaskedHeader, strconv.FormatBool(masked))
+	result, ran := h.Server.Execute(ctx, request.Query, operationName, request.Variables)
+	if !ran {
+		writeJSON(w, http.StatusBadRequest, GraphQLResponse{Errors: graphQLErrors(result.Errors)})
+		return
+	}
+	writeJSON(w, http.StatusOK, GraphQLResponse{Data: result.Data, Errors: graphQLErrors(result.Errors)})
+}
+
+func graphQLErrors(errs []gqlerrors.FormattedError) []GraphQLError {
+	var converted []GraphQLError
+	for _, err := range errs {
+		graphQLErr := GraphQLError{Message: err.Message, Path: err.Path}
+		for _, location := range err.Locations {
+			graphQLErr.Locations = append(graphQLErr.Locations, GraphQLLocation{Line: location.Line, Column: location.Column})
+		}
+		converted = append(converted, graphQLErr)
+	}
+	return converted
+}
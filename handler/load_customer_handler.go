@@ -23,10 +23,6 @@ func NewLoanCustomerHandler(
 }
 
 func (h *LoanCustomerHandler) HandleGetAllLoanSubmission(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	w.Header().Set("Content-Type", "application/json")
 
 	loanCustomerRows, err := h.CustomerStore.GetAllLoanCustomers()
@@ -52,6 +48,7 @@ func (h *LoanCustomerHandler) HandleGetAllLoanSubmission(w http.ResponseWriter,
 			MonthlyIncome: row.MonthlyIncome,
 			AddressStreet: row.AddressStreet,
 			AddressCity:   row.AddressCity,
+			Locale:        row.Locale,
 		}
 		email := row.Email
 		if email.Valid {
@@ -68,11 +65,6 @@ func (h *LoanCustomerHandler) HandleGetAllLoanSubmission(w http.ResponseWriter,
 }
 
 func (h *LoanCustomerHandler) HandleGetCustomerAndSubmissionById(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	customerID := r.PathValue("customerID")
 	if !IsValidUUID(customerID) {
 		errMsg := "Invalid customer ID: " + customerID
@@ -86,7 +78,7 @@ func (h *LoanCustomerHandler) HandleGetCustomerAndSubmissionById(w http.Response
 
 	loanCustomerWithAllSubmissionsRow, err := h.CustomerStore.GetCustomerByCustomerId(customerID)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Println("GetCustomerByCustomerId err:", err)
 		http.Error(w, fmt.Sprintf("Failed to get Customer with CustomerId: %s", customerID), http.StatusInternalServerError)
 		return
 	}
@@ -100,6 +92,7 @@ func (h *LoanCustomerHandler) HandleGetCustomerAndSubmissionById(w http.Response
 		MonthlyIncome: loanCustomerWithAllSubmissionsRow.LoanCustomerRow.MonthlyIncome,
 		AddressStreet: loanCustomerWithAllSubmissionsRow.LoanCustomerRow.AddressStreet,
 		AddressCity:   loanCustomerWithAllSubmissionsRow.LoanCustomerRow.AddressCity,
+		Locale:        loanCustomerWithAllSubmissionsRow.LoanCustomerRow.Locale,
 	}
 	if loanCustomerWithAllSubmissionsRow.LoanCustomerRow.Email.Valid {
 		loanCustomer.Email = &loanCustomerWithAllSubmissionsRow.LoanCustomerRow.Email.String
@@ -107,12 +100,9 @@ func (h *LoanCustomerHandler) HandleGetCustomerAndSubmissionById(w http.Response
 	customerAndSubmissions := CustomerAndSubmissions{
 		Customer: &loanCustomer,
 	}
-	if err != nil {
-		fmt.Sprintf("Failed to get Loan Submission Customer with CustomerId: %s", customerID)
-	}
-
 	loadSubmissions := make([]LoanSubmission, 0, len(loanCustomerWithAllSubmissionsRow.LoanSubmissions))
 	for _, row := range loanCustomerWithAllSubmissionsRow.LoanSubmissions {
+		partyRole := loanCustomerWithAllSubmissionsRow.PartyRoles[row.SubmissionID]
 		loadSubmissions = append(loadSubmissions, LoanSubmission{
 			SubmissionID:            row.SubmissionID,
 			VehicleType:             row.VehicleType,
@@ -124,6 +114,12 @@ func (h *LoanCustomerHandler) HandleGetCustomerAndSubmissionById(w http.Response
 			ProposedLoanAmount:      row.ProposedLoanAmount,
 			ProposedLoanTenureMonth: row.ProposedLoanTenure,
 			IsCommercialVehicle:     row.IsCommercialVehicle,
+			IsDuplicateCollateral:   row.IsDuplicateCollateral,
+			LoanStatus:              row.LoanStatus,
+			ProductID:               nullStringPtr(row.ProductID),
+			DealerID:                nullStringPtr(row.DealerID),
+			AgentID:                 nullStringPtr(row.AgentID),
+			PartyRole:               &partyRole,
 		})
 	}
 	customerAndSubmissions.Submissions = &loadSubmissions
@@ -134,10 +130,6 @@ func (h *LoanCustomerHandler) HandleGetCustomerAndSubmissionById(w http.Response
 }
 
 func (h *LoanCustomerHandler) HandlerUpdateCustomerById(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		http.Error(w, "Only PATCH method allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	customerID := r.PathValue("customerID")
 	if !IsValidUUID(customerID) {
 		errMsg := "Invalid customer ID: " + customerID
@@ -158,12 +150,18 @@ func (h *LoanCustomerHandler) HandlerUpdateCustomerById(w http.ResponseWriter, r
 		return
 	}
 
+	if err := validateCustomerLocale(&request); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, UpdateCustomerByCustomerIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+
 	loanCustomerRow := convertLoanCustomer(&request)
 	loanCustomerRow.CustomerID = customerID
 
 	err := h.CustomerStore.UpdateCustomerByCustomerId(loanCustomerRow)
 	if err != nil {
-		fmt.Sprintf("Failed to update customer with CustomerId: %s", customerID)
+		fmt.Printf("Failed to update customer with CustomerId: %s: %v\n", customerID, err)
 		errorMessage = err.Error()
 		response = UpdateCustomerByCustomerIdResponse{
 			ErrorMessage: &errorMessage,
@@ -182,10 +180,6 @@ func (h *LoanCustomerHandler) HandlerUpdateCustomerById(w http.ResponseWriter, r
 }
 
 func (h *LoanCustomerHandler) HandlerDeleteCustomerById(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Only DELETE method allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	customerID := r.PathValue("customerID")
 	var response DeleteCustomerByCustomerIdResponse
 	var errMsg string
@@ -201,7 +195,7 @@ func (h *LoanCustomerHandler) HandlerDeleteCustomerById(w http.ResponseWriter, r
 
 	err := h.CustomerStore.DeleteCustomerByCustomerId(customerID)
 	if err != nil {
-		fmt.Sprintf("Failed to delete customer with CustomerId: %s", customerID)
+		fmt.Printf("Failed to delete customer with CustomerId: %s: %v\n", customerID, err)
 		errMsg = err.Error()
 		response = DeleteCustomerByCustomerIdResponse{
 			ErrorMessage: &errMsg,
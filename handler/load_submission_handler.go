@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -10,20 +12,25 @@ import (
 
 type LoanSubmissionHandler struct {
 	SubmissionStore datastore.LoanSubmissionStore
+	ValuationStore  datastore.LoanValuationStore
+	QuoteStore      datastore.LoanQuoteStore
+	PartyStore      datastore.SubmissionPartyStore
 }
 
 func NewLoanSubmissionHandler(
-	submissionStore datastore.LoanSubmissionStore) *LoanSubmissionHandler {
+	submissionStore datastore.LoanSubmissionStore,
+	valuationStore datastore.LoanValuationStore,
+	quoteStore datastore.LoanQuoteStore,
+	partyStore datastore.SubmissionPartyStore) *LoanSubmissionHandler {
 	return &LoanSubmissionHandler{
 		SubmissionStore: submissionStore,
+		ValuationStore:  valuationStore,
+		QuoteStore:      quoteStore,
+		PartyStore:      partyStore,
 	}
 }
 
 func (h *LoanSubmissionHandler) HandleGetAllLoanSubmission(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	w.Header().Set("Content-Type", "application/json")
 
 	loanSubmissionRows, err := h.SubmissionStore.GetAllLoanSubmissions()
@@ -40,18 +47,7 @@ func (h *LoanSubmissionHandler) HandleGetAllLoanSubmission(w http.ResponseWriter
 
 	loanSubmissions := make([]LoanSubmission, 0, len(loanSubmissionRows))
 	for _, row := range loanSubmissionRows {
-		loanSubmissions = append(loanSubmissions, LoanSubmission{
-			SubmissionID:            row.SubmissionID,
-			VehicleType:             row.VehicleType,
-			VehicleBrand:            row.VehicleBrand,
-			VehicleModel:            row.VehicleModel,
-			VehicleLicenseNumber:    row.VehicleLicenseNumber,
-			VehicleOdometer:         row.VehicleOdometer,
-			ManufacturingYear:       row.ManufacturingYear,
-			ProposedLoanAmount:      row.ProposedLoanAmount,
-			ProposedLoanTenureMonth: row.ProposedLoanTenure,
-			IsCommercialVehicle:     row.IsCommercialVehicle,
-		})
+		loanSubmissions = append(loanSubmissions, convertLoanSubmissionRow(row))
 	}
 	responseBody := GetAllLoanSubmissionsResponse{
 		Data: &loanSubmissions,
@@ -62,12 +58,7 @@ func (h *LoanSubmissionHandler) HandleGetAllLoanSubmission(w http.ResponseWriter
 }
 
 func (h *LoanSubmissionHandler) HandleSubmissionLoanById(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	loanSubmissionId := r.URL.Query().Get("loan_submission_id")
+	loanSubmissionId := r.PathValue("submissionID")
 
 	if !validateLoanSubmissionID(w, loanSubmissionId) {
 		return
@@ -79,18 +70,7 @@ func (h *LoanSubmissionHandler) HandleSubmissionLoanById(w http.ResponseWriter,
 		return
 	}
 
-	loanSubmission := LoanSubmission{
-		SubmissionID:            loanSubmissionRow.SubmissionID,
-		VehicleType:             loanSubmissionRow.VehicleType,
-		VehicleBrand:            loanSubmissionRow.VehicleBrand,
-		VehicleModel:            loanSubmissionRow.VehicleModel,
-		VehicleLicenseNumber:    loanSubmissionRow.VehicleLicenseNumber,
-		VehicleOdometer:         loanSubmissionRow.VehicleOdometer,
-		ManufacturingYear:       loanSubmissionRow.ManufacturingYear,
-		ProposedLoanAmount:      loanSubmissionRow.ProposedLoanAmount,
-		ProposedLoanTenureMonth: loanSubmissionRow.ProposedLoanTenure,
-		IsCommercialVehicle:     loanSubmissionRow.IsCommercialVehicle,
-	}
+	loanSubmission := convertLoanSubmissionRow(loanSubmissionRow)
 
 	response := GetLoanSubmissionsByIdResponse{
 		Data: &loanSubmission,
@@ -101,6 +81,132 @@ func (h *LoanSubmissionHandler) HandleSubmissionLoanById(w http.ResponseWriter,
 	json.NewEncoder(w).Encode(response)
 }
 
+func (h *LoanSubmissionHandler) HandleGetSubmissionValuation(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetLoanValuationResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	valuationRow, err := h.ValuationStore.GetValuationBySubmissionId(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "No valuation for submission " + submissionID
+		writeJSON(w, http.StatusNotFound, GetLoanValuationResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get valuation for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetLoanValuationResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	loanValuation := convertLoanValuationRow(valuationRow)
+	writeJSON(w, http.StatusOK, GetLoanValuationResponse{Data: &loanValuation})
+}
+
+func (h *LoanSubmissionHandler) HandleGetSubmissionQuote(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetLoanQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	quoteRow, err := h.QuoteStore.GetQuoteBySubmissionId(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "No quote for submission " + submissionID
+		writeJSON(w, http.StatusNotFound, GetLoanQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get quote for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetLoanQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	loanQuote := convertLoanQuoteRow(quoteRow)
+	writeJSON(w, http.StatusOK, GetLoanQuoteResponse{Data: &loanQuote})
+}
+
+func (h *LoanSubmissionHandler) HandleGetDuplicateCollateral(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetDuplicateCollateralResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if _, err := h.SubmissionStore.GetLoanSubmissionById(submissionID); errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, GetDuplicateCollateralResponse{ErrorMessage: &errMsg})
+		return
+	} else if err != nil {
+		errMsg := "Failed to get submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetDuplicateCollateralResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.SubmissionStore.GetDuplicateCollateralSubmissions(submissionID)
+	if err != nil {
+		errMsg := "Failed to get duplicate collateral for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetDuplicateCollateralResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	duplicates := make([]DuplicateCollateral, 0, len(rows))
+	for _, row := range rows {
+		duplicates = append(duplicates, DuplicateCollateral{
+			SubmissionID:         row.SubmissionID,
+			CustomerID:           row.CustomerID,
+			VehicleLicenseNumber: row.VehicleLicenseNumber,
+			LoanStatus:           row.LoanStatus,
+			CreatedAt:            row.CreatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, GetDuplicateCollateralResponse{
+		SubmissionID: &submissionID,
+		Data:         &duplicates,
+	})
+}
+
+func (h *LoanSubmissionHandler) HandleGetSubmissionParties(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetSubmissionPartiesResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.PartyStore.GetSubmissionParties(submissionID)
+	if err != nil {
+		errMsg := "Failed to get parties for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetSubmissionPartiesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if len(rows) == 0 {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, GetSubmissionPartiesResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	parties := make([]SubmissionParty, 0, len(rows))
+	for _, row := range rows {
+		customer := convertLoanCustomerRow(row.LoanCustomerRow)
+		parties = append(parties, SubmissionParty{
+			Role:       row.PartyRole,
+			CustomerID: customer.CustomerID,
+			Customer:   &customer,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, GetSubmissionPartiesResponse{
+		SubmissionID: &submissionID,
+		Data:         &parties,
+	})
+}
+
 func validateLoanSubmissionID(w http.ResponseWriter, loanSubmissionId string) bool {
 	if loanSubmissionId == "" {
 		errMsg := "Missing submission_id query parameter"
@@ -0,0 +1,538 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/google/uuid"
+)
+
+const (
+	maxLoanImportSize          = 64 << 20
+	DefaultLoanImportBatchSize = 500
+	loanImportDateLayout       = "2006-01-02"
+)
+
+// loanImportFields are the fields a loan import fills, named like the JSON
+// fields of the submit API. Columns of the file are mapped onto them.
+var loanImportFields = []string{
+	"reference",
+	"submission_id",
+	"id_card_number",
+	"full_name",
+	"birth_date",
+	"phone_number",
+	"email",
+	"monthly_income",
+	"address_street",
+	"address_city",
+	"locale",
+	"vehicle_type",
+	"vehicle_brand",
+	"vehicle_model",
+	"vehicle_license_number",
+	"vehicle_odometer",
+	"manufacturing_year",
+	"proposed_loan_amount",
+	"proposed_loan_tenure_month",
+	"is_commercial_vehicle",
+	"loan_status",
+	"product_id",
+	"product_code",
+	"dealer_id",
+	"dealer_code",
+	"agent_id",
+	"created_at",
+	"updated_at",
+}
+
+var requiredLoanImportFields = []string{
+	"id_card_number",
+	"full_name",
+	"vehicle_brand",
+	"vehicle_model",
+	"vehicle_license_number",
+	"manufacturing_year",
+	"proposed_loan_amount",
+	"proposed_loan_tenure_month",
+}
+
+// Submission ids of imported loans are derived from their reference in the
+// old system, so importing the same file twice updates the loans instead of
+// duplicating them.
+var loanImportNamespace = uuid.MustParse("3f6d2a4e-8c1b-4f0a-9e57-6b2d1c0a7e93")
+
+// LoanImportHandler loads historical loans from CSV. Rows are checked with the
+// rules of the submit API and saved in batches; rejected rows are reported
+// with their reason and skipped.
+type LoanImportHandler struct {
+	SubmitHandler *LoanSubmitHandler
+	BatchSize     int
+}
+
+func NewLoanImportHandler(submitHandler *LoanSubmitHandler) *LoanImportHandler {
+	return &LoanImportHandler{
+		SubmitHandler: submitHandler,
+		BatchSize:     DefaultLoanImportBatchSize,
+	}
+}
+
+// LoanImportOptions maps loan import fields to the CSV columns holding them.
+// A field left out of Mapping is read from the column of the same name.
+type LoanImportOptions struct {
+	Mapping map[string]string
+	DryRun  bool
+}
+
+// HandleImportLoans accepts the CSV as the raw request body or as the "file"
+// field of a multipart form. The column mapping is a JSON object of field to
+// column given as the "mapping" form field or query parameter. With
+// dry_run=true rows are only checked; with report=csv the rejected rows are
+// returned as a CSV error report instead of the JSON summary.
+func (h *LoanImportHandler) HandleImportLoans(w http.ResponseWriter, r *http.Request) {
+	var options LoanImportOptions
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			errMsg := fmt.Sprintf("invalid dry_run %q", value)
+			writeJSON(w, http.StatusBadRequest, ImportLoansResponse{ErrorMessage: &errMsg})
+			return
+		}
+		options.DryRun = dryRun
+	}
+	report := r.URL.Query().Get("report")
+	if report != "" && report != "csv" {
+		errMsg := fmt.Sprintf("invalid report %q, expected csv", report)
+		writeJSON(w, http.StatusBadRequest, ImportLoansResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxLoanImportSize)
+	body := io.Reader(r.Body)
+	mapping := r.URL.Query().Get("mapping")
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+		if value := r.PostFormValue("mapping"); value != "" {
+			mapping = value
+		}
+	}
+	if mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			errMsg := "mapping must be a JSON object of field to column: " + err.Error()
+			writeJSON(w, http.StatusBadRequest, ImportLoansResponse{ErrorMessage: &errMsg})
+			return
+		}
+	}
+
+	result, err := h.ImportLoans(r.Context(), body, options)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, ImportLoansResponse{ErrorMessage: &errMsg, Data: result})
+		return
+	}
+
+	if report == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="loan-import-errors.csv"`)
+		w.WriteHeader(http.StatusOK)
+		WriteLoanImportErrorReport(w, result)
+		return
+	}
+	writeJSON(w, http.StatusOK, ImportLoansResponse{Data: result})
+}
+
+// ImportLoans reads the whole file, checking every row and saving the valid
+// ones in batches of BatchSize unless options.DryRun is set. A batch the
+// database refuses is retried row by row so that one bad row does not sink
+// its neighbours. The report is returned even when the import stops early.
+func (h *LoanImportHandler) ImportLoans(ctx context.Context, body io.Reader, options LoanImportOptions) (*LoanImportReport, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns, err := loanImportColumns(header, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	lookups, err := h.loadLoanImportLookups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LoanImportReport{DryRun: options.DryRun, Header: header, Errors: []LoanImportRowError{}}
+	batchSize := h.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultLoanImportBatchSize
+	}
+	var batch []*loanImportRow
+	seen := map[string]int{}
+
+	flush := func() {
+		if len(batch) > 0 && !options.DryRun {
+			h.saveLoanImportBatch(result, batch)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		result.Rows++
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			result.reject(parseError.StartLine, record, err)
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		// Quoted fields may span lines, so rows are reported by the line
+		// they start on.
+		line, _ := reader.FieldPos(0)
+
+		row, err := h.parseLoanImportRecord(ctx, record, columns, lookups)
+		if err != nil {
+			result.reject(line, record, err)
+			continue
+		}
+		if previous, ok := seen[row.Record.Submission.SubmissionID]; ok {
+			result.reject(line, record, fmt.Errorf("same loan as line %d", previous))
+			continue
+		}
+		seen[row.Record.Submission.SubmissionID] = line
+
+		row.Line, row.Fields = line, record
+		batch = append(batch, row)
+		if options.DryRun {
+			result.Imported++
+		}
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+	flush()
+	return result, nil
+}
+
+func (h *LoanImportHandler) saveLoanImportBatch(result *LoanImportReport, batch []*loanImportRow) {
+	records := make([]*datastore.LoanImportRecord, 0, len(batch))
+	for _, row := range batch {
+		records = append(records, row.Record)
+	}
+	if err := h.SubmitHandler.SubmissionStore.ImportLoans(records); err == nil {
+		result.Imported += len(batch)
+		return
+	}
+
+	for _, row := range batch {
+		if err := h.SubmitHandler.SubmissionStore.ImportLoans([]*datastore.LoanImportRecord{row.Record}); err != nil {
+			result.reject(row.Line, row.Fields, fmt.Errorf("failed to save: %w", err))
+			continue
+		}
+		result.Imported++
+	}
+}
+
+type loanImportRow struct {
+	Line   int
+	Fields []string
+	Record *datastore.LoanImportRecord
+}
+
+// loanImportLookups resolve the product and dealer codes of the old system.
+type loanImportLookups struct {
+	Products map[string]string
+	Dealers  map[string]string
+}
+
+func (h *LoanImportHandler) loadLoanImportLookups() (*loanImportLookups, error) {
+	products, err := h.SubmitHandler.ProductStore.GetAllLoanProducts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load loan products: %w", err)
+	}
+	dealers, err := h.SubmitHandler.DealerStore.GetAllDealers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dealers: %w", err)
+	}
+
+	lookups := &loanImportLookups{Products: map[string]string{}, Dealers: map[string]string{}}
+	for _, product := range products {
+		lookups.Products[strings.ToUpper(product.Code)] = product.ProductID
+	}
+	for _, dealer := range dealers {
+		lookups.Dealers[strings.ToUpper(dealer.Code)] = dealer.DealerID
+	}
+	return lookups, nil
+}
+
+// loanImportColumns resolves the column index of every field present in the
+// file. Header names are matched case-insensitively.
+func loanImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		indexes[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := map[string]int{}
+	for field, column := range mapping {
+		if !slices.Contains(loanImportFields, field) {
+			return nil, fmt.Errorf("unknown import field %q in mapping", field)
+		}
+		i, ok := indexes[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("mapping of %s names missing csv column %q", field, column)
+		}
+		columns[field] = i
+	}
+	for _, field := range loanImportFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if _, mapped := mapping[field]; mapped {
+			continue
+		}
+		if i, ok := indexes[field]; ok {
+			columns[field] = i
+		}
+	}
+
+	for _, field := range requiredLoanImportFields {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("missing csv column for %q", field)
+		}
+	}
+	return columns, nil
+}
+
+// parseLoanImportRecord turns a row into the request the submit API would
+// receive and runs it through the same checks, valued and priced as of the
+// date the loan was applied for.
+func (h *LoanImportHandler) parseLoanImportRecord(ctx context.Context, record []string, columns map[string]int, lookups *loanImportLookups) (*loanImportRow, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	for _, name := range requiredLoanImportFields {
+		if field(name) == "" {
+			return nil, fmt.Errorf("%s is required", name)
+		}
+	}
+
+	customer := LoanCustomer{
+		IDCardNumber:  field("id_card_number"),
+		FullName:      normaliseCatalogueName(field("full_name")),
+		BirthDate:     field("birth_date"),
+		PhoneNumber:   field("phone_number"),
+		AddressStreet: field("address_street"),
+		AddressCity:   field("address_city"),
+		Locale:        field("locale"),
+	}
+	if email := field("email"); email != "" {
+		customer.Email = &email
+	}
+	if err := validateCustomerLocale(&customer); err != nil {
+		return nil, err
+	}
+
+	proposal := LoanSubmission{
+		VehicleType:          field("vehicle_type"),
+		VehicleBrand:         field("vehicle_brand"),
+		VehicleModel:         field("vehicle_model"),
+		VehicleLicenseNumber: field("vehicle_license_number"),
+		LoanStatus:           strings.ToUpper(field("loan_status")),
+	}
+	var err error
+	if customer.MonthlyIncome, err = parseLoanImportFloat("monthly_income", field("monthly_income")); err != nil {
+		return nil, err
+	}
+	if proposal.VehicleOdometer, err = parseLoanImportInt("vehicle_odometer", field("vehicle_odometer")); err != nil {
+		return nil, err
+	}
+	if proposal.ManufacturingYear, err = parseLoanImportInt("manufacturing_year", field("manufacturing_year")); err != nil {
+		return nil, err
+	}
+	if proposal.ProposedLoanAmount, err = parseLoanImportInt("proposed_loan_amount", field("proposed_loan_amount")); err != nil {
+		return nil, err
+	}
+	if proposal.ProposedLoanTenureMonth, err = parseLoanImportInt("proposed_loan_tenure_month", field("proposed_loan_tenure_month")); err != nil {
+		return nil, err
+	}
+	if value := field("is_commercial_vehicle"); value != "" {
+		if proposal.IsCommercialVehicle, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid is_commercial_vehicle %q", value)
+		}
+	}
+
+	if proposal.LoanStatus == "" {
+		proposal.LoanStatus = "NEW"
+	}
+	if !slices.Contains(loanStatuses, proposal.LoanStatus) {
+		return nil, fmt.Errorf("invalid loan_status %q, expected one of %s", proposal.LoanStatus, strings.Join(loanStatuses, ", "))
+	}
+
+	if proposal.ProductID, err = resolveLoanImportCode("product", field("product_id"), field("product_code"), lookups.Products); err != nil {
+		return nil, err
+	}
+	if proposal.DealerID, err = resolveLoanImportCode("dealer", field("dealer_id"), field("dealer_code"), lookups.Dealers); err != nil {
+		return nil, err
+	}
+	if agentID := field("agent_id"); agentID != "" {
+		proposal.AgentID = &agentID
+	}
+
+	createdAt := time.Now()
+	if value := field("created_at"); value != "" {
+		if createdAt, err = parseLoanImportTime("created_at", value); err != nil {
+			return nil, err
+		}
+	}
+	updatedAt := createdAt
+	if value := field("updated_at"); value != "" {
+		if updatedAt, err = parseLoanImportTime("updated_at", value); err != nil {
+			return nil, err
+		}
+	}
+
+	submissionID := uuid.New().String()
+	switch {
+	case field("submission_id") != "":
+		if !IsValidUUID(field("submission_id")) {
+			return nil, fmt.Errorf("invalid submission_id %q", field("submission_id"))
+		}
+		submissionID = field("submission_id")
+	case field("reference") != "":
+		submissionID = uuid.NewSHA1(loanImportNamespace, []byte(field("reference"))).String()
+	}
+
+	if err := h.SubmitHandler.resolveOrigin(ctx, &proposal); err != nil {
+		return nil, err
+	}
+	if proposal.VehicleLicenseNumber, err = normaliseLicensePlate(proposal.VehicleLicenseNumber); err != nil {
+		return nil, err
+	}
+	vehicleModel, err := canonicaliseVehicle(&h.SubmitHandler.CatalogueStore, &proposal)
+	if err != nil {
+		return nil, err
+	}
+	valuationRow, err := h.SubmitHandler.valueCollateral(vehicleModel, &proposal, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := h.SubmitHandler.quoteProduct(&proposal, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	submissionRow := convertLoanProposal(&proposal, "")
+	submissionRow.SubmissionID = submissionID
+	submissionRow.LoanStatus = proposal.LoanStatus
+	submissionRow.CreatedAt = createdAt.Unix()
+	submissionRow.UpdatedAt = updatedAt.Unix()
+
+	return &loanImportRow{Record: &datastore.LoanImportRecord{
+		Customer:   convertLoanCustomer(&customer),
+		Submission: submissionRow,
+		Quote:      convertPricingQuote(submissionID, quote, submissionRow.CreatedAt),
+		Valuation:  valuationRow,
+	}}, nil
+}
+
+func parseLoanImportInt(name, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(strings.ReplaceAll(value, ",", ""))
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return parsed, nil
+}
+
+func parseLoanImportFloat(name, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return parsed, nil
+}
+
+// parseLoanImportTime accepts a date, an RFC 3339 timestamp or unix seconds.
+func parseLoanImportTime(name, value string) (time.Time, error) {
+	if parsed, err := time.Parse(loanImportDateLayout, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q, expected YYYY-MM-DD, RFC 3339 or unix seconds", name, value)
+}
+
+// resolveLoanImportCode prefers an id over a code of the old system.
+func resolveLoanImportCode(kind, id, code string, ids map[string]string) (*string, error) {
+	if id != "" {
+		return &id, nil
+	}
+	if code == "" {
+		return nil, nil
+	}
+	resolved, ok := ids[strings.ToUpper(code)]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s code %q", kind, code)
+	}
+	return &resolved, nil
+}
+
+// WriteLoanImportErrorReport writes the rejected rows as CSV: the line and
+// reason followed by the row as it appeared in the file.
+func WriteLoanImportErrorReport(w io.Writer, report *LoanImportReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"line", "error"}, report.Header...)); err != nil {
+		return err
+	}
+	for _, rowError := range report.Errors {
+		if err := writer.Write(append([]string{strconv.Itoa(rowError.Line), rowError.Reason}, rowError.Record...)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *LoanImportReport) reject(line int, record []string, err error) {
+	r.Rejected++
+	r.Errors = append(r.Errors, LoanImportRowError{Line: line, Reason: err.Error(), Record: record})
+}
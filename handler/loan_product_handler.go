@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/pricing"
+	"github.com/google/uuid"
+)
+
+type LoanProductHandler struct {
+	ProductStore datastore.LoanProductStore
+}
+
+func NewLoanProductHandler(productStore datastore.LoanProductStore) *LoanProductHandler {
+	return &LoanProductHandler{
+		ProductStore: productStore,
+	}
+}
+
+func (h *LoanProductHandler) HandleGetActiveLoanProducts(w http.ResponseWriter, r *http.Request) {
+	h.writeLoanProducts(w, true)
+}
+
+func (h *LoanProductHandler) HandleGetLoanProducts(w http.ResponseWriter, r *http.Request) {
+	h.writeLoanProducts(w, false)
+}
+
+func (h *LoanProductHandler) HandleCreateLoanProduct(w http.ResponseWriter, r *http.Request) {
+	h.upsertLoanProduct(w, r, uuid.New().String(), http.StatusCreated)
+}
+
+func (h *LoanProductHandler) HandleGetLoanProductById(w http.ResponseWriter, r *http.Request) {
+	productID, ok := productIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	row, err := h.ProductStore.GetLoanProductById(productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Loan product not found: " + productID
+		writeJSON(w, http.StatusNotFound, GetLoanProductByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get loan product " + productID
+		writeJSON(w, http.StatusInternalServerError, GetLoanProductByIdResponse{ErrorMessage: &errMsg})
+		return
+	}
+	product := convertLoanProductRow(row)
+	writeJSON(w, http.StatusOK, GetLoanProductByIdResponse{Data: &product})
+}
+
+func (h *LoanProductHandler) HandleUpdateLoanProduct(w http.ResponseWriter, r *http.Request) {
+	productID, ok := productIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	h.upsertLoanProduct(w, r, productID, http.StatusOK)
+}
+
+func (h *LoanProductHandler) HandleDeactivateLoanProduct(w http.ResponseWriter, r *http.Request) {
+	productID, ok := productIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	if err := h.ProductStore.DeactivateLoanProduct(productID); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusOK, DeactivateLoanProductResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, http.StatusOK, DeactivateLoanProductResponse{ProductID: &productID, Deactivated: true})
+}
+
+func productIDFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	productID := r.PathValue("productID")
+	if !IsValidUUID(productID) {
+		errMsg := "Invalid product ID: " + productID
+		writeJSON(w, http.StatusBadRequest, GetLoanProductByIdResponse{ErrorMessage: &errMsg})
+		return "", false
+	}
+	return productID, true
+}
+
+func (h *LoanProductHandler) writeLoanProducts(w http.ResponseWriter, activeOnly bool) {
+	rows, err := h.ProductStore.GetAllLoanProducts()
+	if err != nil {
+		errMsg := "Failed to get loan products"
+		writeJSON(w, http.StatusInternalServerError, GetAllLoanProductsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	products := make([]LoanProduct, 0, len(rows))
+	for _, row := range rows {
+		if activeOnly && !row.IsActive {
+			continue
+		}
+		products = append(products, convertLoanProductRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetAllLoanProductsResponse{Data: &products})
+}
+
+func (h *LoanProductHandler) upsertLoanProduct(w http.ResponseWriter, r *http.Request, productID string, status int) {
+	var request LoanProduct
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+
+	row := &datastore.LoanProductRow{
+		ProductID:          productID,
+		Code:               normaliseCatalogueName(request.Code),
+		Name:               normaliseCatalogueName(request.Name),
+		IsCommercial:       request.IsCommercial,
+		MinAmount:          request.MinAmount,
+		MaxAmount:          request.MaxAmount,
+		MinVehicleAgeYears: request.MinVehicleAgeYears,
+		MaxVehicleAgeYears: request.MaxVehicleAgeYears,
+		AdminFee:           request.AdminFee,
+		ProvisionFeeRate:   request.ProvisionFeeRate,
+		InsuranceFeeRate:   request.InsuranceFeeRate,
+		LateFee:            request.LateFee,
+		LatePenaltyRate:    request.LatePenaltyRate,
+		PenaltyGraceDays:   request.PenaltyGraceDays,
+		TerminationFeeRate: request.TerminationFeeRate,
+		TerminationMinFee:  request.TerminationMinFee,
+		IsActive:           request.IsActive,
+		Tenures:            request.TenureMonths,
+		RequiredDocuments:  request.RequiredDocuments,
+	}
+	if request.VehicleType != nil && normaliseCatalogueName(*request.VehicleType) != "" {
+		row.VehicleType = sql.NullString{String: normaliseCatalogueName(*request.VehicleType), Valid: true}
+	}
+	for _, rate := range request.Rates {
+		row.Rates = append(row.Rates, &datastore.LoanProductRateRow{
+			MaxTenureMonth:     rate.MaxTenureMonth,
+			MaxVehicleAgeYears: rate.MaxVehicleAgeYears,
+			AnnualInterestRate: rate.AnnualInterestRate,
+		})
+	}
+
+	if err := validateLoanProductRow(row); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, UpsertLoanProductResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	id, err := h.ProductStore.UpsertLoanProduct(row)
+	if err != nil {
+		errMsg := "Failed to save loan product: " + err.Error()
+		writeJSON(w, http.StatusBadRequest, UpsertLoanProductResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, status, UpsertLoanProductResponse{ProductID: &id})
+}
+
+func validateLoanProductRow(row *datastore.LoanProductRow) error {
+	if row.Code == "" || row.Name == "" {
+		return errors.New("code and name are required")
+	}
+	if row.MinAmount < 0 || row.MaxAmount < row.MinAmount {
+		return fmt.Errorf("invalid amount range %d-%d", row.MinAmount, row.MaxAmount)
+	}
+	if row.MinVehicleAgeYears < 0 || row.MaxVehicleAgeYears < row.MinVehicleAgeYears {
+		return fmt.Errorf("invalid vehicle age range %d-%d", row.MinVehicleAgeYears, row.MaxVehicleAgeYears)
+	}
+	if row.AdminFee < 0 || row.ProvisionFeeRate < 0 || row.InsuranceFeeRate < 0 {
+		return errors.New("fees must not be negative")
+	}
+	if row.LateFee < 0 || row.LatePenaltyRate < 0 || row.PenaltyGraceDays < 0 {
+		return errors.New("late payment penalties must not be negative")
+	}
+	if row.TerminationFeeRate < 0 || row.TerminationMinFee < 0 {
+		return errors.New("early termination fees must not be negative")
+	}
+	if len(row.Tenures) == 0 {
+		return errors.New("at least one tenure is required")
+	}
+	for _, tenure := range row.Tenures {
+		if tenure <= 0 {
+			return fmt.Errorf("invalid tenure %d", tenure)
+		}
+	}
+	for _, documentType := range row.RequiredDocuments {
+		if _, ok := allowedDocumentContentTypes[documentType]; !ok {
+			return fmt.Errorf("unknown required document type %q", documentType)
+		}
+	}
+
+	product := convertPricingProduct(row)
+	for _, tenure := range row.Tenures {
+		if _, err := product.RateFor(tenure, row.MaxVehicleAgeYears); err != nil {
+			return fmt.Errorf("no rate covers a %d month tenure up to a vehicle age of %d years", tenure, row.MaxVehicleAgeYears)
+		}
+	}
+	return nil
+}
+
+func convertPricingProduct(row *datastore.LoanProductRow) *pricing.Product {
+	product := &pricing.Product{
+		ProductID:          row.ProductID,
+		VehicleType:        row.VehicleType.String,
+		IsCommercial:       row.IsCommercial,
+		MinAmount:          row.MinAmount,
+		MaxAmount:          row.MaxAmount,
+		MinVehicleAgeYears: row.MinVehicleAgeYears,
+		MaxVehicleAgeYears: row.MaxVehicleAgeYears,
+		AdminFee:           row.AdminFee,
+		ProvisionFeeRate:   row.ProvisionFeeRate,
+		InsuranceFeeRate:   row.InsuranceFeeRate,
+		Tenures:            row.Tenures,
+	}
+	for _, rate := range row.Rates {
+		product.Rates = append(product.Rates, pricing.Rate{
+			MaxTenureMonth:     rate.MaxTenureMonth,
+			MaxVehicleAgeYears: rate.MaxVehicleAgeYears,
+			AnnualInterestRate: rate.AnnualInterestRate,
+		})
+	}
+	return product
+}
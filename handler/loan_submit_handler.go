@@ -1,59 +1,324 @@
 package handler
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/pricing"
+	"github.com/alphaloan/vehicle/valuation"
 )
 
 type LoanSubmitHandler struct {
-	CustomerStore   datastore.LoanCustomerStore
 	SubmissionStore datastore.LoanSubmissionStore
+	CatalogueStore  datastore.VehicleCatalogueStore
+	ProductStore    datastore.LoanProductStore
+	DealerStore     datastore.DealerStore
+	ValuationPolicy valuation.Policy
+	// MaxLoanToValue rejects submissions above the ratio; zero disables the cap.
+	MaxLoanToValue float64
 }
 
 func NewLoanSubmitHandler(
-	customerStore datastore.LoanCustomerStore,
-	submissionStore datastore.LoanSubmissionStore) *LoanSubmitHandler {
+	submissionStore datastore.LoanSubmissionStore,
+	catalogueStore datastore.VehicleCatalogueStore,
+	productStore datastore.LoanProductStore,
+	dealerStore datastore.DealerStore) *LoanSubmitHandler {
 	return &LoanSubmitHandler{
-		CustomerStore:   customerStore,
 		SubmissionStore: submissionStore,
+		CatalogueStore:  catalogueStore,
+		ProductStore:    productStore,
+		DealerStore:     dealerStore,
+		ValuationPolicy: valuation.DefaultPolicy,
 	}
 }
 
 func (h *LoanSubmitHandler) HandleSubmitLoan(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Only PUT method allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var request LoanSubmitRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Bad request body", http.StatusBadRequest)
 		return
 	}
 
-	loanCustomerRow := convertLoanCustomer(&request.Customer)
-
-	upsertCustomerID, err := h.CustomerStore.UpsertCustomer(loanCustomerRow)
-	if err != nil {
-		http.Error(w, "Failed to upsert customer", http.StatusInternalServerError)
+	response, err := h.SubmitLoan(r.Context(), &request)
+	var submitErr *SubmitError
+	if errors.As(err, &submitErr) && submitErr.Status == http.StatusUnprocessableEntity {
+		errMsg := submitErr.Message
+		writeJSON(w, http.StatusUnprocessableEntity, LoanSubmitResponse{ErrorMessage: &errMsg})
 		return
 	}
-
-	loanSubmissionRow := convertLoanProposal(&request.ProposedLoad, upsertCustomerID)
-	upsertSubmissionID, err := h.SubmissionStore.UpsertSubmission(loanSubmissionRow)
 	if err != nil {
-		http.Error(w, "Failed to upsert submission", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	response := LoanSubmitResponse{
-		CustomerID:   &upsertCustomerID,
-		SubmissionID: &upsertSubmissionID,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// SubmitError refuses a submission with the HTTP status the JSON API answers
+// it with; the gRPC API maps the status to its own code.
+type SubmitError struct {
+	Status  int
+	Message string
+}
+
+func (e *SubmitError) Error() string {
+	return e.Message
+}
+
+func unprocessable(err error) *SubmitError {
+	return &SubmitError{Status: http.StatusUnprocessableEntity, Message: err.Error()}
+}
+
+func submitFailure(message string) *SubmitError {
+	return &SubmitError{Status: http.StatusInternalServerError, Message: message}
+}
+
+// refused passes on the *SubmitError of a lookup that failed and refuses the
+// submission as unprocessable for any other error.
+func refused(err error) *SubmitError {
+	var submitErr *SubmitError
+	if errors.As(err, &submitErr) {
+		return submitErr
+	}
+	return unprocessable(err)
+}
+
+// SubmitLoan validates the request, values the vehicle, prices the loan and
+// saves the customer, the submission and its parties. Every error is a
+// *SubmitError.
+//
+// The dealer is taken from the dealer API key the request was authenticated
+// with. Without one the submission cannot be attributed, since the dealer is
+// paid a commission on it, so dealer_id and agent_id are refused.
+func (h *LoanSubmitHandler) SubmitLoan(ctx context.Context, request *LoanSubmitRequest) (*LoanSubmitResponse, error) {
+	if _, ok := dealerIDFromContext(ctx); !ok && (request.ProposedLoad.DealerID != nil || request.ProposedLoad.AgentID != nil) {
+		return nil, unprocessable(errors.New("dealer_id and agent_id are only accepted with a dealer API key"))
+	}
+	if err := validateSubmissionParties(&request.Customer, request.Parties); err != nil {
+		return nil, unprocessable(err)
+	}
+	if err := validateCustomerLocale(&request.Customer); err != nil {
+		return nil, unprocessable(err)
+	}
+	for _, party := range request.Parties {
+		if err := validateCustomerLocale(party.Customer); err != nil {
+			return nil, unprocessable(err)
+		}
+	}
+
+	if err := h.resolveOrigin(ctx, &request.ProposedLoad); err != nil {
+		return nil, refused(err)
+	}
+
+	licenseNumber, err := normaliseLicensePlate(request.ProposedLoad.VehicleLicenseNumber)
+	if err != nil {
+		return nil, unprocessable(err)
+	}
+	request.ProposedLoad.VehicleLicenseNumber = licenseNumber
+
+	vehicleModel, err := canonicaliseVehicle(&h.CatalogueStore, &request.ProposedLoad)
+	if err != nil {
+		return nil, refused(err)
+	}
+
+	now := time.Now()
+	valuationRow, err := h.valueCollateral(vehicleModel, &request.ProposedLoad, now)
+	if err != nil {
+		return nil, unprocessable(err)
+	}
+
+	if _, legacy := legacyRouteFromContext(ctx); legacy && request.ProposedLoad.ProductID == nil {
+		productID, err := h.defaultProduct(&request.ProposedLoad, now)
+		if err != nil {
+			return nil, refused(err)
+		}
+		request.ProposedLoad.ProductID = &productID
+	}
+	quote, err := h.quoteProduct(&request.ProposedLoad, now)
+	if err != nil {
+		return nil, refused(err)
+	}
+
+	submissionRow := convertLoanProposal(&request.ProposedLoad, "")
+	record := &datastore.LoanSubmitRecord{
+		Customer:   convertLoanCustomer(&request.Customer),
+		Submission: submissionRow,
+		Quote:      convertPricingQuote(submissionRow.SubmissionID, quote, submissionRow.CreatedAt),
+		Valuation:  valuationRow,
+	}
+	for _, party := range request.Parties {
+		record.Parties = append(record.Parties, &datastore.SubmissionPartyWithCustomerRow{
+			PartyRole:       party.Role,
+			LoanCustomerRow: convertLoanCustomer(party.Customer),
+		})
+	}
+
+	result, err := h.SubmissionStore.SubmitLoan(record)
+	if err != nil {
+		log.Printf("saving submission %s failed: %v\n", submissionRow.SubmissionID, err)
+		return nil, submitFailure("Failed to save submission")
+	}
+
+	parties := make([]SubmissionParty, 0, len(result.Parties))
+	for _, party := range result.Parties {
+		parties = append(parties, SubmissionParty{Role: party.PartyRole, CustomerID: party.CustomerID})
+	}
+	loanQuote := convertLoanQuoteRow(record.Quote)
+	response := &LoanSubmitResponse{
+		CustomerID:            &result.CustomerID,
+		SubmissionID:          &result.SubmissionID,
+		Parties:               &parties,
+		IsDuplicateCollateral: result.IsDuplicateCollateral,
+		Quote:                 &loanQuote,
+	}
+	if valuationRow != nil {
+		loanValuation := convertLoanValuationRow(valuationRow)
+		response.Valuation = &loanValuation
+	}
+	return response, nil
+}
+
+// resolveOrigin checks the dealer and sales agent a submission is tagged with.
+// Behind a dealer API key the calling dealer is the origin, whatever the body
+// says. Failed lookups are returned as a *SubmitError.
+func (h *LoanSubmitHandler) resolveOrigin(ctx context.Context, proposal *LoanSubmission) error {
+	if dealerID, ok := dealerIDFromContext(ctx); ok {
+		proposal.DealerID = &dealerID
+	}
+	if proposal.DealerID == nil {
+		if proposal.AgentID != nil {
+			return errors.New("agent_id requires a dealer_id")
+		}
+		return nil
+	}
+
+	if !IsValidUUID(*proposal.DealerID) {
+		return fmt.Errorf("invalid dealer_id %q", *proposal.DealerID)
+	}
+	dealer, err := h.DealerStore.GetDealerById(*proposal.DealerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("unknown dealer %s", *proposal.DealerID)
+	}
+	if err != nil {
+		return submitFailure("Failed to get dealer " + *proposal.DealerID)
+	}
+	if !dealer.IsActive {
+		return fmt.Errorf("dealer %s is no longer active", dealer.Code)
+	}
+
+	if proposal.AgentID == nil {
+		return nil
+	}
+	if !IsValidUUID(*proposal.AgentID) {
+		return fmt.Errorf("invalid agent_id %q", *proposal.AgentID)
+	}
+	agent, err := h.DealerStore.GetDealerAgentById(*proposal.AgentID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && agent.DealerID != dealer.DealerID) {
+		return fmt.Errorf("agent %s does not work for dealer %s", *proposal.AgentID, dealer.Code)
+	}
+	if err != nil {
+		return submitFailure("Failed to get agent " + *proposal.AgentID)
+	}
+	if !agent.IsActive {
+		return fmt.Errorf("agent %s is no longer active", agent.FullName)
+	}
+	return nil
+}
+
+// valueCollateral values the vehicle as of asOf. It returns nil when the
+// catalogue has no base price for the model, in which case the submission is
+// accepted without a valuation.
+func (h *LoanSubmitHandler) valueCollateral(model *datastore.VehicleModelRow, proposal *LoanSubmission, asOf time.Time) (*datastore.LoanValuationRow, error) {
+	if !model.BasePrice.Valid {
+		return nil, nil
+	}
+
+	result, err := h.ValuationPolicy.Estimate(valuation.Input{
+		BasePrice:         int(model.BasePrice.Int64),
+		ManufacturingYear: proposal.ManufacturingYear,
+		Odometer:          proposal.VehicleOdometer,
+		IsCommercial:      proposal.IsCommercialVehicle,
+		AsOf:              asOf,
+	})
+	if errors.Is(err, valuation.ErrNoBasePrice) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loanToValue := valuation.LoanToValue(proposal.ProposedLoanAmount, result.EstimatedValue)
+	if h.MaxLoanToValue > 0 && loanToValue > h.MaxLoanToValue {
+		return nil, fmt.Errorf("loan-to-value %.2f exceeds the maximum of %.2f for an estimated value of %d",
+			loanToValue, h.MaxLoanToValue, result.EstimatedValue)
+	}
+
+	return &datastore.LoanValuationRow{
+		ModelID:          sql.NullString{String: model.ModelID, Valid: true},
+		BasePrice:        result.BasePrice,
+		VehicleAgeYears:  result.AgeYears,
+		AgeFactor:        result.AgeFactor,
+		MileageFactor:    result.MileageFactor,
+		CommercialFactor: result.CommercialFactor,
+		EstimatedValue:   result.EstimatedValue,
+		LoanToValue:      loanToValue,
+		ValuedAt:         asOf.Unix(),
+	}, nil
+}
+
+func (h *LoanSubmitHandler) quoteProduct(proposal *LoanSubmission, asOf time.Time) (*pricing.Quote, error) {
+	if proposal.ProductID == nil || !IsValidUUID(*proposal.ProductID) {
+		return nil, errors.New("a valid product_id is required")
+	}
+
+	product, err := h.ProductStore.GetLoanProductById(*proposal.ProductID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("unknown loan product %s", *proposal.ProductID)
+	}
+	if err != nil {
+		return nil, submitFailure("Failed to get loan product " + *proposal.ProductID)
+	}
+	if !product.IsActive {
+		return nil, fmt.Errorf("loan product %s is no longer offered", product.Code)
+	}
+
+	return convertPricingProduct(product).Quote(pricingProposal(proposal, asOf))
+}
+
+// defaultProduct picks the product a loan is quoted on when it is submitted
+// without product_id through the legacy route, whose clients predate loan
+// products: the first active product that takes the loan.
+func (h *LoanSubmitHandler) defaultProduct(proposal *LoanSubmission, asOf time.Time) (string, error) {
+	products, err := h.ProductStore.GetAllLoanProducts()
+	if err != nil {
+		return "", submitFailure("Failed to get loan products")
+	}
+	for _, product := range products {
+		if !product.IsActive {
+			continue
+		}
+		if _, err := convertPricingProduct(product).Quote(pricingProposal(proposal, asOf)); err == nil {
+			return product.ProductID, nil
+		}
+	}
+	return "", errors.New("no loan product takes this loan, choose one with product_id")
+}
+
+func pricingProposal(proposal *LoanSubmission, asOf time.Time) pricing.Proposal {
+	return pricing.Proposal{
+		Amount:       proposal.ProposedLoanAmount,
+		TenureMonth:  proposal.ProposedLoanTenureMonth,
+		VehicleType:  proposal.VehicleType,
+		VehicleAge:   max(asOf.Year()-proposal.ManufacturingYear, 0),
+		IsCommercial: proposal.IsCommercialVehicle,
+	}
+}
@@ -2,45 +2,74 @@ package handler
 
 import (
 	"database/sql"
+	"encoding/json"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/pricing"
 	"github.com/google/uuid"
 )
 
 type LoanCustomer struct {
-	CustomerID    string  `json:"customer_id"`
+	CustomerID    string  `json:"customer_id" openapi:"readonly,format=uuid"`
 	IDCardNumber  string  `json:"id_card_number"`
 	FullName      string  `json:"full_name"`
-	BirthDate     string  `json:"birth_date"`
+	BirthDate     string  `json:"birth_date" openapi:"format=date"`
 	PhoneNumber   string  `json:"phone_number"`
-	Email         *string `json:"email"`
+	Email         *string `json:"email" openapi:"format=email"`
 	MonthlyIncome float64 `json:"monthly_income"`
 	AddressStreet string  `json:"address_street"`
 	AddressCity   string  `json:"address_city"`
+	Locale        string  `json:"locale"`
 }
 
 type LoanSubmission struct {
-	SubmissionID            string `json:"submission_id"`
-	VehicleType             string `json:"vehicle_type"`
-	VehicleBrand            string `json:"vehicle_brand"`
-	VehicleModel            string `json:"vehicle_model"`
-	VehicleLicenseNumber    string `json:"vehicle_license_number"`
-	VehicleOdometer         int    `json:"vehicle_odometer"`
-	ManufacturingYear       int    `json:"manufacturing_year"`
-	ProposedLoanAmount      int    `json:"proposed_loan_amount"`
-	ProposedLoanTenureMonth int    `json:"proposed_loan_tenure_month"`
-	IsCommercialVehicle     bool   `json:"is_commercial_vehicle"`
+	SubmissionID            string  `json:"submission_id" openapi:"readonly,format=uuid"`
+	VehicleType             string  `json:"vehicle_type"`
+	VehicleBrand            string  `json:"vehicle_brand"`
+	VehicleModel            string  `json:"vehicle_model"`
+	VehicleLicenseNumber    string  `json:"vehicle_license_number"`
+	VehicleOdometer         int     `json:"vehicle_odometer"`
+	ManufacturingYear       int     `json:"manufacturing_year"`
+	ProposedLoanAmount      int     `json:"proposed_loan_amount"`
+	ProposedLoanTenureMonth int     `json:"proposed_loan_tenure_month"`
+	IsCommercialVehicle     bool    `json:"is_commercial_vehicle"`
+	IsDuplicateCollateral   bool    `json:"is_duplicate_collateral" openapi:"readonly"`
+	LoanStatus              string  `json:"loan_status" openapi:"readonly"`
+	ProductID               *string `json:"product_id" openapi:"format=uuid"`
+	DealerID                *string `json:"dealer_id" openapi:"format=uuid"`
+	AgentID                 *string `json:"agent_id" openapi:"format=uuid"`
+	PartyRole               *string `json:"party_role,omitempty" openapi:"readonly"`
+}
+
+type SubmissionParty struct {
+	Role       string        `json:"role" openapi:"required"`
+	CustomerID string        `json:"customer_id" openapi:"readonly,format=uuid"`
+	Customer   *LoanCustomer `json:"customer,omitempty"`
 }
 
 type LoanSubmitRequest struct {
-	Customer     LoanCustomer   `json:"customer"`
-	ProposedLoad LoanSubmission `json:"proposed_loan"`
+	Customer     LoanCustomer      `json:"customer" openapi:"required"`
+	Parties      []SubmissionParty `json:"parties"`
+	ProposedLoad LoanSubmission    `json:"proposed_loan" openapi:"required"`
 }
 
 type LoanSubmitResponse struct {
-	CustomerID   *string `json:"customer_id"`
-	SubmissionID *string `json:"submission_id"`
+	ErrorMessage          *string            `json:"error_message"`
+	CustomerID            *string            `json:"customer_id"`
+	SubmissionID          *string            `json:"submission_id"`
+	Valuation             *LoanValuation     `json:"valuation"`
+	Quote                 *LoanQuote         `json:"quote"`
+	Parties               *[]SubmissionParty `json:"parties"`
+	IsDuplicateCollateral bool               `json:"is_duplicate_collateral"`
+}
+
+type GetSubmissionPartiesResponse struct {
+	ErrorMessage *string            `json:"error_message"`
+	SubmissionID *string            `json:"submission_id"`
+	Data         *[]SubmissionParty `json:"data"`
 }
 
 type GetAllLoanSubmissionsResponse struct {
@@ -103,6 +132,22 @@ func convertLoanCustomer(loanCustomer *LoanCustomer) *datastore.LoanCustomerRow
 		MonthlyIncome: loanCustomer.MonthlyIncome,
 		AddressStreet: loanCustomer.AddressStreet,
 		AddressCity:   loanCustomer.AddressCity,
+		Locale:        strings.ToLower(strings.TrimSpace(loanCustomer.Locale)),
+	}
+}
+
+func convertLoanCustomerRow(row *datastore.LoanCustomerRow) LoanCustomer {
+	return LoanCustomer{
+		CustomerID:    row.CustomerID,
+		IDCardNumber:  row.IDCardNumber,
+		FullName:      row.FullName,
+		BirthDate:     row.BirthDate,
+		PhoneNumber:   row.PhoneNumber,
+		Email:         nullStringPtr(row.Email),
+		MonthlyIncome: row.MonthlyIncome,
+		AddressStreet: row.AddressStreet,
+		AddressCity:   row.AddressCity,
+		Locale:        row.Locale,
 	}
 }
 
@@ -113,7 +158,7 @@ func convertLoanProposal(loanProposal *LoanSubmission, customerID string) *datas
 
 	now := time.Now().Unix()
 
-	return &datastore.LoanSubmissionRow{
+	row := &datastore.LoanSubmissionRow{
 		SubmissionID:         uuid.New().String(),
 		VehicleType:          loanProposal.VehicleType,
 		VehicleBrand:         loanProposal.VehicleBrand,
@@ -128,5 +173,1307 @@ func convertLoanProposal(loanProposal *LoanSubmission, customerID string) *datas
 		CreatedAt:            now,
 		UpdatedAt:            now,
 		CustomerID:           customerID,
+		VehicleLicenseKey:    licensePlateKey(loanProposal.VehicleLicenseNumber),
+	}
+	if loanProposal.ProductID != nil {
+		row.ProductID = sql.NullString{String: *loanProposal.ProductID, Valid: true}
+	}
+	if loanProposal.DealerID != nil {
+		row.DealerID = sql.NullString{String: *loanProposal.DealerID, Valid: true}
+	}
+	if loanProposal.AgentID != nil {
+		row.AgentID = sql.NullString{String: *loanProposal.AgentID, Valid: true}
+	}
+	return row
+}
+
+func convertLoanSubmissionRow(row *datastore.LoanSubmissionRow) LoanSubmission {
+	return LoanSubmission{
+		SubmissionID:            row.SubmissionID,
+		VehicleType:             row.VehicleType,
+		VehicleBrand:            row.VehicleBrand,
+		VehicleModel:            row.VehicleModel,
+		VehicleLicenseNumber:    row.VehicleLicenseNumber,
+		VehicleOdometer:         row.VehicleOdometer,
+		ManufacturingYear:       row.ManufacturingYear,
+		ProposedLoanAmount:      row.ProposedLoanAmount,
+		ProposedLoanTenureMonth: row.ProposedLoanTenure,
+		IsCommercialVehicle:     row.IsCommercialVehicle,
+		IsDuplicateCollateral:   row.IsDuplicateCollateral,
+		LoanStatus:              row.LoanStatus,
+		ProductID:               nullStringPtr(row.ProductID),
+		DealerID:                nullStringPtr(row.DealerID),
+		AgentID:                 nullStringPtr(row.AgentID),
+	}
+}
+
+type VehicleType struct {
+	TypeID string `json:"type_id" openapi:"readonly,format=uuid"`
+	Name   string `json:"name" openapi:"required"`
+}
+
+type VehicleBrand struct {
+	BrandID string `json:"brand_id" openapi:"readonly,format=uuid"`
+	Name    string `json:"name" openapi:"required"`
+}
+
+type VehicleModel struct {
+	ModelID      string `json:"model_id" openapi:"readonly,format=uuid"`
+	BrandID      string `json:"brand_id" openapi:"format=uuid"`
+	BrandName    string `json:"brand_name" openapi:"readonly"`
+	TypeID       string `json:"type_id" openapi:"format=uuid"`
+	TypeName     string `json:"type_name" openapi:"readonly"`
+	Name         string `json:"name" openapi:"required"`
+	YearFrom     int    `json:"year_from"`
+	YearTo       *int   `json:"year_to"`
+	IsCommercial bool   `json:"is_commercial"`
+	BasePrice    *int   `json:"base_price"`
+}
+
+type GetAllVehicleTypesResponse struct {
+	ErrorMessage *string        `json:"error_message"`
+	Data         *[]VehicleType `json:"data"`
+}
+
+type GetAllVehicleBrandsResponse struct {
+	ErrorMessage *string         `json:"error_message"`
+	Data         *[]VehicleBrand `json:"data"`
+}
+
+type GetAllVehicleModelsResponse struct {
+	ErrorMessage *string         `json:"error_message"`
+	Data         *[]VehicleModel `json:"data"`
+}
+
+type UpsertVehicleCatalogueResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	ID           *string `json:"id"`
+}
+
+type DeleteVehicleCatalogueResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	ID           *string `json:"id"`
+	Deleted      bool    `json:"deleted"`
+}
+
+type ImportVehicleCatalogueResponse struct {
+	ErrorMessage *string  `json:"error_message"`
+	Imported     int      `json:"imported"`
+	RowErrors    []string `json:"row_errors"`
+}
+
+type LoanValuation struct {
+	SubmissionID     string  `json:"submission_id"`
+	ModelID          *string `json:"model_id"`
+	BasePrice        int     `json:"base_price"`
+	VehicleAgeYears  int     `json:"vehicle_age_years"`
+	AgeFactor        float64 `json:"age_factor"`
+	MileageFactor    float64 `json:"mileage_factor"`
+	CommercialFactor float64 `json:"commercial_factor"`
+	EstimatedValue   int     `json:"estimated_value"`
+	LoanToValue      float64 `json:"loan_to_value"`
+	ValuedAt         int64   `json:"valued_at"`
+}
+
+type GetLoanValuationResponse struct {
+	ErrorMessage *string        `json:"error_message"`
+	Data         *LoanValuation `json:"data"`
+}
+
+func convertLoanValuationRow(row *datastore.LoanValuationRow) LoanValuation {
+	loanValuation := LoanValuation{
+		SubmissionID:     row.SubmissionID,
+		BasePrice:        row.BasePrice,
+		VehicleAgeYears:  row.VehicleAgeYears,
+		AgeFactor:        row.AgeFactor,
+		MileageFactor:    row.MileageFactor,
+		CommercialFactor: row.CommercialFactor,
+		EstimatedValue:   row.EstimatedValue,
+		LoanToValue:      row.LoanToValue,
+		ValuedAt:         row.ValuedAt,
+	}
+	if row.ModelID.Valid {
+		loanValuation.ModelID = &row.ModelID.String
+	}
+	return loanValuation
+}
+
+type DuplicateCollateral struct {
+	SubmissionID         string `json:"submission_id"`
+	CustomerID           string `json:"customer_id"`
+	VehicleLicenseNumber string `json:"vehicle_license_number"`
+	LoanStatus           string `json:"loan_status"`
+	CreatedAt            int64  `json:"created_at"`
+}
+
+type GetDuplicateCollateralResponse struct {
+	ErrorMessage *string                `json:"error_message"`
+	SubmissionID *string                `json:"submission_id"`
+	Data         *[]DuplicateCollateral `json:"data"`
+}
+
+type LoanProductRate struct {
+	MaxTenureMonth     int     `json:"max_tenure_month"`
+	MaxVehicleAgeYears int     `json:"max_vehicle_age_years"`
+	AnnualInterestRate float64 `json:"annual_interest_rate"`
+}
+
+type LoanProduct struct {
+	ProductID          string            `json:"product_id" openapi:"readonly,format=uuid"`
+	Code               string            `json:"code"`
+	Name               string            `json:"name"`
+	VehicleType        *string           `json:"vehicle_type"`
+	IsCommercial       bool              `json:"is_commercial"`
+	MinAmount          int               `json:"min_amount"`
+	MaxAmount          int               `json:"max_amount"`
+	MinVehicleAgeYears int               `json:"min_vehicle_age_years"`
+	MaxVehicleAgeYears int               `json:"max_vehicle_age_years"`
+	AdminFee           int               `json:"admin_fee"`
+	ProvisionFeeRate   float64           `json:"provision_fee_rate"`
+	InsuranceFeeRate   float64           `json:"insurance_fee_rate"`
+	LateFee            int               `json:"late_fee"`
+	LatePenaltyRate    float64           `json:"late_penalty_daily_rate"`
+	PenaltyGraceDays   int               `json:"penalty_grace_days"`
+	TerminationFeeRate float64           `json:"early_termination_fee_rate"`
+	TerminationMinFee  int               `json:"early_termination_min_fee"`
+	IsActive           bool              `json:"is_active"`
+	TenureMonths       []int             `json:"tenure_months"`
+	Rates              []LoanProductRate `json:"rates"`
+	RequiredDocuments  []string          `json:"required_documents"`
+}
+
+type LoanQuote struct {
+	SubmissionID       string  `json:"submission_id"`
+	ProductID          string  `json:"product_id"`
+	AnnualInterestRate float64 `json:"annual_interest_rate"`
+	TenureMonth        int     `json:"tenure_month"`
+	Principal          int     `json:"principal"`
+	MonthlyInstallment int     `json:"monthly_installment"`
+	TotalInterest      int     `json:"total_interest"`
+	AdminFee           int     `json:"admin_fee"`
+	ProvisionFee       int     `json:"provision_fee"`
+	InsuranceFee       int     `json:"insurance_fee"`
+	TotalFees          int     `json:"total_fees"`
+	TotalCost          int     `json:"total_cost"`
+	QuotedAt           int64   `json:"quoted_at"`
+}
+
+type GetAllLoanProductsResponse struct {
+	ErrorMessage *string        `json:"error_message"`
+	Data         *[]LoanProduct `json:"data"`
+}
+
+type GetLoanProductByIdResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *LoanProduct `json:"data"`
+}
+
+type UpsertLoanProductResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	ProductID    *string `json:"product_id"`
+}
+
+type DeactivateLoanProductResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	ProductID    *string `json:"product_id"`
+	Deactivated  bool    `json:"deactivated"`
+}
+
+type GetLoanQuoteResponse struct {
+	ErrorMessage *string    `json:"error_message"`
+	Data         *LoanQuote `json:"data"`
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func convertLoanProductRow(row *datastore.LoanProductRow) LoanProduct {
+	product := LoanProduct{
+		ProductID:          row.ProductID,
+		Code:               row.Code,
+		Name:               row.Name,
+		VehicleType:        nullStringPtr(row.VehicleType),
+		IsCommercial:       row.IsCommercial,
+		MinAmount:          row.MinAmount,
+		MaxAmount:          row.MaxAmount,
+		MinVehicleAgeYears: row.MinVehicleAgeYears,
+		MaxVehicleAgeYears: row.MaxVehicleAgeYears,
+		AdminFee:           row.AdminFee,
+		ProvisionFeeRate:   row.ProvisionFeeRate,
+		InsuranceFeeRate:   row.InsuranceFeeRate,
+		LateFee:            row.LateFee,
+		LatePenaltyRate:    row.LatePenaltyRate,
+		PenaltyGraceDays:   row.PenaltyGraceDays,
+		TerminationFeeRate: row.TerminationFeeRate,
+		TerminationMinFee:  row.TerminationMinFee,
+		IsActive:           row.IsActive,
+		TenureMonths:       make([]int, 0, len(row.Tenures)),
+		Rates:              make([]LoanProductRate, 0, len(row.Rates)),
+		RequiredDocuments:  make([]string, 0, len(row.RequiredDocuments)),
+	}
+	product.TenureMonths = append(product.TenureMonths, row.Tenures...)
+	product.RequiredDocuments = append(product.RequiredDocuments, row.RequiredDocuments...)
+	for _, rate := range row.Rates {
+		product.Rates = append(product.Rates, LoanProductRate{
+			MaxTenureMonth:     rate.MaxTenureMonth,
+			MaxVehicleAgeYears: rate.MaxVehicleAgeYears,
+			AnnualInterestRate: rate.AnnualInterestRate,
+		})
+	}
+	return product
+}
+
+func convertPricingQuote(submissionID string, quote *pricing.Quote, quotedAt int64) *datastore.LoanQuoteRow {
+	return &datastore.LoanQuoteRow{
+		SubmissionID:       submissionID,
+		ProductID:          quote.ProductID,
+		AnnualInterestRate: quote.AnnualInterestRate,
+		TenureMonth:        quote.TenureMonth,
+		Principal:          quote.Principal,
+		MonthlyInstallment: quote.MonthlyInstallment,
+		TotalInterest:      quote.TotalInterest,
+		AdminFee:           quote.AdminFee,
+		ProvisionFee:       quote.ProvisionFee,
+		InsuranceFee:       quote.InsuranceFee,
+		TotalFees:          quote.TotalFees,
+		TotalCost:          quote.TotalCost,
+		QuotedAt:           quotedAt,
+	}
+}
+
+func convertLoanQuoteRow(row *datastore.LoanQuoteRow) LoanQuote {
+	return LoanQuote{
+		SubmissionID:       row.SubmissionID,
+		ProductID:          row.ProductID,
+		AnnualInterestRate: row.AnnualInterestRate,
+		TenureMonth:        row.TenureMonth,
+		Principal:          row.Principal,
+		MonthlyInstallment: row.MonthlyInstallment,
+		TotalInterest:      row.TotalInterest,
+		AdminFee:           row.AdminFee,
+		ProvisionFee:       row.ProvisionFee,
+		InsuranceFee:       row.InsuranceFee,
+		TotalFees:          row.TotalFees,
+		TotalCost:          row.TotalCost,
+		QuotedAt:           row.QuotedAt,
+	}
+}
+
+type Document struct {
+	DocumentID   string  `json:"document_id" openapi:"readonly,format=uuid"`
+	CustomerID   string  `json:"customer_id"`
+	SubmissionID *string `json:"submission_id"`
+	DocumentType string  `json:"document_type"`
+	FileName     string  `json:"file_name"`
+	ContentType  string  `json:"content_type"`
+	SizeBytes    int64   `json:"size_bytes"`
+	SHA256       string  `json:"sha256"`
+	UploadedAt   int64   `json:"uploaded_at"`
+}
+
+type DocumentChecklistItem struct {
+	DocumentType string   `json:"document_type"`
+	Provided     bool     `json:"provided"`
+	DocumentIDs  []string `json:"document_ids"`
+}
+
+type DocumentChecklist struct {
+	SubmissionID string                  `json:"submission_id"`
+	ProductID    *string                 `json:"product_id"`
+	Complete     bool                    `json:"complete"`
+	Items        []DocumentChecklistItem `json:"items"`
+}
+
+type UploadDocumentResponse struct {
+	ErrorMessage *string   `json:"error_message"`
+	Data         *Document `json:"data"`
+}
+
+type GetDocumentsResponse struct {
+	ErrorMessage *string     `json:"error_message"`
+	Data         *[]Document `json:"data"`
+}
+
+type GetDocumentChecklistResponse struct {
+	ErrorMessage *string            `json:"error_message"`
+	Data         *DocumentChecklist `json:"data"`
+}
+
+type DeleteDocumentResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	DocumentID   *string `json:"document_id"`
+	Deleted      bool    `json:"deleted"`
+}
+
+func convertDocumentRow(row *datastore.DocumentRow) Document {
+	return Document{
+		DocumentID:   row.DocumentID,
+		CustomerID:   row.CustomerID,
+		SubmissionID: nullStringPtr(row.SubmissionID),
+		DocumentType: row.DocumentType,
+		FileName:     row.FileName,
+		ContentType:  row.ContentType,
+		SizeBytes:    row.SizeBytes,
+		SHA256:       row.SHA256,
+		UploadedAt:   row.UploadedAt,
+	}
+}
+
+type LoanInstallment struct {
+	InstallmentNumber int     `json:"installment_number"`
+	DueDate           string  `json:"due_date" openapi:"format=date"`
+	PrincipalDue      int     `json:"principal_due"`
+	InterestDue       int     `json:"interest_due"`
+	FeeDue            int     `json:"fee_due"`
+	PenaltyDue        int     `json:"penalty_due"`
+	PrincipalPaid     int     `json:"principal_paid"`
+	InterestPaid      int     `json:"interest_paid"`
+	FeePaid           int     `json:"fee_paid"`
+	PenaltyPaid       int     `json:"penalty_paid"`
+	TotalOutstanding  int     `json:"total_outstanding"`
+	IsPaid            bool    `json:"is_paid"`
+	OverdueSince      *string `json:"overdue_since"`
+}
+
+type GenerateScheduleRequest struct {
+	FirstDueDate *string `json:"first_due_date" openapi:"format=date"`
+}
+
+type GetRepaymentScheduleResponse struct {
+	ErrorMessage *string            `json:"error_message"`
+	SubmissionID *string            `json:"submission_id"`
+	Data         *[]LoanInstallment `json:"data"`
+}
+
+type LoanPaymentAllocation struct {
+	InstallmentNumber int `json:"installment_number"`
+	FeeAmount         int `json:"fee_amount"`
+	InterestAmount    int `json:"interest_amount"`
+	PrincipalAmount   int `json:"principal_amount"`
+	PenaltyAmount     int `json:"penalty_amount"`
+}
+
+type LoanPayment struct {
+	PaymentID       string                  `json:"payment_id"`
+	SubmissionID    string                  `json:"submission_id"`
+	Amount          int                     `json:"amount"`
+	AppliedAmount   int                     `json:"applied_amount"`
+	UnappliedAmount int                     `json:"unapplied_amount"`
+	PaidOn          string                  `json:"paid_on"`
+	Reference       *string                 `json:"reference"`
+	PayoffQuoteID   *string                 `json:"payoff_quote_id"`
+	CreatedAt       int64                   `json:"created_at"`
+	Allocations     []LoanPaymentAllocation `json:"allocations"`
+}
+
+type PostPaymentRequest struct {
+	Amount    int     `json:"amount" openapi:"required"`
+	PaidOn    *string `json:"paid_on" openapi:"format=date"`
+	Reference *string `json:"reference"`
+}
+
+type PostPaymentResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *LoanPayment `json:"data"`
+	Balance      *LoanBalance `json:"balance"`
+}
+
+type GetLoanPaymentsResponse struct {
+	ErrorMessage *string        `json:"error_message"`
+	SubmissionID *string        `json:"submission_id"`
+	Data         *[]LoanPayment `json:"data"`
+}
+
+type LoanBalance struct {
+	SubmissionID         string  `json:"submission_id"`
+	AsOf                 string  `json:"as_of"`
+	OutstandingPrincipal int     `json:"outstanding_principal"`
+	OutstandingInterest  int     `json:"outstanding_interest"`
+	OutstandingFee       int     `json:"outstanding_fee"`
+	OutstandingPenalty   int     `json:"outstanding_penalty"`
+	TotalOutstanding     int     `json:"total_outstanding"`
+	OverdueAmount        int     `json:"overdue_amount"`
+	OverdueInstallments  int     `json:"overdue_installments"`
+	PaidInstallments     int     `json:"paid_installments"`
+	TotalInstallments    int     `json:"total_installments"`
+	NextDueDate          *string `json:"next_due_date"`
+	NextDueAmount        int     `json:"next_due_amount"`
+	Credit               int     `json:"credit"`
+}
+
+type GetLoanBalanceResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *LoanBalance `json:"data"`
+}
+
+func convertLoanPaymentRow(row *datastore.LoanPaymentRow) LoanPayment {
+	payment := LoanPayment{
+		PaymentID:       row.PaymentID,
+		SubmissionID:    row.SubmissionID,
+		Amount:          row.Amount,
+		AppliedAmount:   row.AppliedAmount,
+		UnappliedAmount: row.UnappliedAmount,
+		PaidOn:          row.PaidOn,
+		Reference:       nullStringPtr(row.Reference),
+		PayoffQuoteID:   nullStringPtr(row.PayoffQuoteID),
+		CreatedAt:       row.CreatedAt,
+		Allocations:     make([]LoanPaymentAllocation, 0, len(row.Allocations)),
+	}
+	for _, allocation := range row.Allocations {
+		payment.Allocations = append(payment.Allocations, LoanPaymentAllocation{
+			InstallmentNumber: allocation.InstallmentNumber,
+			FeeAmount:         allocation.FeeAmount,
+			InterestAmount:    allocation.InterestAmount,
+			PrincipalAmount:   allocation.PrincipalAmount,
+			PenaltyAmount:     allocation.PenaltyAmount,
+		})
+	}
+	return payment
+}
+
+type AgingBucket struct {
+	Bucket            string `json:"bucket"`
+	LoanCount         int    `json:"loan_count"`
+	OverdueAmount     int    `json:"overdue_amount"`
+	OutstandingAmount int    `json:"outstanding_amount"`
+}
+
+type AgingLoan struct {
+	SubmissionID         string `json:"submission_id"`
+	CustomerID           string `json:"customer_id"`
+	FullName             string `json:"full_name"`
+	PhoneNumber          string `json:"phone_number"`
+	AddressCity          string `json:"address_city"`
+	VehicleType          string `json:"vehicle_type"`
+	VehicleLicenseNumber string `json:"vehicle_license_number"`
+	Bucket               string `json:"bucket"`
+	DaysPastDue          int    `json:"days_past_due"`
+	OverdueAmount        int    `json:"overdue_amount"`
+	OverdueInstallments  int    `json:"overdue_installments"`
+	OutstandingAmount    int    `json:"outstanding_amount"`
+	PenaltyOutstanding   int    `json:"penalty_outstanding"`
+	AsOf                 string `json:"as_of"`
+}
+
+type AgingReport struct {
+	AddressCity *string       `json:"address_city"`
+	VehicleType *string       `json:"vehicle_type"`
+	Buckets     []AgingBucket `json:"buckets"`
+	Loans       []AgingLoan   `json:"loans"`
+}
+
+type GetAgingReportResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *AgingReport `json:"data"`
+}
+
+type CollectionsRunSummary struct {
+	AsOf                string `json:"as_of"`
+	LoansAssessed       int    `json:"loans_assessed"`
+	LoansFailed         int    `json:"loans_failed"`
+	OverdueLoans        int    `json:"overdue_loans"`
+	PenaltyCharged      int    `json:"penalty_charged"`
+	CommissionClawbacks int    `json:"commission_clawbacks"`
+	RemindersQueued     int    `json:"reminders_queued"`
+}
+
+type RunCollectionsResponse struct {
+	ErrorMessage *string                `json:"error_message"`
+	Data         *CollectionsRunSummary `json:"data"`
+}
+
+type PayoffQuote struct {
+	QuoteID         string `json:"quote_id"`
+	SubmissionID    string `json:"submission_id"`
+	AsOf            string `json:"as_of"`
+	ExpiresOn       string `json:"expires_on"`
+	Principal       int    `json:"principal"`
+	AccruedInterest int    `json:"accrued_interest"`
+	Fee             int    `json:"fee"`
+	Penalty         int    `json:"penalty"`
+	TerminationFee  int    `json:"early_termination_fee"`
+	Waived          int    `json:"waived"`
+	Total           int    `json:"total"`
+	Status          string `json:"status"`
+	SettledAt       *int64 `json:"settled_at"`
+	CreatedAt       int64  `json:"created_at"`
+}
+
+type GetPayoffQuoteResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *PayoffQuote `json:"data"`
+}
+
+type SettleLoanRequest struct {
+	QuoteID   string  `json:"quote_id" openapi:"required,format=uuid"`
+	Amount    int     `json:"amount" openapi:"required"`
+	PaidOn    *string `json:"paid_on" openapi:"format=date"`
+	Reference *string `json:"reference"`
+}
+
+type SettleLoanResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Quote        *PayoffQuote `json:"quote"`
+	Payment      *LoanPayment `json:"payment"`
+	LoanStatus   *string      `json:"loan_status"`
+}
+
+func convertLoanPayoffQuoteRow(row *datastore.LoanPayoffQuoteRow, today string) PayoffQuote {
+	quote := PayoffQuote{
+		QuoteID:         row.QuoteID,
+		SubmissionID:    row.SubmissionID,
+		AsOf:            row.AsOf,
+		ExpiresOn:       row.ExpiresOn,
+		Principal:       row.Principal,
+		AccruedInterest: row.AccruedInterest,
+		Fee:             row.Fee,
+		Penalty:         row.Penalty,
+		TerminationFee:  row.TerminationFee,
+		Waived:          row.Waived,
+		Total:           row.Total,
+		Status:          "OPEN",
+		CreatedAt:       row.CreatedAt,
+	}
+	switch {
+	case row.SettledAt.Valid:
+		quote.Status = "SETTLED"
+		quote.SettledAt = &row.SettledAt.Int64
+	case row.ExpiresOn < today:
+		quote.Status = "EXPIRED"
+	}
+	return quote
+}
+
+type Disbursement struct {
+	DisbursementID string  `json:"disbursement_id"`
+	SubmissionID   string  `json:"submission_id"`
+	PayeeType      string  `json:"payee_type"`
+	PayeeName      string  `json:"payee_name"`
+	BankCode       string  `json:"bank_code"`
+	AccountNumber  string  `json:"account_number"`
+	AccountHolder  string  `json:"account_holder"`
+	LoanAmount     int     `json:"loan_amount"`
+	DeductedFees   int     `json:"deducted_fees"`
+	NetAmount      int     `json:"net_amount"`
+	Status         string  `json:"status"`
+	CreatedBy      string  `json:"created_by"`
+	ReviewedBy     *string `json:"reviewed_by"`
+	ReviewNote     *string `json:"review_note"`
+	PaymentFile    *string `json:"payment_file"`
+	// PaymentFileStatus is PENDING until the payment file is in the outbox,
+	// then DELIVERED, or FAILED when writing it failed.
+	PaymentFileStatus      *string `json:"payment_file_status" openapi:"enum=PENDING|DELIVERED|FAILED"`
+	PaymentFileDeliveredAt *int64  `json:"payment_file_delivered_at"`
+	CreatedAt              int64   `json:"created_at"`
+	ReviewedAt             *int64  `json:"reviewed_at"`
+}
+
+type CreateDisbursementRequest struct {
+	SubmissionID  string `json:"submission_id" openapi:"required,format=uuid"`
+	PayeeType     string `json:"payee_type" openapi:"required"`
+	PayeeName     string `json:"payee_name" openapi:"required"`
+	BankCode      string `json:"bank_code" openapi:"required"`
+	AccountNumber string `json:"account_number" openapi:"required"`
+	AccountHolder string `json:"account_holder" openapi:"required"`
+	DeductedFees  int    `json:"deducted_fees"`
+}
+
+type ReviewDisbursementRequest struct {
+	Note *string `json:"note"`
+}
+
+type DisbursementResponse struct {
+	ErrorMessage *string       `json:"error_message"`
+	Data         *Disbursement `json:"data"`
+}
+
+type GetDisbursementsResponse struct {
+	ErrorMessage *string         `json:"error_message"`
+	Data         *[]Disbursement `json:"data"`
+}
+
+func convertLoanDisbursementRow(row *datastore.LoanDisbursementRow) Disbursement {
+	disbursement := Disbursement{
+		DisbursementID:    row.DisbursementID,
+		SubmissionID:      row.SubmissionID,
+		PayeeType:         row.PayeeType,
+		PayeeName:         row.PayeeName,
+		BankCode:          row.BankCode,
+		AccountNumber:     row.AccountNumber,
+		AccountHolder:     row.AccountHolder,
+		LoanAmount:        row.LoanAmount,
+		DeductedFees:      row.DeductedFees,
+		NetAmount:         row.NetAmount,
+		Status:            row.Status,
+		CreatedBy:         row.CreatedBy,
+		ReviewedBy:        nullStringPtr(row.ReviewedBy),
+		ReviewNote:        nullStringPtr(row.ReviewNote),
+		PaymentFile:       nullStringPtr(row.PaymentFile),
+		PaymentFileStatus: nullStringPtr(row.PaymentFileStatus),
+		CreatedAt:         row.CreatedAt,
+	}
+	if row.PaymentFileDeliveredAt.Valid {
+		disbursement.PaymentFileDeliveredAt = &row.PaymentFileDeliveredAt.Int64
 	}
+	if row.ReviewedAt.Valid {
+		disbursement.ReviewedAt = &row.ReviewedAt.Int64
+	}
+	return disbursement
+}
+
+type ErrorResponse struct {
+	ErrorMessage *string `json:"error_message"`
+}
+
+// RequestValidationResponse is the answer of ContractValidator to a request
+// that breaks the OpenAPI document.
+type RequestValidationResponse struct {
+	ErrorMessage *string                  `json:"error_message"`
+	Errors       []RequestValidationError `json:"errors" openapi:"required"`
+}
+
+// RequestValidationError names the parameter, or the JSON pointer into the
+// body, of one violation.
+type RequestValidationError struct {
+	In        string `json:"in" openapi:"required,enum=path|query|header|body"`
+	Parameter string `json:"parameter,omitempty"`
+	Pointer   string `json:"pointer,omitempty"`
+	Message   string `json:"message" openapi:"required"`
+}
+
+type Dealer struct {
+	DealerID            string           `json:"dealer_id" openapi:"readonly,format=uuid"`
+	Code                string           `json:"code"`
+	Name                string           `json:"name"`
+	PhoneNumber         string           `json:"phone_number"`
+	Email               *string          `json:"email" openapi:"format=email"`
+	AddressCity         string           `json:"address_city"`
+	CommissionRate      float64          `json:"commission_rate"`
+	CommissionFlatFee   int              `json:"commission_flat_fee"`
+	CommissionCap       *int64           `json:"commission_cap"`
+	CommissionTiers     []CommissionTier `json:"commission_tiers"`
+	AgentCommissionRate float64          `json:"agent_commission_rate"`
+	ClawbackMonths      int              `json:"clawback_months"`
+	IsActive            bool             `json:"is_active"`
+	CreatedAt           int64            `json:"created_at" openapi:"readonly"`
+	UpdatedAt           int64            `json:"updated_at" openapi:"readonly"`
+}
+
+type CommissionTier struct {
+	MinMonthlyApprovals int     `json:"min_monthly_approvals"`
+	CommissionRate      float64 `json:"commission_rate"`
+}
+
+type DealerBranch struct {
+	BranchID    string `json:"branch_id" openapi:"readonly,format=uuid"`
+	DealerID    string `json:"dealer_id" openapi:"readonly,format=uuid"`
+	Name        string `json:"name"`
+	AddressCity string `json:"address_city"`
+	IsActive    bool   `json:"is_active" openapi:"readonly"`
+	CreatedAt   int64  `json:"created_at" openapi:"readonly"`
+}
+
+type DealerAgent struct {
+	AgentID     string  `json:"agent_id" openapi:"readonly,format=uuid"`
+	DealerID    string  `json:"dealer_id" openapi:"readonly,format=uuid"`
+	BranchID    *string `json:"branch_id" openapi:"format=uuid"`
+	FullName    string  `json:"full_name"`
+	PhoneNumber string  `json:"phone_number"`
+	Email       *string `json:"email" openapi:"format=email"`
+	IsActive    bool    `json:"is_active" openapi:"readonly"`
+	CreatedAt   int64   `json:"created_at" openapi:"readonly"`
+}
+
+type DealerCredential struct {
+	CredentialID string `json:"credential_id"`
+	KeyPrefix    string `json:"key_prefix"`
+	// APIKey is only returned when the credential is issued.
+	APIKey     *string `json:"api_key,omitempty"`
+	CreatedAt  int64   `json:"created_at"`
+	LastUsedAt *int64  `json:"last_used_at"`
+	RevokedAt  *int64  `json:"revoked_at"`
+}
+
+type DealerSummary struct {
+	DealerID            string   `json:"dealer_id"`
+	Code                string   `json:"code"`
+	Name                string   `json:"name"`
+	SubmissionCount     int      `json:"submission_count"`
+	RequestedAmount     int      `json:"requested_amount"`
+	ApprovedCount       int      `json:"approved_count"`
+	RejectedCount       int      `json:"rejected_count"`
+	ApprovalRate        *float64 `json:"approval_rate"`
+	DisbursedCount      int      `json:"disbursed_count"`
+	DisbursedLoanAmount int      `json:"disbursed_loan_amount"`
+}
+
+type GetAllDealersResponse struct {
+	ErrorMessage *string   `json:"error_message"`
+	Data         *[]Dealer `json:"data"`
+}
+
+type GetDealerByIdResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	Data         *Dealer `json:"data"`
+}
+
+type UpsertDealerResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	DealerID     *string `json:"dealer_id"`
+}
+
+type DeactivateDealerResponse struct {
+	ErrorMessage *string `json:"error_message"`
+	DealerID     *string `json:"dealer_id"`
+	Deactivated  bool    `json:"deactivated"`
+}
+
+type DealerBranchesResponse struct {
+	ErrorMessage *string         `json:"error_message"`
+	DealerID     *string         `json:"dealer_id"`
+	Data         *[]DealerBranch `json:"data"`
+}
+
+type DealerBranchResponse struct {
+	ErrorMessage *string       `json:"error_message"`
+	Data         *DealerBranch `json:"data"`
+}
+
+type DealerAgentsResponse struct {
+	ErrorMessage *string        `json:"error_message"`
+	DealerID     *string        `json:"dealer_id"`
+	Data         *[]DealerAgent `json:"data"`
+}
+
+type DealerAgentResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *DealerAgent `json:"data"`
+}
+
+type DealerCredentialsResponse struct {
+	ErrorMessage *string             `json:"error_message"`
+	DealerID     *string             `json:"dealer_id"`
+	Data         *[]DealerCredential `json:"data"`
+}
+
+type DealerCredentialResponse struct {
+	ErrorMessage *string           `json:"error_message"`
+	Data         *DealerCredential `json:"data"`
+}
+
+type GetDealerSummariesResponse struct {
+	ErrorMessage *string          `json:"error_message"`
+	From         *string          `json:"from"`
+	To           *string          `json:"to"`
+	Data         *[]DealerSummary `json:"data"`
+}
+
+func convertDealerRow(row *datastore.DealerRow) Dealer {
+	dealer := Dealer{
+		DealerID:            row.DealerID,
+		Code:                row.Code,
+		Name:                row.Name,
+		PhoneNumber:         row.PhoneNumber,
+		Email:               nullStringPtr(row.Email),
+		AddressCity:         row.AddressCity,
+		CommissionRate:      row.CommissionRate,
+		CommissionFlatFee:   row.CommissionFlatFee,
+		CommissionTiers:     make([]CommissionTier, 0, len(row.CommissionTiers)),
+		AgentCommissionRate: row.AgentCommissionRate,
+		ClawbackMonths:      row.ClawbackMonths,
+		IsActive:            row.IsActive,
+		CreatedAt:           row.CreatedAt,
+		UpdatedAt:           row.UpdatedAt,
+	}
+	if row.CommissionCap.Valid {
+		dealer.CommissionCap = &row.CommissionCap.Int64
+	}
+	for _, tier := range row.CommissionTiers {
+		dealer.CommissionTiers = append(dealer.CommissionTiers, CommissionTier{
+			MinMonthlyApprovals: tier.MinMonthlyApprovals,
+			CommissionRate:      tier.CommissionRate,
+		})
+	}
+	return dealer
+}
+
+func convertDealerBranchRow(row *datastore.DealerBranchRow) DealerBranch {
+	return DealerBranch{
+		BranchID:    row.BranchID,
+		DealerID:    row.DealerID,
+		Name:        row.Name,
+		AddressCity: row.AddressCity,
+		IsActive:    row.IsActive,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+func convertDealerAgentRow(row *datastore.DealerAgentRow) DealerAgent {
+	return DealerAgent{
+		AgentID:     row.AgentID,
+		DealerID:    row.DealerID,
+		BranchID:    nullStringPtr(row.BranchID),
+		FullName:    row.FullName,
+		PhoneNumber: row.PhoneNumber,
+		Email:       nullStringPtr(row.Email),
+		IsActive:    row.IsActive,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+func convertDealerCredentialRow(row *datastore.DealerCredentialRow) DealerCredential {
+	credential := DealerCredential{
+		CredentialID: row.CredentialID,
+		KeyPrefix:    row.KeyPrefix,
+		CreatedAt:    row.CreatedAt,
+	}
+	if row.LastUsedAt.Valid {
+		credential.LastUsedAt = &row.LastUsedAt.Int64
+	}
+	if row.RevokedAt.Valid {
+		credential.RevokedAt = &row.RevokedAt.Int64
+	}
+	return credential
+}
+
+// convertDealerSummaryRow leaves the approval rate empty until a submission
+// has been decided either way.
+func convertDealerSummaryRow(row *datastore.DealerSummaryRow) DealerSummary {
+	summary := DealerSummary{
+		DealerID:            row.DealerID,
+		Code:                row.Code,
+		Name:                row.Name,
+		SubmissionCount:     row.SubmissionCount,
+		RequestedAmount:     row.RequestedAmount,
+		ApprovedCount:       row.ApprovedCount,
+		RejectedCount:       row.RejectedCount,
+		DisbursedCount:      row.DisbursedCount,
+		DisbursedLoanAmount: row.DisbursedLoanAmount,
+	}
+	if decided := row.ApprovedCount + row.RejectedCount; decided > 0 {
+		rate := math.Round(float64(row.ApprovedCount)/float64(decided)*10000) / 10000
+		summary.ApprovalRate = &rate
+	}
+	return summary
+}
+
+type SubmissionDecisionRequest struct {
+	Decision string `json:"decision" openapi:"required"`
+}
+
+type CommissionEntry struct {
+	EntryID          string  `json:"entry_id"`
+	SubmissionID     string  `json:"submission_id"`
+	DealerID         string  `json:"dealer_id"`
+	AgentID          *string `json:"agent_id"`
+	AgentName        *string `json:"agent_name"`
+	BeneficiaryType  string  `json:"beneficiary_type"`
+	EntryType        string  `json:"entry_type"`
+	StatementMonth   string  `json:"statement_month"`
+	LoanAmount       int     `json:"loan_amount"`
+	CommissionRate   float64 `json:"commission_rate"`
+	MonthlyApprovals *int64  `json:"monthly_approvals"`
+	Amount           int     `json:"amount"`
+	Reason           *string `json:"reason"`
+	CreatedAt        int64   `json:"created_at"`
+}
+
+type SubmissionDecision struct {
+	SubmissionID string            `json:"submission_id"`
+	LoanStatus   string            `json:"loan_status"`
+	DecidedBy    string            `json:"decided_by"`
+	Commissions  []CommissionEntry `json:"commissions"`
+}
+
+type SubmissionDecisionResponse struct {
+	ErrorMessage *string             `json:"error_message"`
+	Data         *SubmissionDecision `json:"data"`
+}
+
+type AgentCommissionTotal struct {
+	AgentID   string  `json:"agent_id"`
+	FullName  *string `json:"full_name"`
+	NetAmount int     `json:"net_amount"`
+}
+
+// CommissionStatement totals the ledger entries booked to a dealer in one
+// month. Clawbacks are negative, so NetAmount is what is owed for the month.
+type CommissionStatement struct {
+	DealerID        string                 `json:"dealer_id"`
+	DealerCode      string                 `json:"dealer_code"`
+	DealerName      string                 `json:"dealer_name"`
+	Month           string                 `json:"month"`
+	AccruedAmount   int                    `json:"accrued_amount"`
+	ClawbackAmount  int                    `json:"clawback_amount"`
+	DealerNetAmount int                    `json:"dealer_net_amount"`
+	AgentNetAmount  int                    `json:"agent_net_amount"`
+	NetAmount       int                    `json:"net_amount"`
+	Agents          []AgentCommissionTotal `json:"agents"`
+	Entries         []CommissionEntry      `json:"entries"`
+}
+
+type CommissionStatementResponse struct {
+	ErrorMessage *string              `json:"error_message"`
+	Data         *CommissionStatement `json:"data"`
+}
+
+func convertCommissionEntryRow(row *datastore.CommissionEntryRow) CommissionEntry {
+	entry := CommissionEntry{
+		EntryID:         row.EntryID,
+		SubmissionID:    row.SubmissionID,
+		DealerID:        row.DealerID,
+		AgentID:         nullStringPtr(row.AgentID),
+		AgentName:       nullStringPtr(row.AgentName),
+		BeneficiaryType: row.BeneficiaryType,
+		EntryType:       row.EntryType,
+		StatementMonth:  row.StatementMonth,
+		LoanAmount:      row.LoanAmount,
+		CommissionRate:  row.CommissionRate,
+		Amount:          row.Amount,
+		Reason:          nullStringPtr(row.Reason),
+		CreatedAt:       row.CreatedAt,
+	}
+	if row.MonthlyApprovals.Valid {
+		entry.MonthlyApprovals = &row.MonthlyApprovals.Int64
+	}
+	return entry
+}
+
+func buildCommissionStatement(dealer *datastore.DealerRow, month string, rows []*datastore.CommissionEntryRow) CommissionStatement {
+	statement := CommissionStatement{
+		DealerID:   dealer.DealerID,
+		DealerCode: dealer.Code,
+		DealerName: dealer.Name,
+		Month:      month,
+		Agents:     []AgentCommissionTotal{},
+		Entries:    make([]CommissionEntry, 0, len(rows)),
+	}
+	agents := make(map[string]int)
+	for _, row := range rows {
+		statement.Entries = append(statement.Entries, convertCommissionEntryRow(row))
+		statement.NetAmount += row.Amount
+		if row.EntryType == datastore.CommissionEntryAccrual {
+			statement.AccruedAmount += row.Amount
+		} else {
+			statement.ClawbackAmount += row.Amount
+		}
+		if row.BeneficiaryType == datastore.CommissionBeneficiaryDealer {
+			statement.DealerNetAmount += row.Amount
+			continue
+		}
+
+		statement.AgentNetAmount += row.Amount
+		i, ok := agents[row.AgentID.String]
+		if !ok {
+			i = len(statement.Agents)
+			agents[row.AgentID.String] = i
+			statement.Agents = append(statement.Agents, AgentCommissionTotal{
+				AgentID:  row.AgentID.String,
+				FullName: nullStringPtr(row.AgentName),
+			})
+		}
+		statement.Agents[i].NetAmount += row.Amount
+	}
+	return statement
+}
+
+type EventSchema struct {
+	EventType     string `json:"event_type"`
+	SchemaVersion int    `json:"schema_version"`
+	SchemaURL     string `json:"schema_url"`
+}
+
+type GetEventSchemasResponse struct {
+	ErrorMessage *string        `json:"error_message"`
+	Data         *[]EventSchema `json:"data"`
+}
+
+type OutboxStats struct {
+	Pending         int    `json:"pending"`
+	Failing         int    `json:"failing"`
+	Published       int    `json:"published"`
+	OldestPendingAt *int64 `json:"oldest_pending_at"`
+	LastPublishedAt *int64 `json:"last_published_at"`
+}
+
+type GetOutboxStatsResponse struct {
+	ErrorMessage *string      `json:"error_message"`
+	Data         *OutboxStats `json:"data"`
+}
+
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" openapi:"format=uri"`
+	EventTypes  []string `json:"event_types"`
+	Description *string  `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+type WebhookSubscription struct {
+	SubscriptionID string   `json:"subscription_id"`
+	URL            string   `json:"url"`
+	EventTypes     []string `json:"event_types"`
+	Description    *string  `json:"description"`
+	IsActive       bool     `json:"is_active"`
+	// Secret is only returned when the subscription is created.
+	Secret    *string `json:"secret,omitempty"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	AttemptNumber int     `json:"attempt_number"`
+	AttemptedAt   int64   `json:"attempted_at"`
+	ResponseCode  *int64  `json:"response_code"`
+	Error         *string `json:"error"`
+	DurationMs    int64   `json:"duration_ms"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID       string                   `json:"delivery_id"`
+	SubscriptionID   string                   `json:"subscription_id"`
+	EventID          string                   `json:"event_id"`
+	EventType        string                   `json:"event_type"`
+	Status           string                   `json:"status"`
+	Attempts         int                      `json:"attempts"`
+	NextAttemptAt    *int64                   `json:"next_attempt_at"`
+	LastResponseCode *int64                   `json:"last_response_code"`
+	LastError        *string                  `json:"last_error"`
+	RedeliveryOf     *string                  `json:"redelivery_of"`
+	CreatedAt        int64                    `json:"created_at"`
+	CompletedAt      *int64                   `json:"completed_at"`
+	Payload          json.RawMessage          `json:"payload"`
+	AttemptLog       []WebhookDeliveryAttempt `json:"attempt_log"`
+}
+
+type WebhookSubscriptionsResponse struct {
+	ErrorMessage *string                `json:"error_message"`
+	Data         *[]WebhookSubscription `json:"data"`
+}
+
+type WebhookSubscriptionResponse struct {
+	ErrorMessage *string              `json:"error_message"`
+	Data         *WebhookSubscription `json:"data"`
+}
+
+type WebhookDeliveriesResponse struct {
+	ErrorMessage *string            `json:"error_message"`
+	Data         *[]WebhookDelivery `json:"data"`
+}
+
+type WebhookDeliveryResponse struct {
+	ErrorMessage *string          `json:"error_message"`
+	Data         *WebhookDelivery `json:"data"`
+}
+
+func convertWebhookSubscriptionRow(row *datastore.WebhookSubscriptionRow) WebhookSubscription {
+	return WebhookSubscription{
+		SubscriptionID: row.SubscriptionID,
+		URL:            row.URL,
+		EventTypes:     row.EventTypes,
+		Description:    nullStringPtr(row.Description),
+		IsActive:       row.IsActive,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
+
+// convertWebhookDeliveryRow only reports the next attempt of deliveries that
+// are still pending.
+func convertWebhookDeliveryRow(row *datastore.WebhookDeliveryRow) WebhookDelivery {
+	delivery := WebhookDelivery{
+		DeliveryID:     row.DeliveryID,
+		SubscriptionID: row.SubscriptionID,
+		EventID:        row.EventID,
+		EventType:      row.EventType,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		LastError:      nullStringPtr(row.LastError),
+		RedeliveryOf:   nullStringPtr(row.RedeliveryOf),
+		CreatedAt:      row.CreatedAt,
+		Payload:        json.RawMessage(row.Payload),
+		AttemptLog:     make([]WebhookDeliveryAttempt, 0, len(row.AttemptLog)),
+	}
+	if row.Status == datastore.WebhookDeliveryPending {
+		delivery.NextAttemptAt = &row.NextAttemptAt
+	}
+	if row.LastResponseCode.Valid {
+		delivery.LastResponseCode = &row.LastResponseCode.Int64
+	}
+	if row.CompletedAt.Valid {
+		delivery.CompletedAt = &row.CompletedAt.Int64
+	}
+	for _, attempt := range row.AttemptLog {
+		item := WebhookDeliveryAttempt{
+			AttemptNumber: attempt.AttemptNumber,
+			AttemptedAt:   attempt.AttemptedAt,
+			Error:         nullStringPtr(attempt.Error),
+			DurationMs:    attempt.DurationMs,
+		}
+		if attempt.ResponseCode.Valid {
+			item.ResponseCode = &attempt.ResponseCode.Int64
+		}
+		delivery.AttemptLog = append(delivery.AttemptLog, item)
+	}
+	return delivery
+}
+
+type Notification struct {
+	NotificationID string          `json:"notification_id"`
+	CustomerID     string          `json:"customer_id"`
+	SubmissionID   *string         `json:"submission_id"`
+	Template       string          `json:"template"`
+	Channel        string          `json:"channel"`
+	Locale         string          `json:"locale"`
+	Recipient      string          `json:"recipient"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *int64          `json:"next_attempt_at"`
+	Subject        *string         `json:"subject"`
+	Body           *string         `json:"body"`
+	LastError      *string         `json:"last_error"`
+	Data           json.RawMessage `json:"data"`
+	CreatedAt      int64           `json:"created_at"`
+	SentAt         *int64          `json:"sent_at"`
+}
+
+type GetNotificationsResponse struct {
+	ErrorMessage *string         `json:"error_message"`
+	Data         *[]Notification `json:"data"`
+}
+
+// convertNotificationRow shows subject and body as last rendered, they are
+// empty until the first send attempt.
+func convertNotificationRow(row *datastore.NotificationRow) Notification {
+	notification := Notification{
+		NotificationID: row.NotificationID,
+		CustomerID:     row.CustomerID,
+		SubmissionID:   nullStringPtr(row.SubmissionID),
+		Template:       row.Template,
+		Channel:        row.Channel,
+		Locale:         row.Locale,
+		Recipient:      row.Recipient,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		Subject:        nullStringPtr(row.Subject),
+		Body:           nullStringPtr(row.Body),
+		LastError:      nullStringPtr(row.LastError),
+		Data:           json.RawMessage(row.Data),
+		CreatedAt:      row.CreatedAt,
+	}
+	if row.Status == datastore.NotificationPending {
+		notification.NextAttemptAt = &row.NextAttemptAt
+	}
+	if row.SentAt.Valid {
+		notification.SentAt = &row.SentAt.Int64
+	}
+	return notification
+}
+
+type LoanImportRowError struct {
+	Line   int      `json:"line"`
+	Reason string   `json:"reason"`
+	Record []string `json:"-"`
+}
+
+// LoanImportReport counts the rows of an import. In a dry run Imported is the
+// number of rows that would have been imported.
+type LoanImportReport struct {
+	DryRun   bool                 `json:"dry_run"`
+	Rows     int                  `json:"rows"`
+	Imported int                  `json:"imported"`
+	Rejected int                  `json:"rejected"`
+	Errors   []LoanImportRowError `json:"errors"`
+	Header   []string             `json:"-"`
+}
+
+type ImportLoansResponse struct {
+	ErrorMessage *string           `json:"error_message"`
+	Data         *LoanImportReport `json:"data"`
+}
+
+// ReportFilter echoes the filters a report was computed with; from and to are
+// inclusive dates.
+type ReportFilter struct {
+	GroupBy   []string `json:"group_by"`
+	From      *string  `json:"from"`
+	To        *string  `json:"to"`
+	DealerID  *string  `json:"dealer_id"`
+	ProductID *string  `json:"product_id"`
+}
+
+type SubmissionVolume struct {
+	Group                   map[string]string `json:"group"`
+	SubmissionCount         int               `json:"submission_count"`
+	TotalProposedLoanAmount int               `json:"total_proposed_loan_amount"`
+}
+
+type SubmissionVolumeReport struct {
+	ReportFilter
+	GeneratedAt int64              `json:"generated_at"`
+	Rows        []SubmissionVolume `json:"rows"`
+	Total       SubmissionVolume   `json:"total"`
+}
+
+type GetSubmissionVolumeReportResponse struct {
+	ErrorMessage *string                 `json:"error_message"`
+	Data         *SubmissionVolumeReport `json:"data"`
+}
+
+// SubmissionFunnel counts submissions by the furthest stage they reached.
+// ApprovalRate is approved over decided (approved plus rejected) and
+// ConversionRate disbursed over submitted; both are null without a
+// denominator.
+type SubmissionFunnel struct {
+	Group              map[string]string `json:"group"`
+	Submitted          int               `json:"submitted"`
+	Pending            int               `json:"pending"`
+	Approved           int               `json:"approved"`
+	Rejected           int               `json:"rejected"`
+	Cancelled          int               `json:"cancelled"`
+	Disbursed          int               `json:"disbursed"`
+	Closed             int               `json:"closed"`
+	ApprovedLoanAmount int               `json:"approved_loan_amount"`
+	ApprovalRate       *float64          `json:"approval_rate"`
+	ConversionRate     *float64          `json:"conversion_rate"`
+}
+
+type SubmissionFunnelReport struct {
+	ReportFilter
+	GeneratedAt int64              `json:"generated_at"`
+	Rows        []SubmissionFunnel `json:"rows"`
+	Total       SubmissionFunnel   `json:"total"`
+}
+
+type GetSubmissionFunnelReportResponse struct {
+	ErrorMessage *string                 `json:"error_message"`
+	Data         *SubmissionFunnelReport `json:"data"`
+}
+
+// GraphQLRequest is a GraphQL query as clients post it.
+type GraphQLRequest struct {
+	Query         string         `json:"query" openapi:"required"`
+	OperationName *string        `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQLResponse holds the data of a query that ran, with the errors of any
+// field that failed, or only the errors of a query that was refused.
+type GraphQLResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message   string            `json:"message" openapi:"required"`
+	Locations []GraphQLLocation `json:"locations,omitempty"`
+	Path      []any             `json:"path,omitempty"`
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+const (
+	defaultNotificationLimit = 100
+	maxNotificationLimit     = 1000
+)
+
+type NotificationHandler struct {
+	NotificationStore datastore.NotificationStore
+}
+
+func NewNotificationHandler(notificationStore datastore.NotificationStore) *NotificationHandler {
+	return &NotificationHandler{
+		NotificationStore: notificationStore,
+	}
+}
+
+// HandleGetNotifications is the send log across customers, filtered by the
+// optional customer_id, submission_id, status and channel parameters.
+func (h *NotificationHandler) HandleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	filter, err := notificationFilter(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetNotificationsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	filter.CustomerID = strings.TrimSpace(r.URL.Query().Get("customer_id"))
+	filter.SubmissionID = strings.TrimSpace(r.URL.Query().Get("submission_id"))
+	if (filter.CustomerID != "" && !IsValidUUID(filter.CustomerID)) ||
+		(filter.SubmissionID != "" && !IsValidUUID(filter.SubmissionID)) {
+		errMsg := "customer_id and submission_id must be UUIDs"
+		writeJSON(w, http.StatusBadRequest, GetNotificationsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	h.writeNotifications(w, filter)
+}
+
+func (h *NotificationHandler) HandleGetCustomerNotifications(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("customerID")
+	if !IsValidUUID(customerID) {
+		errMsg := "Invalid customer ID: " + customerID
+		writeJSON(w, http.StatusBadRequest, GetNotificationsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	filter, err := notificationFilter(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetNotificationsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	filter.CustomerID = customerID
+	h.writeNotifications(w, filter)
+}
+
+func (h *NotificationHandler) writeNotifications(w http.ResponseWriter, filter datastore.NotificationFilter) {
+	rows, err := h.NotificationStore.GetNotifications(filter)
+	if err != nil {
+		errMsg := "Failed to get notifications"
+		writeJSON(w, http.StatusInternalServerError, GetNotificationsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	notifications := make([]Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, convertNotificationRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetNotificationsResponse{Data: &notifications})
+}
+
+func notificationFilter(r *http.Request) (datastore.NotificationFilter, error) {
+	query := r.URL.Query()
+	filter := datastore.NotificationFilter{
+		Status:  strings.ToUpper(strings.TrimSpace(query.Get("status"))),
+		Channel: strings.ToUpper(strings.TrimSpace(query.Get("channel"))),
+		Limit:   defaultNotificationLimit,
+	}
+	switch filter.Status {
+	case "", datastore.NotificationPending, datastore.NotificationSent, datastore.NotificationFailed:
+	default:
+		return filter, fmt.Errorf("invalid status %q, expected PENDING, SENT or FAILED", filter.Status)
+	}
+	switch filter.Channel {
+	case "", datastore.NotificationChannelEmail, datastore.NotificationChannelSMS:
+	default:
+		return filter, fmt.Errorf("invalid channel %q, expected EMAIL or SMS", filter.Channel)
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxNotificationLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxNotificationLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
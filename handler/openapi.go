@@ -0,0 +1,1162 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/export"
+	"github.com/alphaloan/vehicle/openapi"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// Security schemes of the OpenAPI document.
+const (
+	DealerKeySecurity   = "dealerKey"
+	OperatorKeySecurity = "operatorKey"
+	PIITokenSecurity    = "piiToken"
+)
+
+const contentTypeText = "text/plain"
+
+// swaggerInitializer replaces the one shipped with Swagger UI, which points
+// at the petstore example.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+var apiTags = []openapi.Tag{
+	{Name: "Loans", Description: "Submitting loans and reading submissions with their valuation, quote and parties."},
+	{Name: "Customers", Description: "Customers and the submissions they made."},
+	{Name: "Documents", Description: "Documents uploaded for customers and submissions."},
+	{Name: "Repayments", Description: "Repayment schedules, payments, balances and early settlement."},
+	{Name: "Collections", Description: "Delinquency of overdue loans."},
+	{Name: "Events", Description: "Schemas of the domain events and the outbox relaying them."},
+	{Name: "Dealer portal", Description: "The API dealers call with their own API key."},
+	{Name: "Catalogue", Description: "Vehicle types, brands and models."},
+	{Name: "Products", Description: "Loan products and their pricing."},
+	{Name: "Dealers", Description: "Dealers, their branches, agents, API keys and commissions."},
+	{Name: "Disbursements", Description: "Paying out approved loans under four-eyes review."},
+	{Name: "Notifications", Description: "Email and SMS sent to customers."},
+	{Name: "Exports", Description: "Bulk downloads of submissions and customers."},
+	{Name: "Reports", Description: "Portfolio volume and funnel reports."},
+	{Name: "GraphQL", Description: "Customers and submissions read as one graph."},
+	{Name: "Docs", Description: "This document and its browser."},
+}
+
+var apiSecuritySchemes = map[string]*openapi.SecurityScheme{
+	DealerKeySecurity: {
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "dk_...",
+		Description:  "API key issued to a dealer by an administrator.",
+	},
+	OperatorKeySecurity: {
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "ok_...",
+		Description:  "API key of a back-office operator, listed in the -operator-keys file.",
+	},
+	PIITokenSecurity: {
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "Token configured with -pii-export-token; exports and GraphQL responses are masked without it.",
+	},
+}
+
+var (
+	dateParam   = openapi.Param{Format: "date", Description: "YYYY-MM-DD"}
+	exportTypes = []string{
+		export.ContentType(export.FormatCSV),
+		export.ContentType(export.FormatXLSX),
+		export.ContentType(export.FormatNDJSON),
+	}
+	documentTypes = []string{
+		DocumentTypeIDCard,
+		DocumentTypeSTNK,
+		DocumentTypeBPKB,
+		DocumentTypeProofOfIncome,
+		DocumentTypeVehiclePhoto,
+	}
+	disbursementStatuses = []string{
+		datastore.DisbursementStatusPending,
+		datastore.DisbursementStatusApproved,
+		datastore.DisbursementStatusRejected,
+	}
+)
+
+var documentUpload = &openapi.Schema{
+	Type: "object",
+	Properties: map[string]*openapi.Schema{
+		"document_type": {Type: "string", Enum: documentTypes},
+		"file":          {Type: "string", Format: "binary"},
+	},
+	Required: []string{"document_type", "file"},
+}
+
+var csvUpload = &openapi.Schema{
+	Type: "object",
+	Properties: map[string]*openapi.Schema{
+		"file": {Type: "string", Format: "binary"},
+	},
+	Required: []string{"file"},
+}
+
+// apiEndpoints documents every route registered in main. The openapi
+// command's -check flag compares it with the handlers, so a route or reply
+// added without being listed here is caught.
+var apiEndpoints = []openapi.Endpoint{
+	{
+		Method: http.MethodPost, Path: "/api/v1/submissions", Handler: "LoanSubmitHandler.HandleSubmitLoan", Tag: "Loans",
+		Summary: "Submit a loan",
+		Description: "Creates or updates the customer and the submission, values the vehicle and prices the loan. " +
+			"Co-applicants and guarantors are created as customers when new; existing customers are linked without changing their details. " +
+			"The submission is attributed to the dealer whose API key is sent; dealer_id and agent_id are refused without one. " +
+			"product_id is required, except on the deprecated PUT /api/loan/submit: its clients predate loan products, so a " +
+			"loan it receives without one is quoted on the first active product that takes it.",
+		OptionalSecurity: []string{DealerKeySecurity},
+		Request:          LoanSubmitRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(LoanSubmitResponse{}, http.StatusOK, http.StatusUnprocessableEntity),
+			{
+				Statuses: []int{http.StatusUnauthorized},
+				Body:     ErrorResponse{},
+				Headers:  []openapi.Param{{Name: "WWW-Authenticate"}},
+			},
+			plainText(http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions", Handler: "LoanSubmissionHandler.HandleGetAllLoanSubmission",
+		OperationID: "getLoanSubmissions", Tag: "Loans", Summary: "List submissions",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllLoanSubmissionsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}", Handler: "LoanSubmissionHandler.HandleSubmissionLoanById",
+		OperationID: "getLoanSubmission", Tag: "Loans", Summary: "Get a submission",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanSubmissionsByIdResponse{}, http.StatusOK, http.StatusBadRequest),
+			plainText(http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}/valuation",
+		Handler: "LoanSubmissionHandler.HandleGetSubmissionValuation", Tag: "Loans",
+		Summary: "Get the collateral valuation of a submission",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanValuationResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}/duplicates",
+		Handler: "LoanSubmissionHandler.HandleGetDuplicateCollateral", Tag: "Loans",
+		Summary: "List other submissions pledging the same vehicle",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDuplicateCollateralResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}/quote",
+		Handler: "LoanSubmissionHandler.HandleGetSubmissionQuote", Tag: "Loans",
+		Summary: "Get the pricing quote of a submission",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanQuoteResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}/parties",
+		Handler: "LoanSubmissionHandler.HandleGetSubmissionParties", Tag: "Loans",
+		Summary: "List the borrowers and guarantors of a submission",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetSubmissionPartiesResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}/documents",
+		Handler: "DocumentHandler.HandleGetSubmissionDocuments", Tag: "Documents",
+		Summary: "List the documents of a submission",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDocumentsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/submissions/{submissionID}/documents",
+		Handler: "DocumentHandler.HandleUploadSubmissionDocument", OperationID: "uploadSubmissionDocument", Tag: "Documents",
+		Summary: "Upload a document for a submission",
+		Request: documentUpload, RequestTypes: []string{"multipart/form-data"},
+		Responses: documentUploadReplies(),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/submissions/{submissionID}/documents/checklist",
+		Handler: "DocumentHandler.HandleGetDocumentChecklist", Tag: "Documents",
+		Summary: "Check which documents the product requires and which are provided",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDocumentsResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+			openapi.JSON(GetDocumentChecklistResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/customers/{customerID}/documents",
+		Handler: "DocumentHandler.HandleGetCustomerDocuments", Tag: "Documents",
+		Summary: "List the documents of a customer",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDocumentsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/customers/{customerID}/documents",
+		Handler: "DocumentHandler.HandleUploadCustomerDocument", OperationID: "uploadCustomerDocument", Tag: "Documents",
+		Summary: "Upload a document for a customer",
+		Request: documentUpload, RequestTypes: []string{"multipart/form-data"},
+		Responses: documentUploadReplies(),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/documents/{documentID}", Handler: "DocumentHandler.HandleDownloadDocument",
+		OperationID: "downloadDocument", Tag: "Documents", Summary: "Download a document",
+		Responses: []openapi.Reply{
+			{
+				Statuses:     []int{http.StatusOK},
+				ContentTypes: []string{"application/pdf", "image/jpeg", "image/png"},
+				Headers: []openapi.Param{
+					{Name: "Content-Disposition"},
+					{Name: "X-Checksum-SHA256", Description: "Hex SHA-256 of the content."},
+				},
+			},
+			openapi.JSON(DeleteDocumentResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+			plainText(http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/documents/{documentID}", Handler: "DocumentHandler.HandleDeleteDocument",
+		OperationID: "deleteDocument", Tag: "Documents", Summary: "Delete a document",
+		Responses: []openapi.Reply{
+			openapi.JSON(DeleteDocumentResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/products", Handler: "LoanProductHandler.HandleGetActiveLoanProducts",
+		Tag: "Products", Summary: "List the loan products on offer",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllLoanProductsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/customers", Handler: "LoanCustomerHandler.HandleGetAllLoanSubmission",
+		OperationID: "getLoanCustomers", Tag: "Customers", Summary: "List customers",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllLoanCustomersResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/customers/{customerID}",
+		Handler: "LoanCustomerHandler.HandleGetCustomerAndSubmissionById", Tag: "Customers",
+		Summary: "Get a customer with their submissions",
+		Responses: []openapi.Reply{
+			openapi.JSON(CustomerAndSubmissions{}, http.StatusOK),
+			openapi.JSON(GetAllLoanCustomersResponse{}, http.StatusBadRequest),
+			plainText(http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v1/customers/{customerID}",
+		Handler: "LoanCustomerHandler.HandlerUpdateCustomerById", Tag: "Customers", Summary: "Update a customer",
+		Request: LoanCustomer{},
+		Responses: []openapi.Reply{
+			openapi.JSON(UpdateCustomerByCustomerIdResponse{}, http.StatusOK, http.StatusBadRequest),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/customers/{customerID}",
+		Handler: "LoanCustomerHandler.HandlerDeleteCustomerById", Tag: "Customers", Summary: "Delete a customer",
+		Responses: []openapi.Reply{
+			openapi.JSON(DeleteCustomerByCustomerIdResponse{}, http.StatusOK, http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/customers/{customerID}/notifications",
+		Handler: "NotificationHandler.HandleGetCustomerNotifications", Tag: "Notifications",
+		Summary: "List the notifications sent to a customer",
+		Query:   notificationQuery(),
+		Responses: []openapi.Reply{
+			openapi.JSON(GetNotificationsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/loans/{submissionID}/schedule", Handler: "RepaymentHandler.HandleGetRepaymentSchedule",
+		Tag: "Repayments", Summary: "Get the repayment schedule of a loan",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetRepaymentScheduleResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/loans/{submissionID}/schedule", Handler: "RepaymentHandler.HandleGenerateRepaymentSchedule",
+		OperationID: "generateRepaymentSchedule", Tag: "Repayments", Summary: "Generate the repayment schedule of a loan",
+		Request: GenerateScheduleRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetRepaymentScheduleResponse{}, http.StatusCreated, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/loans/{submissionID}/payments", Handler: "RepaymentHandler.HandleGetLoanPayments",
+		Tag: "Repayments", Summary: "List the payments of a loan",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanPaymentsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	},
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/loans/{submissionID}/payments", Handler: "RepaymentHandler.HandlePostLoanPayment",
+		OperationID: "postLoanPayment", Tag: "Repayments", Summary: "Post a payment to a loan",
+		Description: "A payment with a reference already posted is answered with 200 and the original allocation.",
+		Request:     PostPaymentRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(PostPaymentResponse{}, http.StatusCreated, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusInternalServerError),
+			openapi.JSON(GetLoanPaymentsResponse{}, http.StatusBadRequest),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	{
+		Method: http.MethodGet, Path: "/api/v1/loans/{submissionID}/balance", Handler: "RepaymentHandler.HandleGetLoanBalance",
+		Tag: "Repayments", Summary: "Get the outstanding balance of a loan",
+		Query: []openapi.Param{withName(dateParam, "as_of")},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanBalanceResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/loans/{submissionID}/payoff", Handler: "PayoffHandler.HandleGetPayoffQuote",
+		Tag: "Repayments", Summary: "Quote the amount settling a loan early",
+		Query: []openapi.Param{withName(dateParam, "as_of")},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetPayoffQuoteResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusInternalServerError),
+		},
+	},
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/loans/{submissionID}/settlement", Handler: "PayoffHandler.HandleSettleLoan",
+		Tag: "Repayments", Summary: "Settle a loan early against a payoff quote",
+		Request: SettleLoanRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(SettleLoanResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	{
+		Method: http.MethodGet, Path: "/api/v1/collections/aging", Handler: "CollectionsHandler.HandleGetAgingReport",
+		Tag: "Collections", Summary: "Report overdue loans by days past due",
+		Query: []openapi.Param{{Name: "address_city"}, {Name: "vehicle_type"}},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAgingReportResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/events/schemas", Handler: "EventHandler.HandleGetEventSchemas",
+		Tag: "Events", Summary: "List the event types and their schema versions",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetEventSchemasResponse{}, http.StatusOK),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/events/schemas/{eventType}/{version}", Handler: "EventHandler.HandleGetEventSchema",
+		Tag: "Events", Summary: "Get the JSON schema of an event version",
+		PathParams: []openapi.Param{{Name: "version", Type: "integer"}},
+		Responses: []openapi.Reply{
+			openapi.Raw("application/schema+json", http.StatusOK),
+			openapi.JSON(ErrorResponse{}, http.StatusBadRequest, http.StatusNotFound),
+		},
+	},
+
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/dealer/submissions", Handler: "LoanSubmitHandler.HandleSubmitLoan",
+		OperationID: "submitDealerLoan", Summary: "Submit a loan as the calling dealer",
+		Request: LoanSubmitRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(LoanSubmitResponse{}, http.StatusOK, http.StatusUnprocessableEntity),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/submissions", Handler: "DealerHandler.HandleGetOwnSubmissions",
+		Summary: "List the dealer's submissions",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllLoanSubmissionsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/submissions/{submissionID}", Handler: "DealerHandler.HandleGetOwnSubmissionById",
+		Summary: "Get one of the dealer's submissions",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanSubmissionsByIdResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/agents", Handler: "DealerHandler.HandleGetOwnAgents",
+		Summary: "List the dealer's agents",
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerAgentsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/summary", Handler: "DealerHandler.HandleGetOwnSummary",
+		Summary: "Summarise the dealer's volume and approval rate",
+		Query:   []openapi.Param{withName(dateParam, "from"), withName(dateParam, "to")},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDealerSummariesResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/commissions", Handler: "CommissionHandler.HandleGetOwnCommissionStatement",
+		Summary: "Get the dealer's commission statement of a month",
+		Query:   commissionQuery(),
+		Responses: []openapi.Reply{
+			openapi.JSON(CommissionStatementResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+			openapi.Raw("text/csv", http.StatusOK),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/webhooks", Handler: "WebhookHandler.HandleGetWebhooks",
+		Summary: "List the dealer's webhook subscriptions",
+		Responses: []openapi.Reply{
+			openapi.JSON(WebhookSubscriptionsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/dealer/webhooks", Handler: "WebhookHandler.HandleCreateWebhook",
+		OperationID: "createWebhook", Summary: "Subscribe to events",
+		Description: "The signing secret is only returned here.",
+		Request:     WebhookSubscriptionRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(WebhookSubscriptionResponse{}, http.StatusCreated, http.StatusBadRequest, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/webhooks/{subscriptionID}", Handler: "WebhookHandler.HandleGetWebhookById",
+		Summary: "Get a webhook subscription",
+		Responses: []openapi.Reply{
+			openapi.JSON(WebhookSubscriptionResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodPut, Path: "/api/v1/dealer/webhooks/{subscriptionID}", Handler: "WebhookHandler.HandleUpdateWebhook",
+		Summary: "Update a webhook subscription",
+		Request: WebhookSubscriptionRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(WebhookSubscriptionResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodDelete, Path: "/api/v1/dealer/webhooks/{subscriptionID}", Handler: "WebhookHandler.HandleDeleteWebhook",
+		Summary: "Deactivate a webhook subscription",
+		Responses: []openapi.Reply{
+			openapi.Empty(http.StatusNoContent),
+			openapi.JSON(WebhookSubscriptionResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/dealer/webhooks/{subscriptionID}/deliveries",
+		Handler: "WebhookHandler.HandleGetWebhookDeliveries", Summary: "List the deliveries of a subscription, newest first",
+		Query: []openapi.Param{
+			{Name: "status", Enum: []string{datastore.WebhookDeliveryPending, datastore.WebhookDeliverySucceeded,
+				datastore.WebhookDeliveryDead}},
+			{Name: "limit", Type: "integer", Description: "At most 500, 50 by default."},
+		},
+		Responses: []openapi.Reply{
+			openapi.JSON(WebhookDeliveriesResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	}),
+	dealerEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/dealer/webhooks/{subscriptionID}/deliveries/{deliveryID}/redeliver",
+		Handler: "WebhookHandler.HandleRedeliverWebhook", Summary: "Queue a delivery again",
+		Responses: []openapi.Reply{
+			openapi.JSON(WebhookDeliveryResponse{}, http.StatusAccepted, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusInternalServerError),
+		},
+	}),
+
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/vehicle/types", Handler: "VehicleCatalogueHandler.HandleGetVehicleTypes",
+		Tag: "Catalogue", Summary: "List vehicle types",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllVehicleTypesResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/vehicle/types", Handler: "VehicleCatalogueHandler.HandleCreateVehicleType",
+		OperationID: "createVehicleType", Tag: "Catalogue", Summary: "Create a vehicle type",
+		Request: VehicleType{}, Responses: catalogueUpsertReplies(http.StatusCreated),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/vehicle/types/{typeID}", Handler: "VehicleCatalogueHandler.HandleUpdateVehicleType",
+		Tag: "Catalogue", Summary: "Update a vehicle type",
+		Request: VehicleType{}, Responses: catalogueUpsertReplies(http.StatusOK),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/admin/vehicle/types/{typeID}", Handler: "VehicleCatalogueHandler.HandleDeleteVehicleType",
+		Tag: "Catalogue", Summary: "Delete a vehicle type", Responses: catalogueDeleteReplies(),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/vehicle/brands", Handler: "VehicleCatalogueHandler.HandleGetVehicleBrands",
+		Tag: "Catalogue", Summary: "List vehicle brands",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllVehicleBrandsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/vehicle/brands", Handler: "VehicleCatalogueHandler.HandleCreateVehicleBrand",
+		OperationID: "createVehicleBrand", Tag: "Catalogue", Summary: "Create a vehicle brand",
+		Request: VehicleBrand{}, Responses: catalogueUpsertReplies(http.StatusCreated),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/vehicle/brands/{brandID}", Handler: "VehicleCatalogueHandler.HandleUpdateVehicleBrand",
+		Tag: "Catalogue", Summary: "Update a vehicle brand",
+		Request: VehicleBrand{}, Responses: catalogueUpsertReplies(http.StatusOK),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/admin/vehicle/brands/{brandID}", Handler: "VehicleCatalogueHandler.HandleDeleteVehicleBrand",
+		Tag: "Catalogue", Summary: "Delete a vehicle brand", Responses: catalogueDeleteReplies(),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/vehicle/models", Handler: "VehicleCatalogueHandler.HandleGetVehicleModels",
+		Tag: "Catalogue", Summary: "List vehicle models",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllVehicleModelsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/vehicle/models", Handler: "VehicleCatalogueHandler.HandleCreateVehicleModel",
+		OperationID: "createVehicleModel", Tag: "Catalogue", Summary: "Create a vehicle model",
+		Request: VehicleModel{}, Responses: catalogueUpsertReplies(http.StatusCreated),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/vehicle/models/{modelID}", Handler: "VehicleCatalogueHandler.HandleUpdateVehicleModel",
+		Tag: "Catalogue", Summary: "Update a vehicle model",
+		Request: VehicleModel{}, Responses: catalogueUpsertReplies(http.StatusOK),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/admin/vehicle/models/{modelID}", Handler: "VehicleCatalogueHandler.HandleDeleteVehicleModel",
+		Tag: "Catalogue", Summary: "Delete a vehicle model", Responses: catalogueDeleteReplies(),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/vehicle/catalogue/import", Handler: "VehicleCatalogueHandler.HandleImportCatalogue",
+		Tag: "Catalogue", Summary: "Import types, brands and models from CSV",
+		Description: "Columns: " + strings.Join(catalogueImportColumns, ", ") + ". Invalid rows are reported and skipped.",
+		Request:     csvUpload, RequestTypes: []string{"multipart/form-data"}, RawRequestTypes: []string{"text/csv"},
+		Responses: []openapi.Reply{
+			openapi.JSON(ImportVehicleCatalogueResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/products", Handler: "LoanProductHandler.HandleGetLoanProducts",
+		Tag: "Products", Summary: "List loan products, inactive ones included",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllLoanProductsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/products", Handler: "LoanProductHandler.HandleCreateLoanProduct",
+		OperationID: "createLoanProduct", Tag: "Products", Summary: "Create a loan product",
+		Request: LoanProduct{},
+		Responses: []openapi.Reply{
+			openapi.JSON(UpsertLoanProductResponse{}, http.StatusCreated, http.StatusBadRequest),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/products/{productID}", Handler: "LoanProductHandler.HandleGetLoanProductById",
+		Tag: "Products", Summary: "Get a loan product",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetLoanProductByIdResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/products/{productID}", Handler: "LoanProductHandler.HandleUpdateLoanProduct",
+		Tag: "Products", Summary: "Update a loan product",
+		Request: LoanProduct{},
+		Responses: []openapi.Reply{
+			openapi.JSON(UpsertLoanProductResponse{}, http.StatusOK, http.StatusBadRequest),
+			openapi.JSON(GetLoanProductByIdResponse{}, http.StatusBadRequest),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/admin/products/{productID}", Handler: "LoanProductHandler.HandleDeactivateLoanProduct",
+		OperationID: "deactivateLoanProduct", Tag: "Products", Summary: "Deactivate a loan product",
+		Responses: []openapi.Reply{
+			openapi.JSON(DeactivateLoanProductResponse{}, http.StatusOK),
+			openapi.JSON(GetLoanProductByIdResponse{}, http.StatusBadRequest),
+		},
+	},
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/loans/import", Handler: "LoanImportHandler.HandleImportLoans",
+		Tag: "Loans", Summary: "Import historical loans from CSV",
+		Description: "Rows are checked like submissions; rejected rows are reported with their reason and skipped.",
+		Query: []openapi.Param{
+			{Name: "dry_run", Type: "boolean", Description: "Only check the rows."},
+			{Name: "mapping", Description: "JSON object of import field to CSV column."},
+			{Name: "report", Enum: []string{"csv"}, Description: "Answer with the rejected rows as CSV."},
+		},
+		Request:         csvUpload,
+		RequestTypes:    []string{"multipart/form-data"},
+		RawRequestTypes: []string{"text/csv"},
+		Responses: []openapi.Reply{
+			openapi.JSON(ImportLoansResponse{}, http.StatusOK, http.StatusBadRequest),
+			openapi.Raw("text/csv", http.StatusOK),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/submissions/{submissionID}/decision",
+		Handler: "CommissionHandler.HandleSubmissionDecision", OperationID: "decideSubmission", Tag: "Loans",
+		Summary: "Approve, reject or cancel a submission",
+		Description: "Approval accrues the dealer and agent commission; cancelling reverses it. The operator is " +
+			"recorded with the decision.",
+		Request: SubmissionDecisionRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(SubmissionDecisionResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/collections/run", Handler: "CollectionsHandler.HandleRunCollections",
+		Tag: "Collections", Summary: "Assess overdue installments and penalties now",
+		Query: []openapi.Param{withName(dateParam, "as_of")},
+		Responses: []openapi.Reply{
+			openapi.JSON(RunCollectionsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	}),
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/events/outbox", Handler: "EventHandler.HandleGetOutboxStats",
+		Tag: "Events", Summary: "Count outbox events by state",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetOutboxStatsResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/notifications", Handler: "NotificationHandler.HandleGetNotifications",
+		Tag: "Notifications", Summary: "List customer notifications",
+		Query: append(notificationQuery(),
+			openapi.Param{Name: "customer_id", Format: "uuid"},
+			openapi.Param{Name: "submission_id", Format: "uuid"},
+		),
+		Responses: []openapi.Reply{
+			openapi.JSON(GetNotificationsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	},
+	exportEndpoint(openapi.Endpoint{
+		Path: "/api/v1/admin/exports/submissions", Handler: "ExportHandler.HandleExportSubmissions",
+		Summary: "Export submissions",
+		Query: []openapi.Param{
+			{Name: "loan_status", Enum: loanStatuses},
+			{Name: "dealer_id", Format: "uuid"},
+			{Name: "product_id", Format: "uuid"},
+			withName(dateParam, "from"),
+			withName(dateParam, "to"),
+		},
+	}),
+	exportEndpoint(openapi.Endpoint{
+		Path: "/api/v1/admin/exports/customers", Handler: "ExportHandler.HandleExportCustomers",
+		Summary: "Export customers",
+		Query:   []openapi.Param{{Name: "address_city"}, {Name: "locale"}},
+	}),
+	reportEndpoint(openapi.Endpoint{
+		Path: "/api/v1/admin/reports/submissions", Handler: "ReportHandler.HandleGetSubmissionVolumes",
+		Summary: "Count submissions and proposed amounts per group",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetSubmissionVolumeReportResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	}),
+	reportEndpoint(openapi.Endpoint{
+		Path: "/api/v1/admin/reports/funnel", Handler: "ReportHandler.HandleGetSubmissionFunnel",
+		Summary: "Follow submissions through approval, disbursement and closing per group",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetSubmissionFunnelReportResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	}),
+	{
+		Method: http.MethodPost, Path: "/api/v1/graphql", Handler: "GraphQLHandler.HandleGraphQL",
+		OperationID: "queryGraphQL", Tag: "GraphQL", Summary: "Run a GraphQL query",
+		Description: "Queries customer, customers, submission and submissions, with nested submissions, " +
+			"parties, documents and status history. Queries nested too deep or estimated to resolve " +
+			"too many fields are refused with 400; errors of single fields come with 200 and the rest " +
+			"of the data. Customers' personal data is masked as in exports unless the PII token is sent.",
+		OptionalSecurity: []string{PIITokenSecurity},
+		Request:          GraphQLRequest{},
+		Responses: []openapi.Reply{
+			{
+				Statuses: []int{http.StatusOK, http.StatusBadRequest},
+				Body:     GraphQLResponse{},
+				Headers: []openapi.Param{
+					{Name: PIIMaskedHeader, Type: "boolean", Description: "Whether personal data was masked."},
+				},
+			},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers", Handler: "DealerHandler.HandleGetDealers",
+		Tag: "Dealers", Summary: "List dealers",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetAllDealersResponse{}, http.StatusOK, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/dealers", Handler: "DealerHandler.HandleCreateDealer",
+		OperationID: "createDealer", Tag: "Dealers", Summary: "Create a dealer",
+		Request: Dealer{},
+		Responses: []openapi.Reply{
+			openapi.JSON(UpsertDealerResponse{}, http.StatusCreated, http.StatusBadRequest),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers/summary", Handler: "DealerHandler.HandleGetDealerSummaries",
+		Tag: "Dealers", Summary: "Summarise volume and approval rate per dealer",
+		Query: []openapi.Param{{Name: "dealer_id", Format: "uuid"}, withName(dateParam, "from"), withName(dateParam, "to")},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDealerSummariesResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers/{dealerID}", Handler: "DealerHandler.HandleGetDealerById",
+		Tag: "Dealers", Summary: "Get a dealer",
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDealerByIdResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/dealers/{dealerID}", Handler: "DealerHandler.HandleUpdateDealer",
+		Tag: "Dealers", Summary: "Update a dealer",
+		Request: Dealer{},
+		Responses: []openapi.Reply{
+			openapi.JSON(UpsertDealerResponse{}, http.StatusOK, http.StatusBadRequest),
+			openapi.JSON(GetDealerByIdResponse{}, http.StatusBadRequest),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/admin/dealers/{dealerID}", Handler: "DealerHandler.HandleDeactivateDealer",
+		OperationID: "deactivateDealer", Tag: "Dealers", Summary: "Deactivate a dealer",
+		Responses: []openapi.Reply{
+			openapi.JSON(DeactivateDealerResponse{}, http.StatusOK),
+			openapi.JSON(GetDealerByIdResponse{}, http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers/{dealerID}/branches", Handler: "DealerHandler.HandleGetDealerBranches",
+		Tag: "Dealers", Summary: "List the branches of a dealer",
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerBranchesResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/dealers/{dealerID}/branches", Handler: "DealerHandler.HandleCreateDealerBranch",
+		OperationID: "createDealerBranch", Tag: "Dealers", Summary: "Add a branch to a dealer",
+		Request: DealerBranch{},
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerBranchResponse{}, http.StatusCreated, http.StatusBadRequest, http.StatusConflict),
+			openapi.JSON(DealerBranchesResponse{}, http.StatusNotFound, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers/{dealerID}/agents", Handler: "DealerHandler.HandleGetDealerAgents",
+		Tag: "Dealers", Summary: "List the agents of a dealer",
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerAgentsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/dealers/{dealerID}/agents", Handler: "DealerHandler.HandleCreateDealerAgent",
+		OperationID: "createDealerAgent", Tag: "Dealers", Summary: "Add an agent to a dealer",
+		Request: DealerAgent{},
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerAgentResponse{}, http.StatusCreated, http.StatusBadRequest, http.StatusInternalServerError),
+			openapi.JSON(DealerAgentsResponse{}, http.StatusNotFound),
+			plainText(http.StatusBadRequest),
+		},
+	},
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers/{dealerID}/credentials", Handler: "DealerHandler.HandleGetDealerCredentials",
+		Tag: "Dealers", Summary: "List the API keys of a dealer",
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerCredentialsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/dealers/{dealerID}/credentials", Handler: "DealerHandler.HandleIssueDealerCredential",
+		OperationID: "issueDealerCredential", Tag: "Dealers", Summary: "Issue an API key to a dealer",
+		Description: "The key itself is only returned here.",
+		Responses: []openapi.Reply{
+			openapi.JSON(DealerCredentialResponse{}, http.StatusCreated, http.StatusInternalServerError),
+			openapi.JSON(DealerCredentialsResponse{}, http.StatusBadRequest, http.StatusNotFound),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodDelete, Path: "/api/v1/admin/dealers/{dealerID}/credentials/{credentialID}",
+		Handler: "DealerHandler.HandleRevokeDealerCredential", Tag: "Dealers", Summary: "Revoke an API key",
+		Responses: []openapi.Reply{
+			openapi.Empty(http.StatusNoContent),
+			openapi.JSON(DealerCredentialResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}),
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/dealers/{dealerID}/commissions", Handler: "CommissionHandler.HandleGetCommissionStatement",
+		Tag: "Dealers", Summary: "Get the commission statement of a dealer for a month",
+		Query: commissionQuery(),
+		Responses: []openapi.Reply{
+			openapi.JSON(CommissionStatementResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+			openapi.Raw("text/csv", http.StatusOK),
+		},
+	},
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/admin/disbursements", Handler: "DisbursementHandler.HandleGetDisbursements",
+		Tag: "Disbursements", Summary: "List disbursements",
+		Query: []openapi.Param{{Name: "status", Enum: disbursementStatuses}, {Name: "submission_id", Format: "uuid"}},
+		Responses: []openapi.Reply{
+			openapi.JSON(GetDisbursementsResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/disbursements", Handler: "DisbursementHandler.HandleCreateDisbursement",
+		OperationID: "createDisbursement", Tag: "Disbursements", Summary: "Request the disbursement of an approved loan",
+		Request: CreateDisbursementRequest{},
+		Responses: []openapi.Reply{
+			openapi.JSON(DisbursementResponse{}, http.StatusCreated, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+			plainText(http.StatusBadRequest),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodGet, Path: "/api/v1/admin/disbursements/{disbursementID}", Handler: "DisbursementHandler.HandleGetDisbursementById",
+		Tag: "Disbursements", Summary: "Get a disbursement",
+		Responses: []openapi.Reply{
+			openapi.JSON(DisbursementResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusNotFound,
+				http.StatusInternalServerError),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/disbursements/{disbursementID}/approve",
+		Handler: "DisbursementHandler.HandleApproveDisbursement", Tag: "Disbursements",
+		Summary: "Approve a disbursement",
+		Description: "The approving operator must differ from the one who requested the disbursement. Approval writes " +
+			"the bank payment file; should that fail, the payment file is FAILED and is written again by retrying it.",
+		Request:   ReviewDisbursementRequest{},
+		Responses: disbursementReviewReplies(),
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/disbursements/{disbursementID}/payment-file/retry",
+		Handler: "DisbursementHandler.HandleRetryPaymentFile", Tag: "Disbursements",
+		Summary: "Write the payment file of an approved disbursement again",
+		Description: "Only a FAILED payment file is retried, by a different operator than the one who requested the " +
+			"disbursement. A DELIVERED payment file is never written again.",
+		Responses: []openapi.Reply{
+			openapi.JSON(DisbursementResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusForbidden,
+				http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError),
+		},
+	}),
+	operatorEndpoint(openapi.Endpoint{
+		Method: http.MethodPost, Path: "/api/v1/admin/disbursements/{disbursementID}/reject",
+		Handler: "DisbursementHandler.HandleRejectDisbursement", Tag: "Disbursements",
+		Summary: "Reject a disbursement", Request: ReviewDisbursementRequest{},
+		Responses: disbursementReviewReplies(),
+	}),
+	{
+		Method: http.MethodGet, Path: "/openapi.json", Handler: "OpenAPIHandler.HandleGetOpenAPI",
+		Tag: "Docs", Summary: "Get this document",
+		Responses: []openapi.Reply{
+			openapi.JSON(&openapi.Schema{Type: "object", Description: "An OpenAPI 3.0 document."}, http.StatusOK),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/docs/", Handler: "OpenAPIHandler.HandleSwaggerUI",
+		Tag: "Docs", Summary: "Browse this document with Swagger UI",
+		Responses: []openapi.Reply{
+			openapi.Raw("text/html", http.StatusOK),
+			plainText(http.StatusNotFound),
+		},
+	},
+}
+
+// OpenAPIDocument describes the API from apiEndpoints, with schemas derived
+// from the request and response models.
+func OpenAPIDocument() (*openapi.Document, error) {
+	endpoints := make([]openapi.Endpoint, 0, len(apiEndpoints)+len(LegacyRoutes))
+	for _, endpoint := range apiEndpoints {
+		endpoints = append(endpoints, validatedEndpoint(endpoint))
+	}
+	for _, route := range LegacyRoutes {
+		i := slices.IndexFunc(endpoints, func(endpoint openapi.Endpoint) bool {
+			return endpoint.Method+" "+endpoint.Path == route.Successor
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("%s: successor %s is not documented", route.Pattern, route.Successor)
+		}
+		endpoints = append(endpoints, legacyEndpoint(endpoints[i], route))
+	}
+	return openapi.Build(openapi.BuildOptions{
+		Info: openapi.Info{
+			Title:       "AlphaLoan Vehicle API",
+			Description: "Vehicle loan origination, servicing and the dealer portal.",
+			Version:     "1.0.0",
+		},
+		Servers:         []openapi.Server{{URL: "/"}},
+		Tags:            apiTags,
+		SecuritySchemes: apiSecuritySchemes,
+	}, endpoints)
+}
+
+type OpenAPIHandler struct {
+	Document *openapi.Document
+	docs     http.Handler
+}
+
+func NewOpenAPIHandler(document *openapi.Document) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		Document: document,
+		docs:     http.StripPrefix("/docs", http.FileServerFS(swaggerFiles.FS)),
+	}
+}
+
+func (h *OpenAPIHandler) HandleGetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.Document)
+}
+
+// HandleSwaggerUI serves Swagger UI under /docs/, loading /openapi.json.
+func (h *OpenAPIHandler) HandleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/docs/swagger-initializer.js" {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Write([]byte(swaggerInitializer))
+		return
+	}
+	h.docs.ServeHTTP(w, r)
+}
+
+// validatedEndpoint documents how ContractValidator refuses requests to an
+// endpoint that takes parameters or a body.
+func validatedEndpoint(endpoint openapi.Endpoint) openapi.Endpoint {
+	var replies []openapi.Reply
+	if strings.Contains(endpoint.Path, "{") || len(endpoint.Query) > 0 || len(endpoint.Headers) > 0 || endpoint.Request != nil {
+		replies = append(replies, openapi.JSON(RequestValidationResponse{}, http.StatusBadRequest))
+	}
+	if endpoint.Request != nil && (len(endpoint.RequestTypes) == 0 || slices.Contains(endpoint.RequestTypes, openapi.ContentTypeJSON)) {
+		replies = append(replies, openapi.JSON(RequestValidationResponse{}, http.StatusRequestEntityTooLarge))
+	}
+	if len(replies) > 0 && len(endpoint.Security) > 0 {
+		endpoint.Description = strings.TrimSpace(endpoint.Description +
+			" Requests without credentials are answered 401 before they are validated.")
+	}
+	endpoint.Responses = slices.Concat(endpoint.Responses, replies)
+	return endpoint
+}
+
+// legacyEndpoint documents a route registered by Router.Alias as a deprecated
+// copy of its successor.
+func legacyEndpoint(successor openapi.Endpoint, route LegacyRoute) openapi.Endpoint {
+	endpoint := successor
+	endpoint.Method, endpoint.Path, _ = strings.Cut(route.Pattern, " ")
+	id := successor.ID()
+	endpoint.OperationID = "legacy" + strings.ToUpper(id[:1]) + id[1:]
+	endpoint.Description = strings.TrimSpace("Deprecated, use " + route.Successor + " instead. " + successor.Description)
+	endpoint.Deprecated = true
+
+	endpoint.PathParams = slices.DeleteFunc(slices.Clone(successor.PathParams), func(param openapi.Param) bool {
+		return !strings.Contains(endpoint.Path, "{"+param.Name+"}")
+	})
+	var query []openapi.Param
+	for _, name := range slices.Sorted(maps.Keys(route.QueryParams)) {
+		param := openapi.Param{Name: route.QueryParams[name], Required: true}
+		if strings.HasSuffix(name, "ID") {
+			param.Format = "uuid"
+		}
+		query = append(query, param)
+	}
+	endpoint.Query = slices.Concat(query, successor.Query)
+
+	headers := []openapi.Param{
+		{Name: "Deprecation", Description: "When the route was deprecated, as @ and Unix seconds."},
+		{Name: "Sunset", Description: "When the route stops answering, as an HTTP date."},
+		{Name: "Link", Description: "The successor-version of the route."},
+	}
+	endpoint.Responses = make([]openapi.Reply, 0, len(successor.Responses)+1)
+	for _, reply := range successor.Responses {
+		reply.Headers = slices.Concat(reply.Headers, headers)
+		endpoint.Responses = append(endpoint.Responses, reply)
+	}
+	endpoint.Responses = append(endpoint.Responses, openapi.Reply{
+		Statuses: []int{http.StatusGone},
+		Body:     ErrorResponse{},
+		Headers:  headers,
+	})
+	return endpoint
+}
+
+func plainText(statuses ...int) openapi.Reply {
+	return openapi.Raw(contentTypeText, statuses...)
+}
+
+func withName(param openapi.Param, name string) openapi.Param {
+	param.Name = name
+	return param
+}
+
+// dealerEndpoint puts an endpoint behind RequireDealerKey.
+func dealerEndpoint(endpoint openapi.Endpoint) openapi.Endpoint {
+	endpoint.Tag = "Dealer portal"
+	endpoint.Security = []string{DealerKeySecurity}
+	endpoint.Responses = append(endpoint.Responses,
+		openapi.Reply{
+			Statuses: []int{http.StatusUnauthorized},
+			Body:     ErrorResponse{},
+			Headers:  []openapi.Param{{Name: "WWW-Authenticate"}},
+		},
+		plainText(http.StatusInternalServerError),
+	)
+	return endpoint
+}
+
+// operatorEndpoint puts an endpoint behind RequireOperatorKey.
+func operatorEndpoint(endpoint openapi.Endpoint) openapi.Endpoint {
+	endpoint.Security = []string{OperatorKeySecurity}
+	endpoint.Responses = append(endpoint.Responses, openapi.Reply{
+		Statuses: []int{http.StatusUnauthorized},
+		Body:     ErrorResponse{},
+		Headers:  []openapi.Param{{Name: "WWW-Authenticate"}},
+	})
+	return endpoint
+}
+
+func exportEndpoint(endpoint openapi.Endpoint) openapi.Endpoint {
+	endpoint.Method = http.MethodGet
+	endpoint.Tag = "Exports"
+	endpoint.Description = "Streams every matching row. Personal data is masked unless the PII token is sent."
+	endpoint.OptionalSecurity = []string{PIITokenSecurity}
+	endpoint.Query = append([]openapi.Param{
+		{Name: "format", Enum: export.Formats, Description: "Defaults to the Accept header, then csv."},
+		{Name: "columns", Description: "Comma separated columns in the order wanted, all by default."},
+	}, endpoint.Query...)
+	endpoint.Responses = []openapi.Reply{
+		{
+			Statuses:     []int{http.StatusOK},
+			ContentTypes: exportTypes,
+			Headers: []openapi.Param{
+				{Name: "Content-Disposition"},
+				{Name: PIIMaskedHeader, Type: "boolean", Description: "Whether personal data was masked."},
+			},
+		},
+		openapi.JSON(ErrorResponse{}, http.StatusBadRequest),
+	}
+	return endpoint
+}
+
+func reportEndpoint(endpoint openapi.Endpoint) openapi.Endpoint {
+	endpoint.Method = http.MethodGet
+	endpoint.Tag = "Reports"
+	endpoint.Description = "Reports are cached; Cache-Control: no-cache recomputes one."
+	endpoint.Query = []openapi.Param{
+		{Name: "group_by", Description: "Comma separated, at most one period, out of " + strings.Join(datastore.ReportDimensions, ", ") + "."},
+		withName(dateParam, "from"),
+		withName(dateParam, "to"),
+		{Name: "dealer_id", Format: "uuid"},
+		{Name: "product_id", Format: "uuid"},
+		{Name: "format", Enum: append([]string{formatJSON}, export.Formats...)},
+	}
+	endpoint.Headers = []openapi.Param{{Name: "Cache-Control"}, {Name: "If-None-Match"}}
+	endpoint.Responses = append(endpoint.Responses,
+		openapi.Reply{
+			Statuses:     []int{http.StatusOK},
+			ContentTypes: exportTypes,
+			Headers:      []openapi.Param{{Name: "ETag"}, {Name: "Last-Modified"}, {Name: "Cache-Control"}},
+		},
+		openapi.Empty(http.StatusNotModified),
+	)
+	return endpoint
+}
+
+func documentUploadReplies() []openapi.Reply {
+	return []openapi.Reply{
+		openapi.JSON(UploadDocumentResponse{}, http.StatusCreated, http.StatusBadRequest, http.StatusRequestEntityTooLarge,
+			http.StatusUnsupportedMediaType, http.StatusInternalServerError),
+		openapi.JSON(GetDocumentsResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	}
+}
+
+func catalogueUpsertReplies(status int) []openapi.Reply {
+	return []openapi.Reply{
+		openapi.JSON(UpsertVehicleCatalogueResponse{}, status, http.StatusBadRequest),
+		plainText(http.StatusBadRequest),
+	}
+}
+
+func catalogueDeleteReplies() []openapi.Reply {
+	return []openapi.Reply{
+		openapi.JSON(DeleteVehicleCatalogueResponse{}, http.StatusOK),
+		openapi.JSON(UpsertVehicleCatalogueResponse{}, http.StatusBadRequest),
+	}
+}
+
+func disbursementReviewReplies() []openapi.Reply {
+	return []openapi.Reply{
+		openapi.JSON(DisbursementResponse{}, http.StatusOK, http.StatusBadRequest, http.StatusForbidden,
+			http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError),
+	}
+}
+
+func notificationQuery() []openapi.Param {
+	return []openapi.Param{
+		{Name: "status", Enum: []string{datastore.NotificationPending, datastore.NotificationSent, datastore.NotificationFailed}},
+		{Name: "channel", Enum: []string{datastore.NotificationChannelEmail, datastore.NotificationChannelSMS}},
+		{Name: "limit", Type: "integer", Description: "At most 1000, 100 by default."},
+	}
+}
+
+func commissionQuery() []openapi.Param {
+	return []openapi.Param{
+		{Name: "month", Description: "YYYY-MM, the current statement month by default."},
+		{Name: "format", Enum: []string{"csv"}, Description: "Answer with CSV, as does Accept: text/csv."},
+	}
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/collections"
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/repayment"
+	"github.com/google/uuid"
+)
+
+const DefaultPayoffQuoteValidDays = 3
+
+type PayoffHandler struct {
+	SubmissionStore datastore.LoanSubmissionStore
+	ProductStore    datastore.LoanProductStore
+	RepaymentStore  datastore.LoanRepaymentStore
+	PayoffStore     datastore.LoanPayoffStore
+	// QuoteValidDays is how many days after its as_of date a payoff quote can
+	// still be settled.
+	QuoteValidDays int
+}
+
+func NewPayoffHandler(
+	submissionStore datastore.LoanSubmissionStore,
+	productStore datastore.LoanProductStore,
+	repaymentStore datastore.LoanRepaymentStore,
+	payoffStore datastore.LoanPayoffStore) *PayoffHandler {
+	return &PayoffHandler{
+		SubmissionStore: submissionStore,
+		ProductStore:    productStore,
+		RepaymentStore:  repaymentStore,
+		PayoffStore:     payoffStore,
+		QuoteValidDays:  DefaultPayoffQuoteValidDays,
+	}
+}
+
+func (h *PayoffHandler) HandleGetPayoffQuote(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetPayoffQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	today := collections.Today()
+	var asOfParam *string
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		asOfParam = &value
+	}
+	asOf, err := parseDate(asOfParam, today)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetPayoffQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if asOf.Before(today) {
+		errMsg := "as_of must not be in the past"
+		writeJSON(w, http.StatusBadRequest, GetPayoffQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	status, installments, rule, err := h.loadLoan(submissionID)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, GetPayoffQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	payoff := repayment.PayoffAsOf(installments, asOf, rule)
+	quoteRow := &datastore.LoanPayoffQuoteRow{
+		QuoteID:         uuid.New().String(),
+		SubmissionID:    submissionID,
+		AsOf:            asOf.Format(time.DateOnly),
+		ExpiresOn:       asOf.AddDate(0, 0, h.QuoteValidDays).Format(time.DateOnly),
+		Principal:       payoff.Principal,
+		AccruedInterest: payoff.AccruedInterest,
+		Fee:             payoff.Fee,
+		Penalty:         payoff.Penalty,
+		TerminationFee:  payoff.TerminationFee,
+		Waived:          payoff.Waived,
+		Total:           payoff.Total,
+		CreatedAt:       time.Now().Unix(),
+	}
+	if err := h.PayoffStore.InsertPayoffQuote(quoteRow); err != nil {
+		errMsg := "Failed to store payoff quote"
+		writeJSON(w, http.StatusInternalServerError, GetPayoffQuoteResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	quote := convertLoanPayoffQuoteRow(quoteRow, today.Format(time.DateOnly))
+	writeJSON(w, http.StatusOK, GetPayoffQuoteResponse{Data: &quote})
+}
+
+func (h *PayoffHandler) HandleSettleLoan(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, SettleLoanResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	var request SettleLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	today := collections.Today()
+	paidOn, err := parseDate(request.PaidOn, today)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, SettleLoanResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	quoteRow, err := h.PayoffStore.GetPayoffQuoteById(request.QuoteID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && quoteRow.SubmissionID != submissionID) {
+		errMsg := "Payoff quote not found: " + request.QuoteID
+		writeJSON(w, http.StatusNotFound, SettleLoanResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get payoff quote " + request.QuoteID
+		writeJSON(w, http.StatusInternalServerError, SettleLoanResponse{ErrorMessage: &errMsg})
+		return
+	}
+	quote := convertLoanPayoffQuoteRow(quoteRow, today.Format(time.DateOnly))
+	if quoteRow.SettledAt.Valid {
+		errMsg := "Payoff quote already settled"
+		writeJSON(w, http.StatusConflict, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+	if paidOn.Format(time.DateOnly) > quoteRow.ExpiresOn {
+		errMsg := fmt.Sprintf("Payoff quote expired on %s, request a new one", quoteRow.ExpiresOn)
+		writeJSON(w, http.StatusConflict, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+	if request.Amount < quoteRow.Total {
+		errMsg := fmt.Sprintf("Settlement requires %d, received %d", quoteRow.Total, request.Amount)
+		writeJSON(w, http.StatusUnprocessableEntity, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+
+	status, installments, rule, err := h.loadLoan(submissionID)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, SettleLoanResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	asOf, err := time.Parse(time.DateOnly, quoteRow.AsOf)
+	if err != nil {
+		errMsg := "Payoff quote has an invalid as_of date"
+		writeJSON(w, http.StatusInternalServerError, SettleLoanResponse{ErrorMessage: &errMsg})
+		return
+	}
+	priced := slices.Clone(installments)
+	payoff, allocations := repayment.Settle(installments, asOf, rule)
+	if payoff.Total != quoteRow.Total {
+		errMsg := "The loan balance changed since the quote was issued, request a new payoff quote"
+		writeJSON(w, http.StatusConflict, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+
+	paymentRow := &datastore.LoanPaymentRow{
+		PaymentID:       uuid.New().String(),
+		SubmissionID:    submissionID,
+		Amount:          request.Amount,
+		AppliedAmount:   payoff.Total,
+		UnappliedAmount: request.Amount - payoff.Total,
+		PaidOn:          paidOn.Format(time.DateOnly),
+		PayoffQuoteID:   sql.NullString{String: quoteRow.QuoteID, Valid: true},
+		CreatedAt:       time.Now().Unix(),
+	}
+	if request.Reference != nil && strings.TrimSpace(*request.Reference) != "" {
+		paymentRow.Reference = sql.NullString{String: strings.TrimSpace(*request.Reference), Valid: true}
+	}
+	for _, allocation := range allocations {
+		paymentRow.Allocations = append(paymentRow.Allocations, &datastore.LoanPaymentAllocationRow{
+			PaymentID:         paymentRow.PaymentID,
+			InstallmentNumber: allocation.InstallmentNumber,
+			FeeAmount:         allocation.Fee,
+			InterestAmount:    allocation.Interest,
+			PrincipalAmount:   allocation.Principal,
+			PenaltyAmount:     allocation.Penalty,
+		})
+	}
+
+	installmentRows := make([]*datastore.LoanInstallmentRow, 0, len(installments))
+	for i, installment := range installments {
+		installmentRows = append(installmentRows, &datastore.LoanInstallmentRow{
+			SubmissionID:      submissionID,
+			InstallmentNumber: installment.Number,
+			InterestDue:       installment.InterestDue,
+			FeeDue:            installment.FeeDue,
+			PenaltyDue:        priced[i].PenaltyDue,
+			PrincipalPaid:     priced[i].PrincipalPaid,
+			InterestPaid:      priced[i].InterestPaid,
+			FeePaid:           priced[i].FeePaid,
+			PenaltyPaid:       priced[i].PenaltyPaid,
+		})
+	}
+
+	err = h.PayoffStore.SettleLoan(paymentRow, installmentRows)
+	if errors.Is(err, datastore.ErrPayoffQuoteSettled) {
+		errMsg := "Payoff quote already settled"
+		writeJSON(w, http.StatusConflict, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+	if errors.Is(err, datastore.ErrPayoffBalanceChanged) || errors.Is(err, datastore.ErrPaymentConflict) {
+		errMsg := "The loan balance changed since the quote was issued, request a new payoff quote"
+		writeJSON(w, http.StatusConflict, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+	if errors.Is(err, datastore.ErrLoanNotRepayable) {
+		errMsg := fmt.Sprintf("Submission %s is no longer APPROVED or DISBURSED", submissionID)
+		writeJSON(w, http.StatusConflict, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+	if err != nil {
+		log.Printf("settling submission %s against payoff quote %s failed: %v\n", submissionID, quoteRow.QuoteID, err)
+		errMsg := "Failed to settle loan"
+		writeJSON(w, http.StatusInternalServerError, SettleLoanResponse{ErrorMessage: &errMsg, Quote: &quote})
+		return
+	}
+
+	quote.Status = "SETTLED"
+	quote.SettledAt = &paymentRow.CreatedAt
+	payment := convertLoanPaymentRow(paymentRow)
+	loanStatus := "CLOSED"
+	writeJSON(w, http.StatusOK, SettleLoanResponse{
+		Quote:      &quote,
+		Payment:    &payment,
+		LoanStatus: &loanStatus,
+	})
+}
+
+// loadLoan fetches the schedule and early-termination rule of a repayable loan,
+// returning the HTTP status to answer with when it cannot be settled.
+func (h *PayoffHandler) loadLoan(submissionID string) (int, []repayment.Installment, repayment.TerminationRule, error) {
+	rule := repayment.TerminationRule{}
+
+	submission, err := h.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusNotFound, nil, rule, fmt.Errorf("Submission not found: %s", submissionID)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, nil, rule, fmt.Errorf("Failed to get submission %s", submissionID)
+	}
+	if !datastore.IsRepayableLoanStatus(submission.LoanStatus) {
+		return http.StatusConflict, nil, rule, fmt.Errorf("Submission %s is %s, only APPROVED or DISBURSED loans are repaid", submissionID, submission.LoanStatus)
+	}
+
+	rows, err := h.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil {
+		return http.StatusInternalServerError, nil, rule, fmt.Errorf("Failed to get repayment schedule for submission %s", submissionID)
+	}
+	if len(rows) == 0 {
+		return http.StatusNotFound, nil, rule, fmt.Errorf("No repayment schedule for submission %s", submissionID)
+	}
+	installments, err := collections.InstallmentsFromRows(rows)
+	if err != nil {
+		return http.StatusInternalServerError, nil, rule, err
+	}
+
+	if submission.ProductID.Valid {
+		product, err := h.ProductStore.GetLoanProductById(submission.ProductID.String)
+		if err != nil {
+			return http.StatusInternalServerError, nil, rule, fmt.Errorf("Failed to get loan product %s", submission.ProductID.String)
+		}
+		rule = repayment.TerminationRule{
+			FeeRate: product.TerminationFeeRate,
+			MinFee:  product.TerminationMinFee,
+		}
+	}
+	return http.StatusOK, installments, rule, nil
+}
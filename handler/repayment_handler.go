@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/collections"
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/repayment"
+	"github.com/google/uuid"
+)
+
+type RepaymentHandler struct {
+	SubmissionStore datastore.LoanSubmissionStore
+	QuoteStore      datastore.LoanQuoteStore
+	RepaymentStore  datastore.LoanRepaymentStore
+	Waterfall       repayment.Waterfall
+}
+
+func NewRepaymentHandler(
+	submissionStore datastore.LoanSubmissionStore,
+	quoteStore datastore.LoanQuoteStore,
+	repaymentStore datastore.LoanRepaymentStore) *RepaymentHandler {
+	return &RepaymentHandler{
+		SubmissionStore: submissionStore,
+		QuoteStore:      quoteStore,
+		RepaymentStore:  repaymentStore,
+		Waterfall:       repayment.DefaultWaterfall,
+	}
+}
+
+func (h *RepaymentHandler) HandleGetRepaymentSchedule(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil {
+		errMsg := "Failed to get repayment schedule for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if len(rows) == 0 {
+		errMsg := "No repayment schedule for submission " + submissionID
+		writeJSON(w, http.StatusNotFound, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	installments := convertLoanInstallmentRows(rows)
+	writeJSON(w, http.StatusOK, GetRepaymentScheduleResponse{SubmissionID: &submissionID, Data: &installments})
+}
+
+func (h *RepaymentHandler) HandleGenerateRepaymentSchedule(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	h.generateSchedule(w, r, submissionID)
+}
+
+func (h *RepaymentHandler) generateSchedule(w http.ResponseWriter, r *http.Request, submissionID string) {
+	var request GenerateScheduleRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	firstDueDate, err := parseDate(request.FirstDueDate, repayment.AddMonths(collections.Today(), 1))
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	submission, err := h.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if !datastore.IsRepayableLoanStatus(submission.LoanStatus) {
+		errMsg := fmt.Sprintf("Submission %s is %s, only APPROVED or DISBURSED loans are repaid", submissionID, submission.LoanStatus)
+		writeJSON(w, http.StatusConflict, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	existing, err := h.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil {
+		errMsg := "Failed to get repayment schedule for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if len(existing) > 0 {
+		errMsg := "Repayment schedule already exists for submission " + submissionID
+		writeJSON(w, http.StatusConflict, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	quote, err := h.QuoteStore.GetQuoteBySubmissionId(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "No approved terms for submission " + submissionID
+		writeJSON(w, http.StatusUnprocessableEntity, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get quote for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows := collections.ScheduleRows(submissionID, quote, firstDueDate)
+	err = h.RepaymentStore.InsertInstallments(rows)
+	if errors.Is(err, datastore.ErrScheduleExists) {
+		errMsg := "Repayment schedule already exists for submission " + submissionID
+		writeJSON(w, http.StatusConflict, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to store repayment schedule: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, GetRepaymentScheduleResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	installments := convertLoanInstallmentRows(rows)
+	writeJSON(w, http.StatusCreated, GetRepaymentScheduleResponse{SubmissionID: &submissionID, Data: &installments})
+}
+
+func (h *RepaymentHandler) HandleGetLoanPayments(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetLoanPaymentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.RepaymentStore.GetPaymentsBySubmissionId(submissionID)
+	if err != nil {
+		errMsg := "Failed to get payments for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetLoanPaymentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	payments := make([]LoanPayment, 0, len(rows))
+	for _, row := range rows {
+		payments = append(payments, convertLoanPaymentRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetLoanPaymentsResponse{SubmissionID: &submissionID, Data: &payments})
+}
+
+func (h *RepaymentHandler) HandlePostLoanPayment(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetLoanPaymentsResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	h.postPayment(w, r, submissionID)
+}
+
+func (h *RepaymentHandler) postPayment(w http.ResponseWriter, r *http.Request, submissionID string) {
+	var request PostPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	if request.Amount <= 0 {
+		errMsg := "amount must be positive"
+		writeJSON(w, http.StatusBadRequest, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	paidOn, err := parseDate(request.PaidOn, collections.Today())
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	// A payment reported twice with the same reference is answered with the
+	// original posting instead of being applied again.
+	var reference sql.NullString
+	if request.Reference != nil && strings.TrimSpace(*request.Reference) != "" {
+		reference = sql.NullString{String: strings.TrimSpace(*request.Reference), Valid: true}
+		existing, err := h.RepaymentStore.GetPaymentByReference(submissionID, reference.String)
+		if err == nil {
+			payment := convertLoanPaymentRow(existing)
+			writeJSON(w, http.StatusOK, PostPaymentResponse{Data: &payment})
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			errMsg := "Failed to check payment reference"
+			writeJSON(w, http.StatusInternalServerError, PostPaymentResponse{ErrorMessage: &errMsg})
+			return
+		}
+	}
+
+	submission, err := h.SubmissionStore.GetLoanSubmissionById(submissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Submission not found: " + submissionID
+		writeJSON(w, http.StatusNotFound, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if !datastore.IsRepayableLoanStatus(submission.LoanStatus) {
+		errMsg := fmt.Sprintf("Submission %s is %s, only APPROVED or DISBURSED loans are repaid", submissionID, submission.LoanStatus)
+		writeJSON(w, http.StatusConflict, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	rows, err := h.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil {
+		errMsg := "Failed to get repayment schedule for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if len(rows) == 0 {
+		errMsg := "No repayment schedule for submission " + submissionID
+		writeJSON(w, http.StatusNotFound, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	installments, err := collections.InstallmentsFromRows(rows)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusInternalServerError, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	result := h.Waterfall.Allocate(installments, request.Amount)
+
+	paymentRow := &datastore.LoanPaymentRow{
+		PaymentID:       uuid.New().String(),
+		SubmissionID:    submissionID,
+		Amount:          request.Amount,
+		AppliedAmount:   result.Applied,
+		UnappliedAmount: result.Unapplied,
+		PaidOn:          paidOn.Format(time.DateOnly),
+		Reference:       reference,
+		CreatedAt:       time.Now().Unix(),
+	}
+	for _, allocation := range result.Allocations {
+		paymentRow.Allocations = append(paymentRow.Allocations, &datastore.LoanPaymentAllocationRow{
+			PaymentID:         paymentRow.PaymentID,
+			InstallmentNumber: allocation.InstallmentNumber,
+			FeeAmount:         allocation.Fee,
+			InterestAmount:    allocation.Interest,
+			PrincipalAmount:   allocation.Principal,
+			PenaltyAmount:     allocation.Penalty,
+		})
+	}
+	err = h.RepaymentStore.InsertPayment(paymentRow)
+	if errors.Is(err, datastore.ErrPaymentConflict) {
+		errMsg := "Payment conflicts with a concurrent posting, retry it: " + err.Error()
+		writeJSON(w, http.StatusConflict, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to record payment"
+		writeJSON(w, http.StatusInternalServerError, PostPaymentResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	payment := convertLoanPaymentRow(paymentRow)
+	response := PostPaymentResponse{Data: &payment}
+	if balance, err := h.loanBalance(submissionID, paidOn); err == nil {
+		response.Balance = balance
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func (h *RepaymentHandler) HandleGetLoanBalance(w http.ResponseWriter, r *http.Request) {
+	submissionID := r.PathValue("submissionID")
+	if !IsValidUUID(submissionID) {
+		errMsg := "Invalid submission ID: " + submissionID
+		writeJSON(w, http.StatusBadRequest, GetLoanBalanceResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	var asOfParam *string
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		asOfParam = &value
+	}
+	asOf, err := parseDate(asOfParam, collections.Today())
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetLoanBalanceResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	balance, err := h.loanBalance(submissionID, asOf)
+	if err != nil {
+		errMsg := "Failed to get balance for submission " + submissionID
+		writeJSON(w, http.StatusInternalServerError, GetLoanBalanceResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if balance == nil {
+		errMsg := "No repayment schedule for submission " + submissionID
+		writeJSON(w, http.StatusNotFound, GetLoanBalanceResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, http.StatusOK, GetLoanBalanceResponse{Data: balance})
+}
+
+// loanBalance returns nil when the submission has no repayment schedule.
+func (h *RepaymentHandler) loanBalance(submissionID string, asOf time.Time) (*LoanBalance, error) {
+	rows, err := h.RepaymentStore.GetInstallmentsBySubmissionId(submissionID)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	installments, err := collections.InstallmentsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := h.RepaymentStore.GetPaymentsBySubmissionId(submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := repayment.BalanceAsOf(installments, asOf)
+	balance := &LoanBalance{
+		SubmissionID:         submissionID,
+		AsOf:                 asOf.Format(time.DateOnly),
+		OutstandingPrincipal: summary.OutstandingPrincipal,
+		OutstandingInterest:  summary.OutstandingInterest,
+		OutstandingFee:       summary.OutstandingFee,
+		OutstandingPenalty:   summary.OutstandingPenalty,
+		TotalOutstanding:     summary.TotalOutstanding,
+		OverdueAmount:        summary.OverdueAmount,
+		OverdueInstallments:  summary.OverdueInstallments,
+		PaidInstallments:     summary.PaidInstallments,
+		TotalInstallments:    len(installments),
+		NextDueAmount:        summary.NextDueAmount,
+	}
+	if summary.NextDueDate != nil {
+		nextDueDate := summary.NextDueDate.Format(time.DateOnly)
+		balance.NextDueDate = &nextDueDate
+	}
+	for _, payment := range payments {
+		balance.Credit += payment.UnappliedAmount
+	}
+	return balance, nil
+}
+
+func convertLoanInstallmentRows(rows []*datastore.LoanInstallmentRow) []LoanInstallment {
+	installments := make([]LoanInstallment, 0, len(rows))
+	for _, row := range rows {
+		outstanding := row.PrincipalDue + row.InterestDue + row.FeeDue + row.PenaltyDue -
+			row.PrincipalPaid - row.InterestPaid - row.FeePaid - row.PenaltyPaid
+		installments = append(installments, LoanInstallment{
+			InstallmentNumber: row.InstallmentNumber,
+			DueDate:           row.DueDate,
+			PrincipalDue:      row.PrincipalDue,
+			InterestDue:       row.InterestDue,
+			FeeDue:            row.FeeDue,
+			PenaltyDue:        row.PenaltyDue,
+			PrincipalPaid:     row.PrincipalPaid,
+			InterestPaid:      row.InterestPaid,
+			FeePaid:           row.FeePaid,
+			PenaltyPaid:       row.PenaltyPaid,
+			TotalOutstanding:  outstanding,
+			IsPaid:            outstanding == 0,
+			OverdueSince:      nullStringPtr(row.OverdueSince),
+		})
+	}
+	return installments
+}
+
+func parseDate(value *string, fallback time.Time) (time.Time, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse(time.DateOnly, strings.TrimSpace(*value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", *value)
+	}
+	return date, nil
+}
@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/export"
+)
+
+const DefaultReportCacheTTL = 5 * time.Minute
+
+// formatJSON is the default report format besides the export formats.
+const formatJSON = "json"
+
+var reportPeriods = []string{datastore.ReportByDay, datastore.ReportByWeek, datastore.ReportByMonth}
+
+type reportCacheEntry struct {
+	rows        []*datastore.SubmissionReportRow
+	generatedAt time.Time
+	expiresAt   time.Time
+}
+
+// ReportHandler serves portfolio reports. Both reports are computed from the
+// same aggregate, which is cached in memory per filter for CacheTTL so that
+// dashboards polling them do not rescan every submission.
+type ReportHandler struct {
+	ReportStore datastore.ReportStore
+	// CacheTTL is how long a computed report is served; 0 disables caching.
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]reportCacheEntry
+}
+
+func NewReportHandler(reportStore datastore.ReportStore) *ReportHandler {
+	return &ReportHandler{
+		ReportStore: reportStore,
+		CacheTTL:    DefaultReportCacheTTL,
+		cache:       make(map[string]reportCacheEntry),
+	}
+}
+
+// HandleGetSubmissionVolumes counts submissions and sums their proposed loan
+// amount per group_by combination.
+func (h *ReportHandler) HandleGetSubmissionVolumes(w http.ResponseWriter, r *http.Request) {
+	query, filter, format, err := parseReportRequest(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetSubmissionVolumeReportResponse{ErrorMessage: &errMsg})
+		return
+	}
+	entry, err := h.loadReport(r, query)
+	if err != nil {
+		log.Printf("volume report failed: %v\n", err)
+		errMsg := "Failed to get submission volume report"
+		writeJSON(w, http.StatusInternalServerError, GetSubmissionVolumeReportResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	report := SubmissionVolumeReport{
+		ReportFilter: filter,
+		GeneratedAt:  entry.generatedAt.Unix(),
+		Rows:         make([]SubmissionVolume, 0, len(entry.rows)),
+		Total:        SubmissionVolume{Group: map[string]string{}},
+	}
+	table := make([][]any, 0, len(entry.rows))
+	for _, row := range entry.rows {
+		volume := SubmissionVolume{
+			Group:                   reportGroup(query.GroupBy, row),
+			SubmissionCount:         row.SubmissionCount,
+			TotalProposedLoanAmount: row.TotalProposedLoanAmount,
+		}
+		report.Rows = append(report.Rows, volume)
+		report.Total.SubmissionCount += volume.SubmissionCount
+		report.Total.TotalProposedLoanAmount += volume.TotalProposedLoanAmount
+		table = append(table, append(reportGroupValues(row), volume.SubmissionCount, volume.TotalProposedLoanAmount))
+	}
+
+	if !h.writeCacheHeaders(w, r, "submissions", query, format, entry) {
+		return
+	}
+	if format == formatJSON {
+		writeJSON(w, http.StatusOK, GetSubmissionVolumeReportResponse{Data: &report})
+		return
+	}
+	columns := append(slices.Clone(query.GroupBy), "submission_count", "total_proposed_loan_amount")
+	writeReportTable(w, format, "submission-volumes", columns, table)
+}
+
+// HandleGetSubmissionFunnel counts how far submissions got from submitted
+// through approved to disbursed and closed per group_by combination.
+func (h *ReportHandler) HandleGetSubmissionFunnel(w http.ResponseWriter, r *http.Request) {
+	query, filter, format, err := parseReportRequest(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, GetSubmissionFunnelReportResponse{ErrorMessage: &errMsg})
+		return
+	}
+	entry, err := h.loadReport(r, query)
+	if err != nil {
+		log.Printf("funnel report failed: %v\n", err)
+		errMsg := "Failed to get submission funnel report"
+		writeJSON(w, http.StatusInternalServerError, GetSubmissionFunnelReportResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	report := SubmissionFunnelReport{
+		ReportFilter: filter,
+		GeneratedAt:  entry.generatedAt.Unix(),
+		Rows:         make([]SubmissionFunnel, 0, len(entry.rows)),
+		Total:        SubmissionFunnel{Group: map[string]string{}},
+	}
+	table := make([][]any, 0, len(entry.rows))
+	for _, row := range entry.rows {
+		funnel := SubmissionFunnel{
+			Group:              reportGroup(query.GroupBy, row),
+			Submitted:          row.SubmissionCount,
+			Pending:            row.PendingCount,
+			Approved:           row.ApprovedCount,
+			Rejected:           row.RejectedCount,
+			Cancelled:          row.CancelledCount,
+			Disbursed:          row.DisbursedCount,
+			Closed:             row.ClosedCount,
+			ApprovedLoanAmount: row.ApprovedLoanAmount,
+		}
+		setFunnelRates(&funnel)
+		report.Rows = append(report.Rows, funnel)
+		addFunnel(&report.Total, funnel)
+		table = append(table, append(reportGroupValues(row), funnel.Submitted, funnel.Pending, funnel.Approved,
+			funnel.Rejected, funnel.Cancelled, funnel.Disbursed, funnel.Closed, funnel.ApprovedLoanAmount,
+			reportRate(funnel.ApprovalRate), reportRate(funnel.ConversionRate)))
+	}
+	setFunnelRates(&report.Total)
+
+	if !h.writeCacheHeaders(w, r, "funnel", query, format, entry) {
+		return
+	}
+	if format == formatJSON {
+		writeJSON(w, http.StatusOK, GetSubmissionFunnelReportResponse{Data: &report})
+		return
+	}
+	columns := append(slices.Clone(query.GroupBy), "submitted", "pending", "approved", "rejected", "cancelled",
+		"disbursed", "closed", "approved_loan_amount", "approval_rate", "conversion_rate")
+	writeReportTable(w, format, "submission-funnel", columns, table)
+}
+
+// loadReport returns the cached aggregate for query unless it expired or the
+// caller sent Cache-Control: no-cache, in which case it is recomputed.
+func (h *ReportHandler) loadReport(r *http.Request, query datastore.SubmissionReportQuery) (reportCacheEntry, error) {
+	key := reportCacheKey(query)
+	now := time.Now()
+	refresh := strings.Contains(r.Header.Get("Cache-Control"), "no-cache")
+
+	h.mu.Lock()
+	entry, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok && !refresh && now.Before(entry.expiresAt) {
+		return entry, nil
+	}
+
+	rows, err := h.ReportStore.GetSubmissionReport(query)
+	if err != nil {
+		return reportCacheEntry{}, err
+	}
+	entry = reportCacheEntry{rows: rows, generatedAt: now, expiresAt: now.Add(h.CacheTTL)}
+	if h.CacheTTL <= 0 {
+		return entry, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for cached, old := range h.cache {
+		if !now.Before(old.expiresAt) {
+			delete(h.cache, cached)
+		}
+	}
+	h.cache[key] = entry
+	return entry, nil
+}
+
+// writeCacheHeaders sets the validators of the report and answers a matching
+// If-None-Match with 304, returning false when no body should follow.
+func (h *ReportHandler) writeCacheHeaders(w http.ResponseWriter, r *http.Request, name string,
+	query datastore.SubmissionReportQuery, format string, entry reportCacheEntry) bool {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%d", name, reportCacheKey(query), format, entry.generatedAt.UnixNano())))
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	maxAge := int(math.Ceil(time.Until(entry.expiresAt).Seconds()))
+	if h.CacheTTL <= 0 || maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", entry.generatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+
+	if match := r.Header.Get("If-None-Match"); match != "" && (match == "*" || strings.Contains(match, etag)) {
+		w.WriteHeader(http.StatusNotModified)
+		return false
+	}
+	return true
+}
+
+// parseReportRequest reads group_by, a comma separated list of at most one
+// period and any other dimensions, the inclusive from/to creation dates,
+// dealer_id, product_id and the format: json by default, or csv, xlsx or
+// ndjson by parameter or Accept header.
+func parseReportRequest(r *http.Request) (datastore.SubmissionReportQuery, ReportFilter, string, error) {
+	params := r.URL.Query()
+	query := datastore.SubmissionReportQuery{
+		GroupBy:   []string{},
+		DealerID:  strings.TrimSpace(params.Get("dealer_id")),
+		ProductID: strings.TrimSpace(params.Get("product_id")),
+	}
+
+	periods := 0
+	for _, dimension := range strings.Split(params.Get("group_by"), ",") {
+		dimension = strings.ToLower(strings.TrimSpace(dimension))
+		if dimension == "" {
+			continue
+		}
+		if !slices.Contains(datastore.ReportDimensions, dimension) {
+			return query, ReportFilter{}, "", fmt.Errorf("invalid group_by %q, expected some of %s",
+				dimension, strings.Join(datastore.ReportDimensions, ", "))
+		}
+		if slices.Contains(query.GroupBy, dimension) {
+			return query, ReportFilter{}, "", fmt.Errorf("group_by %q is requested twice", dimension)
+		}
+		if slices.Contains(reportPeriods, dimension) {
+			periods++
+		}
+		query.GroupBy = append(query.GroupBy, dimension)
+	}
+	if periods > 1 {
+		return query, ReportFilter{}, "", fmt.Errorf("group_by takes at most one of %s", strings.Join(reportPeriods, ", "))
+	}
+
+	if (query.DealerID != "" && !IsValidUUID(query.DealerID)) || (query.ProductID != "" && !IsValidUUID(query.ProductID)) {
+		return query, ReportFilter{}, "", errors.New("dealer_id and product_id must be UUIDs")
+	}
+	from, to := strings.TrimSpace(params.Get("from")), strings.TrimSpace(params.Get("to"))
+	var err error
+	if query.CreatedFrom, query.CreatedTo, err = exportDateRange(from, to); err != nil {
+		return query, ReportFilter{}, "", err
+	}
+
+	format := formatJSON
+	if requested := strings.ToLower(strings.TrimSpace(params.Get("format"))); requested != formatJSON &&
+		(requested != "" || acceptsExportFormat(r)) {
+		if format, err = exportFormat(r); err != nil {
+			return query, ReportFilter{}, "", fmt.Errorf("%w or %s", err, formatJSON)
+		}
+	}
+
+	filter := ReportFilter{
+		GroupBy:   query.GroupBy,
+		From:      optionalString(from),
+		To:        optionalString(to),
+		DealerID:  optionalString(query.DealerID),
+		ProductID: optionalString(query.ProductID),
+	}
+	return query, filter, format, nil
+}
+
+func acceptsExportFormat(r *http.Request) bool {
+	for _, format := range export.Formats {
+		if strings.Contains(r.Header.Get("Accept"), export.ContentType(format)) {
+			return true
+		}
+	}
+	return false
+}
+
+func reportCacheKey(query datastore.SubmissionReportQuery) string {
+	return fmt.Sprintf("%s|%d|%d|%s|%s", strings.Join(query.GroupBy, ","), query.CreatedFrom, query.CreatedTo,
+		query.DealerID, query.ProductID)
+}
+
+func reportGroup(groupBy []string, row *datastore.SubmissionReportRow) map[string]string {
+	group := make(map[string]string, len(groupBy))
+	for i, dimension := range groupBy {
+		group[dimension] = row.Group[i]
+	}
+	return group
+}
+
+func reportGroupValues(row *datastore.SubmissionReportRow) []any {
+	values := make([]any, len(row.Group))
+	for i, value := range row.Group {
+		values[i] = value
+	}
+	return values
+}
+
+func addFunnel(total *SubmissionFunnel, funnel SubmissionFunnel) {
+	total.Submitted += funnel.Submitted
+	total.Pending += funnel.Pending
+	total.Approved += funnel.Approved
+	total.Rejected += funnel.Rejected
+	total.Cancelled += funnel.Cancelled
+	total.Disbursed += funnel.Disbursed
+	total.Closed += funnel.Closed
+	total.ApprovedLoanAmount += funnel.ApprovedLoanAmount
+}
+
+func setFunnelRates(funnel *SubmissionFunnel) {
+	funnel.ApprovalRate = reportRatio(funnel.Approved, funnel.Approved+funnel.Rejected)
+	funnel.ConversionRate = reportRatio(funnel.Disbursed, funnel.Submitted)
+}
+
+// reportRatio rounds to four decimals, i.e. a hundredth of a percent.
+func reportRatio(part, whole int) *float64 {
+	if whole == 0 {
+		return nil
+	}
+	ratio := math.Round(float64(part)/float64(whole)*10000) / 10000
+	return &ratio
+}
+
+func reportRate(rate *float64) any {
+	if rate == nil {
+		return nil
+	}
+	return *rate
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func writeReportTable(w http.ResponseWriter, format, name string, columns []string, table [][]any) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s-%s.%s\"", name, time.Now().Format("20060102"), format))
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := export.NewWriter(format, w, columns)
+	if err != nil {
+		log.Printf("starting %s report failed: %v\n", name, err)
+		return
+	}
+	for _, values := range table {
+		if err = writer.WriteRow(values); err != nil {
+			break
+		}
+	}
+	finishExport(writer, name+" report", err)
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIPrefix is where the versions of the API are served, e.g. /api/v1.
+const APIPrefix = "/api"
+
+// LegacyDeprecatedAt is when the routes predating /api/v1 were deprecated.
+var LegacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// DefaultLegacySunset is when the legacy routes stop answering unless
+// configured otherwise.
+var DefaultLegacySunset = LegacyDeprecatedAt.AddDate(0, 6, 0)
+
+var routeParamPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+// Router registers routes with Go 1.22 method patterns, so that the mux
+// answers 405 to a method a route does not serve. Versions of the API are
+// registered through Version, and the routes of an older layout through
+// Alias.
+type Router struct {
+	Mux *http.ServeMux
+	// Sunset is when legacy aliases start answering 410 Gone.
+	Sunset   time.Time
+	handlers map[string]http.HandlerFunc
+}
+
+func NewRouter(mux *http.ServeMux) *Router {
+	return &Router{
+		Mux:      mux,
+		Sunset:   DefaultLegacySunset,
+		handlers: make(map[string]http.HandlerFunc),
+	}
+}
+
+// HandleFunc registers a "METHOD /path" pattern outside of any version.
+func (r *Router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" || !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("handler: route %q has no method", pattern))
+	}
+	r.Mux.HandleFunc(pattern, handler)
+	r.handlers[pattern] = handler
+}
+
+// APIVersion registers routes under its prefix. A new version starts empty:
+// routes that did not change are registered with the same handlers again.
+type APIVersion struct {
+	router *Router
+	Prefix string
+}
+
+// Version returns the version served under /api/<name>.
+func (r *Router) Version(name string) *APIVersion {
+	return &APIVersion{router: r, Prefix: APIPrefix + "/" + name}
+}
+
+// HandleFunc registers a "METHOD /path" pattern relative to the prefix.
+func (v *APIVersion) HandleFunc(pattern string, handler http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	v.router.HandleFunc(method+" "+v.Prefix+path, handler)
+}
+
+// LegacyRoute keeps a route of the layout before /api/v1 answering with the
+// handler of its successor. QueryParams fills path values of the successor
+// from the query parameters the legacy route took them from.
+type LegacyRoute struct {
+	Pattern     string
+	Successor   string
+	QueryParams map[string]string
+}
+
+type legacyRouteContextKey struct{}
+
+// legacyRouteFromContext returns the legacy route a request came in through,
+// for handlers that keep behaviour their legacy callers rely on.
+func legacyRouteFromContext(ctx context.Context) (LegacyRoute, bool) {
+	route, ok := ctx.Value(legacyRouteContextKey{}).(LegacyRoute)
+	return route, ok
+}
+
+// Alias registers the legacy route. Its replies carry Deprecation, Sunset and
+// a Link to the successor; after the sunset it answers 410 Gone.
+func (r *Router) Alias(route LegacyRoute) {
+	next, ok := r.handlers[route.Successor]
+	if !ok {
+		panic(fmt.Sprintf("handler: legacy route %q has no successor %q", route.Pattern, route.Successor))
+	}
+	_, successorPath, _ := strings.Cut(route.Successor, " ")
+	deprecation := "@" + strconv.FormatInt(LegacyDeprecatedAt.Unix(), 10)
+
+	r.Mux.HandleFunc(route.Pattern, func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		for name, param := range route.QueryParams {
+			req.SetPathValue(name, query.Get(param))
+			query.Del(param)
+		}
+		link := routeParamPattern.ReplaceAllStringFunc(successorPath, func(param string) string {
+			return url.PathEscape(req.PathValue(strings.Trim(param, "{}")))
+		})
+		if len(query) > 0 {
+			link += "?" + query.Encode()
+		}
+
+		header := w.Header()
+		header.Set("Deprecation", deprecation)
+		header.Set("Sunset", r.Sunset.UTC().Format(http.TimeFormat))
+		header.Set("Link", "<"+link+`>; rel="successor-version"`)
+		if !time.Now().Before(r.Sunset) {
+			errMsg := "This route was retired on " + r.Sunset.UTC().Format(time.DateOnly) + ", use " + route.Successor
+			writeJSON(w, http.StatusGone, ErrorResponse{ErrorMessage: &errMsg})
+			return
+		}
+		next(w, req.WithContext(context.WithValue(req.Context(), legacyRouteContextKey{}, route)))
+	})
+}
+
+// LegacyRoutes maps the routes served before /api/v1 to their successors.
+var LegacyRoutes = []LegacyRoute{
+	{Pattern: "PUT /api/loan/submit", Successor: "POST /api/v1/submissions"},
+	{Pattern: "GET /api/loan/submissions", Successor: "GET /api/v1/submissions"},
+	{Pattern: "GET /api/loan/submission/tracks", Successor: "GET /api/v1/submissions/{submissionID}", QueryParams: map[string]string{"submissionID": "loan_submission_id"}},
+	{Pattern: "GET /api/loan/submissions/{submissionID}/valuation", Successor: "GET /api/v1/submissions/{submissionID}/valuation"},
+	{Pattern: "GET /api/loan/submissions/{submissionID}/duplicates", Successor: "GET /api/v1/submissions/{submissionID}/duplicates"},
+	{Pattern: "GET /api/loan/submissions/{submissionID}/quote", Successor: "GET /api/v1/submissions/{submissionID}/quote"},
+	{Pattern: "GET /api/loan/submissions/{submissionID}/parties", Successor: "GET /api/v1/submissions/{submissionID}/parties"},
+	{Pattern: "GET /api/loan/submissions/{submissionID}/documents", Successor: "GET /api/v1/submissions/{submissionID}/documents"},
+	{Pattern: "POST /api/loan/submissions/{submissionID}/documents", Successor: "POST /api/v1/submissions/{submissionID}/documents"},
+	{Pattern: "GET /api/loan/submissions/{submissionID}/documents/checklist", Successor: "GET /api/v1/submissions/{submissionID}/documents/checklist"},
+	{Pattern: "GET /api/loan/customers/{customerID}/documents", Successor: "GET /api/v1/customers/{customerID}/documents"},
+	{Pattern: "POST /api/loan/customers/{customerID}/documents", Successor: "POST /api/v1/customers/{customerID}/documents"},
+	{Pattern: "GET /api/loan/documents/{documentID}", Successor: "GET /api/v1/documents/{documentID}"},
+	{Pattern: "DELETE /api/loan/documents/{documentID}", Successor: "DELETE /api/v1/documents/{documentID}"},
+	{Pattern: "GET /api/loan/products", Successor: "GET /api/v1/products"},
+	{Pattern: "GET /api/loan/customers", Successor: "GET /api/v1/customers"},
+	{Pattern: "GET /api/loan/customers/{customerID}/info", Successor: "GET /api/v1/customers/{customerID}"},
+	{Pattern: "PATCH /api/loan/customer/{customerID}/update", Successor: "PATCH /api/v1/customers/{customerID}"},
+	{Pattern: "DELETE /api/loan/customer/{customerID}/delete", Successor: "DELETE /api/v1/customers/{customerID}"},
+	{Pattern: "GET /api/loan/customers/{customerID}/notifications", Successor: "GET /api/v1/customers/{customerID}/notifications"},
+	{Pattern: "GET /api/loans/{submissionID}/schedule", Successor: "GET /api/v1/loans/{submissionID}/schedule"},
+	{Pattern: "POST /api/loans/{submissionID}/schedule", Successor: "POST /api/v1/loans/{submissionID}/schedule"},
+	{Pattern: "GET /api/loans/{submissionID}/payments", Successor: "GET /api/v1/loans/{submissionID}/payments"},
+	{Pattern: "POST /api/loans/{submissionID}/payments", Successor: "POST /api/v1/loans/{submissionID}/payments"},
+	{Pattern: "GET /api/loans/{submissionID}/balance", Successor: "GET /api/v1/loans/{submissionID}/balance"},
+	{Pattern: "GET /api/loans/{submissionID}/payoff", Successor: "GET /api/v1/loans/{submissionID}/payoff"},
+	{Pattern: "POST /api/loans/{submissionID}/settlement", Successor: "POST /api/v1/loans/{submissionID}/settlement"},
+	{Pattern: "GET /api/collections/aging", Successor: "GET /api/v1/collections/aging"},
+	{Pattern: "GET /api/events/schemas", Successor: "GET /api/v1/events/schemas"},
+	{Pattern: "GET /api/events/schemas/{eventType}/{version}", Successor: "GET /api/v1/events/schemas/{eventType}/{version}"},
+	{Pattern: "PUT /api/dealer/submit", Successor: "POST /api/v1/dealer/submissions"},
+	{Pattern: "GET /api/dealer/submissions", Successor: "GET /api/v1/dealer/submissions"},
+	{Pattern: "GET /api/dealer/submissions/{submissionID}", Successor: "GET /api/v1/dealer/submissions/{submissionID}"},
+	{Pattern: "GET /api/dealer/agents", Successor: "GET /api/v1/dealer/agents"},
+	{Pattern: "GET /api/dealer/summary", Successor: "GET /api/v1/dealer/summary"},
+	{Pattern: "GET /api/dealer/commissions", Successor: "GET /api/v1/dealer/commissions"},
+	{Pattern: "GET /api/dealer/webhooks", Successor: "GET /api/v1/dealer/webhooks"},
+	{Pattern: "POST /api/dealer/webhooks", Successor: "POST /api/v1/dealer/webhooks"},
+	{Pattern: "GET /api/dealer/webhooks/{subscriptionID}", Successor: "GET /api/v1/dealer/webhooks/{subscriptionID}"},
+	{Pattern: "PUT /api/dealer/webhooks/{subscriptionID}", Successor: "PUT /api/v1/dealer/webhooks/{subscriptionID}"},
+	{Pattern: "DELETE /api/dealer/webhooks/{subscriptionID}", Successor: "DELETE /api/v1/dealer/webhooks/{subscriptionID}"},
+	{Pattern: "GET /api/dealer/webhooks/{subscriptionID}/deliveries", Successor: "GET /api/v1/dealer/webhooks/{subscriptionID}/deliveries"},
+	{Pattern: "POST /api/dealer/webhooks/{subscriptionID}/deliveries/{deliveryID}/redeliver", Successor: "POST /api/v1/dealer/webhooks/{subscriptionID}/deliveries/{deliveryID}/redeliver"},
+	{Pattern: "GET /api/admin/vehicle/types", Successor: "GET /api/v1/admin/vehicle/types"},
+	{Pattern: "POST /api/admin/vehicle/types", Successor: "POST /api/v1/admin/vehicle/types"},
+	{Pattern: "PUT /api/admin/vehicle/types/{typeID}", Successor: "PUT /api/v1/admin/vehicle/types/{typeID}"},
+	{Pattern: "DELETE /api/admin/vehicle/types/{typeID}", Successor: "DELETE /api/v1/admin/vehicle/types/{typeID}"},
+	{Pattern: "GET /api/admin/vehicle/brands", Successor: "GET /api/v1/admin/vehicle/brands"},
+	{Pattern: "POST /api/admin/vehicle/brands", Successor: "POST /api/v1/admin/vehicle/brands"},
+	{Pattern: "PUT /api/admin/vehicle/brands/{brandID}", Successor: "PUT /api/v1/admin/vehicle/brands/{brandID}"},
+	{Pattern: "DELETE /api/admin/vehicle/brands/{brandID}", Successor: "DELETE /api/v1/admin/vehicle/brands/{brandID}"},
+	{Pattern: "GET /api/admin/vehicle/models", Successor: "GET /api/v1/admin/vehicle/models"},
+	{Pattern: "POST /api/admin/vehicle/models", Successor: "POST /api/v1/admin/vehicle/models"},
+	{Pattern: "PUT /api/admin/vehicle/models/{modelID}", Successor: "PUT /api/v1/admin/vehicle/models/{modelID}"},
+	{Pattern: "DELETE /api/admin/vehicle/models/{modelID}", Successor: "DELETE /api/v1/admin/vehicle/models/{modelID}"},
+	{Pattern: "POST /api/admin/vehicle/catalogue/import", Successor: "POST /api/v1/admin/vehicle/catalogue/import"},
+	{Pattern: "GET /api/admin/loan/products", Successor: "GET /api/v1/admin/products"},
+	{Pattern: "POST /api/admin/loan/products", Successor: "POST /api/v1/admin/products"},
+	{Pattern: "GET /api/admin/loan/products/{productID}", Successor: "GET /api/v1/admin/products/{productID}"},
+	{Pattern: "PUT /api/admin/loan/products/{productID}", Successor: "PUT /api/v1/admin/products/{productID}"},
+	{Pattern: "DELETE /api/admin/loan/products/{productID}", Successor: "DELETE /api/v1/admin/products/{productID}"},
+	{Pattern: "POST /api/admin/loan/import", Successor: "POST /api/v1/admin/loans/import"},
+	{Pattern: "POST /api/admin/loan/submissions/{submissionID}/decision", Successor: "POST /api/v1/admin/submissions/{submissionID}/decision"},
+	{Pattern: "POST /api/admin/collections/run", Successor: "POST /api/v1/admin/collections/run"},
+	{Pattern: "GET /api/admin/events/outbox", Successor: "GET /api/v1/admin/events/outbox"},
+	{Pattern: "GET /api/admin/notifications", Successor: "GET /api/v1/admin/notifications"},
+	{Pattern: "GET /api/admin/export/submissions", Successor: "GET /api/v1/admin/exports/submissions"},
+	{Pattern: "GET /api/admin/export/customers", Successor: "GET /api/v1/admin/exports/customers"},
+	{Pattern: "GET /api/admin/reports/submissions", Successor: "GET /api/v1/admin/reports/submissions"},
+	{Pattern: "GET /api/admin/reports/funnel", Successor: "GET /api/v1/admin/reports/funnel"},
+	{Pattern: "GET /api/admin/dealers", Successor: "GET /api/v1/admin/dealers"},
+	{Pattern: "POST /api/admin/dealers", Successor: "POST /api/v1/admin/dealers"},
+	{Pattern: "GET /api/admin/dealers/summary", Successor: "GET /api/v1/admin/dealers/summary"},
+	{Pattern: "GET /api/admin/dealers/{dealerID}", Successor: "GET /api/v1/admin/dealers/{dealerID}"},
+	{Pattern: "PUT /api/admin/dealers/{dealerID}", Successor: "PUT /api/v1/admin/dealers/{dealerID}"},
+	{Pattern: "DELETE /api/admin/dealers/{dealerID}", Successor: "DELETE /api/v1/admin/dealers/{dealerID}"},
+	{Pattern: "GET /api/admin/dealers/{dealerID}/branches", Successor: "GET /api/v1/admin/dealers/{dealerID}/branches"},
+	{Pattern: "POST /api/admin/dealers/{dealerID}/branches", Successor: "POST /api/v1/admin/dealers/{dealerID}/branches"},
+	{Pattern: "GET /api/admin/dealers/{dealerID}/agents", Successor: "GET /api/v1/admin/dealers/{dealerID}/agents"},
+	{Pattern: "POST /api/admin/dealers/{dealerID}/agents", Successor: "POST /api/v1/admin/dealers/{dealerID}/agents"},
+	{Pattern: "GET /api/admin/dealers/{dealerID}/credentials", Successor: "GET /api/v1/admin/dealers/{dealerID}/credentials"},
+	{Pattern: "POST /api/admin/dealers/{dealerID}/credentials", Successor: "POST /api/v1/admin/dealers/{dealerID}/credentials"},
+	{Pattern: "DELETE /api/admin/dealers/{dealerID}/credentials/{credentialID}", Successor: "DELETE /api/v1/admin/dealers/{dealerID}/credentials/{credentialID}"},
+	{Pattern: "GET /api/admin/dealers/{dealerID}/commissions", Successor: "GET /api/v1/admin/dealers/{dealerID}/commissions"},
+	{Pattern: "GET /api/admin/disbursements", Successor: "GET /api/v1/admin/disbursements"},
+	{Pattern: "POST /api/admin/disbursements", Successor: "POST /api/v1/admin/disbursements"},
+	{Pattern: "GET /api/admin/disbursements/{disbursementID}", Successor: "GET /api/v1/admin/disbursements/{disbursementID}"},
+	{Pattern: "POST /api/admin/disbursements/{disbursementID}/approve", Successor: "POST /api/v1/admin/disbursements/{disbursementID}/approve"},
+	{Pattern: "POST /api/admin/disbursements/{disbursementID}/reject", Successor: "POST /api/v1/admin/disbursements/{disbursementID}/reject"},
+}
@@ -1,10 +1,156 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/disbursement"
+	"github.com/alphaloan/vehicle/notification"
 	"github.com/google/uuid"
 )
 
+var loanStatuses = []string{"NEW", "APPROVED", "REJECTED", "CANCELLED", "DISBURSED", "CLOSED"}
+
 func IsValidUUID(uuidString string) bool {
 	_, err := uuid.Parse(uuidString)
 	return err == nil
 }
+
+// Regional plates are a one or two letter area code, up to four digits and an
+// optional suffix of up to three letters, e.g. "B 1234 XYZ".
+var licensePlatePattern = regexp.MustCompile(`^([A-Z]{1,2})([0-9]{1,4})([A-Z]{0,3})$`)
+
+// licensePlateKey strips case, spacing and punctuation so that "b-1234-xyz"
+// and "B 1234 XYZ" compare equal.
+func licensePlateKey(plate string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, plate)
+}
+
+func normaliseLicensePlate(plate string) (string, error) {
+	parts := licensePlatePattern.FindStringSubmatch(licensePlateKey(plate))
+	if parts == nil {
+		return "", fmt.Errorf("invalid vehicle license number %q", plate)
+	}
+	return strings.TrimSpace(strings.Join(parts[1:], " ")), nil
+}
+
+func normaliseCatalogueName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// canonicaliseVehicle rewrites the vehicle type, brand and model of a proposal
+// to their catalogue spelling and rejects combinations the catalogue does not
+// know about. The matched catalogue model is returned; failed lookups are
+// returned as a *SubmitError.
+func canonicaliseVehicle(store *datastore.VehicleCatalogueStore, proposal *LoanSubmission) (*datastore.VehicleModelRow, error) {
+	brandName := normaliseCatalogueName(proposal.VehicleBrand)
+	modelName := normaliseCatalogueName(proposal.VehicleModel)
+
+	brand, err := store.GetVehicleBrandByName(brandName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("unknown vehicle brand %q", brandName)
+	}
+	if err != nil {
+		return nil, submitFailure("Failed to get vehicle brand " + brandName)
+	}
+
+	model, err := store.GetVehicleModelByName(brand.BrandID, modelName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("unknown vehicle model %q for brand %q", modelName, brand.Name)
+	}
+	if err != nil {
+		return nil, submitFailure("Failed to get vehicle model " + modelName)
+	}
+
+	vehicleType := normaliseCatalogueName(proposal.VehicleType)
+	if vehicleType != "" && !strings.EqualFold(vehicleType, model.TypeName) {
+		return nil, fmt.Errorf("vehicle type %q does not match %s %s, expected %q", vehicleType, model.BrandName, model.Name, model.TypeName)
+	}
+
+	if proposal.ManufacturingYear < model.YearFrom ||
+		(model.YearTo.Valid && int64(proposal.ManufacturingYear) > model.YearTo.Int64) {
+		return nil, fmt.Errorf("manufacturing year %d is outside the production years of %s %s", proposal.ManufacturingYear, model.BrandName, model.Name)
+	}
+
+	proposal.VehicleType = model.TypeName
+	proposal.VehicleBrand = model.BrandName
+	proposal.VehicleModel = model.Name
+	if model.IsCommercial {
+		proposal.IsCommercialVehicle = true
+	}
+	return model, nil
+}
+
+// validateSubmissionParties normalises the roles of the additional parties and
+// rejects a party that repeats the primary applicant or another party.
+func validateSubmissionParties(primary *LoanCustomer, parties []SubmissionParty) error {
+	seen := map[string]bool{strings.TrimSpace(primary.IDCardNumber): true}
+	for i := range parties {
+		party := &parties[i]
+		role := strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(strings.TrimSpace(party.Role)))
+		if role != datastore.PartyRoleCoApplicant && role != datastore.PartyRoleGuarantor {
+			return fmt.Errorf("invalid party role %q, expected %s or %s",
+				party.Role, datastore.PartyRoleCoApplicant, datastore.PartyRoleGuarantor)
+		}
+		party.Role = role
+
+		if party.Customer == nil || strings.TrimSpace(party.Customer.IDCardNumber) == "" {
+			return fmt.Errorf("party %d is missing the customer id_card_number", i+1)
+		}
+		idCardNumber := strings.TrimSpace(party.Customer.IDCardNumber)
+		if seen[idCardNumber] {
+			return fmt.Errorf("customer %s appears more than once on the submission", idCardNumber)
+		}
+		seen[idCardNumber] = true
+	}
+	return nil
+}
+
+// validateCustomerLocale accepts an empty locale, which keeps the locale on
+// file or the default for a new customer.
+func validateCustomerLocale(customer *LoanCustomer) error {
+	locale := strings.ToLower(strings.TrimSpace(customer.Locale))
+	if locale != "" && !notification.IsSupportedLocale(locale) {
+		return fmt.Errorf("unsupported locale %q, expected one of %s", customer.Locale, strings.Join(notification.Locales, ", "))
+	}
+	return nil
+}
+
+// validateDisbursementRequest normalises the payee and bank details of a
+// disbursement instruction.
+func validateDisbursementRequest(request *CreateDisbursementRequest) error {
+	request.PayeeType = strings.ToUpper(strings.TrimSpace(request.PayeeType))
+	if request.PayeeType != datastore.PayeeTypeDealer && request.PayeeType != datastore.PayeeTypeCustomer {
+		return fmt.Errorf("invalid payee_type %q, expected %s or %s",
+			request.PayeeType, datastore.PayeeTypeDealer, datastore.PayeeTypeCustomer)
+	}
+	request.PayeeName = normaliseCatalogueName(request.PayeeName)
+	if request.PayeeName == "" {
+		return errors.New("payee_name is required")
+	}
+	request.AccountHolder = normaliseCatalogueName(request.AccountHolder)
+	if request.AccountHolder == "" {
+		return errors.New("account_holder is required")
+	}
+
+	request.BankCode = strings.ToUpper(strings.TrimSpace(request.BankCode))
+	request.AccountNumber = strings.NewReplacer(" ", "", "-", "").Replace(request.AccountNumber)
+	if err := disbursement.ValidateBankAccount(request.BankCode, request.AccountNumber); err != nil {
+		return err
+	}
+
+	if request.DeductedFees < 0 {
+		return errors.New("deducted_fees must not be negative")
+	}
+	return nil
+}
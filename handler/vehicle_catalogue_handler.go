@@ -0,0 +1,404 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/google/uuid"
+)
+
+const maxCatalogueImportSize = 10 << 20
+
+var catalogueImportColumns = []string{"type", "brand", "model", "year_from", "year_to", "is_commercial"}
+
+type VehicleCatalogueHandler struct {
+	CatalogueStore datastore.VehicleCatalogueStore
+}
+
+func NewVehicleCatalogueHandler(catalogueStore datastore.VehicleCatalogueStore) *VehicleCatalogueHandler {
+	return &VehicleCatalogueHandler{
+		CatalogueStore: catalogueStore,
+	}
+}
+
+func (h *VehicleCatalogueHandler) HandleGetVehicleTypes(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.CatalogueStore.GetAllVehicleTypes()
+	if err != nil {
+		errMsg := "Failed to get vehicle types"
+		writeJSON(w, http.StatusInternalServerError, GetAllVehicleTypesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	types := make([]VehicleType, 0, len(rows))
+	for _, row := range rows {
+		types = append(types, VehicleType{TypeID: row.TypeID, Name: row.Name})
+	}
+	writeJSON(w, http.StatusOK, GetAllVehicleTypesResponse{Data: &types})
+}
+
+func (h *VehicleCatalogueHandler) HandleCreateVehicleType(w http.ResponseWriter, r *http.Request) {
+	h.upsertVehicleType(w, r, uuid.New().String(), http.StatusCreated)
+}
+
+func (h *VehicleCatalogueHandler) HandleUpdateVehicleType(w http.ResponseWriter, r *http.Request) {
+	typeID := r.PathValue("typeID")
+	if !validateCatalogueID(w, "type", typeID) {
+		return
+	}
+
+	h.upsertVehicleType(w, r, typeID, http.StatusOK)
+}
+
+func (h *VehicleCatalogueHandler) HandleDeleteVehicleType(w http.ResponseWriter, r *http.Request) {
+	typeID := r.PathValue("typeID")
+	if !validateCatalogueID(w, "type", typeID) {
+		return
+	}
+
+	writeCatalogueDelete(w, typeID, h.CatalogueStore.DeleteVehicleType(typeID))
+}
+
+func (h *VehicleCatalogueHandler) upsertVehicleType(w http.ResponseWriter, r *http.Request, typeID string, status int) {
+	var request VehicleType
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	name := normaliseCatalogueName(request.Name)
+	if name == "" {
+		writeCatalogueError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	id, err := h.CatalogueStore.UpsertVehicleType(&datastore.VehicleTypeRow{TypeID: typeID, Name: name})
+	if err != nil {
+		writeCatalogueError(w, http.StatusBadRequest, "Failed to save vehicle type: "+err.Error())
+		return
+	}
+	writeJSON(w, status, UpsertVehicleCatalogueResponse{ID: &id})
+}
+
+func (h *VehicleCatalogueHandler) HandleGetVehicleBrands(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.CatalogueStore.GetAllVehicleBrands()
+	if err != nil {
+		errMsg := "Failed to get vehicle brands"
+		writeJSON(w, http.StatusInternalServerError, GetAllVehicleBrandsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	brands := make([]VehicleBrand, 0, len(rows))
+	for _, row := range rows {
+		brands = append(brands, VehicleBrand{BrandID: row.BrandID, Name: row.Name})
+	}
+	writeJSON(w, http.StatusOK, GetAllVehicleBrandsResponse{Data: &brands})
+}
+
+func (h *VehicleCatalogueHandler) HandleCreateVehicleBrand(w http.ResponseWriter, r *http.Request) {
+	h.upsertVehicleBrand(w, r, uuid.New().String(), http.StatusCreated)
+}
+
+func (h *VehicleCatalogueHandler) HandleUpdateVehicleBrand(w http.ResponseWriter, r *http.Request) {
+	brandID := r.PathValue("brandID")
+	if !validateCatalogueID(w, "brand", brandID) {
+		return
+	}
+
+	h.upsertVehicleBrand(w, r, brandID, http.StatusOK)
+}
+
+func (h *VehicleCatalogueHandler) HandleDeleteVehicleBrand(w http.ResponseWriter, r *http.Request) {
+	brandID := r.PathValue("brandID")
+	if !validateCatalogueID(w, "brand", brandID) {
+		return
+	}
+
+	writeCatalogueDelete(w, brandID, h.CatalogueStore.DeleteVehicleBrand(brandID))
+}
+
+func (h *VehicleCatalogueHandler) upsertVehicleBrand(w http.ResponseWriter, r *http.Request, brandID string, status int) {
+	var request VehicleBrand
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	name := normaliseCatalogueName(request.Name)
+	if name == "" {
+		writeCatalogueError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	id, err := h.CatalogueStore.UpsertVehicleBrand(&datastore.VehicleBrandRow{BrandID: brandID, Name: name})
+	if err != nil {
+		writeCatalogueError(w, http.StatusBadRequest, "Failed to save vehicle brand: "+err.Error())
+		return
+	}
+	writeJSON(w, status, UpsertVehicleCatalogueResponse{ID: &id})
+}
+
+func (h *VehicleCatalogueHandler) HandleGetVehicleModels(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.CatalogueStore.GetAllVehicleModels()
+	if err != nil {
+		errMsg := "Failed to get vehicle models"
+		writeJSON(w, http.StatusInternalServerError, GetAllVehicleModelsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	models := make([]VehicleModel, 0, len(rows))
+	for _, row := range rows {
+		models = append(models, convertVehicleModelRow(row))
+	}
+	writeJSON(w, http.StatusOK, GetAllVehicleModelsResponse{Data: &models})
+}
+
+func (h *VehicleCatalogueHandler) HandleCreateVehicleModel(w http.ResponseWriter, r *http.Request) {
+	h.upsertVehicleModel(w, r, uuid.New().String(), http.StatusCreated)
+}
+
+func (h *VehicleCatalogueHandler) HandleUpdateVehicleModel(w http.ResponseWriter, r *http.Request) {
+	modelID := r.PathValue("modelID")
+	if !validateCatalogueID(w, "model", modelID) {
+		return
+	}
+
+	h.upsertVehicleModel(w, r, modelID, http.StatusOK)
+}
+
+func (h *VehicleCatalogueHandler) HandleDeleteVehicleModel(w http.ResponseWriter, r *http.Request) {
+	modelID := r.PathValue("modelID")
+	if !validateCatalogueID(w, "model", modelID) {
+		return
+	}
+
+	writeCatalogueDelete(w, modelID, h.CatalogueStore.DeleteVehicleModel(modelID))
+}
+
+func (h *VehicleCatalogueHandler) upsertVehicleModel(w http.ResponseWriter, r *http.Request, modelID string, status int) {
+	var request VehicleModel
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+
+	row := &datastore.VehicleModelRow{
+		ModelID:      modelID,
+		BrandID:      request.BrandID,
+		TypeID:       request.TypeID,
+		Name:         normaliseCatalogueName(request.Name),
+		YearFrom:     request.YearFrom,
+		IsCommercial: request.IsCommercial,
+	}
+	if request.YearTo != nil {
+		row.YearTo = sql.NullInt64{Int64: int64(*request.YearTo), Valid: true}
+	}
+	if request.BasePrice != nil {
+		row.BasePrice = sql.NullInt64{Int64: int64(*request.BasePrice), Valid: true}
+	}
+	if err := validateVehicleModelRow(row); err != nil {
+		writeCatalogueError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.CatalogueStore.UpsertVehicleModel(row)
+	if err != nil {
+		writeCatalogueError(w, http.StatusBadRequest, "Failed to save vehicle model: "+err.Error())
+		return
+	}
+	writeJSON(w, status, UpsertVehicleCatalogueResponse{ID: &id})
+}
+
+// HandleImportCatalogue accepts a CSV with the columns type, brand, model,
+// year_from, year_to, is_commercial and optionally base_price, either as the raw request body or as
+// the "file" field of a multipart form. Valid rows are imported together;
+// invalid rows are reported back and skipped.
+func (h *VehicleCatalogueHandler) HandleImportCatalogue(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCatalogueImportSize)
+	body := io.Reader(r.Body)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	entries, rowErrors, err := parseVehicleCatalogueCSV(body)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, ImportVehicleCatalogueResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if err := h.CatalogueStore.ImportCatalogue(entries, func() string { return uuid.New().String() }); err != nil {
+		errMsg := "Failed to import vehicle catalogue: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, ImportVehicleCatalogueResponse{ErrorMessage: &errMsg, RowErrors: rowErrors})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ImportVehicleCatalogueResponse{Imported: len(entries), RowErrors: rowErrors})
+}
+
+func parseVehicleCatalogueCSV(body io.Reader) ([]*datastore.VehicleCatalogueEntry, []string, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range catalogueImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing csv column %q", name)
+		}
+	}
+
+	var entries []*datastore.VehicleCatalogueEntry
+	rowErrors := []string{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		entry, err := parseVehicleCatalogueRecord(record, columns)
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rowErrors, nil
+}
+
+func parseVehicleCatalogueRecord(record []string, columns map[string]int) (*datastore.VehicleCatalogueEntry, error) {
+	field := func(name string) string {
+		return strings.TrimSpace(record[columns[name]])
+	}
+
+	entry := &datastore.VehicleCatalogueEntry{
+		TypeName:  normaliseCatalogueName(field("type")),
+		BrandName: normaliseCatalogueName(field("brand")),
+		ModelName: normaliseCatalogueName(field("model")),
+	}
+	if entry.TypeName == "" || entry.BrandName == "" || entry.ModelName == "" {
+		return nil, errors.New("type, brand and model are required")
+	}
+
+	yearFrom, err := strconv.Atoi(field("year_from"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid year_from %q", field("year_from"))
+	}
+	entry.YearFrom = yearFrom
+
+	if yearTo := field("year_to"); yearTo != "" {
+		parsed, err := strconv.Atoi(yearTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid year_to %q", yearTo)
+		}
+		entry.YearTo = sql.NullInt64{Int64: int64(parsed), Valid: true}
+	}
+
+	if commercial := field("is_commercial"); commercial != "" {
+		parsed, err := strconv.ParseBool(commercial)
+		if err != nil {
+			return nil, fmt.Errorf("invalid is_commercial %q", commercial)
+		}
+		entry.IsCommercial = parsed
+	}
+
+	if i, ok := columns["base_price"]; ok && strings.TrimSpace(record[i]) != "" {
+		parsed, err := strconv.Atoi(strings.TrimSpace(record[i]))
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid base_price %q", record[i])
+		}
+		entry.BasePrice = sql.NullInt64{Int64: int64(parsed), Valid: true}
+	}
+
+	if err := validateVehicleYears(entry.YearFrom, entry.YearTo); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func validateVehicleModelRow(row *datastore.VehicleModelRow) error {
+	if row.Name == "" {
+		return errors.New("name is required")
+	}
+	if !IsValidUUID(row.BrandID) {
+		return fmt.Errorf("invalid brand_id: %s", row.BrandID)
+	}
+	if !IsValidUUID(row.TypeID) {
+		return fmt.Errorf("invalid type_id: %s", row.TypeID)
+	}
+	if row.BasePrice.Valid && row.BasePrice.Int64 <= 0 {
+		return fmt.Errorf("invalid base_price %d", row.BasePrice.Int64)
+	}
+	return validateVehicleYears(row.YearFrom, row.YearTo)
+}
+
+func validateVehicleYears(yearFrom int, yearTo sql.NullInt64) error {
+	if yearFrom <= 0 {
+		return fmt.Errorf("invalid year_from %d", yearFrom)
+	}
+	if yearTo.Valid && int(yearTo.Int64) < yearFrom {
+		return fmt.Errorf("year_to %d is before year_from %d", yearTo.Int64, yearFrom)
+	}
+	return nil
+}
+
+func convertVehicleModelRow(row *datastore.VehicleModelRow) VehicleModel {
+	model := VehicleModel{
+		ModelID:      row.ModelID,
+		BrandID:      row.BrandID,
+		BrandName:    row.BrandName,
+		TypeID:       row.TypeID,
+		TypeName:     row.TypeName,
+		Name:         row.Name,
+		YearFrom:     row.YearFrom,
+		IsCommercial: row.IsCommercial,
+	}
+	if row.YearTo.Valid {
+		yearTo := int(row.YearTo.Int64)
+		model.YearTo = &yearTo
+	}
+	if row.BasePrice.Valid {
+		basePrice := int(row.BasePrice.Int64)
+		model.BasePrice = &basePrice
+	}
+	return model
+}
+
+func validateCatalogueID(w http.ResponseWriter, kind, id string) bool {
+	if !IsValidUUID(id) {
+		writeCatalogueError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s ID: %s", kind, id))
+		return false
+	}
+	return true
+}
+
+func writeCatalogueError(w http.ResponseWriter, status int, errMsg string) {
+	writeJSON(w, status, UpsertVehicleCatalogueResponse{ErrorMessage: &errMsg})
+}
+
+func writeCatalogueDelete(w http.ResponseWriter, id string, err error) {
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusOK, DeleteVehicleCatalogueResponse{ErrorMessage: &errMsg})
+		return
+	}
+	writeJSON(w, http.StatusOK, DeleteVehicleCatalogueResponse{ID: &id, Deleted: true})
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/webhook"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 500
+)
+
+// WebhookHandler serves the webhook subscriptions of the dealer portal. All
+// handlers sit behind RequireDealerKey and only see the calling dealer's
+// subscriptions.
+type WebhookHandler struct {
+	WebhookStore datastore.WebhookStore
+	// AllowLocalEndpoints accepts plain http and local addresses as endpoints,
+	// for trying webhooks against a local stub.
+	AllowLocalEndpoints bool
+}
+
+func NewWebhookHandler(webhookStore datastore.WebhookStore) *WebhookHandler {
+	return &WebhookHandler{
+		WebhookStore: webhookStore,
+	}
+}
+
+func (h *WebhookHandler) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+
+	rows, err := h.WebhookStore.GetWebhookSubscriptionsByDealerId(dealerID)
+	if err != nil {
+		errMsg := "Failed to get webhook subscriptions"
+		writeJSON(w, http.StatusInternalServerError, WebhookSubscriptionsResponse{ErrorMessage: &errMsg})
+		return
+	}
+	subscriptions := make([]WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subscriptions = append(subscriptions, convertWebhookSubscriptionRow(row))
+	}
+	writeJSON(w, http.StatusOK, WebhookSubscriptionsResponse{Data: &subscriptions})
+}
+
+func (h *WebhookHandler) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		writeUnauthorized(w, "A dealer API key is required")
+		return
+	}
+
+	h.createWebhook(w, r, dealerID)
+}
+
+// createWebhook issues the signing secret, it is shown once in the response.
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request, dealerID string) {
+	var request WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		errMsg := "Failed to generate webhook secret"
+		writeJSON(w, http.StatusInternalServerError, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	now := time.Now().Unix()
+	row := &datastore.WebhookSubscriptionRow{
+		SubscriptionID: uuid.New().String(),
+		DealerID:       dealerID,
+		Secret:         secret,
+		IsActive:       true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := applyWebhookRequest(row, &request, h.AllowLocalEndpoints); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if err := h.WebhookStore.InsertWebhookSubscription(row); err != nil {
+		errMsg := "Failed to save webhook subscription: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	subscription := convertWebhookSubscriptionRow(row)
+	subscription.Secret = &secret
+	writeJSON(w, http.StatusCreated, WebhookSubscriptionResponse{Data: &subscription})
+}
+
+func (h *WebhookHandler) HandleGetWebhookById(w http.ResponseWriter, r *http.Request) {
+	row, status, err := h.lookupSubscription(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	subscription := convertWebhookSubscriptionRow(row)
+	writeJSON(w, http.StatusOK, WebhookSubscriptionResponse{Data: &subscription})
+}
+
+func (h *WebhookHandler) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	row, status, err := h.lookupSubscription(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	var request WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Bad request body", http.StatusBadRequest)
+		return
+	}
+	if err := applyWebhookRequest(row, &request, h.AllowLocalEndpoints); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusBadRequest, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	row.UpdatedAt = time.Now().Unix()
+	if err := h.WebhookStore.UpdateWebhookSubscription(row); err != nil {
+		errMsg := "Failed to save webhook subscription: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	subscription := convertWebhookSubscriptionRow(row)
+	writeJSON(w, http.StatusOK, WebhookSubscriptionResponse{Data: &subscription})
+}
+
+func (h *WebhookHandler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	row, status, err := h.lookupSubscription(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	if err := h.WebhookStore.DeactivateWebhookSubscription(row.DealerID, row.SubscriptionID, time.Now().Unix()); err != nil {
+		errMsg := err.Error()
+		writeJSON(w, http.StatusNotFound, WebhookSubscriptionResponse{ErrorMessage: &errMsg})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetWebhookDeliveries is the delivery log of a subscription, newest
+// first, with every attempt made. ?status narrows it to PENDING, SUCCEEDED or
+// DEAD deliveries.
+func (h *WebhookHandler) HandleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	row, status, err := h.lookupSubscription(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, WebhookDeliveriesResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	deliveryStatus := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("status")))
+	switch deliveryStatus {
+	case "", datastore.WebhookDeliveryPending, datastore.WebhookDeliverySucceeded, datastore.WebhookDeliveryDead:
+	default:
+		errMsg := fmt.Sprintf("invalid status %q, expected PENDING, SUCCEEDED or DEAD", deliveryStatus)
+		writeJSON(w, http.StatusBadRequest, WebhookDeliveriesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	limit := defaultWebhookDeliveryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxWebhookDeliveryLimit {
+			errMsg := fmt.Sprintf("limit must be between 1 and %d", maxWebhookDeliveryLimit)
+			writeJSON(w, http.StatusBadRequest, WebhookDeliveriesResponse{ErrorMessage: &errMsg})
+			return
+		}
+		limit = parsed
+	}
+
+	rows, err := h.WebhookStore.GetWebhookDeliveries(row.SubscriptionID, deliveryStatus, limit)
+	if err != nil {
+		errMsg := "Failed to get webhook deliveries"
+		writeJSON(w, http.StatusInternalServerError, WebhookDeliveriesResponse{ErrorMessage: &errMsg})
+		return
+	}
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, delivery := range rows {
+		deliveries = append(deliveries, convertWebhookDeliveryRow(delivery))
+	}
+	writeJSON(w, http.StatusOK, WebhookDeliveriesResponse{Data: &deliveries})
+}
+
+// HandleRedeliverWebhook queues the payload of any earlier delivery again,
+// dead ones included. The new delivery is sent by the dispatcher.
+func (h *WebhookHandler) HandleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, status, err := h.lookupSubscription(r)
+	if err != nil {
+		errMsg := err.Error()
+		writeJSON(w, status, WebhookDeliveryResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if !subscription.IsActive {
+		errMsg := "Webhook subscription is inactive: " + subscription.SubscriptionID
+		writeJSON(w, http.StatusConflict, WebhookDeliveryResponse{ErrorMessage: &errMsg})
+		return
+	}
+	deliveryID := r.PathValue("deliveryID")
+	if !IsValidUUID(deliveryID) {
+		errMsg := "Invalid delivery ID: " + deliveryID
+		writeJSON(w, http.StatusBadRequest, WebhookDeliveryResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	original, err := h.WebhookStore.GetWebhookDeliveryById(subscription.SubscriptionID, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		errMsg := "Webhook delivery not found: " + deliveryID
+		writeJSON(w, http.StatusNotFound, WebhookDeliveryResponse{ErrorMessage: &errMsg})
+		return
+	}
+	if err != nil {
+		errMsg := "Failed to get webhook delivery " + deliveryID
+		writeJSON(w, http.StatusInternalServerError, WebhookDeliveryResponse{ErrorMessage: &errMsg})
+		return
+	}
+
+	row, err := h.WebhookStore.InsertRedelivery(original, time.Now().Unix())
+	if err != nil {
+		errMsg := "Failed to queue redelivery: " + err.Error()
+		writeJSON(w, http.StatusInternalServerError, WebhookDeliveryResponse{ErrorMessage: &errMsg})
+		return
+	}
+	delivery := convertWebhookDeliveryRow(row)
+	writeJSON(w, http.StatusAccepted, WebhookDeliveryResponse{Data: &delivery})
+}
+
+// lookupSubscription answers 404 for subscriptions of other dealers so their
+// existence is not revealed.
+func (h *WebhookHandler) lookupSubscription(r *http.Request) (*datastore.WebhookSubscriptionRow, int, error) {
+	dealerID, ok := dealerIDFromContext(r.Context())
+	if !ok {
+		return nil, http.StatusUnauthorized, errors.New("A dealer API key is required")
+	}
+	subscriptionID := r.PathValue("subscriptionID")
+	if !IsValidUUID(subscriptionID) {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid subscription ID: %s", subscriptionID)
+	}
+	row, err := h.WebhookStore.GetWebhookSubscriptionById(dealerID, subscriptionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, fmt.Errorf("Webhook subscription not found: %s", subscriptionID)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to get webhook subscription %s", subscriptionID)
+	}
+	return row, http.StatusOK, nil
+}
+
+func applyWebhookRequest(row *datastore.WebhookSubscriptionRow, request *WebhookSubscriptionRequest, allowLocal bool) error {
+	endpoint, err := webhook.CheckURL(request.URL, allowLocal)
+	if err != nil {
+		return err
+	}
+	if len(request.EventTypes) == 0 {
+		return fmt.Errorf("event_types is required, one or more of %s", strings.Join(webhook.EventTypes, ", "))
+	}
+	var eventTypes []string
+	for _, eventType := range request.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !slices.Contains(webhook.EventTypes, eventType) {
+			return fmt.Errorf("unknown event type %q, expected one of %s", eventType, strings.Join(webhook.EventTypes, ", "))
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	row.URL = endpoint.String()
+	row.EventTypes = eventTypes
+	row.Description = sql.NullString{}
+	if request.Description != nil && strings.TrimSpace(*request.Description) != "" {
+		row.Description = sql.NullString{String: strings.TrimSpace(*request.Description), Valid: true}
+	}
+	if request.IsActive != nil {
+		row.IsActive = *request.IsActive
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Message is a rendered notification addressed to one recipient.
+type Message struct {
+	NotificationID string
+	To             string
+	Subject        string
+	Body           string
+}
+
+// Sender delivers messages over one channel.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTPSender sends plain text email through an SMTP relay, upgrading to TLS
+// when the server offers STARTTLS.
+type SMTPSender struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// NewSMTPSender takes smtp://[user:password@]host[:port]?from=<address>.
+func NewSMTPSender(target string) (*SMTPSender, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "smtp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("expected smtp://host:port, got %q", target)
+	}
+	from := u.Query().Get("from")
+	if from == "" {
+		return nil, errors.New("the from query parameter is required")
+	}
+
+	sender := &SMTPSender{Addr: u.Host, From: from}
+	if u.Port() == "" {
+		sender.Addr = net.JoinHostPort(u.Hostname(), "25")
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		sender.Auth = smtp.PlainAuth("", u.User.Username(), password, u.Hostname())
+	}
+	return sender, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	var header strings.Builder
+	fmt.Fprintf(&header, "From: %s\r\n", s.From)
+	fmt.Fprintf(&header, "To: %s\r\n", message.To)
+	fmt.Fprintf(&header, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&header, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&header, "Message-ID: <%s@alphaloan>\r\n", message.NotificationID)
+	header.WriteString("MIME-Version: 1.0\r\n")
+	header.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	header.WriteString("\r\n")
+
+	body := strings.ReplaceAll(message.Body, "\n", "\r\n")
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{message.To}, []byte(header.String()+body+"\r\n"))
+}
+
+// SMSGatewaySender POSTs {"to", "message", "reference"} as JSON to an SMS
+// gateway. A user in the URL is sent as a bearer token. Any 2xx answer counts
+// as accepted.
+type SMSGatewaySender struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func NewSMSGatewaySender(target string) (*SMSGatewaySender, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("expected an http(s) gateway URL, got %q", target)
+	}
+	sender := &SMSGatewaySender{Client: &http.Client{Timeout: 10 * time.Second}}
+	if u.User != nil {
+		sender.Token = u.User.Username()
+		u.User = nil
+	}
+	sender.URL = u.String()
+	return sender, nil
+}
+
+func (s *SMSGatewaySender) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(map[string]string{
+		"to":        message.To,
+		"message":   message.Body,
+		"reference": message.NotificationID,
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("SMS gateway answered %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/worker"
+)
+
+const (
+	DefaultBatchSize   = 100
+	DefaultMaxAttempts = 5
+	DefaultBaseBackoff = time.Minute
+	DefaultMaxBackoff  = time.Hour
+)
+
+// Dispatcher renders and sends pending notifications on the channels it has
+// a sender for. Notifications of other channels stay pending. A failed send
+// is retried with exponential backoff and marked FAILED after MaxAttempts, a
+// notification that cannot be rendered fails straight away.
+type Dispatcher struct {
+	Store       *datastore.NotificationStore
+	Senders     map[string]Sender
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+type Summary struct {
+	Sent     int
+	Retrying int
+	Failed   int
+}
+
+func NewDispatcher(store *datastore.NotificationStore) *Dispatcher {
+	return &Dispatcher{
+		Store:       store,
+		Senders:     make(map[string]Sender),
+		BatchSize:   DefaultBatchSize,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// Start sends due notifications on every tick until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, interval, func(ctx context.Context) {
+		summary, err := d.Run(ctx)
+		if err != nil {
+			log.Println("notification dispatch failed:", err)
+		} else if summary.Sent+summary.Retrying+summary.Failed > 0 {
+			log.Printf("notification dispatch: %d sent, %d retrying, %d failed\n",
+				summary.Sent, summary.Retrying, summary.Failed)
+		}
+	})
+}
+
+func (d *Dispatcher) Run(ctx context.Context) (*Summary, error) {
+	summary := &Summary{}
+	for _, channel := range []string{datastore.NotificationChannelEmail, datastore.NotificationChannelSMS} {
+		sender, ok := d.Senders[channel]
+		if !ok {
+			continue
+		}
+		due, err := d.Store.GetDueNotifications(channel, time.Now().Unix(), d.BatchSize)
+		if err != nil {
+			return summary, err
+		}
+		for _, notification := range due {
+			if ctx.Err() != nil {
+				return summary, ctx.Err()
+			}
+			if err := d.send(ctx, sender, notification); err != nil {
+				return summary, err
+			}
+			switch notification.Status {
+			case datastore.NotificationSent:
+				summary.Sent++
+			case datastore.NotificationFailed:
+				summary.Failed++
+			default:
+				summary.Retrying++
+			}
+		}
+	}
+	return summary, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, sender Sender, notification *datastore.NotificationRow) error {
+	now := time.Now()
+	attempt := notification.Attempts + 1
+	nextAttemptAt := notification.NextAttemptAt
+
+	err := d.deliver(ctx, sender, notification)
+	switch {
+	case err == nil:
+		notification.Status = datastore.NotificationSent
+		notification.SentAt = sql.NullInt64{Int64: now.Unix(), Valid: true}
+		notification.LastError = sql.NullString{}
+	case !notification.Subject.Valid && !notification.Body.Valid, attempt >= d.MaxAttempts:
+		notification.Status = datastore.NotificationFailed
+		notification.LastError = sql.NullString{String: err.Error(), Valid: true}
+	default:
+		notification.LastError = sql.NullString{String: err.Error(), Valid: true}
+		nextAttemptAt = now.Add(worker.Backoff(attempt, d.BaseBackoff, d.MaxBackoff)).Unix()
+	}
+	if err != nil {
+		log.Printf("notification %s (%s %s) failed on attempt %d: %v\n",
+			notification.NotificationID, notification.Template, notification.Channel, attempt, err)
+	}
+	return d.Store.RecordNotificationAttempt(notification, nextAttemptAt)
+}
+
+// deliver renders the notification into its subject and body and sends it.
+// Subject and body stay empty when rendering fails.
+func (d *Dispatcher) deliver(ctx context.Context, sender Sender, notification *datastore.NotificationRow) error {
+	notification.Subject = sql.NullString{}
+	notification.Body = sql.NullString{}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(notification.Data), &data); err != nil {
+		return err
+	}
+	rendered, err := Render(notification.Template, notification.Channel, notification.Locale, data)
+	if err != nil {
+		return err
+	}
+	notification.Subject = sql.NullString{String: rendered.Subject, Valid: rendered.Subject != ""}
+	notification.Body = sql.NullString{String: rendered.Body, Valid: true}
+
+	return sender.Send(ctx, Message{
+		NotificationID: notification.NotificationID,
+		To:             notification.Recipient,
+		Subject:        rendered.Subject,
+		Body:           rendered.Body,
+	})
+}
@@ -0,0 +1,159 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/alphaloan/vehicle/notification/notificationtest"
+	"github.com/google/uuid"
+)
+
+// newTestDispatcher sends email through a fake SMTP server and SMS through a
+// fake gateway.
+func newTestDispatcher(t *testing.T) (*Dispatcher, *sql.DB, *notificationtest.SMTPServer, *notificationtest.SMSServer) {
+	smtpServer := notificationtest.NewSMTPServer()
+	t.Cleanup(func() { smtpServer.Close() })
+	smsServer := notificationtest.NewSMSServer()
+	t.Cleanup(smsServer.Close)
+
+	emailSender, err := NewSMTPSender(smtpServer.URL("noreply@alphaloan.test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	smsSender, err := NewSMSGatewaySender(strings.Replace(smsServer.URL, "://", "://gateway-token@", 1) + "/sms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := datastoretest.Open(t)
+	dispatcher := NewDispatcher(datastore.NewNotificationStore(db))
+	dispatcher.BaseBackoff = 0
+	dispatcher.Senders[datastore.NotificationChannelEmail] = emailSender
+	dispatcher.Senders[datastore.NotificationChannelSMS] = smsSender
+	return dispatcher, db, smtpServer, smsServer
+}
+
+// queueReminder queues an installment reminder to a customer with an email
+// address and a phone number in locale.
+func queueReminder(t *testing.T, store *datastore.NotificationStore, db *sql.DB, locale string) string {
+	t.Helper()
+	customerID := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO loan_customers (customer_id, id_card_number, full_name, birth_date, phone_number, email, address_street, address_city, locale)
+		VALUES ($1, $2, 'Budi', '1990-01-01', '+6281200000000', 'budi@example.com', 'Jl. Sudirman', 'Jakarta', $3)`, customerID, customerID, locale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err := store.QueueNotification(&datastore.NotificationRequest{
+		CustomerID: customerID,
+		Template:   datastore.NotificationInstallmentDue,
+		DedupeKey:  "installment_due:" + customerID,
+		Data: map[string]any{
+			"submission_id":      "0f8fad5b-d9cb-469f-a165-70867728950e",
+			"installment_number": 3,
+			"due_date":           "2026-08-17",
+			"amount_due":         4250000,
+		},
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued != 2 {
+		t.Fatalf("queued on %d channels, want email and SMS", queued)
+	}
+	return customerID
+}
+
+func sendLog(t *testing.T, store *datastore.NotificationStore, customerID string) map[string]*datastore.NotificationRow {
+	t.Helper()
+	rows, err := store.GetNotifications(datastore.NotificationFilter{CustomerID: customerID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d notifications logged, want one per channel", len(rows))
+	}
+	byChannel := make(map[string]*datastore.NotificationRow)
+	for _, row := range rows {
+		byChannel[row.Channel] = row
+	}
+	return byChannel
+}
+
+func TestDispatcherSendsOnBothChannels(t *testing.T) {
+	dispatcher, db, smtpServer, smsServer := newTestDispatcher(t)
+	customerID := queueReminder(t, dispatcher.Store, db, "id")
+
+	summary, err := dispatcher.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Sent != 2 {
+		t.Fatalf("summary = %+v, want both channels sent", summary)
+	}
+
+	emails := smtpServer.Emails()
+	if len(emails) != 1 {
+		t.Fatalf("%d emails received, want 1", len(emails))
+	}
+	email := emails[0]
+	if email.From != "noreply@alphaloan.test" || email.To != "budi@example.com" ||
+		email.Subject != "Angsuran ke-3 jatuh tempo pada 17 Agustus 2026" || !strings.Contains(email.Body, "4.250.000") {
+		t.Errorf("email = %+v, want the Indonesian reminder to budi@example.com", email)
+	}
+
+	messages := smsServer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d text messages received, want 1", len(messages))
+	}
+	if sms := messages[0]; sms.To != "+6281200000000" || sms.Token != "gateway-token" || !strings.Contains(sms.Message, "0F8FAD5B") {
+		t.Errorf("sms = %+v, want the reminder to +6281200000000 with the gateway token", sms)
+	}
+
+	for channel, row := range sendLog(t, dispatcher.Store, customerID) {
+		if row.Status != datastore.NotificationSent || row.Attempts != 1 || !row.SentAt.Valid || !row.Body.Valid {
+			t.Errorf("%s log = %s after %d attempts, want SENT with the body kept", channel, row.Status, row.Attempts)
+		}
+		if channel == datastore.NotificationChannelSMS && messages[0].Reference != row.NotificationID {
+			t.Errorf("sms reference = %s, want the notification id %s", messages[0].Reference, row.NotificationID)
+		}
+	}
+}
+
+func TestDispatcherRetriesThenFails(t *testing.T) {
+	dispatcher, db, _, smsServer := newTestDispatcher(t)
+	dispatcher.MaxAttempts = 2
+	delete(dispatcher.Senders, datastore.NotificationChannelEmail)
+	smsServer.SetStatus(http.StatusServiceUnavailable)
+	customerID := queueReminder(t, dispatcher.Store, db, "en")
+
+	summary, err := dispatcher.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Retrying != 1 {
+		t.Fatalf("first run = %+v, want the SMS retrying", summary)
+	}
+	summary, err = dispatcher.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Failed != 1 {
+		t.Fatalf("second run = %+v, want the SMS failed", summary)
+	}
+
+	log := sendLog(t, dispatcher.Store, customerID)
+	if sms := log[datastore.NotificationChannelSMS]; sms.Status != datastore.NotificationFailed || sms.Attempts != 2 ||
+		!strings.Contains(sms.LastError.String, "503") {
+		t.Errorf("sms log = %s after %d attempts (%s), want FAILED after two 503s", sms.Status, sms.Attempts, sms.LastError.String)
+	}
+	if email := log[datastore.NotificationChannelEmail]; email.Status != datastore.NotificationPending || email.Attempts != 0 {
+		t.Errorf("email log = %s after %d attempts, want PENDING without a sender", email.Status, email.Attempts)
+	}
+}
@@ -0,0 +1,255 @@
+// Package notificationtest runs fake SMTP and SMS gateway servers that accept
+// every message and keep it, for testing notifications without sending them.
+// They speak just enough of their protocols for SMTPSender and
+// SMSGatewaySender.
+package notificationtest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Email is a message as the fake SMTP server received it, with the subject
+// decoded.
+type Email struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// SMTPServer is a fake SMTP server without TLS or authentication.
+type SMTPServer struct {
+	Addr string
+
+	listener net.Listener
+	onEmail  func(Email)
+
+	mu     sync.Mutex
+	emails []Email
+}
+
+// ListenSMTP listens on addr; Serve accepts connections. onEmail, when set,
+// is called with every email received.
+func ListenSMTP(addr string, onEmail func(Email)) (*SMTPServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPServer{Addr: listener.Addr().String(), listener: listener, onEmail: onEmail}, nil
+}
+
+// NewSMTPServer starts a server on a loopback port. The caller closes it.
+func NewSMTPServer() *SMTPServer {
+	server, err := ListenSMTP("127.0.0.1:0", nil)
+	if err != nil {
+		panic(fmt.Sprintf("notificationtest: listening: %v", err))
+	}
+	go server.Serve()
+	return server
+}
+
+// URL is the target NewSMTPSender takes to send from from through the server.
+func (s *SMTPServer) URL(from string) string {
+	return "smtp://" + s.Addr + "?from=" + url.QueryEscape(from)
+}
+
+// Emails returns the emails received so far.
+func (s *SMTPServer) Emails() []Email {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Email(nil), s.emails...)
+}
+
+// Serve accepts connections until the server is closed.
+func (s *SMTPServer) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *SMTPServer) Close() error {
+	return s.listener.Close()
+}
+
+func (s *SMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	var from string
+	reply("220 alphaloan fake SMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		verb, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250 alphaloan")
+		case "MAIL":
+			from = reversePath(args)
+			reply("250 OK")
+		case "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" || line == ".\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			email, err := readEmail(from, data.String())
+			if err != nil {
+				reply("554 " + err.Error())
+				continue
+			}
+			s.received(email)
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *SMTPServer) received(email Email) {
+	s.mu.Lock()
+	s.emails = append(s.emails, email)
+	s.mu.Unlock()
+	if s.onEmail != nil {
+		s.onEmail(email)
+	}
+}
+
+// reversePath reads the address out of "FROM:<address> [parameters]".
+func reversePath(args string) string {
+	_, path, _ := strings.Cut(args, ":")
+	path, _, _ = strings.Cut(strings.TrimSpace(path), " ")
+	return strings.Trim(path, "<>")
+}
+
+func readEmail(from, data string) (Email, error) {
+	message, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		return Email{}, err
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		subject = message.Header.Get("Subject")
+	}
+	body, err := io.ReadAll(message.Body)
+	if err != nil {
+		return Email{}, err
+	}
+	return Email{
+		From:    from,
+		To:      message.Header.Get("To"),
+		Subject: subject,
+		Body:    strings.TrimRight(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n"),
+	}, nil
+}
+
+// SMS is a message as the fake SMS gateway received it.
+type SMS struct {
+	To        string `json:"to"`
+	Message   string `json:"message"`
+	Reference string `json:"reference"`
+	Token     string `json:"-"`
+}
+
+// SMSGateway is a fake SMS gateway. It answers 202 to a well-formed message,
+// or the status set with SetStatus.
+type SMSGateway struct {
+	onMessage func(SMS)
+
+	mu       sync.Mutex
+	status   int
+	messages []SMS
+}
+
+// NewSMSGateway returns the gateway handler. onMessage, when set, is called
+// with every message accepted.
+func NewSMSGateway(onMessage func(SMS)) *SMSGateway {
+	return &SMSGateway{onMessage: onMessage}
+}
+
+// SetStatus makes the gateway answer status instead of accepting messages,
+// zero restores the default.
+func (g *SMSGateway) SetStatus(status int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status = status
+}
+
+// Messages returns the messages accepted so far.
+func (g *SMSGateway) Messages() []SMS {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]SMS(nil), g.messages...)
+}
+
+func (g *SMSGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var message SMS
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil || message.To == "" {
+		http.Error(w, `expected {"to", "message"}`, http.StatusBadRequest)
+		return
+	}
+	message.Token, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	g.mu.Lock()
+	status := g.status
+	if status == 0 {
+		g.messages = append(g.messages, message)
+	}
+	g.mu.Unlock()
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if g.onMessage != nil {
+		g.onMessage(message)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// SMSServer is an SMSGateway on a local httptest server; its URL is the
+// target NewSMSGatewaySender takes.
+type SMSServer struct {
+	*httptest.Server
+	*SMSGateway
+}
+
+// NewSMSServer starts a fake gateway. The caller closes it.
+func NewSMSServer() *SMSServer {
+	gateway := NewSMSGateway(nil)
+	return &SMSServer{Server: httptest.NewServer(gateway), SMSGateway: gateway}
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+const DefaultReminderDays = 3
+
+// Reminders queues a reminder for every unpaid installment falling due within
+// DaysBefore days and an overdue notice for every installment past its due
+// date. Each is queued once per installment however often Run is called.
+type Reminders struct {
+	Store      *datastore.NotificationStore
+	DaysBefore int
+}
+
+func NewReminders(store *datastore.NotificationStore) *Reminders {
+	return &Reminders{
+		Store:      store,
+		DaysBefore: DefaultReminderDays,
+	}
+}
+
+// Run reports how many notifications were queued.
+func (r *Reminders) Run(asOf time.Time) (int, error) {
+	today := asOf.Format(time.DateOnly)
+	installments, err := r.Store.GetInstallmentReminders(asOf.AddDate(0, 0, r.DaysBefore).Format(time.DateOnly))
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, installment := range installments {
+		template := datastore.NotificationInstallmentDue
+		if installment.DueDate < today {
+			template = datastore.NotificationInstallmentOverdue
+		}
+		n, err := r.Store.QueueNotification(&datastore.NotificationRequest{
+			CustomerID:   installment.CustomerID,
+			SubmissionID: sql.NullString{String: installment.SubmissionID, Valid: true},
+			Template:     template,
+			DedupeKey:    fmt.Sprintf("%s:%s:%d", template, installment.SubmissionID, installment.InstallmentNumber),
+			Data: map[string]any{
+				"submission_id":      installment.SubmissionID,
+				"installment_number": installment.InstallmentNumber,
+				"due_date":           installment.DueDate,
+				"amount_due":         installment.AmountDue,
+			},
+			CreatedAt: time.Now().Unix(),
+		})
+		if err != nil {
+			return queued, fmt.Errorf("submission %s installment %d: %w", installment.SubmissionID, installment.InstallmentNumber, err)
+		}
+		queued += n
+	}
+	return queued, nil
+}
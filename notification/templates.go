@@ -0,0 +1,173 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+const DefaultLocale = "en"
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Locales are the languages every template is written in.
+var Locales = []string{"en", "id"}
+
+// Templates are the messages customers can receive.
+var Templates = []string{
+	datastore.NotificationSubmissionReceived,
+	datastore.NotificationSubmissionApproved,
+	datastore.NotificationSubmissionRejected,
+	datastore.NotificationInstallmentDue,
+	datastore.NotificationInstallmentOverdue,
+}
+
+type localeFormat struct {
+	thousands string
+	months    [12]string
+}
+
+var localeFormats = map[string]localeFormat{
+	"en": {
+		thousands: ",",
+		months: [12]string{"January", "February", "March", "April", "May", "June", "July",
+			"August", "September", "October", "November", "December"},
+	},
+	"id": {
+		thousands: ".",
+		months: [12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli",
+			"Agustus", "September", "Oktober", "November", "Desember"},
+	},
+}
+
+// parsed holds every template by locale and name. Parsing at start up makes a
+// broken template stop the server instead of failing sends.
+var parsed = parseTemplates()
+
+// Rendered is a message ready for a channel. SMS messages have no subject.
+type Rendered struct {
+	Subject string
+	Body    string
+}
+
+func IsSupportedLocale(locale string) bool {
+	_, ok := localeFormats[locale]
+	return ok
+}
+
+// Render fills in the template for channel in the customer's locale, falling
+// back to DefaultLocale for locales without templates.
+func Render(name, channel, locale string, data map[string]any) (*Rendered, error) {
+	if !IsSupportedLocale(locale) {
+		locale = DefaultLocale
+	}
+	tmpl, ok := parsed[locale][name]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification template %q", name)
+	}
+
+	execute := func(block string) (string, error) {
+		var out bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&out, block, data); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(out.String()), nil
+	}
+
+	switch channel {
+	case datastore.NotificationChannelEmail:
+		subject, err := execute("email_subject")
+		if err != nil {
+			return nil, err
+		}
+		body, err := execute("email_body")
+		if err != nil {
+			return nil, err
+		}
+		return &Rendered{Subject: subject, Body: body}, nil
+	case datastore.NotificationChannelSMS:
+		body, err := execute("sms")
+		if err != nil {
+			return nil, err
+		}
+		return &Rendered{Body: body}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification channel %q", channel)
+	}
+}
+
+func parseTemplates() map[string]map[string]*template.Template {
+	templates := make(map[string]map[string]*template.Template)
+	for _, locale := range Locales {
+		format := localeFormats[locale]
+		funcs := template.FuncMap{
+			"money": format.money,
+			"date":  format.date,
+			"short": shortID,
+		}
+		templates[locale] = make(map[string]*template.Template)
+		for _, name := range Templates {
+			file := path.Join("templates", locale, name+".tmpl")
+			tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").ParseFS(templateFiles, file)
+			if err != nil {
+				panic(fmt.Sprintf("notification template %s: %v", file, err))
+			}
+			templates[locale][name] = tmpl
+		}
+	}
+	return templates
+}
+
+// money formats a whole amount with the locale's thousands separator. Data
+// decoded from JSON carries numbers as float64.
+func (f localeFormat) money(value any) (string, error) {
+	var amount int64
+	switch v := value.(type) {
+	case int:
+		amount = int64(v)
+	case int64:
+		amount = v
+	case float64:
+		amount = int64(v)
+	default:
+		return "", fmt.Errorf("money: unexpected %T", value)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteString(f.thousands)
+		}
+		out.WriteRune(digit)
+	}
+	return sign + out.String(), nil
+}
+
+// date spells out a YYYY-MM-DD date with the locale's month names.
+func (f localeFormat) date(value string) (string, error) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s %d", date.Day(), f.months[date.Month()-1], date.Year()), nil
+}
+
+// shortID keeps SMS messages short, the first block of a UUID is enough for a
+// customer to quote.
+func shortID(id string) string {
+	before, _, _ := strings.Cut(id, "-")
+	return strings.ToUpper(before)
+}
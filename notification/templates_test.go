@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alphaloan/vehicle/datastore"
+)
+
+var testTemplateData = map[string]any{
+	"customer_name":      "Budi",
+	"submission_id":      "0f8fad5b-d9cb-469f-a165-70867728950e",
+	"loan_amount":        float64(125000000),
+	"tenure_month":       float64(36),
+	"vehicle_brand":      "Toyota",
+	"vehicle_model":      "Avanza",
+	"manufacturing_year": float64(2020),
+	"installment_number": float64(3),
+	"due_date":           "2026-08-17",
+	"amount_due":         float64(4250000),
+	"reason":             "income too low",
+}
+
+func TestEveryTemplateRendersInEveryLocale(t *testing.T) {
+	for _, locale := range Locales {
+		for _, name := range Templates {
+			email, err := Render(name, datastore.NotificationChannelEmail, locale, testTemplateData)
+			if err != nil {
+				t.Errorf("%s %s email: %v", locale, name, err)
+				continue
+			}
+			if email.Subject == "" || email.Body == "" {
+				t.Errorf("%s %s email = %+v, want a subject and a body", locale, name, email)
+			}
+
+			sms, err := Render(name, datastore.NotificationChannelSMS, locale, testTemplateData)
+			if err != nil {
+				t.Errorf("%s %s sms: %v", locale, name, err)
+				continue
+			}
+			if sms.Subject != "" || sms.Body == "" || strings.Contains(sms.Body, "\n") {
+				t.Errorf("%s %s sms = %+v, want one line without a subject", locale, name, sms)
+			}
+		}
+	}
+}
+
+func TestRenderFormatsForTheLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{"en", []string{"4,250,000", "17 August 2026", "0F8FAD5B"}},
+		{"id", []string{"4.250.000", "17 Agustus 2026", "0F8FAD5B"}},
+		{"fr", []string{"4,250,000", "17 August 2026"}},
+	}
+	for _, test := range tests {
+		rendered, err := Render(datastore.NotificationInstallmentDue, datastore.NotificationChannelSMS, test.locale, testTemplateData)
+		if err != nil {
+			t.Fatalf("%s: %v", test.locale, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(rendered.Body, want) {
+				t.Errorf("%s sms %q does not contain %q", test.locale, rendered.Body, want)
+			}
+		}
+	}
+}
+
+func TestRenderRefusesMissingData(t *testing.T) {
+	if _, err := Render(datastore.NotificationInstallmentDue, datastore.NotificationChannelSMS, "en", map[string]any{}); err == nil {
+		t.Error("rendered a reminder without an amount or due date")
+	}
+	if _, err := Render("unknown", datastore.NotificationChannelSMS, "en", testTemplateData); err == nil {
+		t.Error("rendered an unknown template")
+	}
+	if _, err := Render(datastore.NotificationSubmissionReceived, "FAX", "en", testTemplateData); err == nil {
+		t.Error("rendered for an unknown channel")
+	}
+}
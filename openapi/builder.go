@@ -0,0 +1,336 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const ContentTypeJSON = "application/json"
+
+// Endpoint describes one operation in Go terms; Build turns a list of them
+// into a document.
+type Endpoint struct {
+	Method  string
+	Path    string
+	Handler string
+	// OperationID defaults to one derived from Handler and Method.
+	OperationID string
+	Tag         string
+	Summary     string
+	Description string
+	// Security names the schemes of Build that protect the operation.
+	// OptionalSecurity lists schemes that change the response when present.
+	Security         []string
+	OptionalSecurity []string
+	// Path parameters default to a UUID when their name ends in ID and to a
+	// string otherwise; PathParams overrides them by name.
+	PathParams []Param
+	Query      []Param
+	Headers    []Param
+	// Request is the JSON body, or a *Schema for bodies such as forms.
+	// RequestTypes replaces the default application/json. RawRequestTypes
+	// are alternative bodies sent as they are, such as a CSV file.
+	Request         any
+	RequestTypes    []string
+	RawRequestTypes []string
+	Responses       []Reply
+	Deprecated      bool
+}
+
+// ID is the operation id of the endpoint.
+func (e Endpoint) ID() string {
+	if e.OperationID != "" {
+		return e.OperationID
+	}
+	return operationID(e.Handler, e.Method)
+}
+
+type Param struct {
+	Name        string
+	Description string
+	// Type is string unless set to integer, number or boolean.
+	Type     string
+	Format   string
+	Enum     []string
+	Required bool
+}
+
+// Reply documents the statuses answered with Body, a JSON value, or with the
+// non-JSON ContentTypes. A nil Body and no ContentTypes means no content.
+type Reply struct {
+	Statuses     []int
+	Body         any
+	ContentTypes []string
+	Description  string
+	Headers      []Param
+}
+
+// JSON replies with body under every status.
+func JSON(body any, statuses ...int) Reply {
+	return Reply{Statuses: statuses, Body: body}
+}
+
+// Raw replies with a non-JSON representation such as a CSV file or the plain
+// text errors of http.Error.
+func Raw(contentType string, statuses ...int) Reply {
+	return Reply{Statuses: statuses, ContentTypes: []string{contentType}}
+}
+
+// Empty replies without a body.
+func Empty(statuses ...int) Reply {
+	return Reply{Statuses: statuses}
+}
+
+// BuildOptions holds what the document says about the API as a whole.
+type BuildOptions struct {
+	Info            Info
+	Servers         []Server
+	Tags            []Tag
+	SecuritySchemes map[string]*SecurityScheme
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build assembles the document, failing on anything a client could not rely
+// on: duplicate operations, unknown security schemes, undocumented path
+// parameters or types without a schema.
+func Build(options BuildOptions, endpoints []Endpoint) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    options.Info,
+		Servers: options.Servers,
+		Tags:    options.Tags,
+		Paths:   make(map[string]*PathItem),
+	}
+	schemas := NewSchemas()
+	operationIDs := make(map[string]bool)
+
+	for _, endpoint := range endpoints {
+		operation, err := buildOperation(schemas, options, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", endpoint.Method, endpoint.Path, err)
+		}
+		if operationIDs[operation.OperationID] {
+			return nil, fmt.Errorf("%s %s: duplicate operation id %s", endpoint.Method, endpoint.Path, operation.OperationID)
+		}
+		operationIDs[operation.OperationID] = true
+
+		item, ok := doc.Paths[endpoint.Path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[endpoint.Path] = item
+		}
+		slot := item.operation(endpoint.Method)
+		if slot == nil {
+			return nil, fmt.Errorf("%s %s: unsupported method", endpoint.Method, endpoint.Path)
+		}
+		if *slot != nil {
+			return nil, fmt.Errorf("%s %s: documented twice", endpoint.Method, endpoint.Path)
+		}
+		*slot = operation
+	}
+
+	doc.Components = Components{Schemas: schemas.Components(), SecuritySchemes: options.SecuritySchemes}
+	return doc, nil
+}
+
+func buildOperation(schemas *Schemas, options BuildOptions, endpoint Endpoint) (*Operation, error) {
+	operation := &Operation{
+		OperationID: endpoint.ID(),
+		Summary:     endpoint.Summary,
+		Description: endpoint.Description,
+		Responses:   make(map[string]*Response),
+		Deprecated:  endpoint.Deprecated,
+		Handler:     endpoint.Handler,
+	}
+	if endpoint.Tag != "" {
+		operation.Tags = []string{endpoint.Tag}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(endpoint.Path, -1) {
+		param := Param{Name: match[1]}
+		if strings.HasSuffix(param.Name, "ID") {
+			param.Format = "uuid"
+		}
+		if i := slices.IndexFunc(endpoint.PathParams, func(p Param) bool { return p.Name == param.Name }); i >= 0 {
+			param = endpoint.PathParams[i]
+		}
+		param.Required = true
+		operation.Parameters = append(operation.Parameters, parameter(param, "path"))
+	}
+	for _, param := range endpoint.PathParams {
+		if !strings.Contains(endpoint.Path, "{"+param.Name+"}") {
+			return nil, fmt.Errorf("path parameter %s is not in the path", param.Name)
+		}
+	}
+	for _, param := range endpoint.Query {
+		operation.Parameters = append(operation.Parameters, parameter(param, "query"))
+	}
+	for _, param := range endpoint.Headers {
+		operation.Parameters = append(operation.Parameters, parameter(param, "header"))
+	}
+
+	if endpoint.Request != nil || len(endpoint.RawRequestTypes) > 0 {
+		operation.RequestBody = &RequestBody{Required: true, Content: make(map[string]*MediaType)}
+	}
+	if endpoint.Request != nil {
+		schema, err := schemas.SchemaOf(endpoint.Request)
+		if err != nil {
+			return nil, err
+		}
+		types := endpoint.RequestTypes
+		if len(types) == 0 {
+			types = []string{ContentTypeJSON}
+		}
+		for _, contentType := range types {
+			operation.RequestBody.Content[contentType] = &MediaType{Schema: schema}
+		}
+	}
+	for _, contentType := range endpoint.RawRequestTypes {
+		operation.RequestBody.Content[contentType] = &MediaType{Schema: &Schema{Type: "string", Format: rawFormat(contentType)}}
+	}
+
+	for _, reply := range endpoint.Responses {
+		if err := addReply(schemas, operation, reply); err != nil {
+			return nil, err
+		}
+	}
+	if len(operation.Responses) == 0 {
+		return nil, fmt.Errorf("no responses documented")
+	}
+
+	for _, name := range endpoint.Security {
+		if options.SecuritySchemes[name] == nil {
+			return nil, fmt.Errorf("unknown security scheme %s", name)
+		}
+		operation.Security = append(operation.Security, map[string][]string{name: {}})
+	}
+	if len(endpoint.OptionalSecurity) > 0 {
+		operation.Security = append(operation.Security, map[string][]string{})
+		for _, name := range endpoint.OptionalSecurity {
+			if options.SecuritySchemes[name] == nil {
+				return nil, fmt.Errorf("unknown security scheme %s", name)
+			}
+			operation.Security = append(operation.Security, map[string][]string{name: {}})
+		}
+	}
+	return operation, nil
+}
+
+// addReply merges the reply into the responses, so that a status answered
+// both as JSON and as plain text lists both representations. A status answered
+// with different JSON bodies offers them as anyOf, since error envelopes look
+// alike and would match more than one alternative of a oneOf.
+func addReply(schemas *Schemas, operation *Operation, reply Reply) error {
+	var body *Schema
+	if reply.Body != nil {
+		schema, err := schemas.SchemaOf(reply.Body)
+		if err != nil {
+			return err
+		}
+		body = schema
+	}
+
+	for _, status := range reply.Statuses {
+		code := strconv.Itoa(status)
+		response, ok := operation.Responses[code]
+		if !ok {
+			response = &Response{Description: reply.Description}
+			if response.Description == "" {
+				response.Description = http.StatusText(status)
+			}
+			operation.Responses[code] = response
+		}
+
+		if media := response.Content[ContentTypeJSON]; body != nil && media != nil {
+			if media.Schema.AnyOf == nil {
+				media.Schema = &Schema{AnyOf: []*Schema{media.Schema}}
+			}
+			media.Schema.AnyOf = append(media.Schema.AnyOf, body)
+		} else if body != nil {
+			if err := addContent(response, ContentTypeJSON, body, code); err != nil {
+				return err
+			}
+		}
+		for _, contentType := range reply.ContentTypes {
+			if err := addContent(response, contentType, &Schema{Type: "string", Format: rawFormat(contentType)}, code); err != nil {
+				return err
+			}
+		}
+		for _, header := range reply.Headers {
+			if response.Headers == nil {
+				response.Headers = make(map[string]*Header)
+			}
+			response.Headers[header.Name] = &Header{Description: header.Description, Schema: parameter(header, "header").Schema}
+		}
+	}
+	return nil
+}
+
+func addContent(response *Response, contentType string, schema *Schema, code string) error {
+	if response.Content == nil {
+		response.Content = make(map[string]*MediaType)
+	}
+	if _, ok := response.Content[contentType]; ok {
+		return fmt.Errorf("%s response %s documented twice", code, contentType)
+	}
+	response.Content[contentType] = &MediaType{Schema: schema}
+	return nil
+}
+
+// rawFormat marks binary files so tools offer them as downloads.
+func rawFormat(contentType string) string {
+	if strings.HasPrefix(contentType, "text/") || strings.HasSuffix(contentType, "json") {
+		return ""
+	}
+	return "binary"
+}
+
+func parameter(param Param, in string) *Parameter {
+	schema := &Schema{Type: param.Type, Format: param.Format, Enum: param.Enum}
+	if schema.Type == "" {
+		schema.Type = "string"
+	}
+	return &Parameter{
+		Name:        param.Name,
+		In:          in,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      schema,
+	}
+}
+
+// operationID names an operation after its handler, keeping the verb it
+// starts with: HandleSubmitLoan becomes submitLoan. Handlers named after a
+// resource take the method instead, e.g. a POST served by HandleDealers is
+// createDealers.
+func operationID(handler, method string) string {
+	_, name, _ := strings.Cut(handler, ".")
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "Handler"), "Handle")
+	for _, verb := range []string{"Get", "Put", "Post", "Delete", "Patch", "Update", "Submit", "Settle", "Approve",
+		"Reject", "Run", "Import", "Export", "Redeliver", "Revoke", "Create", "Deactivate", "Issue", "Generate",
+		"Upload", "Download"} {
+		if strings.HasPrefix(name, verb) {
+			return lowerFirst(name)
+		}
+	}
+	verb := strings.ToLower(method)
+	switch method {
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	}
+	return verb + name
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const componentPrefix = "#/components/schemas/"
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// Schemas derives schemas from Go types the way encoding/json encodes them.
+// Named structs become components referenced by their type name; fields are
+// named by their json tag and may carry an openapi tag with comma separated
+// options:
+//
+//	required         the property must be present
+//	readonly         set by the server, ignored in requests
+//	format=<format>  e.g. uuid, date, email or uri
+//	enum=<a>|<b>     the allowed values of a string
+//
+// Pointers, slices and maps encode nil as null and are nullable.
+type Schemas struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func NewSchemas() *Schemas {
+	return &Schemas{
+		components: make(map[string]*Schema),
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+// Components returns the schemas of every struct seen so far.
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// SchemaOf returns the schema of the type of value, or value itself when it
+// is already a *Schema.
+func (s *Schemas) SchemaOf(value any) (*Schema, error) {
+	if schema, ok := value.(*Schema); ok {
+		return schema, nil
+	}
+	return s.schema(reflect.TypeOf(value))
+}
+
+func (s *Schemas) schema(t reflect.Type) (*Schema, error) {
+	if t == nil {
+		return &Schema{}, nil
+	}
+	if t == rawMessageType {
+		return &Schema{Description: "Any JSON value.", Nullable: true}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema, err := s.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(schema), nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return s.component(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: t.Kind() == reflect.Slice}, nil
+		}
+		items, err := s.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items, Nullable: t.Kind() == reflect.Slice}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key of %s is not a string", t)
+		}
+		values, err := s.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values, Nullable: true}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}, nil
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("no schema for %s", t)
+}
+
+// component registers a named struct once and refers to it. The placeholder
+// is stored before the fields are walked so that recursive types terminate.
+func (s *Schemas) component(t reflect.Type) (*Schema, error) {
+	name := t.Name()
+	if seen, ok := s.types[name]; ok {
+		if seen != t {
+			return nil, fmt.Errorf("schema name %s is used by both %s and %s", name, seen, t)
+		}
+		return &Schema{Ref: componentPrefix + name}, nil
+	}
+	s.types[name] = t
+	s.components[name] = &Schema{}
+
+	schema, err := s.structSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	*s.components[name] = *schema
+	return &Schema{Ref: componentPrefix + name}, nil
+}
+
+func (s *Schemas) structSchema(t reflect.Type) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened, as encoding/json does.
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded, err := s.structSchema(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for property, value := range embedded.Properties {
+				schema.Properties[property] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := s.schema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		property, required, err := applyTag(property, field.Tag.Get("openapi"))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema, nil
+}
+
+// applyTag returns a copy of schema with the options of an openapi tag, so a
+// shared component reference is never modified.
+func applyTag(schema *Schema, tag string) (*Schema, bool, error) {
+	if tag == "" {
+		return schema, false, nil
+	}
+
+	tagged := *schema
+	required := false
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "required":
+			required = true
+		case "readonly":
+			tagged.ReadOnly = true
+		case "format":
+			tagged.Format = value
+		case "enum":
+			tagged.Enum = strings.Split(value, "|")
+		default:
+			return nil, false, fmt.Errorf("unknown openapi tag option %q", key)
+		}
+	}
+
+	// Siblings of $ref are ignored, so they are hung on an allOf instead.
+	if tagged.Ref != "" {
+		if tagged.Format != "" || tagged.Enum != nil {
+			return nil, false, fmt.Errorf("format and enum do not apply to %s", tagged.Ref)
+		}
+		if tagged.ReadOnly {
+			tagged = Schema{AllOf: []*Schema{{Ref: tagged.Ref}}, ReadOnly: true}
+		}
+	}
+	return &tagged, required, nil
+}
+
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+	copied := *schema
+	copied.Nullable = true
+	return &copied
+}
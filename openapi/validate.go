@@ -0,0 +1,329 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ValidationError locates a value that breaks its schema by JSON pointer,
+// e.g. /proposed_loan/tenor. The pointer of the whole document is empty.
+type ValidationError struct {
+	Pointer string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Pointer == "" {
+		return e.Message
+	}
+	return e.Pointer + ": " + e.Message
+}
+
+// DecodeJSON decodes a single JSON value keeping numbers as json.Number, so
+// that integers can be told from fractions.
+func DecodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// ValidateRequest checks a value decoded by DecodeJSON against schema as a
+// request body. Properties the server sets are readOnly and skipped, so that
+// a resource read from the API can be sent back as it is.
+func (d *Document) ValidateRequest(schema *Schema, value any) []*ValidationError {
+	v := validation{doc: d, request: true}
+	v.validate(schema, value, "")
+	return v.errors
+}
+
+// ValidateResponse checks a value decoded by DecodeJSON against schema as a
+// response body.
+func (d *Document) ValidateResponse(schema *Schema, value any) []*ValidationError {
+	v := validation{doc: d}
+	v.validate(schema, value, "")
+	return v.errors
+}
+
+// ValidateParameter checks the text of a path or query parameter.
+func ValidateParameter(schema *Schema, value string) error {
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errors.New("expected an integer")
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.New("expected a number")
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.New("expected true or false")
+		}
+	case "string":
+		if err := checkFormat(schema.Format, value); err != nil {
+			return err
+		}
+	}
+	// Handlers read enumerated parameters case-insensitively.
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(allowed string) bool {
+		return strings.EqualFold(allowed, value)
+	}) {
+		return fmt.Errorf("expected one of %s", strings.Join(schema.Enum, ", "))
+	}
+	return nil
+}
+
+type validation struct {
+	doc     *Document
+	request bool
+	errors  []*ValidationError
+}
+
+func (v *validation) fail(pointer, format string, args ...any) {
+	v.errors = append(v.errors, &ValidationError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validation) resolve(schema *Schema) (*Schema, error) {
+	for schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, componentPrefix)
+		resolved := v.doc.Components.Schemas[name]
+		if !ok || resolved == nil {
+			return nil, fmt.Errorf("unresolved schema %s", schema.Ref)
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
+
+func (v *validation) validate(schema *Schema, value any, pointer string) {
+	schema, err := v.resolve(schema)
+	if err != nil {
+		v.fail(pointer, "%v", err)
+		return
+	}
+
+	if value == nil {
+		if !schema.Nullable && !isAnySchema(schema) {
+			v.fail(pointer, "must not be null")
+		}
+		return
+	}
+	for _, part := range schema.AllOf {
+		v.validate(part, value, pointer)
+	}
+	if len(schema.AnyOf) > 0 {
+		v.validateAnyOf(schema.AnyOf, value, pointer)
+	}
+
+	switch schema.Type {
+	case "object":
+		v.validateObject(schema, value, pointer)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			v.fail(pointer, "expected an array, got %s", jsonType(value))
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				v.validate(schema.Items, item, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			v.fail(pointer, "expected a string, got %s", jsonType(value))
+			return
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, text) {
+			v.fail(pointer, "expected one of %s, got %q", strings.Join(schema.Enum, ", "), text)
+			return
+		}
+		if err := checkFormat(schema.Format, text); err != nil {
+			v.fail(pointer, "%v, got %q", err, text)
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			v.fail(pointer, "expected %s, got %s", article(schema.Type), jsonType(value))
+			return
+		}
+		v.validateNumber(schema, number, pointer)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(pointer, "expected a boolean, got %s", jsonType(value))
+		}
+	}
+}
+
+func (v *validation) validateObject(schema *Schema, value any, pointer string) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		v.fail(pointer, "expected an object, got %s", jsonType(value))
+		return
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		switch {
+		case ok && v.request && v.readOnly(property):
+		case ok:
+			v.validate(property, object[name], pointer+"/"+escapePointer(name))
+		case schema.AdditionalProperties != nil:
+			v.validate(schema.AdditionalProperties, object[name], pointer+"/"+escapePointer(name))
+		case schema.Properties == nil:
+			// A free-form object, such as the document itself.
+		default:
+			v.fail(pointer+"/"+escapePointer(name), "unknown property")
+		}
+	}
+	for _, name := range schema.Required {
+		if _, ok := object[name]; ok {
+			continue
+		}
+		if v.request && v.readOnly(schema.Properties[name]) {
+			continue
+		}
+		v.fail(pointer+"/"+escapePointer(name), "required property is missing")
+	}
+}
+
+func (v *validation) validateNumber(schema *Schema, number json.Number, pointer string) {
+	if schema.Type == "integer" {
+		value, err := number.Int64()
+		if err != nil {
+			v.fail(pointer, "expected an integer, got %s", number)
+			return
+		}
+		if schema.Format == "int32" && (value < -1<<31 || value > 1<<31-1) {
+			v.fail(pointer, "%s is out of the int32 range", number)
+		}
+	}
+	value, err := number.Float64()
+	if err != nil {
+		v.fail(pointer, "expected a number, got %s", number)
+		return
+	}
+	if schema.Minimum != nil && value < *schema.Minimum {
+		v.fail(pointer, "must be at least %v, got %s", *schema.Minimum, number)
+	}
+}
+
+// validateAnyOf reports the errors of the closest alternative when none
+// matches, which reads better than the errors of all of them.
+func (v *validation) validateAnyOf(alternatives []*Schema, value any, pointer string) {
+	var closest []*ValidationError
+	for _, alternative := range alternatives {
+		attempt := validation{doc: v.doc, request: v.request}
+		attempt.validate(alternative, value, pointer)
+		if len(attempt.errors) == 0 {
+			return
+		}
+		if closest == nil || len(attempt.errors) < len(closest) {
+			closest = attempt.errors
+		}
+	}
+	v.errors = append(v.errors, closest...)
+}
+
+func (v *validation) readOnly(schema *Schema) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.ReadOnly {
+		return true
+	}
+	resolved, err := v.resolve(schema)
+	return err == nil && resolved.ReadOnly
+}
+
+// isAnySchema reports whether the schema accepts every value, as the schema
+// of an interface does.
+func isAnySchema(schema *Schema) bool {
+	return schema.Type == "" && schema.Ref == "" && len(schema.AllOf) == 0 && len(schema.AnyOf) == 0 &&
+		schema.Properties == nil && schema.Items == nil
+}
+
+func checkFormat(format, value string) error {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return errors.New("expected a UUID")
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return errors.New("expected a date as YYYY-MM-DD")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return errors.New("expected an RFC 3339 date-time")
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			return errors.New("expected an email address")
+		}
+	case "uri":
+		if parsed, err := url.Parse(value); err != nil || !parsed.IsAbs() || parsed.Host == "" {
+			return errors.New("expected an absolute URL")
+		}
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return errors.New("expected base64")
+		}
+	}
+	return nil
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func article(typeName string) string {
+	if typeName == "integer" {
+		return "an integer"
+	}
+	return "a " + typeName
+}
+
+// escapePointer escapes a property name as a JSON pointer token (RFC 6901).
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/events"
+	"github.com/alphaloan/vehicle/worker"
+)
+
+const (
+	DefaultBatchSize   = 100
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = 10 * time.Minute
+)
+
+// Relay publishes outbox events to a sink in the order they were written.
+// An event is marked published only after the sink accepted it, so a crash in
+// between sends it again: delivery is at least once.
+type Relay struct {
+	Store       *datastore.EventOutboxStore
+	Sink        events.Sink
+	BatchSize   int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+type Summary struct {
+	Published int
+	Failed    bool
+}
+
+func NewRelay(store *datastore.EventOutboxStore, sink events.Sink) *Relay {
+	return &Relay{
+		Store:       store,
+		Sink:        sink,
+		BatchSize:   DefaultBatchSize,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// Start relays pending events on every tick until ctx is cancelled, then
+// closes the sink.
+func (r *Relay) Start(ctx context.Context, interval time.Duration) {
+	defer r.Sink.Close()
+
+	worker.Every(ctx, interval, func(ctx context.Context) {
+		summary, err := r.Run(ctx)
+		if err != nil {
+			log.Println("event relay failed:", err)
+		} else if summary.Published > 0 {
+			log.Printf("event relay published %d events\n", summary.Published)
+		}
+	})
+}
+
+// Run publishes pending events until the outbox is drained or an event fails.
+// A failed event is retried after a backoff and holds back the events written
+// after it, which keeps the order consumers see.
+func (r *Relay) Run(ctx context.Context) (*Summary, error) {
+	summary := &Summary{}
+	for {
+		pending, err := r.Store.GetPendingEvents(r.BatchSize)
+		if err != nil {
+			return summary, err
+		}
+
+		for _, event := range pending {
+			now := time.Now()
+			if event.NextAttemptAt > now.Unix() {
+				return summary, nil
+			}
+
+			message := events.Message{
+				EventID:   event.EventID,
+				EventType: event.EventType,
+				Body:      []byte(event.Payload),
+			}
+			if err := r.Sink.Publish(ctx, message); err != nil {
+				summary.Failed = true
+				retryAt := now.Add(worker.Backoff(event.Attempts+1, r.BaseBackoff, r.MaxBackoff))
+				log.Printf("publishing event %s (%s) failed on attempt %d, retrying at %s: %v\n",
+					event.EventID, event.EventType, event.Attempts+1, retryAt.Format(time.RFC3339), err)
+				return summary, r.Store.MarkEventFailed(event.EventID, err.Error(), retryAt.Unix())
+			}
+			if err := r.Store.MarkEventPublished(event.EventID, time.Now().Unix()); err != nil {
+				return summary, err
+			}
+			summary.Published++
+		}
+
+		if len(pending) < r.BatchSize {
+			return summary, nil
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+type Rate struct {
+	MaxTenureMonth     int
+	MaxVehicleAgeYears int
+	AnnualInterestRate float64
+}
+
+// Product is the subset of a loan product needed to validate and price a
+// proposal. Fee rates are fractions of the principal; the insurance rate is
+// charged per started year of tenure.
+type Product struct {
+	ProductID          string
+	VehicleType        string
+	IsCommercial       bool
+	MinAmount          int
+	MaxAmount          int
+	MinVehicleAgeYears int
+	MaxVehicleAgeYears int
+	AdminFee           int
+	ProvisionFeeRate   float64
+	InsuranceFeeRate   float64
+	Tenures            []int
+	Rates              []Rate
+}
+
+type Proposal struct {
+	Amount       int
+	TenureMonth  int
+	VehicleType  string
+	VehicleAge   int
+	IsCommercial bool
+}
+
+type Quote struct {
+	ProductID          string
+	AnnualInterestRate float64
+	TenureMonth        int
+	Principal          int
+	MonthlyInstallment int
+	TotalInterest      int
+	AdminFee           int
+	ProvisionFee       int
+	InsuranceFee       int
+	TotalFees          int
+	TotalCost          int
+}
+
+var ErrNoRate = errors.New("no interest rate configured for tenure and vehicle age")
+
+func (p *Product) Validate(proposal Proposal) error {
+	if p.VehicleType != "" && !strings.EqualFold(p.VehicleType, proposal.VehicleType) {
+		return fmt.Errorf("product is only available for vehicle type %q", p.VehicleType)
+	}
+	if p.IsCommercial != proposal.IsCommercial {
+		if p.IsCommercial {
+			return errors.New("product is only available for commercial vehicles")
+		}
+		return errors.New("product is not available for commercial vehicles")
+	}
+	if proposal.Amount < p.MinAmount || proposal.Amount > p.MaxAmount {
+		return fmt.Errorf("loan amount %d is outside the product range %d-%d", proposal.Amount, p.MinAmount, p.MaxAmount)
+	}
+	if !slices.Contains(p.Tenures, proposal.TenureMonth) {
+		return fmt.Errorf("tenure of %d months is not offered, allowed tenures are %v", proposal.TenureMonth, p.Tenures)
+	}
+	if proposal.VehicleAge < p.MinVehicleAgeYears || proposal.VehicleAge > p.MaxVehicleAgeYears {
+		return fmt.Errorf("vehicle age of %d years is outside the product range %d-%d", proposal.VehicleAge, p.MinVehicleAgeYears, p.MaxVehicleAgeYears)
+	}
+	return nil
+}
+
+// RateFor picks the rate of the tightest bracket covering both the tenure and
+// the vehicle age.
+func (p *Product) RateFor(tenureMonth, vehicleAge int) (float64, error) {
+	var best *Rate
+	for i := range p.Rates {
+		rate := &p.Rates[i]
+		if rate.MaxTenureMonth < tenureMonth || rate.MaxVehicleAgeYears < vehicleAge {
+			continue
+		}
+		if best == nil || rate.MaxTenureMonth < best.MaxTenureMonth ||
+			(rate.MaxTenureMonth == best.MaxTenureMonth && rate.MaxVehicleAgeYears < best.MaxVehicleAgeYears) {
+			best = rate
+		}
+	}
+	if best == nil {
+		return 0, ErrNoRate
+	}
+	return best.AnnualInterestRate, nil
+}
+
+func (p *Product) Quote(proposal Proposal) (*Quote, error) {
+	if err := p.Validate(proposal); err != nil {
+		return nil, err
+	}
+	annualRate, err := p.RateFor(proposal.TenureMonth, proposal.VehicleAge)
+	if err != nil {
+		return nil, err
+	}
+
+	installment := MonthlyInstallment(proposal.Amount, annualRate, proposal.TenureMonth)
+	years := (proposal.TenureMonth + 11) / 12
+
+	quote := &Quote{
+		ProductID:          p.ProductID,
+		AnnualInterestRate: annualRate,
+		TenureMonth:        proposal.TenureMonth,
+		Principal:          proposal.Amount,
+		MonthlyInstallment: installment,
+		TotalInterest:      installment*proposal.TenureMonth - proposal.Amount,
+		AdminFee:           p.AdminFee,
+		ProvisionFee:       roundMoney(float64(proposal.Amount) * p.ProvisionFeeRate),
+		InsuranceFee:       roundMoney(float64(proposal.Amount) * p.InsuranceFeeRate * float64(years)),
+	}
+	quote.TotalFees = quote.AdminFee + quote.ProvisionFee + quote.InsuranceFee
+	quote.TotalCost = installment*proposal.TenureMonth + quote.TotalFees
+	return quote, nil
+}
+
+// MonthlyInstallment is the annuity payment repaying principal over the tenure
+// at the given annual rate compounded monthly.
+func MonthlyInstallment(principal int, annualRate float64, tenureMonth int) int {
+	if tenureMonth <= 0 {
+		return 0
+	}
+	monthlyRate := annualRate / 12
+	if monthlyRate == 0 {
+		return roundMoney(float64(principal) / float64(tenureMonth))
+	}
+	factor := math.Pow(1+monthlyRate, float64(tenureMonth))
+	return roundMoney(float64(principal) * monthlyRate * factor / (factor - 1))
+}
+
+func roundMoney(amount float64) int {
+	return int(math.Round(amount))
+}
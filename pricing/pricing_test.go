@@ -0,0 +1,108 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRateForPicksTheTightestBracket(t *testing.T) {
+	product := &Product{Rates: []Rate{
+		{MaxTenureMonth: 60, MaxVehicleAgeYears: 10, AnnualInterestRate: 0.16},
+		{MaxTenureMonth: 36, MaxVehicleAgeYears: 10, AnnualInterestRate: 0.14},
+		{MaxTenureMonth: 12, MaxVehicleAgeYears: 5, AnnualInterestRate: 0.10},
+		{MaxTenureMonth: 36, MaxVehicleAgeYears: 5, AnnualInterestRate: 0.12},
+	}}
+	tests := []struct {
+		tenureMonth int
+		vehicleAge  int
+		want        float64
+		err         error
+	}{
+		{12, 0, 0.10, nil},
+		{12, 5, 0.10, nil},
+		{13, 5, 0.12, nil},
+		{36, 5, 0.12, nil},
+		{12, 6, 0.14, nil},
+		{36, 10, 0.14, nil},
+		{37, 0, 0.16, nil},
+		{60, 10, 0.16, nil},
+		{61, 0, 0, ErrNoRate},
+		{12, 11, 0, ErrNoRate},
+	}
+	for _, test := range tests {
+		got, err := product.RateFor(test.tenureMonth, test.vehicleAge)
+		if got != test.want || !errors.Is(err, test.err) {
+			t.Errorf("RateFor(%d, %d) = %v, %v, want %v, %v", test.tenureMonth, test.vehicleAge, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestMonthlyInstallment(t *testing.T) {
+	tests := []struct {
+		principal   int
+		annualRate  float64
+		tenureMonth int
+		want        int
+	}{
+		{12000, 0.12, 12, 1066},
+		{100000000, 0.12, 36, 3321431},
+		{12000, 0, 12, 1000},
+		{1000, 0, 3, 333},
+		{12000, 0.12, 1, 12120},
+		{12000, 0.12, 0, 0},
+	}
+	for _, test := range tests {
+		if got := MonthlyInstallment(test.principal, test.annualRate, test.tenureMonth); got != test.want {
+			t.Errorf("MonthlyInstallment(%d, %v, %d) = %d, want %d", test.principal, test.annualRate, test.tenureMonth, got, test.want)
+		}
+	}
+}
+
+func testProduct() *Product {
+	return &Product{
+		ProductID: "product", VehicleType: "CAR", MinAmount: 10000, MaxAmount: 50000,
+		MinVehicleAgeYears: 0, MaxVehicleAgeYears: 10, AdminFee: 500, ProvisionFeeRate: 0.01, InsuranceFeeRate: 0.02,
+		Tenures: []int{12, 24},
+		Rates:   []Rate{{MaxTenureMonth: 24, MaxVehicleAgeYears: 10, AnnualInterestRate: 0}},
+	}
+}
+
+func TestValidateChecksTheProductLimits(t *testing.T) {
+	valid := Proposal{Amount: 12000, TenureMonth: 24, VehicleType: "car", VehicleAge: 10}
+	tests := []struct {
+		name   string
+		modify func(*Proposal)
+		ok     bool
+	}{
+		{"within every limit", func(*Proposal) {}, true},
+		{"smallest amount", func(p *Proposal) { p.Amount = 10000 }, true},
+		{"largest amount", func(p *Proposal) { p.Amount = 50000 }, true},
+		{"below the amount range", func(p *Proposal) { p.Amount = 9999 }, false},
+		{"above the amount range", func(p *Proposal) { p.Amount = 50001 }, false},
+		{"tenure not offered", func(p *Proposal) { p.TenureMonth = 18 }, false},
+		{"vehicle too old", func(p *Proposal) { p.VehicleAge = 11 }, false},
+		{"other vehicle type", func(p *Proposal) { p.VehicleType = "MOTORCYCLE" }, false},
+		{"commercial vehicle", func(p *Proposal) { p.IsCommercial = true }, false},
+	}
+	for _, test := range tests {
+		proposal := valid
+		test.modify(&proposal)
+		if err := testProduct().Validate(proposal); (err == nil) != test.ok {
+			t.Errorf("%s: err = %v", test.name, err)
+		}
+	}
+}
+
+func TestQuoteOfAZeroRateProduct(t *testing.T) {
+	quote, err := testProduct().Quote(Proposal{Amount: 12000, TenureMonth: 24, VehicleType: "CAR", VehicleAge: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Quote{
+		ProductID: "product", AnnualInterestRate: 0, TenureMonth: 24, Principal: 12000, MonthlyInstallment: 500,
+		TotalInterest: 0, AdminFee: 500, ProvisionFee: 120, InsuranceFee: 480, TotalFees: 1100, TotalCost: 13100,
+	}
+	if *quote != want {
+		t.Errorf("quote = %+v, want %+v", *quote, want)
+	}
+}
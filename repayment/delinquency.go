@@ -0,0 +1,104 @@
+package repayment
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	BucketCurrent = "CURRENT"
+	Bucket1To30   = "1-30"
+	Bucket31To60  = "31-60"
+	Bucket61To90  = "61-90"
+	BucketOver90  = "90+"
+)
+
+var Buckets = []string{BucketCurrent, Bucket1To30, Bucket31To60, Bucket61To90, BucketOver90}
+
+// PenaltyRule is the late payment policy of a loan product. The flat late fee
+// is charged once per installment when the grace period runs out; from then
+// on the daily rate accrues on the unpaid installment, penalties excluded.
+type PenaltyRule struct {
+	LateFee   int
+	DailyRate float64
+	GraceDays int
+}
+
+func Bucket(daysPastDue int) string {
+	switch {
+	case daysPastDue <= 0:
+		return BucketCurrent
+	case daysPastDue <= 30:
+		return Bucket1To30
+	case daysPastDue <= 60:
+		return Bucket31To60
+	case daysPastDue <= 90:
+		return Bucket61To90
+	default:
+		return BucketOver90
+	}
+}
+
+// DaysPastDue counts the days since the due date of the oldest unpaid
+// installment, zero when nothing is overdue.
+func DaysPastDue(installments []Installment, asOf time.Time) int {
+	for i := range installments {
+		installment := &installments[i]
+		if installment.IsPaid() || !installment.DueDate.Before(asOf) {
+			continue
+		}
+		return daysBetween(installment.DueDate, asOf)
+	}
+	return 0
+}
+
+// Assess marks unpaid installments past their due date as overdue and
+// charges late penalties up to asOf. Days already charged are remembered on
+// the installment so running it repeatedly for the same day, or for an
+// earlier one, charges nothing.
+// It returns the penalty charged by this run.
+func (r PenaltyRule) Assess(installments []Installment, asOf time.Time) int {
+	charged := 0
+	for i := range installments {
+		installment := &installments[i]
+		if installment.IsPaid() || !installment.DueDate.Before(asOf) {
+			continue
+		}
+		if installment.OverdueSince == nil {
+			overdueSince := installment.DueDate.AddDate(0, 0, 1)
+			installment.OverdueSince = &overdueSince
+		}
+
+		graceEnd := installment.DueDate.AddDate(0, 0, r.GraceDays)
+		if !graceEnd.Before(asOf) {
+			continue
+		}
+		// A run backfilling a day already charged charges nothing and leaves
+		// the accrual where the later run put it.
+		if installment.PenaltyAccruedThrough != nil && !asOf.After(*installment.PenaltyAccruedThrough) {
+			continue
+		}
+
+		penalty := 0
+		from := graceEnd
+		if installment.PenaltyAccruedThrough == nil {
+			penalty += r.LateFee
+		} else if installment.PenaltyAccruedThrough.After(from) {
+			from = *installment.PenaltyAccruedThrough
+		}
+
+		days := daysBetween(from, asOf)
+		base := installment.TotalOutstanding() - installment.Outstanding(ComponentPenalty)
+		penalty += int(math.Round(float64(base) * r.DailyRate * float64(days)))
+
+		accruedThrough := asOf
+		installment.PenaltyAccruedThrough = &accruedThrough
+		installment.PenaltyDue += penalty
+		charged += penalty
+	}
+	return charged
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,62 @@
+package repayment
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAssessChargesEachDayOnce(t *testing.T) {
+	rule := PenaltyRule{LateFee: 50, DailyRate: 0.001, GraceDays: 3}
+	installments := []Installment{{Number: 1, DueDate: date(2026, time.March, 1), PrincipalDue: 900, InterestDue: 100}}
+
+	tests := []struct {
+		name           string
+		asOf           time.Time
+		charged        int
+		accruedThrough time.Time
+	}{
+		{"within the grace period", date(2026, time.March, 4), 0, time.Time{}},
+		{"first day past the grace period", date(2026, time.March, 5), 51, date(2026, time.March, 5)},
+		{"the same day again", date(2026, time.March, 5), 0, date(2026, time.March, 5)},
+		{"ten days later", date(2026, time.March, 15), 10, date(2026, time.March, 15)},
+		{"backfilling a missed day", date(2026, time.March, 10), 0, date(2026, time.March, 15)},
+		{"the next day", date(2026, time.March, 16), 1, date(2026, time.March, 16)},
+	}
+	penaltyDue := 0
+	for _, test := range tests {
+		charged := rule.Assess(installments, test.asOf)
+		penaltyDue += charged
+		installment := installments[0]
+		if charged != test.charged || installment.PenaltyDue != penaltyDue {
+			t.Errorf("%s: charged %d, %d due, want %d, %d due", test.name, charged, installment.PenaltyDue, test.charged, penaltyDue)
+		}
+		var accruedThrough time.Time
+		if installment.PenaltyAccruedThrough != nil {
+			accruedThrough = *installment.PenaltyAccruedThrough
+		}
+		if !accruedThrough.Equal(test.accruedThrough) {
+			t.Errorf("%s: accrued through %s, want %s", test.name, accruedThrough, test.accruedThrough)
+		}
+	}
+}
+
+func TestAssessBackfillNeverUndoesPenaltiesPaid(t *testing.T) {
+	rule := PenaltyRule{LateFee: 50, DailyRate: 0.01, GraceDays: 0}
+	accruedThrough := date(2026, time.March, 20)
+	installments := []Installment{{
+		Number: 1, DueDate: date(2026, time.March, 1), PrincipalDue: 900, InterestDue: 100,
+		PenaltyDue: 240, PenaltyPaid: 240, PenaltyAccruedThrough: &accruedThrough,
+	}}
+
+	if charged := rule.Assess(installments, date(2026, time.March, 10)); charged != 0 {
+		t.Errorf("backfill charged %d, want 0", charged)
+	}
+	if installment := installments[0]; installment.PenaltyDue != 240 || !installment.PenaltyAccruedThrough.Equal(accruedThrough) {
+		t.Errorf("after the backfill %d penalty is due through %s, want 240 through %s",
+			installment.PenaltyDue, installment.PenaltyAccruedThrough, accruedThrough)
+	}
+}
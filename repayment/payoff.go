@@ -0,0 +1,111 @@
+package repayment
+
+import (
+	"math"
+	"time"
+)
+
+// TerminationRule is the early-termination fee of a loan product, a fraction
+// of the principal repaid ahead of schedule with a floor.
+type TerminationRule struct {
+	FeeRate float64
+	MinFee  int
+}
+
+func (r TerminationRule) Fee(principal int) int {
+	if principal <= 0 {
+		return 0
+	}
+	return max(int(math.Round(float64(principal)*r.FeeRate)), r.MinFee)
+}
+
+type Payoff struct {
+	Principal       int
+	AccruedInterest int
+	Fee             int
+	Penalty         int
+	TerminationFee  int
+	// Waived is the scheduled interest and fees of future periods the
+	// customer no longer owes when settling early.
+	Waived int
+	Total  int
+}
+
+// PayoffAsOf prices an early settlement on the given day without touching the
+// installments.
+func PayoffAsOf(installments []Installment, asOf time.Time, rule TerminationRule) Payoff {
+	scratch := make([]Installment, len(installments))
+	copy(scratch, installments)
+	payoff, _ := Settle(scratch, asOf, rule)
+	return payoff
+}
+
+// Settle pays off the loan on the given day. Everything due up to asOf is
+// settled in full, the principal still to come is repaid early, interest of
+// the running period is charged pro rata by day and later interest and fees
+// are waived by lowering what the installments owe. The installments are
+// updated in place.
+func Settle(installments []Installment, asOf time.Time, rule TerminationRule) (Payoff, []Allocation) {
+	payoff := Payoff{}
+	var allocations []Allocation
+	currentPeriodSeen := false
+	repaidEarly := 0
+
+	for i := range installments {
+		installment := &installments[i]
+		allocation := Allocation{
+			InstallmentNumber: installment.Number,
+			Principal:         installment.Outstanding(ComponentPrincipal),
+			Penalty:           installment.Outstanding(ComponentPenalty),
+		}
+
+		if !installment.DueDate.After(asOf) {
+			allocation.Interest = installment.Outstanding(ComponentInterest)
+			allocation.Fee = installment.Outstanding(ComponentFee)
+		} else {
+			repaidEarly += allocation.Principal
+			accrued := 0
+			if !currentPeriodSeen {
+				currentPeriodSeen = true
+				periodStart := AddMonths(installment.DueDate, -1)
+				if i > 0 {
+					periodStart = installments[i-1].DueDate
+				}
+				accrued = accruedInterest(installment.InterestDue, periodStart, installment.DueDate, asOf)
+			}
+			allocation.Interest = max(accrued-installment.InterestPaid, 0)
+
+			waivedInterest := installment.Outstanding(ComponentInterest) - allocation.Interest
+			waivedFee := installment.Outstanding(ComponentFee)
+			payoff.Waived += waivedInterest + waivedFee
+			installment.InterestDue -= waivedInterest
+			installment.FeeDue -= waivedFee
+		}
+
+		installment.PrincipalPaid += allocation.Principal
+		installment.InterestPaid += allocation.Interest
+		installment.FeePaid += allocation.Fee
+		installment.PenaltyPaid += allocation.Penalty
+
+		payoff.Principal += allocation.Principal
+		payoff.AccruedInterest += allocation.Interest
+		payoff.Fee += allocation.Fee
+		payoff.Penalty += allocation.Penalty
+		if allocation.Total() > 0 {
+			allocations = append(allocations, allocation)
+		}
+	}
+
+	payoff.TerminationFee = rule.Fee(repaidEarly)
+	payoff.Total = payoff.Principal + payoff.AccruedInterest + payoff.Fee + payoff.Penalty + payoff.TerminationFee
+	return payoff, allocations
+}
+
+func accruedInterest(interest int, periodStart, periodEnd, asOf time.Time) int {
+	periodDays := daysBetween(periodStart, periodEnd)
+	if periodDays <= 0 {
+		return interest
+	}
+	elapsed := min(max(daysBetween(periodStart, asOf), 0), periodDays)
+	return int(math.Round(float64(interest) * float64(elapsed) / float64(periodDays)))
+}
@@ -0,0 +1,287 @@
+package repayment
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type Component string
+
+const (
+	ComponentFee       Component = "FEE"
+	ComponentInterest  Component = "INTEREST"
+	ComponentPrincipal Component = "PRINCIPAL"
+	ComponentPenalty   Component = "PENALTY"
+)
+
+// Waterfall is the order in which a payment settles the components of each
+// installment. Installments are always settled oldest first.
+type Waterfall []Component
+
+var DefaultWaterfall = Waterfall{ComponentPenalty, ComponentFee, ComponentInterest, ComponentPrincipal}
+
+// ParseWaterfall reads a comma separated component order such as
+// "penalty,interest,fee,principal". Every component must appear exactly once.
+func ParseWaterfall(value string) (Waterfall, error) {
+	var waterfall Waterfall
+	seen := make(map[Component]bool)
+	for _, part := range strings.Split(value, ",") {
+		component := Component(strings.ToUpper(strings.TrimSpace(part)))
+		switch component {
+		case ComponentFee, ComponentInterest, ComponentPrincipal, ComponentPenalty:
+		default:
+			return nil, fmt.Errorf("unknown waterfall component %q", part)
+		}
+		if seen[component] {
+			return nil, fmt.Errorf("waterfall component %s listed twice", component)
+		}
+		seen[component] = true
+		waterfall = append(waterfall, component)
+	}
+	if len(waterfall) != len(DefaultWaterfall) {
+		return nil, fmt.Errorf("waterfall must list %s, %s, %s and %s",
+			ComponentPenalty, ComponentFee, ComponentInterest, ComponentPrincipal)
+	}
+	return waterfall, nil
+}
+
+func (w Waterfall) String() string {
+	parts := make([]string, len(w))
+	for i, component := range w {
+		parts[i] = strings.ToLower(string(component))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Terms are the approved loan terms a schedule is generated from. Fees are
+// spread evenly over the installments.
+type Terms struct {
+	Principal          int
+	AnnualInterestRate float64
+	TenureMonth        int
+	MonthlyInstallment int
+	TotalFees          int
+	FirstDueDate       time.Time
+}
+
+type Installment struct {
+	Number        int
+	DueDate       time.Time
+	PrincipalDue  int
+	InterestDue   int
+	FeeDue        int
+	PenaltyDue    int
+	PrincipalPaid int
+	InterestPaid  int
+	FeePaid       int
+	PenaltyPaid   int
+	// OverdueSince is set once the installment has been found unpaid after
+	// its due date.
+	OverdueSince *time.Time
+	// PenaltyAccruedThrough is the last day late penalties were charged for.
+	PenaltyAccruedThrough *time.Time
+}
+
+func (i *Installment) Due(component Component) int {
+	switch component {
+	case ComponentFee:
+		return i.FeeDue
+	case ComponentInterest:
+		return i.InterestDue
+	case ComponentPenalty:
+		return i.PenaltyDue
+	default:
+		return i.PrincipalDue
+	}
+}
+
+func (i *Installment) Paid(component Component) int {
+	switch component {
+	case ComponentFee:
+		return i.FeePaid
+	case ComponentInterest:
+		return i.InterestPaid
+	case ComponentPenalty:
+		return i.PenaltyPaid
+	default:
+		return i.PrincipalPaid
+	}
+}
+
+func (i *Installment) Outstanding(component Component) int {
+	return i.Due(component) - i.Paid(component)
+}
+
+func (i *Installment) TotalDue() int {
+	return i.PrincipalDue + i.InterestDue + i.FeeDue + i.PenaltyDue
+}
+
+func (i *Installment) TotalOutstanding() int {
+	return i.TotalDue() - i.PrincipalPaid - i.InterestPaid - i.FeePaid - i.PenaltyPaid
+}
+
+func (i *Installment) IsPaid() bool {
+	return i.TotalOutstanding() == 0
+}
+
+// Schedule amortises the principal with the quoted installment. Interest is
+// charged on the opening balance of each month and the last installment takes
+// whatever principal is left so rounding never leaves a residue.
+func Schedule(terms Terms) []Installment {
+	if terms.TenureMonth <= 0 {
+		return nil
+	}
+
+	monthlyRate := terms.AnnualInterestRate / 12
+	balance := terms.Principal
+	feePerInstallment := terms.TotalFees / terms.TenureMonth
+	feeRemainder := terms.TotalFees % terms.TenureMonth
+
+	installments := make([]Installment, 0, terms.TenureMonth)
+	for n := 1; n <= terms.TenureMonth; n++ {
+		interest := int(math.Round(float64(balance) * monthlyRate))
+		principal := terms.MonthlyInstallment - interest
+		if n == terms.TenureMonth || principal > balance {
+			principal = balance
+		}
+		if principal < 0 {
+			principal = 0
+		}
+		balance -= principal
+
+		fee := feePerInstallment
+		if n == 1 {
+			fee += feeRemainder
+		}
+
+		installments = append(installments, Installment{
+			Number:       n,
+			DueDate:      AddMonths(terms.FirstDueDate, n-1),
+			PrincipalDue: principal,
+			InterestDue:  interest,
+			FeeDue:       fee,
+		})
+	}
+	return installments
+}
+
+// AddMonths keeps the day of month, clamping to the last day of shorter
+// months so a loan due on the 31st falls due on the 28th in February.
+func AddMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, date.Location())
+}
+
+type Allocation struct {
+	InstallmentNumber int
+	Fee               int
+	Interest          int
+	Principal         int
+	Penalty           int
+}
+
+func (a *Allocation) Total() int {
+	return a.Fee + a.Interest + a.Principal + a.Penalty
+}
+
+type Result struct {
+	Allocations []Allocation
+	Applied     int
+	// Unapplied is the part of an overpayment left once every installment is
+	// settled; it is held as a credit on the loan.
+	Unapplied int
+}
+
+// Allocate settles installments oldest first, taking the components of each
+// one in waterfall order. A partial payment leaves the last touched
+// installment partly paid; money beyond the current installment runs on to
+// the next ones. The installments are updated in place.
+func (w Waterfall) Allocate(installments []Installment, amount int) Result {
+	result := Result{}
+	remaining := amount
+	for i := range installments {
+		if remaining == 0 {
+			break
+		}
+		installment := &installments[i]
+		if installment.IsPaid() {
+			continue
+		}
+
+		allocation := Allocation{InstallmentNumber: installment.Number}
+		for _, component := range w {
+			take := min(installment.Outstanding(component), remaining)
+			if take <= 0 {
+				continue
+			}
+			remaining -= take
+			switch component {
+			case ComponentFee:
+				allocation.Fee += take
+				installment.FeePaid += take
+			case ComponentInterest:
+				allocation.Interest += take
+				installment.InterestPaid += take
+			case ComponentPrincipal:
+				allocation.Principal += take
+				installment.PrincipalPaid += take
+			case ComponentPenalty:
+				allocation.Penalty += take
+				installment.PenaltyPaid += take
+			}
+		}
+		if allocation.Total() > 0 {
+			result.Allocations = append(result.Allocations, allocation)
+		}
+	}
+	result.Applied = amount - remaining
+	result.Unapplied = remaining
+	return result
+}
+
+type Balance struct {
+	OutstandingPrincipal int
+	OutstandingInterest  int
+	OutstandingFee       int
+	OutstandingPenalty   int
+	TotalOutstanding     int
+	OverdueAmount        int
+	OverdueInstallments  int
+	PaidInstallments     int
+	NextDueDate          *time.Time
+	NextDueAmount        int
+}
+
+// BalanceAsOf summarises the schedule on the given day. An installment is
+// overdue when its due date is before asOf and it is not fully paid.
+func BalanceAsOf(installments []Installment, asOf time.Time) Balance {
+	balance := Balance{}
+	for i := range installments {
+		installment := &installments[i]
+		balance.OutstandingPrincipal += installment.Outstanding(ComponentPrincipal)
+		balance.OutstandingInterest += installment.Outstanding(ComponentInterest)
+		balance.OutstandingFee += installment.Outstanding(ComponentFee)
+		balance.OutstandingPenalty += installment.Outstanding(ComponentPenalty)
+
+		if installment.IsPaid() {
+			balance.PaidInstallments++
+			continue
+		}
+		if installment.DueDate.Before(asOf) {
+			balance.OverdueAmount += installment.TotalOutstanding()
+			balance.OverdueInstallments++
+			continue
+		}
+		if balance.NextDueDate == nil {
+			dueDate := installment.DueDate
+			balance.NextDueDate = &dueDate
+			balance.NextDueAmount = installment.TotalOutstanding()
+		}
+	}
+	balance.TotalOutstanding = balance.OutstandingPrincipal + balance.OutstandingInterest +
+		balance.OutstandingFee + balance.OutstandingPenalty
+	return balance
+}
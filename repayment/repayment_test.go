@@ -0,0 +1,161 @@
+package repayment
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduleLeavesNoPrincipalBehind(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms Terms
+	}{
+		{"one year", Terms{Principal: 12000, AnnualInterestRate: 0.12, TenureMonth: 12, MonthlyInstallment: 1066}},
+		{"three years", Terms{Principal: 100000000, AnnualInterestRate: 0.12, TenureMonth: 36, MonthlyInstallment: 3321431}},
+		{"uneven rate", Terms{Principal: 10000, AnnualInterestRate: 0.085, TenureMonth: 7, MonthlyInstallment: 1469}},
+		{"zero rate", Terms{Principal: 1000, TenureMonth: 3, MonthlyInstallment: 333}},
+	}
+	for _, test := range tests {
+		installments := Schedule(test.terms)
+		if len(installments) != test.terms.TenureMonth {
+			t.Errorf("%s: %d installments, want %d", test.name, len(installments), test.terms.TenureMonth)
+			continue
+		}
+		principal := 0
+		for _, installment := range installments[:len(installments)-1] {
+			principal += installment.PrincipalDue
+			if paid := installment.PrincipalDue + installment.InterestDue; paid != test.terms.MonthlyInstallment {
+				t.Errorf("%s: installment %d is %d, want %d", test.name, installment.Number, paid, test.terms.MonthlyInstallment)
+			}
+		}
+		last := installments[len(installments)-1]
+		if principal+last.PrincipalDue != test.terms.Principal {
+			t.Errorf("%s: schedule repays %d, want %d", test.name, principal+last.PrincipalDue, test.terms.Principal)
+		}
+		if last.PrincipalDue != test.terms.Principal-principal {
+			t.Errorf("%s: last installment repays %d, want the remaining %d", test.name, last.PrincipalDue, test.terms.Principal-principal)
+		}
+	}
+}
+
+func TestScheduleSpreadsFeesAndClampsDueDates(t *testing.T) {
+	installments := Schedule(Terms{
+		Principal: 1000, TenureMonth: 3, MonthlyInstallment: 333, TotalFees: 100,
+		FirstDueDate: date(2026, time.January, 31),
+	})
+	want := []Installment{
+		{Number: 1, DueDate: date(2026, time.January, 31), PrincipalDue: 333, FeeDue: 34},
+		{Number: 2, DueDate: date(2026, time.February, 28), PrincipalDue: 333, FeeDue: 33},
+		{Number: 3, DueDate: date(2026, time.March, 31), PrincipalDue: 334, FeeDue: 33},
+	}
+	if !reflect.DeepEqual(installments, want) {
+		t.Errorf("schedule = %+v, want %+v", installments, want)
+	}
+	if installments := Schedule(Terms{Principal: 1000}); installments != nil {
+		t.Errorf("schedule without a tenure = %+v, want none", installments)
+	}
+}
+
+func testInstallments() []Installment {
+	return []Installment{
+		{Number: 1, PrincipalDue: 900, InterestDue: 100, FeeDue: 10, PenaltyDue: 5},
+		{Number: 2, PrincipalDue: 950, InterestDue: 50, FeeDue: 10},
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	principalFirst := Waterfall{ComponentPrincipal, ComponentInterest, ComponentFee, ComponentPenalty}
+	tests := []struct {
+		name        string
+		waterfall   Waterfall
+		amount      int
+		allocations []Allocation
+		unapplied   int
+	}{
+		{"nothing paid", DefaultWaterfall, 0, nil, 0},
+		{"partial payment", DefaultWaterfall, 60,
+			[]Allocation{{InstallmentNumber: 1, Penalty: 5, Fee: 10, Interest: 45}}, 0},
+		{"exactly the first installment", DefaultWaterfall, 1015,
+			[]Allocation{{InstallmentNumber: 1, Penalty: 5, Fee: 10, Interest: 100, Principal: 900}}, 0},
+		{"running on to the next installment", DefaultWaterfall, 1100, []Allocation{
+			{InstallmentNumber: 1, Penalty: 5, Fee: 10, Interest: 100, Principal: 900},
+			{InstallmentNumber: 2, Fee: 10, Interest: 50, Principal: 25},
+		}, 0},
+		{"overpayment", DefaultWaterfall, 3000, []Allocation{
+			{InstallmentNumber: 1, Penalty: 5, Fee: 10, Interest: 100, Principal: 900},
+			{InstallmentNumber: 2, Fee: 10, Interest: 50, Principal: 950},
+		}, 975},
+		{"custom waterfall", principalFirst, 950,
+			[]Allocation{{InstallmentNumber: 1, Principal: 900, Interest: 50}}, 0},
+	}
+	for _, test := range tests {
+		installments := testInstallments()
+		result := test.waterfall.Allocate(installments, test.amount)
+		if !reflect.DeepEqual(result.Allocations, test.allocations) {
+			t.Errorf("%s: allocations %+v, want %+v", test.name, result.Allocations, test.allocations)
+		}
+		if result.Applied != test.amount-test.unapplied || result.Unapplied != test.unapplied {
+			t.Errorf("%s: applied %d and left %d, want %d and %d", test.name,
+				result.Applied, result.Unapplied, test.amount-test.unapplied, test.unapplied)
+		}
+		for _, allocation := range result.Allocations {
+			installment := installments[allocation.InstallmentNumber-1]
+			if installment.PenaltyPaid != allocation.Penalty || installment.FeePaid != allocation.Fee ||
+				installment.InterestPaid != allocation.Interest || installment.PrincipalPaid != allocation.Principal {
+				t.Errorf("%s: installment %d is %+v after %+v", test.name, installment.Number, installment, allocation)
+			}
+		}
+	}
+}
+
+func TestAllocateSkipsPaidInstallments(t *testing.T) {
+	installments := testInstallments()
+	DefaultWaterfall.Allocate(installments, 1015)
+	result := DefaultWaterfall.Allocate(installments, 100)
+	want := []Allocation{{InstallmentNumber: 2, Fee: 10, Interest: 50, Principal: 40}}
+	if !reflect.DeepEqual(result.Allocations, want) {
+		t.Errorf("allocations %+v, want %+v", result.Allocations, want)
+	}
+}
+
+func TestBalanceAsOf(t *testing.T) {
+	installments := []Installment{
+		{Number: 1, DueDate: date(2026, time.February, 15), PrincipalDue: 900, InterestDue: 100, PrincipalPaid: 900, InterestPaid: 100},
+		{Number: 2, DueDate: date(2026, time.March, 15), PrincipalDue: 900, InterestDue: 100, InterestPaid: 100},
+		{Number: 3, DueDate: date(2026, time.April, 15), PrincipalDue: 900, InterestDue: 100, PenaltyDue: 20},
+		{Number: 4, DueDate: date(2026, time.May, 15), PrincipalDue: 900, InterestDue: 100},
+	}
+	tests := []struct {
+		name                string
+		asOf                time.Time
+		overdueAmount       int
+		overdueInstallments int
+		nextDueDate         time.Time
+		nextDueAmount       int
+	}{
+		{"before any overdue", date(2026, time.March, 1), 0, 0, date(2026, time.March, 15), 900},
+		{"on the due date", date(2026, time.March, 15), 0, 0, date(2026, time.March, 15), 900},
+		{"the day after the due date", date(2026, time.March, 16), 900, 1, date(2026, time.April, 15), 1020},
+		{"past the last due date", date(2026, time.June, 1), 2920, 3, time.Time{}, 0},
+	}
+	for _, test := range tests {
+		balance := BalanceAsOf(installments, test.asOf)
+		if balance.OutstandingPrincipal != 2700 || balance.OutstandingInterest != 200 ||
+			balance.OutstandingPenalty != 20 || balance.TotalOutstanding != 2920 || balance.PaidInstallments != 1 {
+			t.Errorf("%s: balance %+v, want 2700 principal, 200 interest and 20 penalty outstanding with 1 paid", test.name, balance)
+		}
+		if balance.OverdueAmount != test.overdueAmount || balance.OverdueInstallments != test.overdueInstallments {
+			t.Errorf("%s: %d overdue in %d installments, want %d in %d", test.name,
+				balance.OverdueAmount, balance.OverdueInstallments, test.overdueAmount, test.overdueInstallments)
+		}
+		var nextDueDate time.Time
+		if balance.NextDueDate != nil {
+			nextDueDate = *balance.NextDueDate
+		}
+		if !nextDueDate.Equal(test.nextDueDate) || balance.NextDueAmount != test.nextDueAmount {
+			t.Errorf("%s: next due %d on %s, want %d on %s", test.name,
+				balance.NextDueAmount, nextDueDate, test.nextDueAmount, test.nextDueDate)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package valuation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrNoBasePrice = errors.New("vehicle model has no base price")
+
+// Policy holds the depreciation parameters applied to a catalogue base price.
+// Rates are fractions, e.g. 0.2 for 20%.
+type Policy struct {
+	FirstYearDepreciation  float64
+	AnnualDepreciation     float64
+	ExpectedKmPerYear      int
+	ExcessKmStep           int
+	ExcessKmDepreciation   float64
+	MaxMileageDepreciation float64
+	CommercialAdjustment   float64
+	MinimumResidual        float64
+}
+
+var DefaultPolicy = Policy{
+	FirstYearDepreciation:  0.20,
+	AnnualDepreciation:     0.10,
+	ExpectedKmPerYear:      15000,
+	ExcessKmStep:           10000,
+	ExcessKmDepreciation:   0.02,
+	MaxMileageDepreciation: 0.30,
+	CommercialAdjustment:   0.85,
+	MinimumResidual:        0.10,
+}
+
+type Input struct {
+	BasePrice         int
+	ManufacturingYear int
+	Odometer          int
+	IsCommercial      bool
+	AsOf              time.Time
+}
+
+type Result struct {
+	BasePrice        int
+	AgeYears         int
+	AgeFactor        float64
+	MileageFactor    float64
+	CommercialFactor float64
+	EstimatedValue   int
+}
+
+func (p Policy) Estimate(in Input) (*Result, error) {
+	if in.BasePrice <= 0 {
+		return nil, ErrNoBasePrice
+	}
+	age := in.AsOf.Year() - in.ManufacturingYear
+	if age < 0 {
+		return nil, fmt.Errorf("manufacturing year %d is in the future", in.ManufacturingYear)
+	}
+	if in.Odometer < 0 {
+		return nil, fmt.Errorf("invalid odometer %d", in.Odometer)
+	}
+
+	result := &Result{
+		BasePrice:        in.BasePrice,
+		AgeYears:         age,
+		AgeFactor:        round4(p.ageFactor(age)),
+		MileageFactor:    round4(p.mileageFactor(age, in.Odometer)),
+		CommercialFactor: 1,
+	}
+	if in.IsCommercial {
+		result.CommercialFactor = p.CommercialAdjustment
+	}
+
+	value := float64(in.BasePrice) * result.AgeFactor * result.MileageFactor * result.CommercialFactor
+	value = math.Max(value, float64(in.BasePrice)*p.MinimumResidual)
+	result.EstimatedValue = int(math.Round(value))
+	return result, nil
+}
+
+func (p Policy) ageFactor(age int) float64 {
+	if age == 0 {
+		return 1
+	}
+	return (1 - p.FirstYearDepreciation) * math.Pow(1-p.AnnualDepreciation, float64(age-1))
+}
+
+// mileageFactor only penalises distance driven beyond what is expected for the
+// vehicle's age; a vehicle in its first year is expected to have done a full
+// year's worth.
+func (p Policy) mileageFactor(age, odometer int) float64 {
+	if p.ExcessKmStep <= 0 {
+		return 1
+	}
+	expected := p.ExpectedKmPerYear * max(age, 1)
+	excess := odometer - expected
+	if excess <= 0 {
+		return 1
+	}
+	depreciation := float64(excess) / float64(p.ExcessKmStep) * p.ExcessKmDepreciation
+	return 1 - math.Min(depreciation, p.MaxMileageDepreciation)
+}
+
+func LoanToValue(loanAmount, estimatedValue int) float64 {
+	if estimatedValue <= 0 {
+		return 0
+	}
+	return round4(float64(loanAmount) / float64(estimatedValue))
+}
+
+func round4(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
@@ -0,0 +1,85 @@
+package valuation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEstimateDepreciates(t *testing.T) {
+	asOf := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		year             int
+		odometer         int
+		commercial       bool
+		ageFactor        float64
+		mileageFactor    float64
+		commercialFactor float64
+		value            int
+	}{
+		{"new vehicle within the first year's distance", 2026, 15000, false, 1, 1, 1, 100000000},
+		{"new vehicle driven beyond a year's distance", 2026, 35000, false, 1, 0.96, 1, 96000000},
+		{"first year depreciation", 2025, 0, false, 0.8, 1, 1, 80000000},
+		{"annual depreciation after the first year", 2023, 0, false, 0.648, 1, 1, 64800000},
+		{"expected distance for the age", 2024, 30000, false, 0.72, 1, 1, 72000000},
+		{"excess distance", 2024, 50000, false, 0.72, 0.96, 1, 69120000},
+		{"mileage depreciation capped", 2025, 500000, false, 0.8, 0.7, 1, 56000000},
+		{"commercial adjustment", 2025, 0, true, 0.8, 1, 0.85, 68000000},
+		{"floored at the minimum residual", 2001, 0, false, 0.0638, 1, 1, 10000000},
+	}
+	for _, test := range tests {
+		result, err := DefaultPolicy.Estimate(Input{
+			BasePrice: 100000000, ManufacturingYear: test.year, Odometer: test.odometer,
+			IsCommercial: test.commercial, AsOf: asOf,
+		})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if result.AgeFactor != test.ageFactor || result.MileageFactor != test.mileageFactor ||
+			result.CommercialFactor != test.commercialFactor || result.EstimatedValue != test.value {
+			t.Errorf("%s: factors %v, %v, %v and value %d, want %v, %v, %v and %d", test.name,
+				result.AgeFactor, result.MileageFactor, result.CommercialFactor, result.EstimatedValue,
+				test.ageFactor, test.mileageFactor, test.commercialFactor, test.value)
+		}
+	}
+}
+
+func TestEstimateRejectsInvalidInput(t *testing.T) {
+	asOf := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input Input
+	}{
+		{"no base price", Input{BasePrice: 0, ManufacturingYear: 2020, AsOf: asOf}},
+		{"manufactured in the future", Input{BasePrice: 100000000, ManufacturingYear: 2027, AsOf: asOf}},
+		{"negative odometer", Input{BasePrice: 100000000, ManufacturingYear: 2020, Odometer: -1, AsOf: asOf}},
+	}
+	for _, test := range tests {
+		if _, err := DefaultPolicy.Estimate(test.input); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+	if _, err := DefaultPolicy.Estimate(tests[0].input); !errors.Is(err, ErrNoBasePrice) {
+		t.Errorf("no base price: err = %v, want ErrNoBasePrice", err)
+	}
+}
+
+func TestLoanToValue(t *testing.T) {
+	tests := []struct {
+		loanAmount     int
+		estimatedValue int
+		want           float64
+	}{
+		{150000000, 200000000, 0.75},
+		{100000000, 300000000, 0.3333},
+		{250000000, 200000000, 1.25},
+		{100000000, 0, 0},
+	}
+	for _, test := range tests {
+		if got := LoanToValue(test.loanAmount, test.estimatedValue); got != test.want {
+			t.Errorf("LoanToValue(%d, %d) = %v, want %v", test.loanAmount, test.estimatedValue, got, test.want)
+		}
+	}
+}
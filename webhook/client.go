@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned for endpoints on loopback, private,
+// link-local and other addresses that are not reachable from the internet.
+// Dealers choose the endpoints, so without it a subscription could make the
+// service call into its own network and read the answers in the delivery log.
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicPrefixes are the special purpose ranges netip has no predicate for.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// NewClient returns the client deliveries are sent with. Unless allowLocal is
+// set it only connects to public addresses, and only follows redirects to
+// https URLs. Addresses are checked as they are dialled, after the host name
+// is resolved, so a name that resolves or rebinds to a private address is
+// refused too. allowLocal is for trying webhooks against a local stub.
+func NewClient(allowLocal bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowLocal {
+		dialer.Control = refuseNonPublic
+	}
+	client := &http.Client{
+		// No proxy: it would dial the endpoint on our behalf, unchecked.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		Timeout: 10 * time.Second,
+	}
+	if !allowLocal {
+		client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+			if request.URL.Scheme != "https" {
+				return fmt.Errorf("refusing redirect to %s, endpoints must be https", request.URL.Scheme)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	return client
+}
+
+// CheckURL parses the endpoint of a subscription. It must be an absolute
+// https URL, and neither localhost nor an address that is not public, unless
+// allowLocal is set; then plain http and local addresses are accepted too.
+func CheckURL(rawURL string, allowLocal bool) (*url.URL, error) {
+	endpoint, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "https" && (!allowLocal || endpoint.Scheme != "http")) {
+		if allowLocal {
+			return nil, errors.New("url must be an absolute http or https URL")
+		}
+		return nil, errors.New("url must be an absolute https URL")
+	}
+	if allowLocal {
+		return endpoint, nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(endpoint.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, fmt.Errorf("url must not point at %s: %w", host, ErrNonPublicAddress)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublic(addr) {
+		return nil, fmt.Errorf("url must not point at %s: %w", host, ErrNonPublicAddress)
+	}
+	return endpoint, nil
+}
+
+// IsPublic reports whether addr is a unicast address reachable from the
+// internet.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublic(addr) {
+		return fmt.Errorf("refusing to connect to %s: %w", host, ErrNonPublicAddress)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/events"
+	"github.com/alphaloan/vehicle/worker"
+)
+
+const (
+	DefaultBatchSize   = 100
+	DefaultMaxAttempts = 10
+	DefaultBaseBackoff = 30 * time.Second
+	DefaultMaxBackoff  = 6 * time.Hour
+
+	userAgent = "AlphaLoan-Webhooks/1"
+)
+
+// EventTypes are the events dealers can subscribe to.
+var EventTypes = []string{
+	events.TypeSubmissionApproved,
+	events.TypeSubmissionRejected,
+}
+
+// Dispatcher sends due webhook deliveries. A delivery succeeds on any 2xx
+// answer, other answers and network errors are retried with exponential
+// backoff until MaxAttempts is reached and the delivery is dead-lettered.
+type Dispatcher struct {
+	Store       *datastore.WebhookStore
+	Client      *http.Client
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+type Summary struct {
+	Succeeded int
+	Retrying  int
+	Dead      int
+}
+
+func NewDispatcher(store *datastore.WebhookStore) *Dispatcher {
+	return &Dispatcher{
+		Store:       store,
+		Client:      NewClient(false),
+		BatchSize:   DefaultBatchSize,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// Start sends due deliveries on every tick until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, interval, func(ctx context.Context) {
+		summary, err := d.Run(ctx)
+		if err != nil {
+			log.Println("webhook dispatch failed:", err)
+		} else if summary.Succeeded+summary.Retrying+summary.Dead > 0 {
+			log.Printf("webhook dispatch: %d delivered, %d retrying, %d dead\n",
+				summary.Succeeded, summary.Retrying, summary.Dead)
+		}
+	})
+}
+
+// Run attempts every delivery that is due now once. Deliveries to different
+// endpoints are independent, a failing endpoint does not hold back others.
+func (d *Dispatcher) Run(ctx context.Context) (*Summary, error) {
+	summary := &Summary{}
+	due, err := d.Store.GetDueDeliveries(time.Now().Unix(), d.BatchSize)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		}
+		status, err := d.attempt(ctx, delivery)
+		if err != nil {
+			return summary, err
+		}
+		switch status {
+		case datastore.WebhookDeliverySucceeded:
+			summary.Succeeded++
+		case datastore.WebhookDeliveryDead:
+			summary.Dead++
+		default:
+			summary.Retrying++
+		}
+	}
+	return summary, nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery *datastore.WebhookDueDeliveryRow) (string, error) {
+	started := time.Now()
+	attempt := &datastore.WebhookDeliveryAttemptRow{
+		DeliveryID:    delivery.DeliveryID,
+		AttemptNumber: delivery.Attempts + 1,
+		AttemptedAt:   started.Unix(),
+	}
+
+	var err error
+	if delivery.SubscriptionActive {
+		var code int
+		code, err = d.send(ctx, delivery, started)
+		if code != 0 {
+			attempt.ResponseCode = sql.NullInt64{Int64: int64(code), Valid: true}
+		}
+	} else {
+		err = fmt.Errorf("subscription %s is inactive", delivery.SubscriptionID)
+	}
+	attempt.DurationMs = time.Since(started).Milliseconds()
+
+	status := datastore.WebhookDeliverySucceeded
+	nextAttemptAt := delivery.NextAttemptAt
+	if err != nil {
+		attempt.Error = sql.NullString{String: err.Error(), Valid: true}
+		if !delivery.SubscriptionActive || attempt.AttemptNumber >= d.MaxAttempts {
+			status = datastore.WebhookDeliveryDead
+		} else {
+			status = datastore.WebhookDeliveryPending
+			nextAttemptAt = started.Add(worker.Backoff(attempt.AttemptNumber, d.BaseBackoff, d.MaxBackoff)).Unix()
+		}
+		log.Printf("webhook delivery %s (%s) failed on attempt %d: %v\n",
+			delivery.DeliveryID, delivery.EventType, attempt.AttemptNumber, err)
+	}
+
+	if _, err := d.Store.RecordDeliveryAttempt(attempt, status, nextAttemptAt); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// send POSTs the payload and returns the response code, 0 when no response
+// was received.
+func (d *Dispatcher) send(ctx context.Context, delivery *datastore.WebhookDueDeliveryRow, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(DeliveryHeader, delivery.DeliveryID)
+	request.Header.Set(SignatureHeader, SignatureHeaderValue(delivery.Secret, now.Unix(), body))
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("endpoint answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
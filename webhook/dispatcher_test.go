@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alphaloan/vehicle/datastore"
+	"github.com/alphaloan/vehicle/datastore/datastoretest"
+	"github.com/alphaloan/vehicle/events"
+	"github.com/google/uuid"
+)
+
+const testPayload = `{"event_type":"submission.approved","submission_id":"42"}`
+
+// queueDelivery subscribes a dealer to endpoint and queues one delivery due
+// now.
+func queueDelivery(t *testing.T, store *datastore.WebhookStore, dealers *datastore.DealerStore, endpoint, secret string) *datastore.WebhookDeliveryRow {
+	t.Helper()
+	now := time.Now().Unix()
+	dealerID, err := dealers.UpsertDealer(&datastore.DealerRow{
+		DealerID: uuid.New().String(), Code: "D1", Name: "Dealer", PhoneNumber: "0812",
+		AddressCity: "Jakarta", IsActive: true, CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscription := &datastore.WebhookSubscriptionRow{
+		SubscriptionID: uuid.New().String(), DealerID: dealerID, URL: endpoint, Secret: secret,
+		EventTypes: EventTypes, IsActive: true, CreatedAt: now, UpdatedAt: now,
+	}
+	if err := store.InsertWebhookSubscription(subscription); err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := store.InsertRedelivery(&datastore.WebhookDeliveryRow{
+		DeliveryID: uuid.New().String(), SubscriptionID: subscription.SubscriptionID, EventID: uuid.New().String(),
+		EventType: events.TypeSubmissionApproved, Payload: testPayload,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func newTestDispatcher(t *testing.T, allowLocal bool) (*Dispatcher, *datastore.DealerStore) {
+	db := datastoretest.Open(t)
+	dispatcher := NewDispatcher(datastore.NewWebhookStore(db))
+	dispatcher.Client = NewClient(allowLocal)
+	return dispatcher, datastore.NewDealerStore(db)
+}
+
+func reload(t *testing.T, store *datastore.WebhookStore, delivery *datastore.WebhookDeliveryRow) *datastore.WebhookDeliveryRow {
+	t.Helper()
+	row, err := store.GetWebhookDeliveryById(delivery.SubscriptionID, delivery.DeliveryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+func TestDispatcherDeliversSignedPayload(t *testing.T) {
+	dispatcher, dealers := newTestDispatcher(t, true)
+	received := make(chan *http.Request, 1)
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify("secret", r.Header.Get(SignatureHeader), body, time.Minute, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if string(body) != testPayload {
+			http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+			return
+		}
+		received <- r
+	}))
+	defer stub.Close()
+	delivery := queueDelivery(t, dispatcher.Store, dealers, stub.URL, "secret")
+
+	summary, err := dispatcher.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Succeeded != 1 {
+		t.Fatalf("summary = %+v, want one delivered", summary)
+	}
+	request := <-received
+	if got := request.Header.Get(DeliveryHeader); got != delivery.DeliveryID {
+		t.Errorf("%s = %q, want %q", DeliveryHeader, got, delivery.DeliveryID)
+	}
+	if got := request.Header.Get(EventHeader); got != events.TypeSubmissionApproved {
+		t.Errorf("%s = %q, want %q", EventHeader, got, events.TypeSubmissionApproved)
+	}
+
+	row := reload(t, dispatcher.Store, delivery)
+	if row.Status != datastore.WebhookDeliverySucceeded || len(row.AttemptLog) != 1 ||
+		row.AttemptLog[0].ResponseCode.Int64 != http.StatusOK {
+		t.Errorf("delivery = %s with %d attempts, want SUCCEEDED after one 200", row.Status, len(row.AttemptLog))
+	}
+}
+
+func TestDispatcherRetriesThenDeadLetters(t *testing.T) {
+	dispatcher, dealers := newTestDispatcher(t, true)
+	dispatcher.MaxAttempts = 2
+	dispatcher.BaseBackoff = 0
+	var calls atomic.Int32
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer stub.Close()
+	delivery := queueDelivery(t, dispatcher.Store, dealers, stub.URL, "secret")
+
+	summary, err := dispatcher.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Retrying != 1 {
+		t.Fatalf("first run = %+v, want one retrying", summary)
+	}
+	if row := reload(t, dispatcher.Store, delivery); row.Status != datastore.WebhookDeliveryPending ||
+		row.LastResponseCode.Int64 != http.StatusServiceUnavailable {
+		t.Fatalf("after the first attempt delivery = %s (%d), want PENDING after 503", row.Status, row.LastResponseCode.Int64)
+	}
+
+	summary, err = dispatcher.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Dead != 1 {
+		t.Fatalf("second run = %+v, want one dead", summary)
+	}
+	row := reload(t, dispatcher.Store, delivery)
+	if row.Status != datastore.WebhookDeliveryDead || len(row.AttemptLog) != 2 || calls.Load() != 2 {
+		t.Errorf("delivery = %s with %d attempts and %d calls, want DEAD after 2", row.Status, len(row.AttemptLog), calls.Load())
+	}
+}
+
+func TestDispatcherRefusesLocalEndpoints(t *testing.T) {
+	dispatcher, dealers := newTestDispatcher(t, false)
+	var calls atomic.Int32
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer stub.Close()
+	delivery := queueDelivery(t, dispatcher.Store, dealers, stub.URL, "secret")
+
+	if _, err := dispatcher.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("the stub on %s was called", stub.URL)
+	}
+	row := reload(t, dispatcher.Store, delivery)
+	if row.LastResponseCode.Valid || !strings.Contains(row.LastError.String, ErrNonPublicAddress.Error()) {
+		t.Errorf("delivery log = %d %q, want no response and a refused connection", row.LastResponseCode.Int64, row.LastError.String)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		allowLocal bool
+		wantErr    bool
+	}{
+		{"https://dealer.example.com/hooks", false, false},
+		{"https://93.184.216.34/hooks", false, false},
+		{"http://dealer.example.com/hooks", false, true},
+		{"http://dealer.example.com/hooks", true, false},
+		{"https://localhost/hooks", false, true},
+		{"https://127.0.0.1/hooks", false, true},
+		{"https://10.1.2.3/hooks", false, true},
+		{"https://192.168.0.10/hooks", false, true},
+		{"https://169.254.169.254/latest/meta-data", false, true},
+		{"https://[::1]/hooks", false, true},
+		{"https://[fe80::1]/hooks", false, true},
+		{"https://[::ffff:127.0.0.1]/hooks", false, true},
+		{"http://127.0.0.1:9090/hooks", true, false},
+		{"ftp://dealer.example.com/hooks", true, true},
+		{"/hooks", false, true},
+	}
+	for _, test := range tests {
+		_, err := CheckURL(test.url, test.allowLocal)
+		if (err != nil) != test.wantErr {
+			t.Errorf("CheckURL(%q, %v) = %v, want error %v", test.url, test.allowLocal, err, test.wantErr)
+		}
+	}
+}
+
+func TestRefuseNonPublic(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:443", "10.0.0.1:443", "100.64.0.1:443", "169.254.169.254:80", "[::1]:443", "0.0.0.0:80"} {
+		if err := refuseNonPublic("tcp", address, nil); !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("dialling %s = %v, want %v", address, err, ErrNonPublicAddress)
+		}
+	}
+	if err := refuseNonPublic("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dialling a public address = %v", err)
+	}
+	if !IsPublic(netip.MustParseAddr("2606:4700::1111")) {
+		t.Error("a public IPv6 address is not public")
+	}
+}
@@ -0,0 +1,36 @@
+// Package worker holds what the background jobs of the service share: the
+// loop that runs them on a ticker and the backoff their retries wait for.
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// Every calls run straight away and then on every tick until ctx is
+// cancelled. A run that takes longer than interval delays the next one
+// instead of overlapping it.
+func Every(ctx context.Context, interval time.Duration, run func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Backoff waits base after the first failed attempt and doubles the wait
+// after every further one, up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	return min(wait, max)
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, time.Minute},
+	}
+	for _, test := range tests {
+		if got := Backoff(test.attempts, time.Second, time.Minute); got != test.want {
+			t.Errorf("Backoff(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+	if got := Backoff(3, 0, time.Minute); got != 0 {
+		t.Errorf("Backoff without a base = %s, want 0", got)
+	}
+}
+
+func TestEveryRunsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Every(ctx, time.Millisecond, func(context.Context) {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Every did not return after ctx was cancelled")
+	}
+	if runs != 3 {
+		t.Errorf("ran %d times, want 3", runs)
+	}
+}